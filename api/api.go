@@ -0,0 +1,191 @@
+// Package api holds the request and response bodies of the HTTP API, the server and the client share them so
+// they cannot drift apart
+package api
+
+import "time"
+
+type (
+	AddFilmRequest struct {
+		Name     string `json:"name" xml:"name"`
+		Director string `json:"director" xml:"director"`
+	}
+
+	// UpdateFilmRequest replaces the director and release of the film named in the query
+	UpdateFilmRequest struct {
+		Director string `json:"director" xml:"director"`
+		Release  string `json:"release" xml:"release"`
+	}
+
+	Film struct {
+		Name     string `json:"name" xml:"name"`
+		Director string `json:"director" xml:"director"`
+		Release  string `json:"release" xml:"release"`
+	}
+
+	// FilmList is the whole catalogue ordered by film name
+	FilmList struct {
+		Films []Film `json:"films" xml:"film"`
+	}
+
+	// Rental names the rental id its copy was rented out under when it was rented through RentRequest
+	Rental struct {
+		Name     string `json:"name" xml:"name"`
+		Days     uint16 `json:"days" xml:"days"`
+		RentalID string `json:"rentalId,omitempty" xml:"rentalId,omitempty"`
+	}
+
+	// RentRequest rents a copy of a catalogued film out for Days
+	RentRequest struct {
+		Name string `json:"name" xml:"name"`
+		Days uint16 `json:"days" xml:"days"`
+	}
+
+	// StartedRental is a rental which stays open until a return names its RentalID
+	StartedRental struct {
+		RentalID string `json:"rentalId" xml:"rentalId"`
+		Name     string `json:"name" xml:"name"`
+		Days     uint16 `json:"days" xml:"days"`
+	}
+
+	ReturnRequest struct {
+		Return []Rental `json:"return" xml:"return"`
+	}
+
+	Invoice struct {
+		Return       []Rental
+		Price        uint64
+		Currency     string
+		MonetaryUnit string
+	}
+
+	// Stock is how many copies of a film a store holds
+	Stock struct {
+		Film   string `json:"film" xml:"film"`
+		Copies int    `json:"copies" xml:"copies"`
+	}
+
+	// Inventory is every film a store holds a copy of ordered by film name
+	Inventory struct {
+		Stock []Stock `json:"stock" xml:"stock"`
+	}
+
+	AddStockRequest struct {
+		Film   string `json:"film" xml:"film"`
+		Copies int    `json:"copies" xml:"copies"`
+	}
+
+	// PriceList is what a store charges a day in SEK, new releases cost premium and the other releases basic
+	PriceList struct {
+		Premium uint64 `json:"premium" xml:"premium"`
+		Basic   uint64 `json:"basic" xml:"basic"`
+	}
+
+	// IssuedInvoice is an invoice as it is kept by the store which issued it
+	IssuedInvoice struct {
+		ID       string    `json:"id" xml:"id"`
+		Store    string    `json:"store" xml:"store"`
+		Return   []Rental  `json:"return" xml:"return"`
+		Price    uint64    `json:"price" xml:"price"`
+		IssuedAt time.Time `json:"issuedAt" xml:"issuedAt"`
+	}
+
+	// InvoiceHistory is every invoice a store has issued ordered by issue time
+	InvoiceHistory struct {
+		Invoices []IssuedInvoice `json:"invoices" xml:"invoice"`
+	}
+
+	TransferRequest struct {
+		Film   string `json:"film" xml:"film"`
+		From   string `json:"from" xml:"from"`
+		To     string `json:"to" xml:"to"`
+		Copies int    `json:"copies" xml:"copies"`
+	}
+
+	// Transfer moves copies of a film from one store to another, its status goes from Requested to Approved,
+	// InTransit and Received
+	Transfer struct {
+		ID          string    `json:"id" xml:"id"`
+		Film        string    `json:"film" xml:"film"`
+		From        string    `json:"from" xml:"from"`
+		To          string    `json:"to" xml:"to"`
+		Copies      int       `json:"copies" xml:"copies"`
+		Status      string    `json:"status" xml:"status"`
+		RequestedAt time.Time `json:"requestedAt" xml:"requestedAt"`
+	}
+
+	// TransferList is every transfer from or to a store ordered by request time
+	TransferList struct {
+		Transfers []Transfer `json:"transfers" xml:"transfer"`
+	}
+
+	AuditEntry struct {
+		Sequence  uint64            `json:"sequence"`
+		Timestamp time.Time         `json:"timestamp"`
+		Actor     string            `json:"actor"`
+		Operation string            `json:"operation"`
+		Entity    string            `json:"entity"`
+		Inputs    map[string]string `json:"inputs"`
+		Outcome   string            `json:"outcome"`
+		Error     string            `json:"error,omitempty"`
+		Hash      string            `json:"hash"`
+	}
+
+	AuditResponse struct {
+		Entries []AuditEntry `json:"entries"`
+	}
+
+	// Error is the RFC 7807 application/problem+json body of every response which is not successful, the
+	// request id correlates it with the server logs
+	Error struct {
+		Type      string       `json:"type"`
+		Title     string       `json:"title"`
+		Status    int          `json:"status"`
+		Detail    string       `json:"detail"`
+		Instance  string       `json:"instance,omitempty"`
+		RequestID string       `json:"requestId,omitempty"`
+		Errors    []FieldError `json:"errors,omitempty"`
+	}
+
+	// FieldError names the query parameter, body field or film the request was rejected for
+	FieldError struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	}
+)
+
+func (r AddFilmRequest) IsValid() bool {
+	return r.Name != "" && r.Director != ""
+}
+
+func (r UpdateFilmRequest) IsValid() bool {
+	return r.Director != "" && r.Release != ""
+}
+
+func (r Rental) IsValid() bool {
+	return r.Name != "" && r.Days > 0
+}
+
+func (r RentRequest) IsValid() bool {
+	return r.Name != "" && r.Days > 0
+}
+
+func (r AddStockRequest) IsValid() bool {
+	return r.Film != "" && r.Copies > 0
+}
+
+func (r TransferRequest) IsValid() bool {
+	return r.Film != "" && r.From != "" && r.To != "" && r.Copies > 0
+}
+
+func (p PriceList) IsValid() bool {
+	return p.Premium > 0 && p.Basic > 0
+}
+
+func (r ReturnRequest) IsValid() bool {
+	for _, rental := range r.Return {
+		if !rental.IsValid() {
+			return false
+		}
+	}
+	return len(r.Return) > 0
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The bodies which can be exchanged as text/csv are flattened into a header row followed by a row per film or
+// rental, the columns of a request may come in any order
+
+var (
+	filmColumns    = []string{"name", "director", "release"}
+	rentalColumns  = []string{"name", "days"}
+	invoiceColumns = []string{"name", "days", "price", "currency"}
+	// startedColumns begin with the columns of a rent request
+	startedColumns = []string{"name", "days", "rentalId"}
+	stockColumns   = []string{"film", "copies"}
+	priceColumns   = []string{"premium", "basic"}
+	// transferColumns begin with the columns of a transfer request
+	transferColumns = []string{"film", "from", "to", "copies", "id", "status", "requestedAt"}
+)
+
+func (f Film) MarshalCSV() [][]string {
+	return [][]string{filmColumns, {f.Name, f.Director, f.Release}}
+}
+
+func (l FilmList) MarshalCSV() [][]string {
+	records := [][]string{filmColumns}
+	for _, film := range l.Films {
+		records = append(records, []string{film.Name, film.Director, film.Release})
+	}
+	return records
+}
+
+// MarshalCSV repeats the price of the whole invoice on the row of every rental
+func (i Invoice) MarshalCSV() [][]string {
+	records := [][]string{invoiceColumns}
+	for _, rental := range i.Return {
+		records = append(records, []string{rental.Name, strconv.Itoa(int(rental.Days)), strconv.FormatUint(i.Price, 10), i.Currency})
+	}
+	return records
+}
+
+func (r StartedRental) MarshalCSV() [][]string {
+	return [][]string{startedColumns, {r.Name, strconv.Itoa(int(r.Days)), r.RentalID}}
+}
+
+func (s Stock) MarshalCSV() [][]string {
+	return [][]string{stockColumns, {s.Film, strconv.Itoa(s.Copies)}}
+}
+
+func (i Inventory) MarshalCSV() [][]string {
+	records := [][]string{stockColumns}
+	for _, stock := range i.Stock {
+		records = append(records, []string{stock.Film, strconv.Itoa(stock.Copies)})
+	}
+	return records
+}
+
+func (p PriceList) MarshalCSV() [][]string {
+	return [][]string{priceColumns, {strconv.FormatUint(p.Premium, 10), strconv.FormatUint(p.Basic, 10)}}
+}
+
+func (t Transfer) MarshalCSV() [][]string {
+	return [][]string{transferColumns, t.record()}
+}
+
+func (l TransferList) MarshalCSV() [][]string {
+	records := [][]string{transferColumns}
+	for _, transfer := range l.Transfers {
+		records = append(records, transfer.record())
+	}
+	return records
+}
+
+func (t Transfer) record() []string {
+	return []string{t.Film, t.From, t.To, strconv.Itoa(t.Copies), t.ID, t.Status, t.RequestedAt.Format(time.RFC3339Nano)}
+}
+
+func (r *AddFilmRequest) UnmarshalCSV(records [][]string) error {
+	rows, err := csvRows(records, "name", "director")
+	if err != nil {
+		return err
+	}
+	if len(rows) != 1 {
+		return fmt.Errorf("csv: expected a single film but got %d", len(rows))
+	}
+	r.Name, r.Director = rows[0]["name"], rows[0]["director"]
+	return nil
+}
+
+func (r *UpdateFilmRequest) UnmarshalCSV(records [][]string) error {
+	rows, err := csvRows(records, "director", "release")
+	if err != nil {
+		return err
+	}
+	if len(rows) != 1 {
+		return fmt.Errorf("csv: expected a single film but got %d", len(rows))
+	}
+	r.Director, r.Release = rows[0]["director"], rows[0]["release"]
+	return nil
+}
+
+func (r *ReturnRequest) UnmarshalCSV(records [][]string) error {
+	rows, err := csvRows(records, rentalColumns...)
+	if err != nil {
+		return err
+	}
+	r.Return = make([]Rental, 0, len(rows))
+	for i, row := range rows {
+		days, err := strconv.ParseUint(row["days"], 10, 16)
+		if err != nil {
+			return fmt.Errorf("csv: row %d days must be a number of days: %w", i+1, err)
+		}
+		r.Return = append(r.Return, Rental{Name: row["name"], Days: uint16(days)})
+	}
+	return nil
+}
+
+func (r *RentRequest) UnmarshalCSV(records [][]string) error {
+	rows, err := csvRows(records, startedColumns[:2]...)
+	if err != nil {
+		return err
+	}
+	if len(rows) != 1 {
+		return fmt.Errorf("csv: expected a single rental but got %d", len(rows))
+	}
+	days, err := strconv.ParseUint(rows[0]["days"], 10, 16)
+	if err != nil {
+		return fmt.Errorf("csv: days must be a number of days: %w", err)
+	}
+	r.Name, r.Days = rows[0]["name"], uint16(days)
+	return nil
+}
+
+func (r *AddStockRequest) UnmarshalCSV(records [][]string) error {
+	rows, err := csvRows(records, stockColumns...)
+	if err != nil {
+		return err
+	}
+	if len(rows) != 1 {
+		return fmt.Errorf("csv: expected a single film but got %d", len(rows))
+	}
+	copies, err := strconv.Atoi(rows[0]["copies"])
+	if err != nil {
+		return fmt.Errorf("csv: copies must be a number of copies: %w", err)
+	}
+	r.Film, r.Copies = rows[0]["film"], copies
+	return nil
+}
+
+func (r *TransferRequest) UnmarshalCSV(records [][]string) error {
+	rows, err := csvRows(records, transferColumns[:4]...)
+	if err != nil {
+		return err
+	}
+	if len(rows) != 1 {
+		return fmt.Errorf("csv: expected a single transfer but got %d", len(rows))
+	}
+	copies, err := strconv.Atoi(rows[0]["copies"])
+	if err != nil {
+		return fmt.Errorf("csv: copies must be a number of copies: %w", err)
+	}
+	r.Film, r.From, r.To, r.Copies = rows[0]["film"], rows[0]["from"], rows[0]["to"], copies
+	return nil
+}
+
+func (p *PriceList) UnmarshalCSV(records [][]string) error {
+	rows, err := csvRows(records, priceColumns...)
+	if err != nil {
+		return err
+	}
+	if len(rows) != 1 {
+		return fmt.Errorf("csv: expected a single price list but got %d", len(rows))
+	}
+	if p.Premium, err = strconv.ParseUint(rows[0]["premium"], 10, 64); err != nil {
+		return fmt.Errorf("csv: premium must be a price: %w", err)
+	}
+	if p.Basic, err = strconv.ParseUint(rows[0]["basic"], 10, 64); err != nil {
+		return fmt.Errorf("csv: basic must be a price: %w", err)
+	}
+	return nil
+}
+
+// csvRows keys every row following the header by its column names, each of the columns must be present
+func csvRows(records [][]string, columns ...string) ([]map[string]string, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("csv: missing header row %v", columns)
+	}
+
+	index := map[string]int{}
+	for i, column := range records[0] {
+		index[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, column := range columns {
+		if _, ok := index[column]; !ok {
+			return nil, fmt.Errorf("csv: missing column %q", column)
+		}
+	}
+
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(columns))
+		for _, column := range columns {
+			row[column] = record[index[column]]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,492 @@
+// Package client calls the video store over its HTTP API
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/api"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"go.opentelemetry.io/otel/propagation"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const contentType = "application/json"
+
+type (
+	Client struct {
+		baseURL    string
+		httpClient *http.Client
+		retries    int
+		backoff    time.Duration
+		headers    map[string]string
+		store      string
+		idempotent bool
+	}
+
+	Option func(c *Client)
+
+	// APIError is returned for every response which is not successful, it unwraps to the driven error the
+	// status stands for when there is one. RequestID correlates it with the server logs
+	APIError struct {
+		Status    int
+		Type      string
+		Title     string
+		Detail    string
+		RequestID string
+		Errors    []api.FieldError
+		cause     error
+	}
+)
+
+func (e *APIError) Error() string {
+	if e.RequestID == "" {
+		return fmt.Sprintf("video store responded %d: %s", e.Status, e.Detail)
+	}
+	return fmt.Sprintf("video store responded %d to request %s: %s", e.Status, e.RequestID, e.Detail)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.cause
+}
+
+func New(baseURL string, options ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		retries:    2,
+		backoff:    100 * time.Millisecond,
+		headers:    map[string]string{},
+		store:      "main",
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTimeout bounds every attempt of a call, the context passed to the call bounds all of them together
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.httpClient.Timeout = timeout
+	}
+}
+
+// WithRetries retries idempotent calls up to retries times, waiting backoff before the first retry and doubling
+// it after every attempt. A rate limited call waits at least as long as its Retry-After
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+// WithIdempotencyKeys sends every POST under a fresh Idempotency-Key and retries it like the idempotent calls, the
+// server must be configured with an idempotency store or a retried return may be invoiced twice
+func WithIdempotencyKeys() Option {
+	return func(c *Client) {
+		c.idempotent = true
+	}
+}
+
+// WithAPIKey authenticates every call as the kiosk the key was issued to
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.headers["X-API-Key"] = key
+	}
+}
+
+// WithBearerToken authenticates every call with the JWT of a member of staff
+func WithBearerToken(token string) Option {
+	return func(c *Client) {
+		c.headers["Authorization"] = "Bearer " + token
+	}
+}
+
+// WithStore makes every call for the branch store rather than the main store
+func WithStore(store string) Option {
+	return func(c *Client) {
+		c.store = store
+		c.headers["X-Store-ID"] = store
+	}
+}
+
+// Find returns a driven.FilmNotFoundError when the film is not catalogued
+func (c *Client) Find(ctx context.Context, name string) (*api.Film, error) {
+	var film api.Film
+	err := c.do(ctx, http.MethodGet, "/catalogue/film?name="+url.QueryEscape(name), nil, &film, func(e *APIError) error {
+		if e.Status == http.StatusNotFound {
+			return &driven.FilmNotFoundError{Name: name}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &film, nil
+}
+
+// FindWithETag also returns the ETag of the film, which Update requires and which changes with every update
+func (c *Client) FindWithETag(ctx context.Context, name string) (*api.Film, string, error) {
+	var film api.Film
+	header, err := c.exchange(ctx, http.MethodGet, "/catalogue/film?name="+url.QueryEscape(name), nil, nil, &film, func(e *APIError) error {
+		if e.Status == http.StatusNotFound {
+			return &driven.FilmNotFoundError{Name: name}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return &film, header.Get("ETag"), nil
+}
+
+// Update replaces the director and release of the film read at etag and returns it with its new ETag. It returns a
+// driven.FilmVersionConflictError when the film has changed since and a driven.FilmNotFoundError when it is not
+// catalogued. Updates are never retried, a retry of an update which went through would conflict with itself
+func (c *Client) Update(ctx context.Context, name string, etag string, update api.UpdateFilmRequest) (*api.Film, string, error) {
+	var film api.Film
+	header, err := c.exchange(ctx, http.MethodPut, "/catalogue/film?name="+url.QueryEscape(name), map[string]string{"If-Match": etag}, update, &film, func(e *APIError) error {
+		switch e.Status {
+		case http.StatusPreconditionFailed:
+			version, _, _ := strings.Cut(strings.Trim(etag, `"`), "-")
+			expected, _ := strconv.ParseUint(version, 10, 64)
+			return &driven.FilmVersionConflictError{Name: name, Expected: expected}
+		case http.StatusNotFound:
+			return &driven.FilmNotFoundError{Name: name}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return &film, header.Get("ETag"), nil
+}
+
+// List returns the whole catalogue ordered by film name
+func (c *Client) List(ctx context.Context) ([]api.Film, error) {
+	var list api.FilmList
+	err := c.do(ctx, http.MethodGet, "/catalogue/films", nil, &list, func(e *APIError) error {
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.Films, nil
+}
+
+func (c *Client) AddNew(ctx context.Context, name string, director string) (*api.Film, error) {
+	return c.add(ctx, "new", name, director)
+}
+
+func (c *Client) AddRegular(ctx context.Context, name string, director string) (*api.Film, error) {
+	return c.add(ctx, "regular", name, director)
+}
+
+func (c *Client) AddOld(ctx context.Context, name string, director string) (*api.Film, error) {
+	return c.add(ctx, "old", name, director)
+}
+
+// add returns a driven.FilmAlreadyExistError when a film with the same name is catalogued
+func (c *Client) add(ctx context.Context, release string, name string, director string) (*api.Film, error) {
+	var film api.Film
+	err := c.do(ctx, http.MethodPost, "/catalogue/film/"+release, api.AddFilmRequest{Name: name, Director: director}, &film, func(e *APIError) error {
+		if e.Status == http.StatusConflict {
+			return &driven.FilmAlreadyExistError{Name: name}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &film, nil
+}
+
+// Return returns a driven.InvalidRentalRequestError when the store rejects any of the returns
+func (c *Client) Return(ctx context.Context, returns ...api.Rental) (*api.Invoice, error) {
+	var invoice api.Invoice
+	err := c.do(ctx, http.MethodPost, "/store/return", api.ReturnRequest{Return: returns}, &invoice, func(e *APIError) error {
+		if e.Status != http.StatusBadRequest {
+			return nil
+		}
+		invalid := driven.InvalidRentalRequestError{}
+		for _, field := range e.Errors {
+			invalid.Append(fmt.Errorf("%s: %s", field.Field, field.Message))
+		}
+		if len(invalid) == 0 {
+			invalid.Append(fmt.Errorf("%s", e.Detail))
+		}
+		return &invalid
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// Inventory returns every film the store holds a copy of ordered by film name
+func (c *Client) Inventory(ctx context.Context) ([]api.Stock, error) {
+	var inventory api.Inventory
+	err := c.do(ctx, http.MethodGet, c.storePath("inventory"), nil, &inventory, func(e *APIError) error {
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inventory.Stock, nil
+}
+
+// AddStock returns a driven.FilmNotFoundError when the film is not catalogued
+func (c *Client) AddStock(ctx context.Context, film string, copies int) (*api.Stock, error) {
+	var stock api.Stock
+	err := c.do(ctx, http.MethodPost, c.storePath("inventory"), api.AddStockRequest{Film: film, Copies: copies}, &stock, func(e *APIError) error {
+		for _, field := range e.Errors {
+			if field.Field == "film" {
+				return &driven.FilmNotFoundError{Name: film}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &stock, nil
+}
+
+func (c *Client) PriceList(ctx context.Context) (*api.PriceList, error) {
+	var prices api.PriceList
+	err := c.do(ctx, http.MethodGet, c.storePath("prices"), nil, &prices, func(e *APIError) error {
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &prices, nil
+}
+
+func (c *Client) ChangePrices(ctx context.Context, prices api.PriceList) error {
+	return c.do(ctx, http.MethodPut, c.storePath("prices"), prices, &prices, func(e *APIError) error {
+		return nil
+	})
+}
+
+// Invoices returns every invoice the store has issued ordered by issue time
+func (c *Client) Invoices(ctx context.Context) ([]api.IssuedInvoice, error) {
+	var history api.InvoiceHistory
+	err := c.do(ctx, http.MethodGet, c.storePath("invoices"), nil, &history, func(e *APIError) error {
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return history.Invoices, nil
+}
+
+// RequestTransfer returns a driven.FilmNotFoundError when the film is not catalogued
+func (c *Client) RequestTransfer(ctx context.Context, film string, from string, to string, copies int) (*api.Transfer, error) {
+	var transfer api.Transfer
+	request := api.TransferRequest{Film: film, From: from, To: to, Copies: copies}
+	err := c.do(ctx, http.MethodPost, "/transfers", request, &transfer, func(e *APIError) error {
+		for _, field := range e.Errors {
+			if field.Field == "film" {
+				return &driven.FilmNotFoundError{Name: film}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// Transfer returns a driven.TransferNotFoundError when no transfer is identified by id
+func (c *Client) Transfer(ctx context.Context, id string) (*api.Transfer, error) {
+	return c.transfer(ctx, http.MethodGet, id, "")
+}
+
+func (c *Client) ApproveTransfer(ctx context.Context, id string) (*api.Transfer, error) {
+	return c.transfer(ctx, http.MethodPost, id, "/approve")
+}
+
+func (c *Client) ShipTransfer(ctx context.Context, id string) (*api.Transfer, error) {
+	return c.transfer(ctx, http.MethodPost, id, "/ship")
+}
+
+func (c *Client) ReceiveTransfer(ctx context.Context, id string) (*api.Transfer, error) {
+	return c.transfer(ctx, http.MethodPost, id, "/receive")
+}
+
+// Transfers returns every transfer from or to the store of the client ordered by request time
+func (c *Client) Transfers(ctx context.Context) ([]api.Transfer, error) {
+	var list api.TransferList
+	err := c.do(ctx, http.MethodGet, c.storePath("transfers"), nil, &list, func(e *APIError) error {
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.Transfers, nil
+}
+
+func (c *Client) transfer(ctx context.Context, method string, id string, step string) (*api.Transfer, error) {
+	var transfer api.Transfer
+	err := c.do(ctx, method, "/transfers/"+url.PathEscape(id)+step, nil, &transfer, func(e *APIError) error {
+		if e.Status == http.StatusNotFound {
+			return &driven.TransferNotFoundError{ID: id}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func (c *Client) storePath(resource string) string {
+	return "/stores/" + url.PathEscape(c.store) + "/" + resource
+}
+
+// do sends the request and decodes a successful response into out, only GET requests are retried since posting
+// twice would add the film, stock or invoice the returns twice. A POST is retried under the same idempotency key
+// once idempotency keys are enabled
+func (c *Client) do(ctx context.Context, method string, path string, in interface{}, out interface{}, cause func(e *APIError) error) error {
+	_, err := c.exchange(ctx, method, path, nil, in, out, cause)
+	return err
+}
+
+// exchange is do sending header along with the request and returning the header of the response
+func (c *Client) exchange(ctx context.Context, method string, path string, header map[string]string, in interface{}, out interface{}, cause func(e *APIError) error) (http.Header, error) {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return nil, fmt.Errorf("unable to encode request: %w", err)
+		}
+	}
+
+	attempts, key := 1, ""
+	if method == http.MethodPost && c.idempotent {
+		key = newIdempotencyKey()
+	}
+	if method == http.MethodGet || key != "" {
+		attempts += c.retries
+	}
+
+	wait := c.backoff
+	for attempt := 1; ; attempt++ {
+		res, err := c.send(ctx, method, path, body, header, key)
+		if err == nil && !retryable(res.StatusCode) || attempt >= attempts {
+			if err != nil {
+				return nil, err
+			}
+			return res.Header, decode(res, out, cause)
+		}
+		delay := wait
+		if res != nil {
+			if after := retryAfter(res); after > delay {
+				delay = after
+			}
+			res.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		wait *= 2
+	}
+}
+
+func (c *Client) send(ctx context.Context, method string, path string, body []byte, header map[string]string, idempotencyKey string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	// the server expects the content type on every request, GET included
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", contentType)
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	// the store continues the trace ctx belongs to through the W3C traceparent header
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	return c.httpClient.Do(req)
+}
+
+func decode(res *http.Response, out interface{}, cause func(e *APIError) error) error {
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read response: %w", err)
+	}
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("unable to decode response: %w", err)
+		}
+		return nil
+	}
+
+	var body api.Error
+	if err := json.Unmarshal(data, &body); err != nil || body.Detail == "" {
+		body.Detail = http.StatusText(res.StatusCode)
+	}
+	if body.RequestID == "" {
+		body.RequestID = res.Header.Get("X-Request-ID")
+	}
+	apiErr := &APIError{
+		Status:    res.StatusCode,
+		Type:      body.Type,
+		Title:     body.Title,
+		Detail:    body.Detail,
+		RequestID: body.RequestID,
+		Errors:    body.Errors,
+	}
+	apiErr.cause = cause(apiErr)
+	return apiErr
+}
+
+func newIdempotencyKey() string {
+	key := make([]byte, 16)
+	rand.Read(key)
+	return hex.EncodeToString(key)
+}
+
+// retryAfter reads the seconds form of Retry-After, the server never sends the date form
+func retryAfter(res *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(res.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,402 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/shawnritchie/go-video-store/api"
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/inmem"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/auth"
+	web "github.com/shawnritchie/go-video-store/internal/adapter/web/http"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/shawnritchie/go-video-store/internal/service"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newStore(t *testing.T) *httptest.Server {
+	catalogue := inmem.NewStoreCatalogue()
+	svc := service.New(catalogue, catalogue, service.WithLister(catalogue))
+	s := web.New(svc, svc, svc, web.WithLister(svc))
+
+	server := httptest.NewServer(s.Router())
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_EndToEnd(t *testing.T) {
+	client := New(newStore(t).URL)
+	ctx := context.Background()
+
+	added, err := client.AddNew(ctx, "Loki", "Marvel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *added != (api.Film{Name: "Loki", Director: "Marvel", Release: "New"}) {
+		t.Errorf("received unexpected film %#v", added)
+	}
+	if _, err := client.AddOld(ctx, "Out of Africa", "Pollack"); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := client.Find(ctx, "Out of Africa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Director != "Pollack" || found.Release != "Old" {
+		t.Errorf("received unexpected film %#v", found)
+	}
+
+	films, err := client.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(films) != 2 || films[0].Name != "Loki" || films[1].Name != "Out of Africa" {
+		t.Errorf("received unexpected catalogue %#v", films)
+	}
+
+	invoice, err := client.Return(ctx, api.Rental{Name: "Loki", Days: 2}, api.Rental{Name: "Out of Africa", Days: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if invoice.Price != 170 || invoice.Currency != "SEK" || len(invoice.Return) != 2 {
+		t.Errorf("received unexpected invoice %#v", invoice)
+	}
+}
+
+func TestClient_DrivenErrors(t *testing.T) {
+	client := New(newStore(t).URL)
+	ctx := context.Background()
+	if _, err := client.AddRegular(ctx, "Loki", "Marvel"); err != nil {
+		t.Fatal(err)
+	}
+
+	var notFound *driven.FilmNotFoundError
+	if _, err := client.Find(ctx, "Black Widow"); !errors.As(err, &notFound) || notFound.Name != "Black Widow" {
+		t.Errorf("was expecting FilmNotFoundError but got %v", err)
+	}
+
+	var alreadyExist *driven.FilmAlreadyExistError
+	if _, err := client.AddRegular(ctx, "Loki", "Marvel"); !errors.As(err, &alreadyExist) {
+		t.Errorf("was expecting FilmAlreadyExistError but got %v", err)
+	}
+
+	var invalidRequest *driven.InvalidRentalRequestError
+	if _, err := client.Return(ctx, api.Rental{Name: "Black Widow", Days: 1}); !errors.As(err, &invalidRequest) {
+		t.Errorf("was expecting InvalidRentalRequestError but got %v", err)
+	}
+
+	var apiErr *APIError
+	_, err := client.Return(ctx, api.Rental{Name: "Loki"})
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusBadRequest || len(apiErr.Errors) != 1 || apiErr.Errors[0].Field != "return.0.days" {
+		t.Errorf("was expecting the offending field to be reported but got %#v", err)
+	}
+}
+
+func TestClient_Updates(t *testing.T) {
+	catalogue := inmem.NewStoreCatalogue()
+	svc := service.New(catalogue, catalogue, service.WithUpdater(catalogue))
+	server := httptest.NewServer(web.New(svc, svc, svc, web.WithUpdater(svc)).Router())
+	t.Cleanup(server.Close)
+
+	client := New(server.URL)
+	ctx := context.Background()
+	if _, err := client.AddNew(ctx, "Loki", "Marvel"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, etag, err := client.FindWithETag(ctx, "Loki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if etag != `"1-application/json"` {
+		t.Errorf("was expecting the film to be served at ETag \"1-application/json\" but got %q", etag)
+	}
+
+	updated, next, err := client.Update(ctx, "Loki", etag, api.UpdateFilmRequest{Director: "Disney", Release: "Regular"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *updated != (api.Film{Name: "Loki", Director: "Disney", Release: "Regular"}) || next != `"2-application/json"` {
+		t.Errorf("received unexpected film %#v at ETag %q", updated, next)
+	}
+
+	var conflict *driven.FilmVersionConflictError
+	if _, _, err := client.Update(ctx, "Loki", etag, api.UpdateFilmRequest{Director: "Marvel", Release: "Old"}); !errors.As(err, &conflict) || conflict.Expected != 1 {
+		t.Errorf("was expecting FilmVersionConflictError but got %v", err)
+	}
+
+	var notFound *driven.FilmNotFoundError
+	if _, _, err := client.Update(ctx, "Thor", next, api.UpdateFilmRequest{Director: "Marvel", Release: "Old"}); !errors.As(err, &notFound) {
+		t.Errorf("was expecting FilmNotFoundError but got %v", err)
+	}
+}
+
+// flaky answers 503 to the first failures requests and forwards the rest to next
+func flaky(failures int32, next http.Handler) (http.Handler, *int32) {
+	var requests int32
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}), &requests
+}
+
+func TestClient_RetriesIdempotentCalls(t *testing.T) {
+	store := newStore(t)
+	if _, err := New(store.URL).AddNew(context.Background(), "Loki", "Marvel"); err != nil {
+		t.Fatal(err)
+	}
+
+	handler, requests := flaky(2, store.Config.Handler)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client := New(server.URL, WithRetries(2, time.Millisecond))
+
+	if _, err := client.Find(context.Background(), "Loki"); err != nil || atomic.LoadInt32(requests) != 3 {
+		t.Errorf("was expecting the find to succeed on its third attempt but got %v after %d", err, atomic.LoadInt32(requests))
+	}
+
+	atomic.StoreInt32(requests, 0)
+	var apiErr *APIError
+	if _, err := client.AddNew(context.Background(), "Thor", "Marvel"); !errors.As(err, &apiErr) || apiErr.Status != http.StatusServiceUnavailable {
+		t.Errorf("was expecting the add to fail without being retried but got %v", err)
+	}
+	if atomic.LoadInt32(requests) != 1 {
+		t.Errorf("was expecting a single attempt to add the film but got %d", atomic.LoadInt32(requests))
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	_, err := New(server.URL, WithTimeout(20*time.Millisecond), WithRetries(0, 0)).Find(context.Background(), "Loki")
+	if err == nil || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("was expecting the call to time out but got %v after %v", err, time.Since(start))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := New(server.URL).Find(ctx, "Loki"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("was expecting the context deadline to end the call but got %v", err)
+	}
+}
+
+func TestClient_ProblemDetails(t *testing.T) {
+	client := New(newStore(t).URL)
+
+	var apiErr *APIError
+	_, err := client.Return(context.Background(), api.Rental{Name: "Loki", Days: 1}, api.Rental{Name: "Thor", Days: 1})
+	switch {
+	case !errors.As(err, &apiErr):
+		t.Fatalf("was expecting an APIError but got %v", err)
+	case apiErr.RequestID == "" || apiErr.Title != "Bad Request":
+		t.Errorf("was expecting the problem to be decoded but got %#v", apiErr)
+	case len(apiErr.Errors) != 2 || apiErr.Errors[0].Field != "return.0.name" || apiErr.Errors[1].Field != "return.1.name":
+		t.Errorf("was expecting both unknown films to be listed but got %#v", apiErr.Errors)
+	}
+}
+
+func TestClient_APIKey(t *testing.T) {
+	keys, err := auth.NewAPIKeys([]auth.APIKey{{Hash: auth.HashAPIKey("kiosk"), Subject: "kiosk-1", Role: "clerk"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalogue := inmem.NewStoreCatalogue()
+	svc := service.New(catalogue, catalogue)
+	server := httptest.NewServer(web.New(svc, svc, svc, web.WithAuthenticator(keys)).Router())
+	defer server.Close()
+
+	var apiErr *APIError
+	if _, err := New(server.URL).Find(context.Background(), "Loki"); !errors.As(err, &apiErr) || apiErr.Status != http.StatusUnauthorized {
+		t.Errorf("was expecting an anonymous call to be refused but got %v", err)
+	}
+	var notFound *driven.FilmNotFoundError
+	if _, err := New(server.URL, WithAPIKey("kiosk")).Find(context.Background(), "Loki"); !errors.As(err, &notFound) {
+		t.Errorf("was expecting the kiosk to be let through but got %v", err)
+	}
+}
+
+func TestClient_Stores(t *testing.T) {
+	catalogue, outbox, stores := inmem.NewStoreCatalogue(), inmem.NewOutbox(), inmem.NewStores()
+	svc := service.New(catalogue, catalogue,
+		service.WithUnitOfWork(inmem.NewUnitOfWork(catalogue, outbox, stores)),
+		service.WithStores(stores),
+	)
+	server := httptest.NewServer(web.New(svc, svc, svc, web.WithStores(svc, svc, svc)).Router())
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	north, south := New(server.URL, WithStore("north")), New(server.URL, WithStore("south"))
+	if _, err := north.AddNew(ctx, "Loki", "Marvel"); err != nil {
+		t.Fatal(err)
+	}
+
+	if stock, err := north.AddStock(ctx, "Loki", 2); err != nil || *stock != (api.Stock{Film: "Loki", Copies: 2}) {
+		t.Fatalf("was expecting north to stock 2 copies but got %#v: %v", stock, err)
+	}
+	if _, err := north.AddStock(ctx, "Morbius", 1); !errors.As(err, &driven.TypeFilmNotFound) {
+		t.Errorf("was expecting a film which is not catalogued to be refused but got %v", err)
+	}
+	if err := north.ChangePrices(ctx, api.PriceList{Premium: 50, Basic: 20}); err != nil {
+		t.Fatal(err)
+	}
+
+	invoice, err := north.Return(ctx, api.Rental{Name: "Loki", Days: 2})
+	if err != nil || invoice.Price != 100 {
+		t.Errorf("was expecting north to charge its own prices but got %#v: %v", invoice, err)
+	}
+	if invoice, err := south.Return(ctx, api.Rental{Name: "Loki", Days: 2}); err != nil || invoice.Price != 80 {
+		t.Errorf("was expecting south to take back a film it does not stock at the default prices but got %#v: %v", invoice, err)
+	}
+
+	if inventory, err := south.Inventory(ctx); err != nil || len(inventory) != 0 {
+		t.Errorf("was expecting the stock of north to be invisible to south but got %#v: %v", inventory, err)
+	}
+	if invoices, err := south.Invoices(ctx); err != nil || len(invoices) != 1 || invoices[0].Store != "south" {
+		t.Errorf("was expecting the invoices of north to be invisible to south but got %#v: %v", invoices, err)
+	}
+	invoices, err := north.Invoices(ctx)
+	if err != nil || len(invoices) != 1 || invoices[0].Store != "north" || invoices[0].Price != 100 {
+		t.Errorf("was expecting north to keep its invoice but got %#v: %v", invoices, err)
+	}
+	if prices, err := south.PriceList(ctx); err != nil || *prices != (api.PriceList{Premium: 40, Basic: 30}) {
+		t.Errorf("was expecting south to keep the default prices but got %#v: %v", prices, err)
+	}
+}
+
+func TestClient_Transfers(t *testing.T) {
+	catalogue, outbox, stores := inmem.NewStoreCatalogue(), inmem.NewOutbox(), inmem.NewStores()
+	svc := service.New(catalogue, catalogue,
+		service.WithUnitOfWork(inmem.NewUnitOfWork(catalogue, outbox, stores)),
+		service.WithStores(stores),
+	)
+	server := httptest.NewServer(web.New(svc, svc, svc, web.WithStores(svc, svc, svc), web.WithTransfers(svc)).Router())
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	north, south := New(server.URL, WithStore("north")), New(server.URL, WithStore("south"))
+	if _, err := north.AddNew(ctx, "Loki", "Marvel"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := north.AddStock(ctx, "Loki", 3); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := south.RequestTransfer(ctx, "Morbius", "north", "south", 1); !errors.As(err, &driven.TypeFilmNotFound) {
+		t.Errorf("was expecting a film which is not catalogued to be refused but got %v", err)
+	}
+	transfer, err := south.RequestTransfer(ctx, "Loki", "north", "south", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := north.ApproveTransfer(ctx, transfer.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := north.ShipTransfer(ctx, transfer.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, client := range []*Client{north, south} {
+		if inventory, err := client.Inventory(ctx); err != nil || len(inventory) > 1 || len(inventory) == 1 && inventory[0].Copies != 1 {
+			t.Errorf("was expecting the shipped copies to be stocked nowhere but got %#v: %v", inventory, err)
+		}
+	}
+	if transfer, err = south.ReceiveTransfer(ctx, transfer.ID); err != nil || transfer.Status != "Received" {
+		t.Fatalf("was expecting the transfer to be received but got %#v: %v", transfer, err)
+	}
+	if inventory, err := south.Inventory(ctx); err != nil || len(inventory) != 1 || inventory[0].Copies != 2 {
+		t.Errorf("was expecting south to stock the received copies but got %#v: %v", inventory, err)
+	}
+
+	if transfers, err := north.Transfers(ctx); err != nil || len(transfers) != 1 || transfers[0] != *transfer {
+		t.Errorf("was expecting north to see %#v but got %#v: %v", transfer, transfers, err)
+	}
+	if _, err := north.Transfer(ctx, "missing"); !errors.As(err, &driven.TypeTransferNotFound) {
+		t.Errorf("was expecting TransferNotFoundError but got %v", err)
+	}
+}
+
+func TestClient_HonoursRetryAfter(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"name":"Loki","director":"Marvel","release":"New"}`))
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	if _, err := New(server.URL, WithRetries(1, time.Millisecond)).Find(context.Background(), "Loki"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("was expecting the retry to wait for Retry-After but it came after %s", elapsed)
+	}
+}
+
+func TestClient_RetriesPostsUnderIdempotencyKey(t *testing.T) {
+	catalogue := inmem.NewStoreCatalogue()
+	svc := service.New(catalogue, catalogue, service.WithLister(catalogue))
+	router := web.New(svc, svc, svc, web.WithIdempotency(inmem.NewIdempotencyStore(), time.Hour)).Router()
+
+	// the first response is lost after the film has been added
+	var requests int32
+	keys := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys[r.Header.Get("Idempotency-Key")] = true
+		if atomic.AddInt32(&requests, 1) == 1 {
+			router.ServeHTTP(httptest.NewRecorder(), r)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		router.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := New(server.URL, WithRetries(1, time.Millisecond), WithIdempotencyKeys())
+	if film, err := client.AddNew(context.Background(), "Loki", "Marvel"); err != nil || film.Name != "Loki" {
+		t.Fatalf("was expecting the retry to be replayed the film added by the lost attempt but got %#v: %v", film, err)
+	}
+	if requests != 2 || len(keys) != 1 || keys[""] {
+		t.Errorf("was expecting both attempts to be sent under the same key but got %v after %d", keys, requests)
+	}
+}
+
+func TestClient_PropagatesTrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	catalogue := inmem.NewStoreCatalogue()
+	svc := service.New(catalogue, catalogue, service.WithTracerProvider(provider))
+	server := httptest.NewServer(web.New(svc, svc, svc, web.WithTracerProvider(provider)).Router())
+	defer server.Close()
+
+	ctx, span := provider.Tracer("kiosk").Start(context.Background(), "checkout")
+	New(server.URL).Find(ctx, "Loki")
+	span.End()
+
+	for _, served := range exporter.GetSpans() {
+		if served.Name == "GET /catalogue/film" {
+			if served.SpanContext.TraceID() != span.SpanContext().TraceID() || served.Parent.SpanID() != span.SpanContext().SpanID() {
+				t.Errorf("was expecting the request to be traced within the span of the caller")
+			}
+			return
+		}
+	}
+	t.Errorf("was expecting the request to be traced by the store but got %d spans", len(exporter.GetSpans()))
+}
@@ -0,0 +1,65 @@
+// boltctl performs offline maintenance of the bolt catalogue used by single binary deployments
+//
+//	boltctl backup -db videostore.bolt -out videostore.bak
+//	boltctl compact -db videostore.bolt -out videostore.compact.bolt
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/bolt"
+	"log"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	cmd := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	db := cmd.String("db", "videostore.bolt", "bolt database to read from")
+	out := cmd.String("out", "", "file to write the backup or compacted database to")
+	cmd.Parse(os.Args[2:])
+
+	if *out == "" {
+		log.Fatal("-out must be set")
+	}
+
+	catalogue, err := bolt.Open(*db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer catalogue.Close()
+
+	switch os.Args[1] {
+	case "backup":
+		err = backup(catalogue, *out)
+	case "compact":
+		err = catalogue.Compact(*out)
+	default:
+		usage()
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func backup(catalogue *bolt.Catalogue, path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := catalogue.Backup(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: boltctl [backup|compact] -db <path> -out <path>")
+	os.Exit(2)
+}
@@ -0,0 +1,71 @@
+// projections rebuilds a read model from scratch by replaying the event log, saves it along with its checkpoint
+// for the server to resume from and prints it as JSON. The event log is locked while the server runs, its own
+// projections are rebuilt through POST /projections/rebuild on its admin endpoints instead
+//
+//	projections rebuild -event-log videostore.events -name revenue_per_day
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/eventstore"
+	"github.com/shawnritchie/go-video-store/internal/projection"
+	"log"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "rebuild" {
+		usage()
+	}
+
+	cmd := flag.NewFlagSet("rebuild", flag.ExitOnError)
+	eventLog := cmd.String("event-log", "videostore.events", "append only event log to replay")
+	name := cmd.String("name", "", "projection to rebuild [films_by_director,currently_rented,revenue_per_day]")
+	director := cmd.String("director", "", "director to report on when rebuilding films_by_director")
+	cmd.Parse(os.Args[2:])
+
+	if _, err := os.Stat(*eventLog); err != nil {
+		log.Fatal(err)
+	}
+	store, err := eventstore.OpenFileStore(*eventLog)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+	checkpoints, err := eventstore.OpenFileCheckpoints(*eventLog + ".checkpoints")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	byDirector := projection.NewFilmsByDirector()
+	rented := projection.NewCurrentlyRented()
+	revenue := projection.NewRevenuePerDay()
+	runner := projection.NewRunner(store, checkpoints, byDirector, rented, revenue)
+
+	if err := runner.Rebuild(*name); err != nil {
+		log.Fatal(err)
+	}
+
+	var model interface{}
+	switch *name {
+	case byDirector.Name():
+		model = byDirector.Films(*director)
+	case rented.Name():
+		model = rented.Rentals()
+	case revenue.Name():
+		model = revenue.Revenue()
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(model); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: projections rebuild -event-log <path> -name <projection>")
+	os.Exit(2)
+}
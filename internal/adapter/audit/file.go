@@ -0,0 +1,174 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+type (
+	// FileLog appends every entry as a JSON line whose hash covers the hash of the previous line,
+	// editing or removing any line breaks the chain from that point onwards
+	FileLog struct {
+		mu      sync.Mutex
+		file    *os.File
+		entries []domain.AuditEntry
+	}
+
+	TamperedError struct {
+		Sequence uint64
+		Reason   string
+	}
+)
+
+// GenesisHash is the PrevHash of the first entry of every log
+var GenesisHash = strings.Repeat("0", sha256.Size*2)
+
+func (e *TamperedError) Error() string {
+	return fmt.Sprintf("audit log has been tampered with at entry %d: %s", e.Sequence, e.Reason)
+}
+
+// OpenFileLog opens the log at path verifying the whole chain before accepting new entries
+func OpenFileLog(path string) (*FileLog, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open audit log %q: %w", path, err)
+	}
+
+	entries, err := read(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &FileLog{
+		file:    file,
+		entries: entries,
+	}, nil
+}
+
+func (l *FileLog) Record(entry domain.AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.Sequence = uint64(len(l.entries) + 1)
+	entry.PrevHash = GenesisHash
+	if len(l.entries) > 0 {
+		entry.PrevHash = l.entries[len(l.entries)-1].Hash
+	}
+
+	hash, err := hashOf(entry)
+	if err != nil {
+		return err
+	}
+	entry.Hash = hash
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("unable to encode audit entry: %w", err)
+	}
+
+	// a short write or failed sync is truncated away, a partial line would fail verification on every open
+	offset, err := l.file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("unable to append audit entry: %w", err)
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return l.truncate(offset, fmt.Errorf("unable to append audit entry: %w", err))
+	}
+	if err := l.file.Sync(); err != nil {
+		return l.truncate(offset, fmt.Errorf("unable to sync audit log: %w", err))
+	}
+
+	l.entries = append(l.entries, entry)
+	return nil
+}
+
+// truncate drops whatever a failed Record wrote past offset and passes err through
+func (l *FileLog) truncate(offset int64, err error) error {
+	if truncErr := l.file.Truncate(offset); truncErr != nil {
+		return errors.Join(err, fmt.Errorf("unable to truncate audit log: %w", truncErr))
+	}
+	return err
+}
+
+func (l *FileLog) Query(query domain.AuditQuery) ([]domain.AuditEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var entries []domain.AuditEntry
+	for _, entry := range l.entries {
+		if query.Matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (l *FileLog) Close() error {
+	return l.file.Close()
+}
+
+// Verify checks the hash chain of a log returning a TamperedError for the first entry which does not hold
+func Verify(r io.Reader) error {
+	_, err := read(r)
+	return err
+}
+
+func read(r io.Reader) ([]domain.AuditEntry, error) {
+	var entries []domain.AuditEntry
+	prevHash := GenesisHash
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		sequence := uint64(len(entries) + 1)
+
+		var entry domain.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, &TamperedError{Sequence: sequence, Reason: "entry cannot be decoded"}
+		}
+
+		switch {
+		case entry.Sequence != sequence:
+			return nil, &TamperedError{Sequence: sequence, Reason: fmt.Sprintf("found sequence %d", entry.Sequence)}
+		case entry.PrevHash != prevHash:
+			return nil, &TamperedError{Sequence: sequence, Reason: "previous hash does not match"}
+		}
+
+		hash, err := hashOf(entry)
+		if err != nil {
+			return nil, err
+		}
+		if hash != entry.Hash {
+			return nil, &TamperedError{Sequence: sequence, Reason: "hash does not match its contents"}
+		}
+
+		entries = append(entries, entry)
+		prevHash = entry.Hash
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+func hashOf(entry domain.AuditEntry) (string, error) {
+	entry.Hash = ""
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return "", fmt.Errorf("unable to encode audit entry: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2021, 6, 9, 12, 0, 0, 0, time.UTC)
+
+func record(t *testing.T, log *FileLog, entity string, at time.Time) {
+	t.Helper()
+	err := log.Record(domain.AuditEntry{
+		Timestamp: at,
+		Actor:     "clerk",
+		Operation: "AddFilm",
+		Entity:    entity,
+		Inputs:    map[string]string{"name": entity},
+		Outcome:   domain.OutcomeSuccess,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileLog_RecordAndQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	log, err := OpenFileLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record(t, log, "film:Loki", start)
+	record(t, log, "film:Dune", start.Add(time.Hour))
+	record(t, log, "film:Loki", start.Add(2*time.Hour))
+	log.Close()
+
+	reopened, err := OpenFileLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	record(t, reopened, "film:Loki", start.Add(3*time.Hour))
+
+	entries, err := reopened.Query(domain.AuditQuery{Entity: "film:Loki", From: start.Add(time.Hour), To: start.Add(3 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Sequence != 3 {
+		t.Errorf("was expecting only entry 3 to match but got %#v", entries)
+	}
+
+	all, _ := reopened.Query(domain.AuditQuery{})
+	if len(all) != 4 || all[0].PrevHash != GenesisHash || all[3].PrevHash != all[2].Hash {
+		t.Errorf("was expecting 4 chained entries but got %#v", all)
+	}
+}
+
+func TestVerify_DetectsTampering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	log, err := OpenFileLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record(t, log, "film:Loki", start)
+	record(t, log, "film:Dune", start.Add(time.Hour))
+	record(t, log, "film:Alien", start.Add(2*time.Hour))
+	log.Close()
+
+	original, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Verify(bytes.NewReader(original)); err != nil {
+		t.Fatalf("untouched log should verify but got %v", err)
+	}
+
+	lines := bytes.SplitAfter(original, []byte("\n"))
+	tests := []struct {
+		name     string
+		log      []byte
+		sequence uint64
+	}{
+		{"EditedEntry", bytes.Replace(original, []byte(`"actor":"clerk"`), []byte(`"actor":"admin"`), 1), 1},
+		{"RemovedEntry", append(append([]byte{}, lines[0]...), lines[2]...), 2},
+		{"ReorderedEntries", append(append(append([]byte{}, lines[1]...), lines[0]...), lines[2]...), 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var tampered *TamperedError
+			if err := Verify(bytes.NewReader(test.log)); !errors.As(err, &tampered) {
+				t.Fatalf("was expecting TamperedError but got %v", err)
+			}
+			if tampered.Sequence != test.sequence {
+				t.Errorf("was expecting tampering to be detected at entry %d but got %d", test.sequence, tampered.Sequence)
+			}
+		})
+	}
+
+	if err := os.WriteFile(path, tests[0].log, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFileLog(path); err == nil {
+		t.Errorf("was expecting a tampered log to be refused")
+	}
+}
@@ -0,0 +1,110 @@
+package eventbus
+
+import (
+	"context"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"sync"
+)
+
+type (
+	// Broadcaster fans the envelopes it handles out to its followers and keeps the most recent ones so followers
+	// can resume where they left off. It never blocks the bus, a follower whose queue is full is dropped
+	Broadcaster struct {
+		mu        sync.Mutex
+		replay    []driven.PublishedEvent
+		capacity  int
+		queueSize int
+		followers map[*follower]struct{}
+	}
+
+	follower struct {
+		events chan driven.PublishedEvent
+	}
+)
+
+func NewBroadcaster(capacity int, queueSize int) *Broadcaster {
+	return &Broadcaster{
+		capacity:  capacity,
+		queueSize: queueSize,
+		followers: map[*follower]struct{}{},
+	}
+}
+
+// Handle is meant to be subscribed to the bus, envelopes the relay publishes again are ignored
+func (b *Broadcaster) Handle(envelope driver.Envelope) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if n := len(b.replay); n > 0 && envelope.Sequence <= b.replay[n-1].ID {
+		return
+	}
+
+	event := driven.PublishedEvent{ID: envelope.Sequence, Event: envelope.Event, OccurredAt: envelope.OccurredAt}
+	if len(b.replay) == b.capacity {
+		b.replay = append(b.replay[:0], b.replay[1:]...)
+	}
+	b.replay = append(b.replay, event)
+
+	for f := range b.followers {
+		select {
+		case f.events <- event:
+		default:
+			b.drop(f)
+		}
+	}
+}
+
+// Follow replays the retained events after lastID, an EventsExpiredError is returned when some of the events
+// following lastID are no longer retained
+func (b *Broadcaster) Follow(ctx context.Context, lastID uint64) (<-chan driven.PublishedEvent, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	replay, err := b.since(lastID)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &follower{events: make(chan driven.PublishedEvent, len(replay)+b.queueSize)}
+	for _, event := range replay {
+		f.events <- event
+	}
+	b.followers[f] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.drop(f)
+	}()
+	return f.events, nil
+}
+
+// since replays the buffered events following lastID. A follower starting without an ID, or with ID 0, is served the
+// whole buffer even when older events have already been evicted, it is not told about the gap
+func (b *Broadcaster) since(lastID uint64) ([]driven.PublishedEvent, error) {
+	if lastID == 0 {
+		return b.replay, nil
+	}
+
+	// the buffer only holds what this process has relayed, a durable outbox continues its sequence across restarts
+	// while the in memory one starts over, so an ID ahead of the newest event is as stale as an evicted one
+	if len(b.replay) == 0 || lastID+1 < b.replay[0].ID || lastID > b.replay[len(b.replay)-1].ID {
+		return nil, &driven.EventsExpiredError{LastID: lastID}
+	}
+
+	for i, event := range b.replay {
+		if event.ID > lastID {
+			return b.replay[i:], nil
+		}
+	}
+	return nil, nil
+}
+
+func (b *Broadcaster) drop(f *follower) {
+	if _, ok := b.followers[f]; ok {
+		delete(b.followers, f)
+		close(f.events)
+	}
+}
@@ -0,0 +1,98 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"testing"
+)
+
+func envelope(sequence uint64) driver.Envelope {
+	return driver.Envelope{Sequence: sequence, Event: domain.FilmAdded{Name: "Alien", Director: "Scott", Release: domain.Old}}
+}
+
+func receive(t *testing.T, events <-chan driven.PublishedEvent, ids ...uint64) {
+	t.Helper()
+	for _, id := range ids {
+		select {
+		case event := <-events:
+			if event.ID != id {
+				t.Fatalf("was expecting event %d but got %d", id, event.ID)
+			}
+		default:
+			t.Fatalf("was expecting event %d to be delivered", id)
+		}
+	}
+}
+
+func TestBroadcaster_ReplayAfterLastID(t *testing.T) {
+	b := NewBroadcaster(3, 4)
+	for sequence := uint64(1); sequence <= 5; sequence++ {
+		b.Handle(envelope(sequence))
+	}
+	b.Handle(envelope(4))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := b.Follow(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receive(t, events, 4, 5)
+
+	b.Handle(envelope(6))
+	receive(t, events, 6)
+}
+
+func TestBroadcaster_ExpiredLastID(t *testing.T) {
+	b := NewBroadcaster(3, 4)
+	for sequence := uint64(1); sequence <= 5; sequence++ {
+		b.Handle(envelope(sequence))
+	}
+
+	for _, lastID := range []uint64{1, 9} {
+		var expired *driven.EventsExpiredError
+		if _, err := b.Follow(context.Background(), lastID); !errors.As(err, &expired) || expired.LastID != lastID {
+			t.Errorf("was expecting the events following %d to have expired but got %v", lastID, err)
+		}
+	}
+
+	if _, err := b.Follow(context.Background(), 2); err != nil {
+		t.Errorf("was expecting every event following 2 to be retained but got %v", err)
+	}
+}
+
+func TestBroadcaster_SlowFollowerIsDropped(t *testing.T) {
+	b := NewBroadcaster(10, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	slow, _ := b.Follow(ctx, 0)
+	fast, _ := b.Follow(ctx, 0)
+
+	b.Handle(envelope(1))
+	receive(t, fast, 1)
+	b.Handle(envelope(2))
+	receive(t, fast, 2)
+
+	receive(t, slow, 1)
+	if _, open := <-slow; open {
+		t.Error("was expecting the slow follower to have been dropped")
+	}
+}
+
+func TestBroadcaster_FollowEndsWithContext(t *testing.T) {
+	b := NewBroadcaster(10, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	events, _ := b.Follow(ctx, 0)
+	cancel()
+
+	if _, open := <-events; open {
+		t.Error("was expecting the channel to be closed once the context is done")
+	}
+	b.Handle(envelope(1))
+}
@@ -0,0 +1,59 @@
+package eventbus
+
+import (
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"sync"
+)
+
+type (
+	Handler func(envelope driver.Envelope)
+
+	// Bus delivers every published envelope to its subscribers in the order they subscribed. Handlers run on the
+	// publishing goroutine so anything slow must be handed off
+	Bus struct {
+		mu       sync.RWMutex
+		next     uint64
+		handlers []subscription
+	}
+
+	subscription struct {
+		id      uint64
+		handler Handler
+	}
+)
+
+func New() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers handler for every envelope published from now on, the returned func removes it again
+func (b *Bus) Subscribe(handler Handler) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.next++
+	id := b.next
+	b.handlers = append(b.handlers, subscription{id: id, handler: handler})
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		for i, s := range b.handlers {
+			if s.id == id {
+				b.handlers = append(b.handlers[:i:i], b.handlers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (b *Bus) Publish(envelope driver.Envelope) {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, s := range handlers {
+		s.handler(envelope)
+	}
+}
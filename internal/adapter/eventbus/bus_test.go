@@ -0,0 +1,93 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/inmem"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"testing"
+	"time"
+)
+
+func TestBus_PublishInSubscriptionOrder(t *testing.T) {
+	bus := New()
+	var received []string
+	bus.Subscribe(func(driver.Envelope) { received = append(received, "first") })
+	unsubscribe := bus.Subscribe(func(driver.Envelope) { received = append(received, "second") })
+	bus.Subscribe(func(driver.Envelope) { received = append(received, "third") })
+
+	bus.Publish(driver.Envelope{Sequence: 1})
+	unsubscribe()
+	bus.Publish(driver.Envelope{Sequence: 2})
+
+	expected := []string{"first", "second", "third", "first", "third"}
+	if len(received) != len(expected) {
+		t.Fatalf("was expecting %v but got %v", expected, received)
+	}
+	for i := range expected {
+		if received[i] != expected[i] {
+			t.Errorf("was expecting %v but got %v", expected, received)
+			break
+		}
+	}
+}
+
+func TestRelay_Flush(t *testing.T) {
+	outbox := inmem.NewOutbox()
+	for _, name := range []string{"Alien", "Heat", "Ran"} {
+		outbox.Enqueue(domain.FilmAdded{Name: name, Director: "Dwight", Release: domain.Old})
+	}
+
+	bus := New()
+	var published []uint64
+	bus.Subscribe(func(envelope driver.Envelope) { published = append(published, envelope.Sequence) })
+
+	relay := NewRelay(outbox, bus)
+	relay.batchSize = 2
+	if err := relay.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(published) != 3 || published[0] != 1 || published[2] != 3 {
+		t.Errorf("was expecting every envelope to be published in order but got %v", published)
+	}
+	if pending, _ := outbox.Pending(10); len(pending) != 0 {
+		t.Errorf("was expecting the outbox to be drained but got %#v", pending)
+	}
+}
+
+func TestRelay_FailureLeavesEventsPending(t *testing.T) {
+	outbox := &failingOutbox{OutboxStore: inmem.NewOutbox()}
+	outbox.Enqueue(domain.FilmAdded{Name: "Alien", Director: "Dwight", Release: domain.Old})
+
+	relay := NewRelay(outbox, New())
+	if err := relay.Flush(); !errors.Is(err, errMarkDispatched) {
+		t.Fatalf("was expecting %v but got %v", errMarkDispatched, err)
+	}
+
+	if pending, _ := outbox.Pending(10); len(pending) != 1 {
+		t.Errorf("was expecting the event to be published again but got %#v", pending)
+	}
+}
+
+func TestRelay_RunStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- NewRelay(inmem.NewOutbox(), New()).Run(ctx, time.Millisecond) }()
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("was expecting the relay to stop with the context but got %v", err)
+	}
+}
+
+var errMarkDispatched = errors.New("unable to mark dispatched")
+
+type failingOutbox struct {
+	driver.OutboxStore
+}
+
+func (*failingOutbox) MarkDispatched(uint64) error {
+	return errMarkDispatched
+}
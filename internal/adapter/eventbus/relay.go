@@ -0,0 +1,65 @@
+package eventbus
+
+import (
+	"context"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"time"
+)
+
+const defaultBatchSize = 100
+
+type (
+	// Relay moves committed events from the outbox onto the bus. An envelope is only marked dispatched once it
+	// has been published so a crash in between publishes it again, subscribers must tolerate duplicates. A
+	// subscriber which hands an envelope off must keep it durably before returning, such as a spooled
+	// webhook.Dispatcher, since the outbox forgets it once marked
+	Relay struct {
+		outbox    driver.OutboxStore
+		bus       *Bus
+		batchSize int
+	}
+)
+
+func NewRelay(outbox driver.OutboxStore, bus *Bus) *Relay {
+	return &Relay{
+		outbox:    outbox,
+		bus:       bus,
+		batchSize: defaultBatchSize,
+	}
+}
+
+// Flush publishes every pending envelope
+func (r *Relay) Flush() error {
+	for {
+		pending, err := r.outbox.Pending(r.batchSize)
+		if err != nil || len(pending) == 0 {
+			return err
+		}
+
+		for _, envelope := range pending {
+			r.bus.Publish(envelope)
+		}
+
+		if err := r.outbox.MarkDispatched(pending[len(pending)-1].Sequence); err != nil {
+			return err
+		}
+	}
+}
+
+// Run flushes every interval until ctx is cancelled
+func (r *Relay) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Flush(); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
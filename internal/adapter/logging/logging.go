@@ -0,0 +1,64 @@
+// Package logging builds the structured loggers of the video store, a record logged with the context of a request
+// carries the id of that request so its lines can be told apart from those of the requests served alongside it,
+// and the trace it belongs to when the request is traced
+package logging
+
+import (
+	"context"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+type (
+	// requestHandler adds the request id and trace of the context to every record it handles
+	requestHandler struct {
+		slog.Handler
+	}
+)
+
+const (
+	JSON = "json"
+	Text = "text"
+)
+
+// New logs the records of level and above to w as json or text, level is one of debug, info, warn or error
+func New(w io.Writer, level string, format string) (*slog.Logger, error) {
+	var minimum slog.Level
+	if err := minimum.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("unknown log level %q must be one of [debug,info,warn,error]", level)
+	}
+
+	options := &slog.HandlerOptions{Level: minimum}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case JSON:
+		handler = slog.NewJSONHandler(w, options)
+	case Text:
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return nil, fmt.Errorf("unknown log format %q must be one of [%s,%s]", format, JSON, Text)
+	}
+	return slog.New(requestHandler{handler}), nil
+}
+
+func (h requestHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := driven.RequestIDFrom(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h requestHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return requestHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h requestHandler) WithGroup(name string) slog.Handler {
+	return requestHandler{h.Handler.WithGroup(name)}
+}
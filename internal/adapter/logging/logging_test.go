@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+	"testing"
+)
+
+func TestNew_JSONCarriesRequestID(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New(&out, "info", JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := driven.WithRequestID(context.Background(), "abc123")
+	logger.With("component", "http").InfoContext(ctx, "request", "status", 200)
+	logger.DebugContext(ctx, "below the level")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("was expecting a single line above the level but got %q", out.String())
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["msg"] != "request" || record["request_id"] != "abc123" || record["component"] != "http" || record["status"] != 200.0 {
+		t.Errorf("received unexpected record %v", record)
+	}
+}
+
+func TestNew_CarriesTrace(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New(&out, "info", Text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	span := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35},
+		SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa},
+	})
+	logger.InfoContext(trace.ContextWithSpanContext(context.Background(), span), "request")
+
+	line := out.String()
+	if !strings.Contains(line, "trace_id="+span.TraceID().String()) || !strings.Contains(line, "span_id="+span.SpanID().String()) {
+		t.Errorf("was expecting the line to carry the trace of its context but got %q", line)
+	}
+}
+
+func TestNew_Text(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New(&out, "DEBUG", "TEXT")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Debug("started")
+	if line := out.String(); !strings.Contains(line, "level=DEBUG") || !strings.Contains(line, "msg=started") || strings.Contains(line, "request_id") {
+		t.Errorf("received unexpected line %q", line)
+	}
+}
+
+func TestNew_Rejected(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "verbose", JSON); err == nil {
+		t.Error("was expecting an unknown level to be rejected")
+	}
+	if _, err := New(&bytes.Buffer{}, "info", "logfmt"); err == nil {
+		t.Error("was expecting an unknown format to be rejected")
+	}
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metrics holds the collectors published on /metrics, the adapters and decorators of this package record into them
+type Metrics struct {
+	registry   *prometheus.Registry
+	requests   *prometheus.CounterVec
+	latency    *prometheus.HistogramVec
+	filmsAdded *prometheus.CounterVec
+	invoices   prometheus.Counter
+	revenue    *prometheus.CounterVec
+	validation *prometheus.CounterVec
+	repository *prometheus.HistogramVec
+}
+
+var standardMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true, http.MethodPatch: true,
+	http.MethodDelete: true, http.MethodConnect: true, http.MethodOptions: true, http.MethodTrace: true,
+}
+
+// New registers the collectors of the store along with those of the go runtime and the process
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "videostore_http_requests_total",
+			Help: "HTTP requests served by route template, method and status.",
+		}, []string{"route", "method", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "videostore_http_request_duration_seconds",
+			Help:    "Time taken to serve HTTP requests by route template and method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		filmsAdded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "videostore_films_added_total",
+			Help: "Films added to the catalogue by release.",
+		}, []string{"release"}),
+		invoices: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "videostore_invoices_issued_total",
+			Help: "Invoices issued for returned rentals.",
+		}),
+		revenue: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "videostore_invoice_revenue_sek_total",
+			Help: "Revenue invoiced in SEK by release of the film rented.",
+		}, []string{"release"}),
+		validation: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "videostore_validation_failures_total",
+			Help: "Requests the service rejected by type of validation error.",
+		}, []string{"type"}),
+		repository: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "videostore_repository_operation_duration_seconds",
+			Help:    "Time taken by repository operations by repository and operation.",
+			Buckets: []float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1},
+		}, []string{"repository", "operation"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests, m.latency, m.filmsAdded, m.invoices, m.revenue, m.validation, m.repository,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveRequest records a request served on route, the template it matched rather than its path so a label
+// value is kept per route and not per film. Methods other than the standard ones are recorded as OTHER so a client
+// cannot add label values by making up methods
+func (m *Metrics) ObserveRequest(route string, method string, status int, latency time.Duration) {
+	if !standardMethods[method] {
+		method = "OTHER"
+	}
+	m.requests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+	m.latency.WithLabelValues(route, method).Observe(latency.Seconds())
+}
+
+// observe records how long operation on repository has taken since start
+func (m *Metrics) observe(repository string, operation string, start time.Time) {
+	m.repository.WithLabelValues(repository, operation).Observe(time.Since(start).Seconds())
+}
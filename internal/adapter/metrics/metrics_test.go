@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	m.ObserveRequest("/catalogue/film", http.MethodGet, http.StatusOK, 20*time.Millisecond)
+	m.ObserveRequest("/catalogue/film", http.MethodGet, http.StatusNotFound, time.Millisecond)
+	m.ObserveRequest("unmatched", "FOO", http.StatusMethodNotAllowed, time.Millisecond)
+	m.ObserveRequest("unmatched", "BAR", http.StatusMethodNotAllowed, time.Millisecond)
+
+	res := httptest.NewRecorder()
+	m.Handler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := res.Body.String()
+	for _, want := range []string{
+		`videostore_http_requests_total{method="GET",route="/catalogue/film",status="200"} 1`,
+		`videostore_http_requests_total{method="GET",route="/catalogue/film",status="404"} 1`,
+		`videostore_http_request_duration_seconds_count{method="GET",route="/catalogue/film"} 2`,
+		`videostore_http_requests_total{method="OTHER",route="unmatched",status="405"} 2`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("was expecting %q to be exposed but got\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, `method="FOO"`) {
+		t.Errorf("was expecting made up methods not to be exposed but got\n%s", body)
+	}
+}
+
+func TestMetrics_ProjectionLag(t *testing.T) {
+	m := New()
+	m.ObserveProjectionLag(func() (map[string]uint64, error) {
+		return map[string]uint64{"currently_rented": 3, "revenue_per_day": 0}, nil
+	})
+
+	res := httptest.NewRecorder()
+	m.Handler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := res.Body.String()
+	for _, want := range []string{
+		`videostore_projection_lag_events{projection="currently_rented"} 3`,
+		`videostore_projection_lag_events{projection="revenue_per_day"} 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("was expecting %q to be exposed but got\n%s", want, body)
+		}
+	}
+}
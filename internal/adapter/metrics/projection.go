@@ -0,0 +1,34 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// lagCollector asks for the lag of every projection on each scrape, so the gauge is never older than the scrape
+type lagCollector struct {
+	desc *prometheus.Desc
+	lag  func() (map[string]uint64, error)
+}
+
+// ObserveProjectionLag publishes how many events each projection is behind the head of the event log, as
+// reported by lag whenever the metrics are scraped
+func (m *Metrics) ObserveProjectionLag(lag func() (map[string]uint64, error)) {
+	m.registry.MustRegister(&lagCollector{
+		desc: prometheus.NewDesc("videostore_projection_lag_events",
+			"Events each projection is behind the head of the event log.", []string{"projection"}, nil),
+		lag: lag,
+	})
+}
+
+func (c *lagCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *lagCollector) Collect(ch chan<- prometheus.Metric) {
+	lag, err := c.lag()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	for projection, events := range lag {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(events), projection)
+	}
+}
@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"time"
+)
+
+type (
+	catalogue struct {
+		next    driver.Catalogue
+		metrics *Metrics
+	}
+
+	stores struct {
+		next    driver.Stores
+		metrics *Metrics
+	}
+
+	outbox struct {
+		next    driver.Outbox
+		metrics *Metrics
+	}
+
+	outboxStore struct {
+		outbox
+		store driver.OutboxStore
+	}
+
+	unitOfWork struct {
+		next    driver.UnitOfWork
+		metrics *Metrics
+	}
+
+	// tx times the repositories taking part in a unit of work like those used outside of one
+	tx struct {
+		next    driver.Tx
+		metrics *Metrics
+	}
+)
+
+// NewCatalogue times every operation on the catalogue
+func NewCatalogue(next driver.Catalogue, m *Metrics) driver.Catalogue {
+	return &catalogue{next: next, metrics: m}
+}
+
+// NewStores times every operation on the stores
+func NewStores(next driver.Stores, m *Metrics) driver.Stores {
+	return &stores{next: next, metrics: m}
+}
+
+// NewOutbox times the events enqueued in the outbox and their dispatch
+func NewOutbox(next driver.OutboxStore, m *Metrics) driver.OutboxStore {
+	return &outboxStore{outbox: outbox{next: next, metrics: m}, store: next}
+}
+
+// NewUnitOfWork times every unit of work as a whole along with the operations made within it
+func NewUnitOfWork(next driver.UnitOfWork, m *Metrics) driver.UnitOfWork {
+	return &unitOfWork{next: next, metrics: m}
+}
+
+func (c *catalogue) FindBy(name string) (*domain.Film, error) {
+	defer c.metrics.observe("catalogue", "FindBy", time.Now())
+	return c.next.FindBy(name)
+}
+
+func (c *catalogue) FindAll(names []string) ([]domain.Film, error) {
+	defer c.metrics.observe("catalogue", "FindAll", time.Now())
+	return c.next.FindAll(names)
+}
+
+func (c *catalogue) InsertIfAbsent(film domain.Film) error {
+	defer c.metrics.observe("catalogue", "InsertIfAbsent", time.Now())
+	return c.next.InsertIfAbsent(film)
+}
+
+func (c *catalogue) List() ([]domain.Film, error) {
+	defer c.metrics.observe("catalogue", "List", time.Now())
+	return c.next.List()
+}
+
+func (c *catalogue) Update(film domain.Film) error {
+	defer c.metrics.observe("catalogue", "Update", time.Now())
+	return c.next.Update(film)
+}
+
+func (s *stores) Stock(store domain.StoreID, film string) (int, error) {
+	defer s.metrics.observe("stores", "Stock", time.Now())
+	return s.next.Stock(store, film)
+}
+
+func (s *stores) AdjustStock(store domain.StoreID, film string, delta int) (int, error) {
+	defer s.metrics.observe("stores", "AdjustStock", time.Now())
+	return s.next.AdjustStock(store, film, delta)
+}
+
+func (s *stores) Inventory(store domain.StoreID) ([]domain.Stock, error) {
+	defer s.metrics.observe("stores", "Inventory", time.Now())
+	return s.next.Inventory(store)
+}
+
+func (s *stores) PriceList(store domain.StoreID) (domain.PriceList, error) {
+	defer s.metrics.observe("stores", "PriceList", time.Now())
+	return s.next.PriceList(store)
+}
+
+func (s *stores) SavePriceList(store domain.StoreID, prices domain.PriceList) error {
+	defer s.metrics.observe("stores", "SavePriceList", time.Now())
+	return s.next.SavePriceList(store, prices)
+}
+
+func (s *stores) SaveInvoice(invoice domain.IssuedInvoice) error {
+	defer s.metrics.observe("stores", "SaveInvoice", time.Now())
+	return s.next.SaveInvoice(invoice)
+}
+
+func (s *stores) Invoices(store domain.StoreID) ([]domain.IssuedInvoice, error) {
+	defer s.metrics.observe("stores", "Invoices", time.Now())
+	return s.next.Invoices(store)
+}
+
+func (s *stores) Transfer(id string) (*domain.Transfer, error) {
+	defer s.metrics.observe("stores", "Transfer", time.Now())
+	return s.next.Transfer(id)
+}
+
+func (s *stores) SaveTransfer(transfer domain.Transfer) error {
+	defer s.metrics.observe("stores", "SaveTransfer", time.Now())
+	return s.next.SaveTransfer(transfer)
+}
+
+func (s *stores) Transfers(store domain.StoreID) ([]domain.Transfer, error) {
+	defer s.metrics.observe("stores", "Transfers", time.Now())
+	return s.next.Transfers(store)
+}
+
+func (o *outbox) Enqueue(events ...domain.Event) error {
+	defer o.metrics.observe("outbox", "Enqueue", time.Now())
+	return o.next.Enqueue(events...)
+}
+
+func (o *outboxStore) Pending(limit int) ([]driver.Envelope, error) {
+	defer o.metrics.observe("outbox", "Pending", time.Now())
+	return o.store.Pending(limit)
+}
+
+func (o *outboxStore) MarkDispatched(sequence uint64) error {
+	defer o.metrics.observe("outbox", "MarkDispatched", time.Now())
+	return o.store.MarkDispatched(sequence)
+}
+
+func (u *unitOfWork) Atomically(fx func(tx driver.Tx) error) error {
+	defer u.metrics.observe("unit_of_work", "Atomically", time.Now())
+	return u.next.Atomically(func(next driver.Tx) error {
+		return fx(&tx{next: next, metrics: u.metrics})
+	})
+}
+
+func (t *tx) Catalogue() driver.Catalogue {
+	return NewCatalogue(t.next.Catalogue(), t.metrics)
+}
+
+func (t *tx) Outbox() driver.Outbox {
+	return &outbox{next: t.next.Outbox(), metrics: t.metrics}
+}
+
+func (t *tx) Stores() driver.Stores {
+	return NewStores(t.next.Stores(), t.metrics)
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/catalogtest"
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/inmem"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"testing"
+)
+
+func TestCatalogue(t *testing.T) {
+	catalogtest.Run(t, func(t *testing.T) driver.Catalogue {
+		return NewCatalogue(inmem.NewStoreCatalogue(), New())
+	})
+}
+
+func TestUnitOfWork_TimesOperations(t *testing.T) {
+	m := New()
+	catalogue, outbox, stores := inmem.NewStoreCatalogue(), inmem.NewOutbox(), inmem.NewStores()
+	uow := NewUnitOfWork(inmem.NewUnitOfWork(catalogue, outbox, stores), m)
+
+	err := uow.Atomically(func(tx driver.Tx) error {
+		if err := tx.Catalogue().InsertIfAbsent(domain.Film{Name: "Loki", Director: "Marvel", Release: domain.New}); err != nil {
+			return err
+		}
+		_, err := tx.Stores().AdjustStock(domain.DefaultStore, "Loki", 2)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, operation := range [][2]string{{"unit_of_work", "Atomically"}, {"catalogue", "InsertIfAbsent"}, {"stores", "AdjustStock"}} {
+		if count := observations(t, m, operation[0], operation[1]); count != 1 {
+			t.Errorf("was expecting %s %s to be timed once but got %d", operation[0], operation[1], count)
+		}
+	}
+}
+
+// observations returns how many times operation on repository has been timed
+func observations(t *testing.T, m *Metrics, repository string, operation string) uint64 {
+	families, err := m.registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "videostore_repository_operation_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["repository"] == repository && labels["operation"] == operation {
+				return metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+)
+
+type (
+	filmAppender struct {
+		next    driven.FilmAppender
+		metrics *Metrics
+	}
+
+	filmUpdater struct {
+		next    driven.FilmUpdater
+		metrics *Metrics
+	}
+
+	filmInvoicer struct {
+		next    driven.FilmInvoicer
+		prices  driven.PriceLists
+		metrics *Metrics
+	}
+)
+
+// validationFailures names the label every validation error is counted under, errors which are not listed are
+// failures of the store rather than of the request and are not counted
+var validationFailures = []struct {
+	err  error
+	kind string
+}{
+	{domain.UnknownReleaseError, "unknown_release"},
+	{domain.EmptyFilmNameError, "empty_film_name"},
+	{domain.EmptyFilmDirectorError, "empty_film_director"},
+	{domain.ReleaseUnchangedError, "release_unchanged"},
+	{domain.InvalidCopiesError, "invalid_copies"},
+	{domain.InvalidPriceListError, "invalid_price_list"},
+	{domain.InvalidStoreIDError, "invalid_store_id"},
+}
+
+// NewFilmAppender counts the films added to the catalogue by release and the validation errors the others are
+// rejected with
+func NewFilmAppender(next driven.FilmAppender, m *Metrics) driven.FilmAppender {
+	return &filmAppender{next: next, metrics: m}
+}
+
+// NewFilmUpdater counts the validation errors updates are rejected with
+func NewFilmUpdater(next driven.FilmUpdater, m *Metrics) driven.FilmUpdater {
+	return &filmUpdater{next: next, metrics: m}
+}
+
+// NewFilmInvoicer counts the invoices issued and the revenue they bring in by release. Invoices only carry their
+// total, every rental is priced again with the price list of the store to split it by release
+func NewFilmInvoicer(next driven.FilmInvoicer, prices driven.PriceLists, m *Metrics) driven.FilmInvoicer {
+	return &filmInvoicer{next: next, prices: prices, metrics: m}
+}
+
+func (a *filmAppender) AddNew(ctx context.Context, name string, director string) error {
+	return a.added(string(domain.New), a.next.AddNew(ctx, name, director))
+}
+
+func (a *filmAppender) AddRegular(ctx context.Context, name string, director string) error {
+	return a.added(string(domain.Regular), a.next.AddRegular(ctx, name, director))
+}
+
+func (a *filmAppender) AddOld(ctx context.Context, name string, director string) error {
+	return a.added(string(domain.Old), a.next.AddOld(ctx, name, director))
+}
+
+func (a *filmAppender) added(release string, err error) error {
+	if err != nil {
+		a.metrics.validationFailed(err)
+		return err
+	}
+	a.metrics.filmsAdded.WithLabelValues(release).Inc()
+	return nil
+}
+
+func (u *filmUpdater) UpdateFilm(ctx context.Context, film domain.Film) (*domain.Film, error) {
+	updated, err := u.next.UpdateFilm(ctx, film)
+	if err != nil {
+		u.metrics.validationFailed(err)
+	}
+	return updated, err
+}
+
+func (i *filmInvoicer) Invoice(ctx context.Context, request []driven.FilmReturn) (*domain.RentalInvoice, error) {
+	invoice, err := i.next.Invoice(ctx, request)
+	if err != nil {
+		i.metrics.validationFailed(err)
+		return nil, err
+	}
+
+	i.metrics.invoices.Inc()
+	prices, err := i.prices.PriceList(ctx)
+	if err != nil {
+		// the invoice has been issued, its revenue is kept even though it cannot be split
+		i.metrics.revenue.WithLabelValues("unknown").Add(float64(invoice.Cost))
+		return invoice, nil
+	}
+	for _, rental := range invoice.Rentals {
+		cost, _ := rental.PriceWith(prices)
+		i.metrics.revenue.WithLabelValues(string(rental.Film.Release)).Add(float64(cost))
+	}
+	return invoice, nil
+}
+
+// validationFailed counts every error a request has been rejected with, an invalid film or rental request is
+// counted once for each of the errors it lists
+func (m *Metrics) validationFailed(err error) {
+	var film *domain.InvalidFilmError
+	var rentals *driven.InvalidRentalRequestError
+	var notFound *driven.FilmNotFoundError
+	var exists *driven.FilmAlreadyExistError
+	var conflict *driven.FilmVersionConflictError
+	var stock *driven.InsufficientStockError
+	switch {
+	case errors.As(err, &film):
+		for _, err := range *film {
+			m.validationFailed(err)
+		}
+	case errors.As(err, &rentals):
+		for _, err := range *rentals {
+			m.validationFailed(err)
+		}
+	case errors.As(err, &notFound):
+		m.validation.WithLabelValues("film_not_found").Inc()
+	case errors.As(err, &exists):
+		m.validation.WithLabelValues("film_already_exists").Inc()
+	case errors.As(err, &conflict):
+		m.validation.WithLabelValues("film_version_conflict").Inc()
+	case errors.As(err, &stock):
+		m.validation.WithLabelValues("insufficient_stock").Inc()
+	default:
+		for _, failure := range validationFailures {
+			if errors.Is(err, failure.err) {
+				m.validation.WithLabelValues(failure.kind).Inc()
+				return
+			}
+		}
+	}
+}
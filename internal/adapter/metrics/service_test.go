@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/inmem"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/shawnritchie/go-video-store/internal/service"
+	"testing"
+)
+
+func newService() *service.StoreService {
+	catalogue := inmem.NewStoreCatalogue(
+		domain.Film{Name: "Matrix 11", Director: "Wachowski", Release: domain.New},
+		domain.Film{Name: "Spider Man", Director: "Raimi", Release: domain.Regular},
+		domain.Film{Name: "Out of Africa", Director: "Pollack", Release: domain.Old},
+	)
+	return service.New(catalogue, catalogue, service.WithUpdater(catalogue))
+}
+
+func TestFilmAppender_CountsFilmsAndFailures(t *testing.T) {
+	m := New()
+	appender := NewFilmAppender(newService(), m)
+	ctx := context.Background()
+
+	appender.AddNew(ctx, "Loki", "Marvel")
+	appender.AddNew(ctx, "Thor", "Marvel")
+	appender.AddOld(ctx, "Casablanca", "Curtiz")
+	appender.AddRegular(ctx, "Spider Man", "Raimi")
+	appender.AddRegular(ctx, "", "")
+
+	switch {
+	case testutil.ToFloat64(m.filmsAdded.WithLabelValues("New")) != 2:
+		t.Errorf("was expecting 2 new films to be counted")
+	case testutil.ToFloat64(m.filmsAdded.WithLabelValues("Old")) != 1 || testutil.ToFloat64(m.filmsAdded.WithLabelValues("Regular")) != 0:
+		t.Errorf("was expecting only the films added to be counted")
+	case testutil.ToFloat64(m.validation.WithLabelValues("film_already_exists")) != 1:
+		t.Errorf("was expecting the duplicate film to be counted as a validation failure")
+	case testutil.ToFloat64(m.validation.WithLabelValues("empty_film_name")) != 1 || testutil.ToFloat64(m.validation.WithLabelValues("empty_film_director")) != 1:
+		t.Errorf("was expecting every error of an invalid film to be counted")
+	}
+}
+
+func TestFilmInvoicer_CountsRevenueByRelease(t *testing.T) {
+	m := New()
+	svc := newService()
+	invoicer := NewFilmInvoicer(svc, svc, m)
+	ctx := context.Background()
+
+	invoice, err := invoicer.Invoice(ctx, []driven.FilmReturn{{FilmName: "Matrix 11", Days: 2}, {FilmName: "Out of Africa", Days: 7}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	invoicer.Invoice(ctx, []driven.FilmReturn{{FilmName: "Black Widow", Days: 1}, {FilmName: "Iron Man", Days: 1}})
+
+	newRevenue := testutil.ToFloat64(m.revenue.WithLabelValues("New"))
+	oldRevenue := testutil.ToFloat64(m.revenue.WithLabelValues("Old"))
+	switch {
+	case testutil.ToFloat64(m.invoices) != 1:
+		t.Errorf("was expecting only the issued invoice to be counted")
+	case newRevenue != 80 || oldRevenue != 90 || newRevenue+oldRevenue != float64(invoice.Cost):
+		t.Errorf("was expecting the revenue of %d to be split by release but got New %v and Old %v", invoice.Cost, newRevenue, oldRevenue)
+	case testutil.ToFloat64(m.validation.WithLabelValues("film_not_found")) != 2:
+		t.Errorf("was expecting every film which is not catalogued to be counted as a validation failure")
+	}
+}
+
+func TestFilmUpdater_CountsFailures(t *testing.T) {
+	m := New()
+	updater := NewFilmUpdater(newService(), m)
+
+	updater.UpdateFilm(context.Background(), domain.Film{Name: "Matrix 11", Director: "Wachowski", Release: domain.Old, Version: 7})
+	updater.UpdateFilm(context.Background(), domain.Film{Name: "Matrix 11", Release: domain.Old, Version: 1})
+
+	switch {
+	case testutil.ToFloat64(m.validation.WithLabelValues("film_version_conflict")) != 1:
+		t.Errorf("was expecting the stale update to be counted as a validation failure")
+	case testutil.ToFloat64(m.validation.WithLabelValues("empty_film_director")) != 1:
+		t.Errorf("was expecting the update without a director to be counted as a validation failure")
+	}
+}
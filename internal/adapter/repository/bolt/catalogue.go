@@ -0,0 +1,246 @@
+package bolt
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"go.etcd.io/bbolt"
+	"io"
+	"time"
+)
+
+type (
+	Catalogue struct {
+		db *bbolt.DB
+	}
+
+	// txCatalogue holds the catalogue operations shared by the single statement methods and the unit of work
+	txCatalogue struct {
+		tx *bbolt.Tx
+	}
+)
+
+var (
+	filmsBucket      = []byte("films")
+	byDirectorBucket = []byte("films_by_director")
+	byReleaseBucket  = []byte("films_by_release")
+	outboxBucket     = []byte("outbox")
+	stockBucket      = []byte("stock")
+	priceListsBucket = []byte("price_lists")
+	invoicesBucket   = []byte("invoices")
+	transfersBucket  = []byte("transfers")
+
+	// index keys are "<indexed value>\x00<film name>" so a prefix scan returns every film sharing the value
+	indexSeparator = []byte{0}
+)
+
+func Open(path string) (*Catalogue, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open bolt database %q: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, bucket := range [][]byte{filmsBucket, byDirectorBucket, byReleaseBucket, outboxBucket, stockBucket, priceListsBucket, invoicesBucket, transfersBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return fmt.Errorf("unable to create bucket %q: %w", bucket, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Catalogue{db: db}, nil
+}
+
+func (cat *Catalogue) Close() error {
+	return cat.db.Close()
+}
+
+func (cat *Catalogue) FindBy(name string) (film *domain.Film, err error) {
+	err = cat.db.View(func(tx *bbolt.Tx) error {
+		film, err = txCatalogue{tx}.FindBy(name)
+		return err
+	})
+	return film, err
+}
+
+func (cat *Catalogue) FindAll(names []string) (films []domain.Film, err error) {
+	err = cat.db.View(func(tx *bbolt.Tx) error {
+		films, err = txCatalogue{tx}.FindAll(names)
+		return err
+	})
+	return films, err
+}
+
+func (cat *Catalogue) InsertIfAbsent(film domain.Film) error {
+	return cat.db.Update(func(tx *bbolt.Tx) error {
+		return txCatalogue{tx}.InsertIfAbsent(film)
+	})
+}
+
+func (cat *Catalogue) Update(film domain.Film) error {
+	return cat.db.Update(func(tx *bbolt.Tx) error {
+		return txCatalogue{tx}.Update(film)
+	})
+}
+
+func (cat *Catalogue) List() (films []domain.Film, err error) {
+	err = cat.db.View(func(tx *bbolt.Tx) error {
+		films, err = txCatalogue{tx}.List()
+		return err
+	})
+	return films, err
+}
+
+// FindByDirector returns every film by the director ordered by name
+func (cat *Catalogue) FindByDirector(director string) (films []domain.Film, err error) {
+	err = cat.db.View(func(tx *bbolt.Tx) error {
+		films, err = txCatalogue{tx}.scanIndex(byDirectorBucket, director)
+		return err
+	})
+	return films, err
+}
+
+// FindByRelease returns every film of the release ordered by name
+func (cat *Catalogue) FindByRelease(release string) (films []domain.Film, err error) {
+	r, err := domain.ParseRelease(release)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cat.db.View(func(tx *bbolt.Tx) error {
+		films, err = txCatalogue{tx}.scanIndex(byReleaseBucket, string(r))
+		return err
+	})
+	return films, err
+}
+
+// Backup writes a consistent snapshot of the database to w without blocking writers
+func (cat *Catalogue) Backup(w io.Writer) (int64, error) {
+	var written int64
+	err := cat.db.View(func(tx *bbolt.Tx) (err error) {
+		written, err = tx.WriteTo(w)
+		return err
+	})
+	return written, err
+}
+
+// Compact copies the live data into a fresh database at path, reclaiming the space held by freed pages
+func (cat *Catalogue) Compact(path string) error {
+	dst, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return fmt.Errorf("unable to open compaction target %q: %w", path, err)
+	}
+	defer dst.Close()
+
+	if err := bbolt.Compact(dst, cat.db, 0); err != nil {
+		return fmt.Errorf("unable to compact into %q: %w", path, err)
+	}
+	return nil
+}
+
+func (c txCatalogue) FindBy(name string) (*domain.Film, error) {
+	data := c.tx.Bucket(filmsBucket).Get([]byte(name))
+	if data == nil {
+		return nil, &driven.FilmNotFoundError{Name: name}
+	}
+	return decodeFilm(data)
+}
+
+func (c txCatalogue) FindAll(names []string) (films []domain.Film, err error) {
+	bucket := c.tx.Bucket(filmsBucket)
+	for _, name := range names {
+		data := bucket.Get([]byte(name))
+		if data == nil {
+			continue
+		}
+		film, err := decodeFilm(data)
+		if err != nil {
+			return nil, err
+		}
+		films = append(films, *film)
+	}
+	return films, nil
+}
+
+func (c txCatalogue) InsertIfAbsent(film domain.Film) error {
+	films := c.tx.Bucket(filmsBucket)
+	if films.Get([]byte(film.Name)) != nil {
+		return &driven.FilmAlreadyExistError{Name: film.Name}
+	}
+
+	film.Version = 1
+	return c.put(film)
+}
+
+// Update moves the film to its new director and release in the indexes
+func (c txCatalogue) Update(film domain.Film) error {
+	current, err := c.FindBy(film.Name)
+	if err != nil {
+		return err
+	}
+	if current.Version != film.Version {
+		return &driven.FilmVersionConflictError{Name: film.Name, Expected: film.Version, Actual: current.Version}
+	}
+
+	if err := c.tx.Bucket(byDirectorBucket).Delete(indexKey(current.Director, current.Name)); err != nil {
+		return err
+	}
+	if err := c.tx.Bucket(byReleaseBucket).Delete(indexKey(string(current.Release), current.Name)); err != nil {
+		return err
+	}
+	film.Version++
+	return c.put(film)
+}
+
+func (c txCatalogue) put(film domain.Film) error {
+	data, err := encodeFilm(film)
+	if err != nil {
+		return err
+	}
+
+	if err := c.tx.Bucket(filmsBucket).Put([]byte(film.Name), data); err != nil {
+		return err
+	}
+
+	if err := c.tx.Bucket(byDirectorBucket).Put(indexKey(film.Director, film.Name), nil); err != nil {
+		return err
+	}
+	return c.tx.Bucket(byReleaseBucket).Put(indexKey(string(film.Release), film.Name), nil)
+}
+
+func (c txCatalogue) List() (films []domain.Film, err error) {
+	err = c.tx.Bucket(filmsBucket).ForEach(func(_, data []byte) error {
+		film, err := decodeFilm(data)
+		if err != nil {
+			return err
+		}
+		films = append(films, *film)
+		return nil
+	})
+	return films, err
+}
+
+func (c txCatalogue) scanIndex(bucket []byte, value string) (films []domain.Film, err error) {
+	prefix := append([]byte(value), indexSeparator...)
+	data := c.tx.Bucket(filmsBucket)
+	cursor := c.tx.Bucket(bucket).Cursor()
+	for k, _ := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = cursor.Next() {
+		film, err := decodeFilm(data.Get(k[len(prefix):]))
+		if err != nil {
+			return nil, err
+		}
+		films = append(films, *film)
+	}
+	return films, nil
+}
+
+func indexKey(value string, name string) []byte {
+	key := append([]byte(value), indexSeparator...)
+	return append(key, name...)
+}
@@ -0,0 +1,119 @@
+package bolt
+
+import (
+	"bytes"
+	"errors"
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/catalogtest"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var films = catalogtest.Films
+
+func newCatalogue(t *testing.T) *Catalogue {
+	t.Helper()
+	cat, err := Open(filepath.Join(t.TempDir(), "videostore.bolt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cat.Close() })
+	return cat
+}
+
+func setupCatalogue(t *testing.T) *Catalogue {
+	t.Helper()
+	return catalogtest.Seed(t, func(t *testing.T) driver.Catalogue {
+		return newCatalogue(t)
+	}).(*Catalogue)
+}
+
+func TestCatalogue(t *testing.T) {
+	catalogtest.Run(t, func(t *testing.T) driver.Catalogue {
+		return newCatalogue(t)
+	})
+}
+
+func TestInsertIfAbsent_DuplicateIsNotIndexed(t *testing.T) {
+	repo := setupCatalogue(t)
+
+	var duplicate = films[1]
+	duplicate.Director = "Someone Else"
+
+	if err := repo.InsertIfAbsent(duplicate); !errors.As(err, &driven.TypeFilmAlreadyExist) {
+		t.Errorf("was expecting TypeFilmAlreadyExist error but got %#v", err)
+	}
+
+	if found, err := repo.FindByDirector(duplicate.Director); err != nil || len(found) != 0 {
+		t.Errorf("rejected duplicate must not be indexed but found %#v, %v", found, err)
+	}
+}
+
+func TestFindByIndexes(t *testing.T) {
+	repo := setupCatalogue(t)
+	if err := repo.InsertIfAbsent(domain.Film{Name: "Loki", Director: "Marvel", Release: domain.Regular}); err != nil {
+		t.Fatal(err)
+	}
+
+	byDirector, err := repo.FindByDirector("Dwight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byDirector) != len(films) {
+		t.Errorf("was expecting %d films by Dwight but got %#v", len(films), byDirector)
+	}
+
+	byRelease, err := repo.FindByRelease("regular")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, film := range byRelease {
+		names = append(names, film.Name)
+	}
+	if len(names) != 3 || names[0] != "Loki" || names[1] != "Spider Man" || names[2] != "Spider Man 2" {
+		t.Errorf("was expecting regular releases ordered by name but got %v", names)
+	}
+
+	if _, err := repo.FindByRelease("Disney"); err == nil {
+		t.Errorf("was expecting unknown release to be rejected")
+	}
+}
+
+func TestBackupAndCompact(t *testing.T) {
+	repo := setupCatalogue(t)
+	dir := t.TempDir()
+
+	var backup bytes.Buffer
+	if _, err := repo.Backup(&backup); err != nil {
+		t.Fatal(err)
+	}
+
+	backupPath := filepath.Join(dir, "backup.bolt")
+	if err := os.WriteFile(backupPath, backup.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	compactPath := filepath.Join(dir, "compact.bolt")
+	if err := repo.Compact(compactPath); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{backupPath, compactPath} {
+		restored, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, film := range films {
+			if found, err := restored.FindBy(film.Name); err != nil || *found != catalogtest.Catalogued(film) {
+				t.Errorf("%s: was expecting %#v but found %#v, %v", filepath.Base(path), catalogtest.Catalogued(film), found, err)
+			}
+		}
+		restored.Close()
+	}
+}
@@ -0,0 +1,134 @@
+package bolt
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"go.etcd.io/bbolt"
+	"time"
+)
+
+type (
+	// Outbox stores pending events in the catalogue database so they are written in the same transaction as the films
+	Outbox struct {
+		db  *bbolt.DB
+		now func() time.Time
+	}
+
+	txOutbox struct {
+		tx  *bbolt.Tx
+		now func() time.Time
+	}
+
+	outboxRecordV1 struct {
+		Type       string          `json:"type"`
+		Data       json.RawMessage `json:"data"`
+		OccurredAt time.Time       `json:"occurredAt"`
+	}
+)
+
+func NewOutbox(catalogue *Catalogue) *Outbox {
+	return &Outbox{
+		db:  catalogue.db,
+		now: time.Now,
+	}
+}
+
+func (o *Outbox) Enqueue(events ...domain.Event) error {
+	return o.db.Update(func(tx *bbolt.Tx) error {
+		return txOutbox{tx: tx, now: o.now}.Enqueue(events...)
+	})
+}
+
+func (o *Outbox) Pending(limit int) (envelopes []driver.Envelope, err error) {
+	err = o.db.View(func(tx *bbolt.Tx) error {
+		envelopes, err = txOutbox{tx: tx, now: o.now}.pending(limit)
+		return err
+	})
+	return envelopes, err
+}
+
+// MarkDispatched removes every entry up to and including sequence, dispatched events are not kept
+func (o *Outbox) MarkDispatched(sequence uint64) error {
+	return o.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(outboxBucket)
+
+		// deleting through a cursor while iterating skips entries, so the keys are collected first
+		var dispatched [][]byte
+		cursor := bucket.Cursor()
+		for key, _ := cursor.First(); key != nil && binary.BigEndian.Uint64(key) <= sequence; key, _ = cursor.Next() {
+			dispatched = append(dispatched, key)
+		}
+
+		for _, key := range dispatched {
+			if err := bucket.Delete(key); err != nil {
+				return fmt.Errorf("unable to mark outbox entry %d dispatched: %w", binary.BigEndian.Uint64(key), err)
+			}
+		}
+		return nil
+	})
+}
+
+func (o txOutbox) Enqueue(events ...domain.Event) error {
+	bucket := o.tx.Bucket(outboxBucket)
+	occurredAt := o.now().UTC()
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("unable to encode %s event: %w", event.EventType(), err)
+		}
+
+		record, err := json.Marshal(outboxRecordV1{Type: event.EventType(), Data: data, OccurredAt: occurredAt})
+		if err != nil {
+			return fmt.Errorf("unable to encode %s event: %w", event.EventType(), err)
+		}
+
+		sequence, err := bucket.NextSequence()
+		if err != nil {
+			return fmt.Errorf("unable to enqueue %s event: %w", event.EventType(), err)
+		}
+		if err := bucket.Put(sequenceKey(sequence), append([]byte{recordVersion}, record...)); err != nil {
+			return fmt.Errorf("unable to enqueue %s event: %w", event.EventType(), err)
+		}
+	}
+	return nil
+}
+
+func (o txOutbox) pending(limit int) ([]driver.Envelope, error) {
+	var envelopes []driver.Envelope
+	cursor := o.tx.Bucket(outboxBucket).Cursor()
+	for key, value := cursor.First(); key != nil && len(envelopes) < limit; key, value = cursor.Next() {
+		envelope, err := decodeEnvelope(binary.BigEndian.Uint64(key), value)
+		if err != nil {
+			return nil, err
+		}
+		envelopes = append(envelopes, envelope)
+	}
+	return envelopes, nil
+}
+
+func decodeEnvelope(sequence uint64, data []byte) (driver.Envelope, error) {
+	if len(data) == 0 || data[0] != recordVersion {
+		return driver.Envelope{}, fmt.Errorf("unsupported outbox record for entry %d", sequence)
+	}
+
+	var record outboxRecordV1
+	if err := json.Unmarshal(data[1:], &record); err != nil {
+		return driver.Envelope{}, fmt.Errorf("unable to decode outbox entry %d: %w", sequence, err)
+	}
+
+	event, err := domain.DecodeEvent(record.Type, record.Data)
+	if err != nil {
+		return driver.Envelope{}, fmt.Errorf("outbox entry %d: %w", sequence, err)
+	}
+	return driver.Envelope{Sequence: sequence, Event: event, OccurredAt: record.OccurredAt}, nil
+}
+
+// sequenceKey is big endian so the bucket iterates entries in the order they were enqueued
+func sequenceKey(sequence uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, sequence)
+	return key
+}
@@ -0,0 +1,67 @@
+package bolt
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+)
+
+// recordVersion is written as the first byte of every stored film so the encoding can evolve without a migration
+const recordVersion byte = 2
+
+type (
+	filmRecordV1 struct {
+		Name     string `json:"name"`
+		Director string `json:"director"`
+		Release  string `json:"release"`
+	}
+
+	// filmRecordV2 adds the version of the film, films recorded before it are at version 1
+	filmRecordV2 struct {
+		Name     string `json:"name"`
+		Director string `json:"director"`
+		Release  string `json:"release"`
+		Version  uint64 `json:"version"`
+	}
+)
+
+func encodeFilm(film domain.Film) ([]byte, error) {
+	payload, err := json.Marshal(filmRecordV2{
+		Name:     film.Name,
+		Director: film.Director,
+		Release:  string(film.Release),
+		Version:  film.Version,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode film %q: %w", film.Name, err)
+	}
+	return append([]byte{recordVersion}, payload...), nil
+}
+
+func decodeFilm(data []byte) (*domain.Film, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("unable to decode empty film record")
+	}
+
+	var record filmRecordV2
+	switch data[0] {
+	case 1:
+		var v1 filmRecordV1
+		if err := json.Unmarshal(data[1:], &v1); err != nil {
+			return nil, fmt.Errorf("unable to decode film record: %w", err)
+		}
+		record = filmRecordV2{Name: v1.Name, Director: v1.Director, Release: v1.Release, Version: 1}
+	case 2:
+		if err := json.Unmarshal(data[1:], &record); err != nil {
+			return nil, fmt.Errorf("unable to decode film record: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported film record version %d", data[0])
+	}
+
+	release, err := domain.ParseRelease(record.Release)
+	if err != nil {
+		return nil, fmt.Errorf("film %q has been stored with a corrupted release %q: %w", record.Name, record.Release, err)
+	}
+	return &domain.Film{Name: record.Name, Director: record.Director, Release: release, Version: record.Version}, nil
+}
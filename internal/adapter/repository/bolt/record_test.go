@@ -0,0 +1,44 @@
+package bolt
+
+import (
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"testing"
+)
+
+func TestFilmRecord_RoundTrip(t *testing.T) {
+	film := domain.Film{Name: "Loki", Director: "Marvel", Release: domain.Old, Version: 3}
+
+	data, err := encodeFilm(film)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data[0] != recordVersion {
+		t.Errorf("was expecting record to be prefixed with version %d but got %d", recordVersion, data[0])
+	}
+
+	if decoded, err := decodeFilm(data); err != nil {
+		t.Error(err)
+	} else if *decoded != film {
+		t.Errorf("was expecting %#v but decoded %#v", film, *decoded)
+	}
+}
+
+func TestFilmRecord_DecodesV1AtVersion1(t *testing.T) {
+	data := append([]byte{1}, `{"name":"Loki","director":"Marvel","release":"Old"}`...)
+
+	want := domain.Film{Name: "Loki", Director: "Marvel", Release: domain.Old, Version: 1}
+	if decoded, err := decodeFilm(data); err != nil {
+		t.Error(err)
+	} else if *decoded != want {
+		t.Errorf("was expecting %#v but decoded %#v", want, *decoded)
+	}
+}
+
+func TestFilmRecord_UnsupportedVersion(t *testing.T) {
+	for _, data := range [][]byte{nil, {99, '{', '}'}} {
+		if _, err := decodeFilm(data); err == nil {
+			t.Errorf("was expecting record %v to be rejected", data)
+		}
+	}
+}
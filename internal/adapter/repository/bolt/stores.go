@@ -0,0 +1,304 @@
+package bolt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"go.etcd.io/bbolt"
+	"sort"
+	"time"
+)
+
+// issuedAtLayout is fixed width so the invoices of a store iterate in the order they were issued
+const issuedAtLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+type (
+	// Stores keeps the stock, prices and invoices of every store in the catalogue database, keys are prefixed
+	// with the store so a prefix scan never crosses into another store
+	Stores struct {
+		db *bbolt.DB
+	}
+
+	txStores struct {
+		tx *bbolt.Tx
+	}
+
+	priceListRecordV1 struct {
+		Premium domain.SEK `json:"premium"`
+		Basic   domain.SEK `json:"basic"`
+	}
+
+	transferRecordV1 struct {
+		ID          string    `json:"id"`
+		Film        string    `json:"film"`
+		From        string    `json:"from"`
+		To          string    `json:"to"`
+		Copies      int       `json:"copies"`
+		Status      string    `json:"status"`
+		RequestedAt time.Time `json:"requestedAt"`
+	}
+
+	invoiceRecordV1 struct {
+		ID       string          `json:"id"`
+		Store    string          `json:"store"`
+		Rentals  []domain.Rental `json:"rentals"`
+		Cost     domain.SEK      `json:"cost"`
+		IssuedAt time.Time       `json:"issuedAt"`
+	}
+)
+
+func NewStores(catalogue *Catalogue) *Stores {
+	return &Stores{db: catalogue.db}
+}
+
+func (s *Stores) Transfer(id string) (transfer *domain.Transfer, err error) {
+	err = s.db.View(func(tx *bbolt.Tx) error {
+		transfer, err = txStores{tx}.Transfer(id)
+		return err
+	})
+	return transfer, err
+}
+
+func (s *Stores) SaveTransfer(transfer domain.Transfer) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return txStores{tx}.SaveTransfer(transfer)
+	})
+}
+
+func (s *Stores) Transfers(store domain.StoreID) (transfers []domain.Transfer, err error) {
+	err = s.db.View(func(tx *bbolt.Tx) error {
+		transfers, err = txStores{tx}.Transfers(store)
+		return err
+	})
+	return transfers, err
+}
+
+func (s *Stores) Stock(store domain.StoreID, film string) (copies int, err error) {
+	err = s.db.View(func(tx *bbolt.Tx) error {
+		copies, err = txStores{tx}.Stock(store, film)
+		return err
+	})
+	return copies, err
+}
+
+func (s *Stores) AdjustStock(store domain.StoreID, film string, delta int) (copies int, err error) {
+	err = s.db.Update(func(tx *bbolt.Tx) error {
+		copies, err = txStores{tx}.AdjustStock(store, film, delta)
+		return err
+	})
+	return copies, err
+}
+
+func (s *Stores) Inventory(store domain.StoreID) (inventory []domain.Stock, err error) {
+	err = s.db.View(func(tx *bbolt.Tx) error {
+		inventory, err = txStores{tx}.Inventory(store)
+		return err
+	})
+	return inventory, err
+}
+
+func (s *Stores) PriceList(store domain.StoreID) (prices domain.PriceList, err error) {
+	err = s.db.View(func(tx *bbolt.Tx) error {
+		prices, err = txStores{tx}.PriceList(store)
+		return err
+	})
+	return prices, err
+}
+
+func (s *Stores) SavePriceList(store domain.StoreID, prices domain.PriceList) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return txStores{tx}.SavePriceList(store, prices)
+	})
+}
+
+func (s *Stores) SaveInvoice(invoice domain.IssuedInvoice) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return txStores{tx}.SaveInvoice(invoice)
+	})
+}
+
+func (s *Stores) Invoices(store domain.StoreID) (invoices []domain.IssuedInvoice, err error) {
+	err = s.db.View(func(tx *bbolt.Tx) error {
+		invoices, err = txStores{tx}.Invoices(store)
+		return err
+	})
+	return invoices, err
+}
+
+func (s txStores) Stock(store domain.StoreID, film string) (int, error) {
+	data := s.tx.Bucket(stockBucket).Get(indexKey(string(store), film))
+	if data == nil {
+		return 0, nil
+	}
+	return int(binary.BigEndian.Uint64(data)), nil
+}
+
+// AdjustStock deletes the key once the store holds no copy so the inventory is a plain prefix scan
+func (s txStores) AdjustStock(store domain.StoreID, film string, delta int) (int, error) {
+	available, err := s.Stock(store, film)
+	if err != nil {
+		return 0, err
+	}
+	copies := available + delta
+	if copies < 0 {
+		return 0, &driven.InsufficientStockError{Store: store, Film: film, Available: available, Requested: -delta}
+	}
+
+	bucket, key := s.tx.Bucket(stockBucket), indexKey(string(store), film)
+	if copies == 0 {
+		return 0, bucket.Delete(key)
+	}
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, uint64(copies))
+	if err := bucket.Put(key, value); err != nil {
+		return 0, fmt.Errorf("unable to adjust stock of %q at %q: %w", film, store, err)
+	}
+	return copies, nil
+}
+
+func (s txStores) Inventory(store domain.StoreID) ([]domain.Stock, error) {
+	prefix := indexKey(string(store), "")
+	inventory := []domain.Stock{}
+	cursor := s.tx.Bucket(stockBucket).Cursor()
+	for k, v := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cursor.Next() {
+		inventory = append(inventory, domain.Stock{Film: string(k[len(prefix):]), Copies: int(binary.BigEndian.Uint64(v))})
+	}
+	return inventory, nil
+}
+
+func (s txStores) PriceList(store domain.StoreID) (domain.PriceList, error) {
+	data := s.tx.Bucket(priceListsBucket).Get([]byte(store))
+	if data == nil {
+		return domain.DefaultPriceList, nil
+	}
+	if data[0] != recordVersion {
+		return domain.PriceList{}, fmt.Errorf("unsupported price list record for %q", store)
+	}
+
+	var record priceListRecordV1
+	if err := json.Unmarshal(data[1:], &record); err != nil {
+		return domain.PriceList{}, fmt.Errorf("unable to decode price list of %q: %w", store, err)
+	}
+	return domain.PriceList{Premium: record.Premium, Basic: record.Basic}, nil
+}
+
+func (s txStores) SavePriceList(store domain.StoreID, prices domain.PriceList) error {
+	record, err := json.Marshal(priceListRecordV1{Premium: prices.Premium, Basic: prices.Basic})
+	if err != nil {
+		return fmt.Errorf("unable to encode price list of %q: %w", store, err)
+	}
+	return s.tx.Bucket(priceListsBucket).Put([]byte(store), append([]byte{recordVersion}, record...))
+}
+
+func (s txStores) SaveInvoice(invoice domain.IssuedInvoice) error {
+	record, err := json.Marshal(invoiceRecordV1{
+		ID:       invoice.ID,
+		Store:    string(invoice.Store),
+		Rentals:  invoice.Rentals,
+		Cost:     invoice.Cost,
+		IssuedAt: invoice.IssuedAt.UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to encode invoice %q: %w", invoice.ID, err)
+	}
+
+	key := indexKey(string(invoice.Store), invoice.IssuedAt.UTC().Format(issuedAtLayout)+string(indexSeparator)+invoice.ID)
+	return s.tx.Bucket(invoicesBucket).Put(key, append([]byte{recordVersion}, record...))
+}
+
+func (s txStores) Invoices(store domain.StoreID) ([]domain.IssuedInvoice, error) {
+	prefix := indexKey(string(store), "")
+	var invoices []domain.IssuedInvoice
+	cursor := s.tx.Bucket(invoicesBucket).Cursor()
+	for k, v := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cursor.Next() {
+		if len(v) == 0 || v[0] != recordVersion {
+			return nil, fmt.Errorf("unsupported invoice record %q", k)
+		}
+
+		var record invoiceRecordV1
+		if err := json.Unmarshal(v[1:], &record); err != nil {
+			return nil, fmt.Errorf("unable to decode invoice %q: %w", k, err)
+		}
+		invoices = append(invoices, domain.IssuedInvoice{
+			ID:       record.ID,
+			Store:    domain.StoreID(record.Store),
+			Rentals:  record.Rentals,
+			Cost:     record.Cost,
+			IssuedAt: record.IssuedAt,
+		})
+	}
+	return invoices, nil
+}
+
+func (s txStores) Transfer(id string) (*domain.Transfer, error) {
+	data := s.tx.Bucket(transfersBucket).Get([]byte(id))
+	if data == nil {
+		return nil, &driven.TransferNotFoundError{ID: id}
+	}
+	return decodeTransfer(id, data)
+}
+
+func (s txStores) SaveTransfer(transfer domain.Transfer) error {
+	record, err := json.Marshal(transferRecordV1{
+		ID:          transfer.ID,
+		Film:        transfer.Film,
+		From:        string(transfer.From),
+		To:          string(transfer.To),
+		Copies:      transfer.Copies,
+		Status:      string(transfer.Status),
+		RequestedAt: transfer.RequestedAt.UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to encode transfer %q: %w", transfer.ID, err)
+	}
+	return s.tx.Bucket(transfersBucket).Put([]byte(transfer.ID), append([]byte{recordVersion}, record...))
+}
+
+// Transfers scans every transfer, they are few compared with films and invoices so they are not indexed by store
+func (s txStores) Transfers(store domain.StoreID) ([]domain.Transfer, error) {
+	var transfers []domain.Transfer
+	err := s.tx.Bucket(transfersBucket).ForEach(func(k, v []byte) error {
+		transfer, err := decodeTransfer(string(k), v)
+		if err != nil {
+			return err
+		}
+		if transfer.From == store || transfer.To == store {
+			transfers = append(transfers, *transfer)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(transfers, func(i, j int) bool {
+		if !transfers[i].RequestedAt.Equal(transfers[j].RequestedAt) {
+			return transfers[i].RequestedAt.Before(transfers[j].RequestedAt)
+		}
+		return transfers[i].ID < transfers[j].ID
+	})
+	return transfers, nil
+}
+
+func decodeTransfer(id string, data []byte) (*domain.Transfer, error) {
+	if len(data) == 0 || data[0] != recordVersion {
+		return nil, fmt.Errorf("unsupported transfer record %q", id)
+	}
+
+	var record transferRecordV1
+	if err := json.Unmarshal(data[1:], &record); err != nil {
+		return nil, fmt.Errorf("unable to decode transfer %q: %w", id, err)
+	}
+	return &domain.Transfer{
+		ID:          record.ID,
+		Film:        record.Film,
+		From:        domain.StoreID(record.From),
+		To:          domain.StoreID(record.To),
+		Copies:      record.Copies,
+		Status:      domain.TransferStatus(record.Status),
+		RequestedAt: record.RequestedAt,
+	}, nil
+}
@@ -0,0 +1,51 @@
+package bolt
+
+import (
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"go.etcd.io/bbolt"
+)
+
+type (
+	// UnitOfWork runs fx within a single read-write bolt transaction, bolt allows one writer at a time
+	UnitOfWork struct {
+		catalogue *Catalogue
+		outbox    *Outbox
+		stores    *Stores
+	}
+
+	tx struct {
+		catalogue txCatalogue
+		outbox    txOutbox
+		stores    txStores
+	}
+)
+
+func NewUnitOfWork(catalogue *Catalogue, outbox *Outbox, stores *Stores) *UnitOfWork {
+	return &UnitOfWork{
+		catalogue: catalogue,
+		outbox:    outbox,
+		stores:    stores,
+	}
+}
+
+func (uow *UnitOfWork) Atomically(fx func(tx driver.Tx) error) error {
+	return uow.catalogue.db.Update(func(boltTx *bbolt.Tx) error {
+		return fx(&tx{
+			catalogue: txCatalogue{boltTx},
+			outbox:    txOutbox{tx: boltTx, now: uow.outbox.now},
+			stores:    txStores{boltTx},
+		})
+	})
+}
+
+func (t *tx) Catalogue() driver.Catalogue {
+	return t.catalogue
+}
+
+func (t *tx) Outbox() driver.Outbox {
+	return t.outbox
+}
+
+func (t *tx) Stores() driver.Stores {
+	return t.stores
+}
@@ -0,0 +1,21 @@
+package bolt
+
+import (
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/catalogtest"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"testing"
+)
+
+func TestUnitOfWork(t *testing.T) {
+	catalogtest.RunUnitOfWork(t, func(t *testing.T) (driver.UnitOfWork, driver.Catalogue, driver.OutboxStore, driver.Stores) {
+		cat := newCatalogue(t)
+		outbox, stores := NewOutbox(cat), NewStores(cat)
+		return NewUnitOfWork(cat, outbox, stores), cat, outbox, stores
+	})
+}
+
+func TestStores(t *testing.T) {
+	catalogtest.RunStores(t, func(t *testing.T) driver.Stores {
+		return NewStores(newCatalogue(t))
+	})
+}
@@ -0,0 +1,407 @@
+// Package catalogtest is a conformance suite every driver.Catalogue implementation is expected to pass
+//
+//	func TestCatalogue(t *testing.T) {
+//		catalogtest.Run(t, func(t *testing.T) driver.Catalogue {
+//			return NewStoreCatalogue()
+//		})
+//	}
+package catalogtest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// Factory returns an empty catalogue, any resources it holds should be released through t.Cleanup
+type Factory func(t *testing.T) driver.Catalogue
+
+var Films = []domain.Film{
+	{Name: "Matrix 11", Director: "Dwight", Release: domain.New},
+	{Name: "Spider Man", Director: "Dwight", Release: domain.Regular},
+	{Name: "Spider Man 2", Director: "Dwight", Release: domain.Regular},
+	{Name: "Out of Africa", Director: "Dwight", Release: domain.Old},
+}
+
+func Run(t *testing.T, newCatalogue Factory) {
+	tests := []struct {
+		name string
+		fx   func(t *testing.T, newCatalogue Factory)
+	}{
+		{"FindBy", testFindBy},
+		{"FindBy_FilmNotFoundError", testFindByNotFound},
+		{"FindAll", testFindAll},
+		{"InsertIfAbsent", testInsertIfAbsent},
+		{"InsertIfAbsent_FilmAlreadyExistError", testInsertDuplicate},
+		{"Update", testUpdate},
+		{"Update_FilmVersionConflictError", testUpdateConflict},
+		{"Update_FilmNotFoundError", testUpdateNotFound},
+		{"CopySemantics", testCopySemantics},
+		{"ConcurrentDuplicates", testConcurrentDuplicates},
+		{"ConcurrentUpdates", testConcurrentUpdates},
+		{"ConcurrentReadersAndWriters", testConcurrentReadersAndWriters},
+		{"List_OrderedByName", testListOrdering},
+		{"List_Empty", testListEmpty},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.fx(t, newCatalogue)
+		})
+	}
+}
+
+// Seed returns a catalogue created by the factory holding a copy of Films
+func Seed(t *testing.T, newCatalogue Factory) driver.Catalogue {
+	t.Helper()
+	cat := newCatalogue(t)
+	for _, film := range Films {
+		if err := cat.InsertIfAbsent(film); err != nil {
+			t.Fatalf("unable to seed catalogue with %#v: %v", film, err)
+		}
+	}
+	return cat
+}
+
+// Catalogued returns the film as the catalogue holds it once inserted, at version 1
+func Catalogued(film domain.Film) domain.Film {
+	film.Version = 1
+	return film
+}
+
+func testFindBy(t *testing.T, newCatalogue Factory) {
+	cat := Seed(t, newCatalogue)
+	for _, film := range Films {
+		if found, err := cat.FindBy(film.Name); err != nil {
+			t.Error(err)
+		} else if *found != Catalogued(film) {
+			t.Errorf("searched for %#v but got %#v", film, *found)
+		}
+	}
+}
+
+func testFindByNotFound(t *testing.T, newCatalogue Factory) {
+	cat := Seed(t, newCatalogue)
+
+	found, err := cat.FindBy("Black Widow")
+	if found != nil {
+		t.Errorf("was expecting film to be nil but got %#v", found)
+	}
+
+	var notFound *driven.FilmNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("was expecting FilmNotFoundError but got %#v", err)
+	}
+	if notFound.Name != "Black Widow" {
+		t.Errorf("FilmNotFoundError should name the missing film but got %q", notFound.Name)
+	}
+}
+
+func testFindAll(t *testing.T, newCatalogue Factory) {
+	cat := Seed(t, newCatalogue)
+
+	found, err := cat.FindAll([]string{"Out of Africa", "Black Widow", "Matrix 11"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Name < found[j].Name
+	})
+	if len(found) != 2 || found[0] != Catalogued(Films[0]) || found[1] != Catalogued(Films[3]) {
+		t.Errorf("was expecting only the catalogued films among the names but got %#v", found)
+	}
+
+	if found, err := cat.FindAll(nil); err != nil || len(found) != 0 {
+		t.Errorf("was expecting no films for no names but got %#v: %v", found, err)
+	}
+}
+
+func testInsertIfAbsent(t *testing.T, newCatalogue Factory) {
+	cat := Seed(t, newCatalogue)
+
+	newFilm := domain.Film{Name: "Loki", Director: "Marvel", Release: domain.New}
+	if err := cat.InsertIfAbsent(newFilm); err != nil {
+		t.Fatalf("was expecting film %#v to be inserted but failed with %v", newFilm, err)
+	}
+
+	if found, err := cat.FindBy(newFilm.Name); err != nil {
+		t.Error(err)
+	} else if *found != Catalogued(newFilm) {
+		t.Errorf("was expecting %#v but found %#v", Catalogued(newFilm), *found)
+	}
+}
+
+func testInsertDuplicate(t *testing.T, newCatalogue Factory) {
+	cat := Seed(t, newCatalogue)
+
+	duplicate := Films[1]
+	duplicate.Director = "Someone Else"
+	duplicate.Release = domain.Old
+
+	var alreadyExist *driven.FilmAlreadyExistError
+	if err := cat.InsertIfAbsent(duplicate); !errors.As(err, &alreadyExist) {
+		t.Fatalf("was expecting FilmAlreadyExistError but got %#v", err)
+	}
+	if alreadyExist.Name != duplicate.Name {
+		t.Errorf("FilmAlreadyExistError should name the duplicate film but got %q", alreadyExist.Name)
+	}
+
+	if found, err := cat.FindBy(duplicate.Name); err != nil {
+		t.Error(err)
+	} else if *found != Catalogued(Films[1]) {
+		t.Errorf("catalogued film was overwritten by duplicate %#v", *found)
+	}
+
+	if films, err := cat.List(); err != nil {
+		t.Error(err)
+	} else if len(films) != len(Films) {
+		t.Errorf("rejected duplicate must not be listed, was expecting %d films but got %d", len(Films), len(films))
+	}
+}
+
+func testUpdate(t *testing.T, newCatalogue Factory) {
+	cat := Seed(t, newCatalogue)
+
+	updated := Catalogued(Films[1])
+	updated.Director = "Raimi"
+	updated.Release = domain.Old
+	if err := cat.Update(updated); err != nil {
+		t.Fatalf("was expecting film %#v to be updated but failed with %v", updated, err)
+	}
+
+	want := updated
+	want.Version = 2
+	if found, err := cat.FindBy(updated.Name); err != nil {
+		t.Error(err)
+	} else if *found != want {
+		t.Errorf("was expecting %#v but found %#v", want, *found)
+	}
+
+	if films, err := cat.List(); err != nil {
+		t.Error(err)
+	} else if len(films) != len(Films) || films[2] != want {
+		t.Errorf("was expecting the update to be listed in place but got %#v", films)
+	}
+}
+
+func testUpdateConflict(t *testing.T, newCatalogue Factory) {
+	cat := Seed(t, newCatalogue)
+
+	first := Catalogued(Films[1])
+	first.Director = "Raimi"
+	if err := cat.Update(first); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, version := range []uint64{0, 1, 3} {
+		stale := Catalogued(Films[1])
+		stale.Release = domain.Old
+		stale.Version = version
+
+		var conflict *driven.FilmVersionConflictError
+		if err := cat.Update(stale); !errors.As(err, &conflict) {
+			t.Fatalf("was expecting FilmVersionConflictError at version %d but got %#v", version, err)
+		}
+		if conflict.Name != stale.Name || conflict.Expected != version || conflict.Actual != 2 {
+			t.Errorf("was expecting the conflict to name %q at version %d rather than 2 but got %#v", stale.Name, version, conflict)
+		}
+	}
+
+	want := first
+	want.Version = 2
+	if found, err := cat.FindBy(first.Name); err != nil {
+		t.Error(err)
+	} else if *found != want {
+		t.Errorf("conflicting updates must not be kept, was expecting %#v but found %#v", want, *found)
+	}
+}
+
+func testUpdateNotFound(t *testing.T, newCatalogue Factory) {
+	cat := Seed(t, newCatalogue)
+
+	missing := domain.Film{Name: "Black Widow", Director: "Shortland", Release: domain.New, Version: 1}
+	var notFound *driven.FilmNotFoundError
+	if err := cat.Update(missing); !errors.As(err, &notFound) {
+		t.Fatalf("was expecting FilmNotFoundError but got %#v", err)
+	}
+
+	if _, err := cat.FindBy(missing.Name); !errors.As(err, &driven.TypeFilmNotFound) {
+		t.Errorf("updating a missing film must not catalogue it but got %#v", err)
+	}
+}
+
+func testCopySemantics(t *testing.T, newCatalogue Factory) {
+	cat := newCatalogue(t)
+
+	film := domain.Film{Name: "Loki", Director: "Marvel", Release: domain.New}
+	if err := cat.InsertIfAbsent(film); err != nil {
+		t.Fatal(err)
+	}
+	inserted := Catalogued(film)
+	film.Director = "mutated after insert"
+
+	found, err := cat.FindBy(inserted.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *found != inserted {
+		t.Errorf("mutating the inserted value leaked into the catalogue %#v", *found)
+	}
+
+	found.Director = "mutated after find"
+	if again, err := cat.FindBy(inserted.Name); err != nil {
+		t.Error(err)
+	} else if *again != inserted {
+		t.Errorf("mutating a found film leaked into the catalogue %#v", *again)
+	}
+
+	listed, err := cat.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed[0].Director = "mutated after list"
+	if again, err := cat.FindBy(inserted.Name); err != nil {
+		t.Error(err)
+	} else if *again != inserted {
+		t.Errorf("mutating a listed film leaked into the catalogue %#v", *again)
+	}
+}
+
+func testConcurrentDuplicates(t *testing.T, newCatalogue Factory) {
+	cat := newCatalogue(t)
+	film := domain.Film{Name: "Dune", Director: "Villeneuve", Release: domain.New}
+
+	var wg sync.WaitGroup
+	results := make(chan error, 20)
+	for i := 0; i < cap(results); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- cat.InsertIfAbsent(film)
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	inserted := 0
+	for err := range results {
+		switch {
+		case err == nil:
+			inserted++
+		case !errors.As(err, &driven.TypeFilmAlreadyExist):
+			t.Errorf("was expecting FilmAlreadyExistError but got %#v", err)
+		}
+	}
+
+	if inserted != 1 {
+		t.Errorf("was expecting exactly one successful insert but got %d", inserted)
+	}
+}
+
+func testConcurrentUpdates(t *testing.T, newCatalogue Factory) {
+	cat := Seed(t, newCatalogue)
+
+	var wg sync.WaitGroup
+	results := make(chan error, 20)
+	for i := 0; i < cap(results); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			film := Catalogued(Films[0])
+			film.Director = fmt.Sprintf("Director %02d", i)
+			results <- cat.Update(film)
+		}(i)
+	}
+	wg.Wait()
+	close(results)
+
+	updated := 0
+	for err := range results {
+		switch {
+		case err == nil:
+			updated++
+		case !errors.As(err, &driven.TypeFilmVersionConflict):
+			t.Errorf("was expecting FilmVersionConflictError but got %#v", err)
+		}
+	}
+
+	if updated != 1 {
+		t.Errorf("was expecting exactly one update at version 1 to succeed but got %d", updated)
+	}
+	if found, err := cat.FindBy(Films[0].Name); err != nil {
+		t.Error(err)
+	} else if found.Version != 2 {
+		t.Errorf("was expecting the film at version 2 but got %d", found.Version)
+	}
+}
+
+func testConcurrentReadersAndWriters(t *testing.T, newCatalogue Factory) {
+	cat := Seed(t, newCatalogue)
+	const writers = 10
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			film := domain.Film{Name: fmt.Sprintf("Sequel %02d", i), Director: "Dwight", Release: domain.New}
+			if err := cat.InsertIfAbsent(film); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := cat.FindBy(Films[i%len(Films)].Name); err != nil {
+				t.Error(err)
+			}
+			if _, err := cat.List(); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if films, err := cat.List(); err != nil {
+		t.Error(err)
+	} else if len(films) != len(Films)+writers {
+		t.Errorf("was expecting %d films after concurrent inserts but got %d", len(Films)+writers, len(films))
+	}
+}
+
+func testListOrdering(t *testing.T, newCatalogue Factory) {
+	cat := newCatalogue(t)
+	names := []string{"Zorro", "Alien", "Spider Man 2", "Matrix", "Spider Man", "alien"}
+	for _, name := range names {
+		if err := cat.InsertIfAbsent(domain.Film{Name: name, Director: "Dwight", Release: domain.Regular}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	films, err := cat.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(names)
+	if len(films) != len(names) {
+		t.Fatalf("was expecting %d films but got %d", len(names), len(films))
+	}
+	for i, film := range films {
+		if film.Name != names[i] {
+			t.Errorf("was expecting films ordered by name %v but position %d held %q", names, i, film.Name)
+		}
+	}
+}
+
+func testListEmpty(t *testing.T, newCatalogue Factory) {
+	cat := newCatalogue(t)
+	if films, err := cat.List(); err != nil {
+		t.Error(err)
+	} else if len(films) != 0 {
+		t.Errorf("was expecting an empty catalogue but got %#v", films)
+	}
+}
@@ -0,0 +1,235 @@
+package catalogtest
+
+import (
+	"errors"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"testing"
+	"time"
+)
+
+// StoresFactory returns empty stores, any resources they hold should be released through t.Cleanup
+type StoresFactory func(t *testing.T) driver.Stores
+
+// RunStores checks the stores behave as the driver.Stores port describes, above all that no store observes the
+// stock, prices or invoices of another
+func RunStores(t *testing.T, newStores StoresFactory) {
+	tests := []struct {
+		name string
+		fx   func(t *testing.T, newStores StoresFactory)
+	}{
+		{"Stock", testStock},
+		{"Stock_Insufficient", testInsufficientStock},
+		{"Stock_Isolated", testStockIsolated},
+		{"PriceList", testPriceList},
+		{"Invoices_Isolated", testInvoicesIsolated},
+		{"Transfers", testTransfers},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.fx(t, newStores)
+		})
+	}
+}
+
+func testStock(t *testing.T, newStores StoresFactory) {
+	stores := newStores(t)
+	assertStock(t, stores, "north", Films[0].Name, 0)
+
+	for _, adjustment := range []struct {
+		film   string
+		delta  int
+		copies int
+	}{
+		{Films[1].Name, 3, 3},
+		{Films[0].Name, 2, 2},
+		{Films[1].Name, -1, 2},
+		{Films[2].Name, 1, 1},
+		{Films[2].Name, -1, 0},
+	} {
+		copies, err := stores.AdjustStock("north", adjustment.film, adjustment.delta)
+		if err != nil || copies != adjustment.copies {
+			t.Fatalf("was expecting %d copies of %q after adjusting by %d but got %d: %v", adjustment.copies, adjustment.film, adjustment.delta, copies, err)
+		}
+	}
+
+	inventory, err := stores.Inventory("north")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []domain.Stock{{Film: Films[0].Name, Copies: 2}, {Film: Films[1].Name, Copies: 2}}
+	if len(inventory) != len(expected) {
+		t.Fatalf("was expecting the films still in stock ordered by name %#v but got %#v", expected, inventory)
+	}
+	for i := range expected {
+		if inventory[i] != expected[i] {
+			t.Errorf("was expecting %#v but got %#v", expected[i], inventory[i])
+		}
+	}
+}
+
+func testInsufficientStock(t *testing.T, newStores StoresFactory) {
+	stores := newStores(t)
+	if _, err := stores.AdjustStock("north", Films[0].Name, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := stores.AdjustStock("north", Films[0].Name, -2)
+	var insufficient *driven.InsufficientStockError
+	if !errors.As(err, &insufficient) || insufficient.Available != 1 || insufficient.Requested != 2 || insufficient.Store != "north" {
+		t.Errorf("was expecting an InsufficientStockError for 2 of 1 copies but got %#v", err)
+	}
+	assertStock(t, stores, "north", Films[0].Name, 1)
+}
+
+func testStockIsolated(t *testing.T, newStores StoresFactory) {
+	stores := newStores(t)
+	if _, err := stores.AdjustStock("north", Films[0].Name, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	assertStock(t, stores, "south", Films[0].Name, 0)
+	if inventory, err := stores.Inventory("south"); err != nil || len(inventory) != 0 {
+		t.Errorf("was expecting the stock of north to be invisible to south but got %#v: %v", inventory, err)
+	}
+	if _, err := stores.AdjustStock("south", Films[0].Name, -1); !errors.As(err, &driven.TypeInsufficientStock) {
+		t.Errorf("was expecting south to be unable to draw on the stock of north but got %v", err)
+	}
+	assertStock(t, stores, "north", Films[0].Name, 4)
+}
+
+func testPriceList(t *testing.T, newStores StoresFactory) {
+	stores := newStores(t)
+	assertPriceList(t, stores, "north", domain.DefaultPriceList)
+
+	prices := domain.PriceList{Premium: 45, Basic: 25}
+	if err := stores.SavePriceList("north", prices); err != nil {
+		t.Fatal(err)
+	}
+	assertPriceList(t, stores, "north", prices)
+	assertPriceList(t, stores, "south", domain.DefaultPriceList)
+
+	prices.Premium = 55
+	if err := stores.SavePriceList("north", prices); err != nil {
+		t.Fatal(err)
+	}
+	assertPriceList(t, stores, "north", prices)
+}
+
+func testInvoicesIsolated(t *testing.T, newStores StoresFactory) {
+	stores := newStores(t)
+	first, second, other := issuedInvoice("north", "invoice-1"), issuedInvoice("north", "invoice-2"), issuedInvoice("south", "invoice-3")
+	second.IssuedAt = first.IssuedAt.Add(time.Minute)
+
+	for _, invoice := range []domain.IssuedInvoice{second, other, first} {
+		if err := stores.SaveInvoice(invoice); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assertInvoices(t, stores, "north", first, second)
+	assertInvoices(t, stores, "south", other)
+	assertInvoices(t, stores, "east")
+}
+
+func testTransfers(t *testing.T, newStores StoresFactory) {
+	stores := newStores(t)
+	if _, err := stores.Transfer("transfer-1"); !errors.As(err, &driven.TypeTransferNotFound) {
+		t.Errorf("was expecting a TransferNotFoundError but got %v", err)
+	}
+
+	first, second, other := transfer("transfer-1", "north", "south"), transfer("transfer-2", "east", "north"), transfer("transfer-3", "east", "south")
+	second.RequestedAt = first.RequestedAt.Add(time.Minute)
+	for _, transfer := range []domain.Transfer{second, other, first} {
+		if err := stores.SaveTransfer(transfer); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first.Status = domain.TransferInTransitStatus
+	if err := stores.SaveTransfer(first); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := stores.Transfer(first.ID)
+	if err != nil || !sameTransfer(*saved, first) {
+		t.Errorf("was expecting the saved transfer to be replaced by %#v but got %#v: %v", first, saved, err)
+	}
+
+	for store, expected := range map[domain.StoreID][]domain.Transfer{"north": {first, second}, "south": {first, other}, "west": nil} {
+		transfers, err := stores.Transfers(store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(transfers) != len(expected) {
+			t.Fatalf("was expecting %q to take part in %d transfers but got %#v", store, len(expected), transfers)
+		}
+		for i := range expected {
+			if !sameTransfer(transfers[i], expected[i]) {
+				t.Errorf("was expecting %q to take part in %#v but got %#v", store, expected[i], transfers[i])
+			}
+		}
+	}
+}
+
+func transfer(id string, from domain.StoreID, to domain.StoreID) domain.Transfer {
+	return domain.Transfer{
+		ID:          id,
+		Film:        Films[0].Name,
+		From:        from,
+		To:          to,
+		Copies:      2,
+		Status:      domain.TransferRequestedStatus,
+		RequestedAt: time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func sameTransfer(transfer domain.Transfer, expected domain.Transfer) bool {
+	issued := transfer.RequestedAt
+	transfer.RequestedAt = expected.RequestedAt
+	return transfer == expected && issued.Equal(expected.RequestedAt)
+}
+
+func issuedInvoice(store domain.StoreID, id string) domain.IssuedInvoice {
+	return domain.IssuedInvoice{
+		ID:       id,
+		Store:    store,
+		Rentals:  []domain.Rental{{Film: Films[0], Days: 2}, {Film: Films[3], Days: 1}},
+		Cost:     110,
+		IssuedAt: time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func assertStock(t *testing.T, stores driver.Stores, store domain.StoreID, film string, expected int) {
+	t.Helper()
+	if copies, err := stores.Stock(store, film); err != nil || copies != expected {
+		t.Errorf("was expecting %q to hold %d copies of %q but got %d: %v", store, expected, film, copies, err)
+	}
+}
+
+func assertPriceList(t *testing.T, stores driver.Stores, store domain.StoreID, expected domain.PriceList) {
+	t.Helper()
+	if prices, err := stores.PriceList(store); err != nil || prices != expected {
+		t.Errorf("was expecting %q to charge %#v but got %#v: %v", store, expected, prices, err)
+	}
+}
+
+func assertInvoices(t *testing.T, stores driver.Stores, store domain.StoreID, expected ...domain.IssuedInvoice) {
+	t.Helper()
+	invoices, err := stores.Invoices(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(invoices) != len(expected) {
+		t.Fatalf("was expecting %q to have issued %d invoices but got %#v", store, len(expected), invoices)
+	}
+	for i, invoice := range invoices {
+		want := expected[i]
+		switch {
+		case invoice.ID != want.ID || invoice.Store != want.Store || invoice.Cost != want.Cost || !invoice.IssuedAt.Equal(want.IssuedAt):
+			t.Errorf("was expecting invoice %#v but got %#v", want, invoice)
+		case len(invoice.Rentals) != len(want.Rentals) || invoice.Rentals[0] != want.Rentals[0]:
+			t.Errorf("was expecting the rentals %#v but got %#v", want.Rentals, invoice.Rentals)
+		}
+	}
+}
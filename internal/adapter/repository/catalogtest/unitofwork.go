@@ -0,0 +1,305 @@
+package catalogtest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"sync"
+	"testing"
+)
+
+// UnitOfWorkFactory returns a unit of work together with the catalogue, outbox and stores it writes to once
+// committed
+type UnitOfWorkFactory func(t *testing.T) (driver.UnitOfWork, driver.Catalogue, driver.OutboxStore, driver.Stores)
+
+var errAbort = errors.New("abort unit of work")
+
+func RunUnitOfWork(t *testing.T, newUnitOfWork UnitOfWorkFactory) {
+	tests := []struct {
+		name string
+		fx   func(t *testing.T, newUnitOfWork UnitOfWorkFactory)
+	}{
+		{"Commit", testCommit},
+		{"ReadYourWrites", testReadYourWrites},
+		{"Rollback_NoPartialWrites", testRollback},
+		{"Rollback_RepositoryError", testRollbackOnRepositoryError},
+		{"ConcurrentUnitsOfWork", testConcurrentUnitsOfWork},
+		{"Outbox_Commit", testOutboxCommit},
+		{"Outbox_Rollback", testOutboxRollback},
+		{"Outbox_MarkDispatched", testOutboxMarkDispatched},
+		{"Stores_Commit", testStoresCommit},
+		{"Stores_Rollback", testStoresRollback},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.fx(t, newUnitOfWork)
+		})
+	}
+}
+
+func testCommit(t *testing.T, newUnitOfWork UnitOfWorkFactory) {
+	uow, cat, _, _ := newUnitOfWork(t)
+
+	err := uow.Atomically(func(tx driver.Tx) error {
+		for _, film := range Films {
+			if err := tx.Catalogue().InsertIfAbsent(film); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertCatalogued(t, cat, Films...)
+}
+
+func testReadYourWrites(t *testing.T, newUnitOfWork UnitOfWorkFactory) {
+	uow, _, _, _ := newUnitOfWork(t)
+
+	err := uow.Atomically(func(tx driver.Tx) error {
+		if err := tx.Catalogue().InsertIfAbsent(Films[0]); err != nil {
+			return err
+		}
+
+		found, err := tx.Catalogue().FindBy(Films[0].Name)
+		if err != nil {
+			return err
+		}
+		if *found != Catalogued(Films[0]) {
+			return fmt.Errorf("was expecting %#v within the transaction but found %#v", Catalogued(Films[0]), *found)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func testRollback(t *testing.T, newUnitOfWork UnitOfWorkFactory) {
+	uow, cat, _, _ := newUnitOfWork(t)
+
+	err := uow.Atomically(func(tx driver.Tx) error {
+		for _, film := range Films {
+			if err := tx.Catalogue().InsertIfAbsent(film); err != nil {
+				return err
+			}
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Errorf("was expecting the error returned by the unit of work but got %#v", err)
+	}
+
+	assertNotCatalogued(t, cat, Films...)
+}
+
+func testRollbackOnRepositoryError(t *testing.T, newUnitOfWork UnitOfWorkFactory) {
+	uow, cat, _, _ := newUnitOfWork(t)
+	if err := cat.InsertIfAbsent(Films[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	err := uow.Atomically(func(tx driver.Tx) error {
+		if err := tx.Catalogue().InsertIfAbsent(Films[0]); err != nil {
+			return err
+		}
+		return tx.Catalogue().InsertIfAbsent(Films[1])
+	})
+	if !errors.As(err, &driven.TypeFilmAlreadyExist) {
+		t.Errorf("was expecting FilmAlreadyExistError but got %#v", err)
+	}
+
+	assertNotCatalogued(t, cat, Films[0])
+	assertCatalogued(t, cat, Films[1])
+}
+
+func testConcurrentUnitsOfWork(t *testing.T, newUnitOfWork UnitOfWorkFactory) {
+	uow, cat, _, _ := newUnitOfWork(t)
+
+	var wg sync.WaitGroup
+	for i, film := range Films {
+		wg.Add(1)
+		go func(i int, film domain.Film) {
+			defer wg.Done()
+			err := uow.Atomically(func(tx driver.Tx) error {
+				if err := tx.Catalogue().InsertIfAbsent(film); err != nil {
+					return err
+				}
+				if i%2 == 1 {
+					return errAbort
+				}
+				return nil
+			})
+			if i%2 == 0 && err != nil {
+				t.Error(err)
+			}
+		}(i, film)
+	}
+	wg.Wait()
+
+	for i, film := range Films {
+		if i%2 == 0 {
+			assertCatalogued(t, cat, film)
+		} else {
+			assertNotCatalogued(t, cat, film)
+		}
+	}
+}
+
+func testOutboxCommit(t *testing.T, newUnitOfWork UnitOfWorkFactory) {
+	uow, _, outbox, _ := newUnitOfWork(t)
+
+	err := uow.Atomically(func(tx driver.Tx) error {
+		if err := tx.Catalogue().InsertIfAbsent(Films[0]); err != nil {
+			return err
+		}
+		return tx.Outbox().Enqueue(filmAdded(Films[0]), filmAdded(Films[1]))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertPending(t, outbox, filmAdded(Films[0]), filmAdded(Films[1]))
+}
+
+func testOutboxRollback(t *testing.T, newUnitOfWork UnitOfWorkFactory) {
+	uow, _, outbox, _ := newUnitOfWork(t)
+
+	err := uow.Atomically(func(tx driver.Tx) error {
+		if err := tx.Outbox().Enqueue(filmAdded(Films[0])); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Errorf("was expecting the error returned by the unit of work but got %#v", err)
+	}
+
+	assertPending(t, outbox)
+}
+
+func testOutboxMarkDispatched(t *testing.T, newUnitOfWork UnitOfWorkFactory) {
+	_, _, outbox, _ := newUnitOfWork(t)
+	for _, film := range Films[:3] {
+		if err := outbox.Enqueue(filmAdded(film)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pending, err := outbox.Pending(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 || pending[0].Sequence >= pending[1].Sequence {
+		t.Fatalf("was expecting the first 2 envelopes in sequence order but got %#v", pending)
+	}
+
+	if err := outbox.MarkDispatched(pending[1].Sequence); err != nil {
+		t.Fatal(err)
+	}
+	assertPending(t, outbox, filmAdded(Films[2]))
+}
+
+func testStoresCommit(t *testing.T, newUnitOfWork UnitOfWorkFactory) {
+	uow, _, _, stores := newUnitOfWork(t)
+	invoice := issuedInvoice("north", "invoice-1")
+
+	err := uow.Atomically(func(tx driver.Tx) error {
+		if _, err := tx.Stores().AdjustStock("north", Films[0].Name, 2); err != nil {
+			return err
+		}
+		if copies, err := tx.Stores().Stock("north", Films[0].Name); err != nil || copies != 2 {
+			return fmt.Errorf("was expecting to read 2 copies within the unit of work but got %d: %v", copies, err)
+		}
+		if err := tx.Stores().SavePriceList("north", domain.PriceList{Premium: 50, Basic: 35}); err != nil {
+			return err
+		}
+		return tx.Stores().SaveInvoice(invoice)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStock(t, stores, "north", Films[0].Name, 2)
+	assertPriceList(t, stores, "north", domain.PriceList{Premium: 50, Basic: 35})
+	assertInvoices(t, stores, "north", invoice)
+}
+
+func testStoresRollback(t *testing.T, newUnitOfWork UnitOfWorkFactory) {
+	uow, _, _, stores := newUnitOfWork(t)
+	if _, err := stores.AdjustStock("north", Films[0].Name, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	err := uow.Atomically(func(tx driver.Tx) error {
+		if _, err := tx.Stores().AdjustStock("north", Films[0].Name, 3); err != nil {
+			return err
+		}
+		if err := tx.Stores().SaveInvoice(issuedInvoice("north", "invoice-1")); err != nil {
+			return err
+		}
+		if err := tx.Stores().SaveTransfer(transfer("transfer-1", "north", "south")); err != nil {
+			return err
+		}
+		// the stock cannot drop below zero so the whole unit is undone
+		_, err := tx.Stores().AdjustStock("north", Films[1].Name, -1)
+		return err
+	})
+	if !errors.As(err, &driven.TypeInsufficientStock) {
+		t.Errorf("was expecting an InsufficientStockError but got %#v", err)
+	}
+
+	assertStock(t, stores, "north", Films[0].Name, 1)
+	assertInvoices(t, stores, "north")
+	if _, err := stores.Transfer("transfer-1"); !errors.As(err, &driven.TypeTransferNotFound) {
+		t.Errorf("was expecting the transfer to be undone but got %v", err)
+	}
+}
+
+func filmAdded(film domain.Film) domain.Event {
+	return domain.FilmAdded{Name: film.Name, Director: film.Director, Release: film.Release}
+}
+
+func assertPending(t *testing.T, outbox driver.OutboxStore, events ...domain.Event) {
+	t.Helper()
+	pending, err := outbox.Pending(len(events) + 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pending) != len(events) {
+		t.Fatalf("was expecting %d pending events but got %#v", len(events), pending)
+	}
+	for i, envelope := range pending {
+		if envelope.Event != events[i] {
+			t.Errorf("was expecting pending event %#v but got %#v", events[i], envelope.Event)
+		}
+		if envelope.OccurredAt.IsZero() {
+			t.Errorf("was expecting pending event %d to record when it occurred", i)
+		}
+	}
+}
+
+func assertCatalogued(t *testing.T, cat driver.Catalogue, films ...domain.Film) {
+	t.Helper()
+	for _, film := range films {
+		if found, err := cat.FindBy(film.Name); err != nil {
+			t.Errorf("was expecting %q to be catalogued but got %v", film.Name, err)
+		} else if *found != Catalogued(film) {
+			t.Errorf("was expecting %#v but found %#v", Catalogued(film), *found)
+		}
+	}
+}
+
+func assertNotCatalogued(t *testing.T, cat driver.Catalogue, films ...domain.Film) {
+	t.Helper()
+	for _, film := range films {
+		if _, err := cat.FindBy(film.Name); !errors.As(err, &driven.TypeFilmNotFound) {
+			t.Errorf("was expecting %q to have been rolled back but got %v", film.Name, err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package eventstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+)
+
+type (
+	// Aggregates rehydrates aggregates from the latest snapshot followed by the events recorded since,
+	// a new snapshot is taken every snapshotEvery events
+	Aggregates struct {
+		events        driver.EventStore
+		snapshots     driver.SnapshotStore
+		snapshotEvery uint64
+	}
+
+	aggregate interface {
+		Apply(e domain.Event)
+	}
+)
+
+func NewAggregates(events driver.EventStore, snapshots driver.SnapshotStore, snapshotEvery uint64) *Aggregates {
+	return &Aggregates{
+		events:        events,
+		snapshots:     snapshots,
+		snapshotEvery: snapshotEvery,
+	}
+}
+
+func (a *Aggregates) Film(name string) (*domain.FilmAggregate, error) {
+	var film domain.FilmAggregate
+	if err := a.rehydrate(domain.FilmStream(name), &film, &film.Version); err != nil {
+		return nil, err
+	}
+	return &film, nil
+}
+
+func (a *Aggregates) SaveFilm(film *domain.FilmAggregate, events ...domain.Event) error {
+	name := film.Film.Name
+	if added, ok := firstFilmAdded(events); ok {
+		name = added.Name
+	}
+	return a.save(domain.FilmStream(name), film, &film.Version, events)
+}
+
+func (a *Aggregates) Rental(rentalID string) (*domain.RentalAggregate, error) {
+	var rental domain.RentalAggregate
+	if err := a.rehydrate(domain.RentalStream(rentalID), &rental, &rental.Version); err != nil {
+		return nil, err
+	}
+	return &rental, nil
+}
+
+func (a *Aggregates) SaveRental(rental *domain.RentalAggregate, events ...domain.Event) error {
+	rentalID := rental.ID
+	if started, ok := firstRentalStarted(events); ok {
+		rentalID = started.RentalID
+	}
+	return a.save(domain.RentalStream(rentalID), rental, &rental.Version, events)
+}
+
+func (a *Aggregates) rehydrate(stream string, agg aggregate, version *uint64) error {
+	if a.snapshots != nil {
+		snapshot, err := a.snapshots.LoadSnapshot(stream)
+		if err != nil {
+			return fmt.Errorf("unable to load snapshot of %q: %w", stream, err)
+		}
+		if snapshot != nil {
+			if err := json.Unmarshal(snapshot.State, agg); err != nil {
+				return fmt.Errorf("unable to decode snapshot of %q: %w", stream, err)
+			}
+		}
+	}
+
+	records, err := a.events.ReadStream(stream, *version+1)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		agg.Apply(record.Event)
+	}
+	return nil
+}
+
+func (a *Aggregates) save(stream string, agg aggregate, version *uint64, events []domain.Event) error {
+	expected := *version
+	if err := a.events.Append(stream, expected, events...); err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		agg.Apply(event)
+	}
+
+	if a.snapshots == nil || a.snapshotEvery == 0 || *version/a.snapshotEvery == expected/a.snapshotEvery {
+		return nil
+	}
+
+	state, err := json.Marshal(agg)
+	if err != nil {
+		return fmt.Errorf("unable to encode snapshot of %q: %w", stream, err)
+	}
+	return a.snapshots.SaveSnapshot(driver.Snapshot{Stream: stream, Version: *version, State: state})
+}
+
+func firstFilmAdded(events []domain.Event) (domain.FilmAdded, bool) {
+	for _, event := range events {
+		if added, ok := event.(domain.FilmAdded); ok {
+			return added, true
+		}
+	}
+	return domain.FilmAdded{}, false
+}
+
+func firstRentalStarted(events []domain.Event) (domain.RentalStarted, bool) {
+	for _, event := range events {
+		if started, ok := event.(domain.RentalStarted); ok {
+			return started, true
+		}
+	}
+	return domain.RentalStarted{}, false
+}
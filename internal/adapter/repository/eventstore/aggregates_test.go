@@ -0,0 +1,110 @@
+package eventstore
+
+import (
+	"errors"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"testing"
+)
+
+type spyEventStore struct {
+	driver.EventStore
+	readFrom []uint64
+}
+
+func (s *spyEventStore) ReadStream(stream string, fromVersion uint64) ([]driver.RecordedEvent, error) {
+	s.readFrom = append(s.readFrom, fromVersion)
+	return s.EventStore.ReadStream(stream, fromVersion)
+}
+
+func TestAggregates_Rehydration(t *testing.T) {
+	aggregates := NewAggregates(NewMemoryStore(), nil, 0)
+
+	started, err := domain.StartRental("r-1", domain.Film{Name: "Loki", Director: "Marvel", Release: domain.New}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := aggregates.SaveRental(&domain.RentalAggregate{}, started); err != nil {
+		t.Fatal(err)
+	}
+
+	rental, err := aggregates.Rental("r-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rental.Version != 1 || rental.Film.Name != "Loki" || rental.Returned {
+		t.Fatalf("unexpected rehydrated rental %#v", rental)
+	}
+
+	returned, err := rental.Return(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := aggregates.SaveRental(rental, returned); err != nil {
+		t.Fatal(err)
+	}
+
+	if again, err := aggregates.Rental("r-1"); err != nil || !again.Returned || again.Version != 2 {
+		t.Errorf("was expecting a returned rental at version 2 but got %#v, %v", again, err)
+	}
+}
+
+func TestAggregates_StaleWriteIsRejected(t *testing.T) {
+	aggregates := NewAggregates(NewMemoryStore(), nil, 0)
+	added, _ := domain.AddFilm(domain.Film{Name: "Loki", Director: "Marvel", Release: domain.New})
+	if err := aggregates.SaveFilm(&domain.FilmAggregate{}, added); err != nil {
+		t.Fatal(err)
+	}
+
+	first, _ := aggregates.Film("Loki")
+	second, _ := aggregates.Film("Loki")
+
+	toOld, _ := first.Reclassify(domain.Old)
+	if err := aggregates.SaveFilm(first, toOld); err != nil {
+		t.Fatal(err)
+	}
+
+	toRegular, _ := second.Reclassify(domain.Regular)
+	if err := aggregates.SaveFilm(second, toRegular); !errors.As(err, &driven.TypeStreamConflict) {
+		t.Errorf("was expecting StreamConflictError but got %#v", err)
+	}
+}
+
+func TestAggregates_Snapshotting(t *testing.T) {
+	store := &spyEventStore{EventStore: NewMemoryStore()}
+	snapshots := NewMemorySnapshots()
+	aggregates := NewAggregates(store, snapshots, 2)
+
+	film := &domain.FilmAggregate{}
+	added, _ := domain.AddFilm(domain.Film{Name: "Loki", Director: "Marvel", Release: domain.New})
+	if err := aggregates.SaveFilm(film, added); err != nil {
+		t.Fatal(err)
+	}
+	for _, release := range []string{"Regular", "Old"} {
+		to, _ := domain.ParseRelease(release)
+		changed, err := film.Reclassify(to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := aggregates.SaveFilm(film, changed); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshot, err := snapshots.LoadSnapshot(domain.FilmStream("Loki"))
+	if err != nil || snapshot == nil || snapshot.Version != 2 {
+		t.Fatalf("was expecting a snapshot at version 2 but got %#v, %v", snapshot, err)
+	}
+
+	rehydrated, err := aggregates.Film("Loki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rehydrated.Version != 3 || rehydrated.Film.Release != domain.Old {
+		t.Errorf("unexpected rehydrated film %#v", rehydrated)
+	}
+	if from := store.readFrom[len(store.readFrom)-1]; from != 3 {
+		t.Errorf("was expecting only the events after the snapshot to be read but read from version %d", from)
+	}
+}
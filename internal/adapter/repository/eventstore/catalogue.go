@@ -0,0 +1,139 @@
+package eventstore
+
+import (
+	"errors"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"sort"
+	"sync"
+)
+
+type (
+	// Catalogue is a projection of the film events in the log, it catches up with the log before every read
+	Catalogue struct {
+		events   driver.EventStore
+		mu       sync.Mutex
+		position uint64
+		films    map[string]domain.Film
+	}
+)
+
+func NewCatalogue(events driver.EventStore) *Catalogue {
+	return &Catalogue{
+		events: events,
+		films:  map[string]domain.Film{},
+	}
+}
+
+func (cat *Catalogue) FindBy(name string) (*domain.Film, error) {
+	cat.mu.Lock()
+	defer cat.mu.Unlock()
+
+	if err := cat.catchUp(); err != nil {
+		return nil, err
+	}
+
+	film, ok := cat.films[name]
+	if !ok {
+		return nil, &driven.FilmNotFoundError{Name: name}
+	}
+	return &film, nil
+}
+
+func (cat *Catalogue) FindAll(names []string) ([]domain.Film, error) {
+	cat.mu.Lock()
+	defer cat.mu.Unlock()
+
+	if err := cat.catchUp(); err != nil {
+		return nil, err
+	}
+
+	var films []domain.Film
+	for _, name := range names {
+		if film, ok := cat.films[name]; ok {
+			films = append(films, film)
+		}
+	}
+	return films, nil
+}
+
+// InsertIfAbsent relies on the optimistic concurrency of the event store, a film stream can only be started once
+func (cat *Catalogue) InsertIfAbsent(film domain.Film) error {
+	added := domain.FilmAdded{Name: film.Name, Director: film.Director, Release: film.Release}
+	err := cat.events.Append(domain.FilmStream(film.Name), 0, added)
+	var conflict *driven.StreamConflictError
+	if errors.As(err, &conflict) {
+		return &driven.FilmAlreadyExistError{Name: film.Name}
+	}
+	return err
+}
+
+// Update appends to the film stream at the version the film was read at, so the stream version is the film version
+func (cat *Catalogue) Update(film domain.Film) error {
+	if film.Version == 0 {
+		// appending at version 0 would start the stream of a film which was never added
+		current, err := cat.FindBy(film.Name)
+		if err != nil {
+			return err
+		}
+		return &driven.FilmVersionConflictError{Name: film.Name, Expected: film.Version, Actual: current.Version}
+	}
+	updated := domain.FilmUpdated{Name: film.Name, Director: film.Director, Release: film.Release, Version: film.Version + 1}
+	err := cat.events.Append(domain.FilmStream(film.Name), film.Version, updated)
+	var conflict *driven.StreamConflictError
+	switch {
+	case errors.As(err, &conflict) && conflict.Actual == 0:
+		return &driven.FilmNotFoundError{Name: film.Name}
+	case errors.As(err, &conflict):
+		return &driven.FilmVersionConflictError{Name: film.Name, Expected: film.Version, Actual: conflict.Actual}
+	}
+	return err
+}
+
+func (cat *Catalogue) List() ([]domain.Film, error) {
+	cat.mu.Lock()
+	defer cat.mu.Unlock()
+
+	if err := cat.catchUp(); err != nil {
+		return nil, err
+	}
+
+	films := make([]domain.Film, 0, len(cat.films))
+	for _, film := range cat.films {
+		films = append(films, film)
+	}
+	sort.Slice(films, func(i, j int) bool {
+		return films[i].Name < films[j].Name
+	})
+	return films, nil
+}
+
+func (cat *Catalogue) catchUp() error {
+	records, err := cat.events.ReadAll(cat.position + 1)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		switch event := record.Event.(type) {
+		case domain.FilmAdded:
+			cat.films[event.Name] = domain.Film{Name: event.Name, Director: event.Director, Release: event.Release, Version: record.Version}
+		case domain.FilmReleaseChanged:
+			if film, ok := cat.films[event.Name]; ok {
+				film.Release = event.To
+				film.Version = record.Version
+				cat.films[event.Name] = film
+			}
+		case domain.FilmUpdated:
+			if film, ok := cat.films[event.Name]; ok {
+				film.Director = event.Director
+				film.Release = event.Release
+				film.Version = record.Version
+				cat.films[event.Name] = film
+			}
+		}
+		cat.position = record.Position
+	}
+	return nil
+}
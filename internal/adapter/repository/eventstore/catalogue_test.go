@@ -0,0 +1,56 @@
+package eventstore
+
+import (
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/catalogtest"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"path/filepath"
+	"testing"
+)
+
+func TestCatalogue(t *testing.T) {
+	t.Run("MemoryStore", func(t *testing.T) {
+		catalogtest.Run(t, func(t *testing.T) driver.Catalogue {
+			return NewCatalogue(NewMemoryStore())
+		})
+	})
+
+	t.Run("FileStore", func(t *testing.T) {
+		catalogtest.Run(t, func(t *testing.T) driver.Catalogue {
+			store, err := OpenFileStore(filepath.Join(t.TempDir(), "events.log"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { store.Close() })
+			return NewCatalogue(store)
+		})
+	})
+}
+
+func TestCatalogue_ProjectsReclassification(t *testing.T) {
+	store := NewMemoryStore()
+	cat := NewCatalogue(store)
+	aggregates := NewAggregates(store, nil, 0)
+
+	if err := cat.InsertIfAbsent(domain.Film{Name: "Loki", Director: "Marvel", Release: domain.New}); err != nil {
+		t.Fatal(err)
+	}
+
+	film, err := aggregates.Film("Loki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err := film.Reclassify(domain.Old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := aggregates.SaveFilm(film, changed); err != nil {
+		t.Fatal(err)
+	}
+
+	if found, err := cat.FindBy("Loki"); err != nil {
+		t.Error(err)
+	} else if found.Release != domain.Old {
+		t.Errorf("was expecting the projection to follow the reclassification but got %#v", found)
+	}
+}
@@ -0,0 +1,107 @@
+package eventstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+type (
+	// FileCheckpoints keeps the checkpoint of every projection, with the read model saved along with it, in a
+	// single JSON file which is replaced as a whole on every save so a crash leaves the previous checkpoints
+	FileCheckpoints struct {
+		mu          sync.RWMutex
+		path        string
+		checkpoints map[string]checkpoint
+	}
+
+	checkpoint struct {
+		Position uint64          `json:"position"`
+		State    json.RawMessage `json:"state,omitempty"`
+	}
+)
+
+// OpenFileCheckpoints loads the checkpoints kept at path, every projection starts from the beginning of the log
+// when there are none yet
+func OpenFileCheckpoints(path string) (*FileCheckpoints, error) {
+	c := &FileCheckpoints{path: path, checkpoints: map[string]checkpoint{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read checkpoints %q: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &c.checkpoints); err != nil {
+		return nil, fmt.Errorf("unable to decode checkpoints %q: %w", path, err)
+	}
+	return c, nil
+}
+
+func (c *FileCheckpoints) Checkpoint(projection string) (uint64, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.checkpoints[projection].Position, nil
+}
+
+func (c *FileCheckpoints) SaveCheckpoint(projection string, position uint64) error {
+	return c.save(projection, checkpoint{Position: position})
+}
+
+func (c *FileCheckpoints) State(projection string) ([]byte, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.checkpoints[projection].State, nil
+}
+
+func (c *FileCheckpoints) SaveState(projection string, position uint64, state []byte) error {
+	if !json.Valid(state) {
+		return fmt.Errorf("unable to save the state of projection %q: state is not JSON", projection)
+	}
+	return c.save(projection, checkpoint{Position: position, State: append(json.RawMessage(nil), state...)})
+}
+
+// save writes every checkpoint through a synced temporary file, the checkpoint is only kept in memory once it
+// has been written
+func (c *FileCheckpoints) save(projection string, saved checkpoint) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	checkpoints := make(map[string]checkpoint, len(c.checkpoints)+1)
+	for name, cp := range c.checkpoints {
+		checkpoints[name] = cp
+	}
+	checkpoints[projection] = saved
+
+	data, err := json.Marshal(checkpoints)
+	if err != nil {
+		return fmt.Errorf("unable to encode checkpoints: %w", err)
+	}
+
+	tmp := c.path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to save checkpoints %q: %w", c.path, err)
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, c.path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("unable to save checkpoints %q: %w", c.path, err)
+	}
+
+	c.checkpoints = checkpoints
+	return nil
+}
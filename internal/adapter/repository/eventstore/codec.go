@@ -0,0 +1,61 @@
+package eventstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"time"
+)
+
+type (
+	// line is the on disk representation of a driver.RecordedEvent, one JSON document per line
+	line struct {
+		Position   uint64          `json:"position"`
+		Version    uint64          `json:"version"`
+		Stream     string          `json:"stream"`
+		Type       string          `json:"type"`
+		RecordedAt time.Time       `json:"recordedAt"`
+		Data       json.RawMessage `json:"data"`
+	}
+)
+
+func encodeRecord(record driver.RecordedEvent) ([]byte, error) {
+	data, err := json.Marshal(record.Event)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode %s event: %w", record.Event.EventType(), err)
+	}
+
+	encoded, err := json.Marshal(line{
+		Position:   record.Position,
+		Version:    record.Version,
+		Stream:     record.Stream,
+		Type:       record.Event.EventType(),
+		RecordedAt: record.RecordedAt,
+		Data:       data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(encoded, '\n'), nil
+}
+
+func decodeRecord(data []byte) (driver.RecordedEvent, error) {
+	var l line
+	if err := json.Unmarshal(data, &l); err != nil {
+		return driver.RecordedEvent{}, fmt.Errorf("unable to decode event record: %w", err)
+	}
+
+	event, err := domain.DecodeEvent(l.Type, l.Data)
+	if err != nil {
+		return driver.RecordedEvent{}, fmt.Errorf("event at position %d: %w", l.Position, err)
+	}
+
+	return driver.RecordedEvent{
+		Position:   l.Position,
+		Version:    l.Version,
+		Stream:     l.Stream,
+		Event:      event,
+		RecordedAt: l.RecordedAt,
+	}, nil
+}
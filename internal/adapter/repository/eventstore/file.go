@@ -0,0 +1,157 @@
+package eventstore
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"io"
+	"os"
+	"sync"
+)
+
+type (
+	// FileStore appends every event as a JSON line to a log file and serves reads from memory,
+	// the log is replayed when the store is opened
+	FileStore struct {
+		mu     sync.Mutex
+		memory *MemoryStore
+		file   logFile
+	}
+
+	logFile interface {
+		io.ReadWriteSeeker
+		io.Closer
+		Sync() error
+		Truncate(size int64) error
+	}
+)
+
+// OpenFileStore opens the log for appending, the log is locked against every other process appending to it
+// until the store is closed
+func OpenFileStore(path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open event log %q: %w", path, err)
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("unable to lock event log %q, is it open in another process? %w", path, err)
+	}
+	return openFileStore(path, file)
+}
+
+func openFileStore(path string, file logFile) (*FileStore, error) {
+	store := &FileStore{
+		memory: NewMemoryStore(),
+		file:   file,
+	}
+
+	if err := store.replay(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("unable to replay event log %q: %w", path, err)
+	}
+	return store, nil
+}
+
+func (s *FileStore) Append(stream string, expectedVersion uint64, events ...domain.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.memory.mu.Lock()
+	defer s.memory.mu.Unlock()
+
+	records, err := s.memory.prepare(stream, expectedVersion, events)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, record := range records {
+		encoded, err := encodeRecord(record)
+		if err != nil {
+			return err
+		}
+		buf.Write(encoded)
+	}
+
+	// a short write or failed sync is truncated away, the events were never acknowledged so they must not be
+	// replayed on the next open either
+	offset, err := s.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("unable to append to event log: %w", err)
+	}
+	if _, err := s.file.Write(buf.Bytes()); err != nil {
+		return s.truncate(offset, fmt.Errorf("unable to append to event log: %w", err))
+	}
+	if err := s.file.Sync(); err != nil {
+		return s.truncate(offset, fmt.Errorf("unable to sync event log: %w", err))
+	}
+
+	s.memory.commit(records)
+	return nil
+}
+
+// truncate drops whatever a failed Append wrote past offset, so the next append continues from it, and passes
+// err through
+func (s *FileStore) truncate(offset int64, err error) error {
+	if truncErr := s.file.Truncate(offset); truncErr != nil {
+		return errors.Join(err, fmt.Errorf("unable to truncate event log: %w", truncErr))
+	}
+	if _, seekErr := s.file.Seek(offset, io.SeekStart); seekErr != nil {
+		return errors.Join(err, fmt.Errorf("unable to truncate event log: %w", seekErr))
+	}
+	return err
+}
+
+func (s *FileStore) ReadStream(stream string, fromVersion uint64) ([]driver.RecordedEvent, error) {
+	return s.memory.ReadStream(stream, fromVersion)
+}
+
+func (s *FileStore) ReadAll(fromPosition uint64) ([]driver.RecordedEvent, error) {
+	return s.memory.ReadAll(fromPosition)
+}
+
+func (s *FileStore) Head() (uint64, error) {
+	return s.memory.Head()
+}
+
+func (s *FileStore) Close() error {
+	return s.file.Close()
+}
+
+// replay loads the log into memory, a trailing line without a newline is the remains of an interrupted
+// append which was never acknowledged so it is truncated away
+func (s *FileStore) replay() error {
+	reader := bufio.NewReader(s.file)
+	var offset int64
+	for {
+		data, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(data) > 0 {
+				if err := s.file.Truncate(offset); err != nil {
+					return err
+				}
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		record, err := decodeRecord(data)
+		if err != nil {
+			return err
+		}
+		if expected := uint64(len(s.memory.events) + 1); record.Position != expected {
+			return fmt.Errorf("event log is out of sequence, was expecting position %d but found %d", expected, record.Position)
+		}
+		s.memory.commit([]driver.RecordedEvent{record})
+		offset += int64(len(data))
+	}
+
+	_, err := s.file.Seek(offset, io.SeekStart)
+	return err
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package eventstore
+
+import "os"
+
+// lockFile is a no-op where flock is not available, a single process is then trusted to append to the log
+func lockFile(file *os.File) error {
+	return nil
+}
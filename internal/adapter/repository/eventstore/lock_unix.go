@@ -0,0 +1,14 @@
+//go:build unix
+
+package eventstore
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive lock on file which is released when it is closed, it fails rather than waits when
+// another process holds the lock
+func lockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+}
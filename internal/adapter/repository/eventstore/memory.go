@@ -0,0 +1,98 @@
+package eventstore
+
+import (
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"sync"
+	"time"
+)
+
+type (
+	MemoryStore struct {
+		mu      sync.RWMutex
+		events  []driver.RecordedEvent
+		streams map[string][]int
+		now     func() time.Time
+	}
+)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		streams: map[string][]int{},
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Append(stream string, expectedVersion uint64, events ...domain.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.prepare(stream, expectedVersion, events)
+	if err != nil {
+		return err
+	}
+	s.commit(records)
+	return nil
+}
+
+func (s *MemoryStore) ReadStream(stream string, fromVersion uint64) ([]driver.RecordedEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var records []driver.RecordedEvent
+	for _, i := range s.streams[stream] {
+		if s.events[i].Version >= fromVersion {
+			records = append(records, s.events[i])
+		}
+	}
+	return records, nil
+}
+
+func (s *MemoryStore) ReadAll(fromPosition uint64) ([]driver.RecordedEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if fromPosition == 0 {
+		fromPosition = 1
+	}
+	if fromPosition > uint64(len(s.events)) {
+		return nil, nil
+	}
+	return append([]driver.RecordedEvent(nil), s.events[fromPosition-1:]...), nil
+}
+
+func (s *MemoryStore) Head() (uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return uint64(len(s.events)), nil
+}
+
+// prepare checks the optimistic concurrency expectation and numbers the events without storing them,
+// callers must hold the lock until the records have been committed
+func (s *MemoryStore) prepare(stream string, expectedVersion uint64, events []domain.Event) ([]driver.RecordedEvent, error) {
+	actual := uint64(len(s.streams[stream]))
+	if actual != expectedVersion {
+		return nil, &driven.StreamConflictError{Stream: stream, Expected: expectedVersion, Actual: actual}
+	}
+
+	now := s.now().UTC()
+	records := make([]driver.RecordedEvent, 0, len(events))
+	for i, event := range events {
+		records = append(records, driver.RecordedEvent{
+			Position:   uint64(len(s.events) + i + 1),
+			Version:    actual + uint64(i) + 1,
+			Stream:     stream,
+			Event:      event,
+			RecordedAt: now,
+		})
+	}
+	return records, nil
+}
+
+func (s *MemoryStore) commit(records []driver.RecordedEvent) {
+	for _, record := range records {
+		s.streams[record.Stream] = append(s.streams[record.Stream], len(s.events))
+		s.events = append(s.events, record)
+	}
+}
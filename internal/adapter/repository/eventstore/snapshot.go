@@ -0,0 +1,42 @@
+package eventstore
+
+import (
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"sync"
+)
+
+type (
+	MemorySnapshots struct {
+		mu        sync.RWMutex
+		snapshots map[string]driver.Snapshot
+	}
+)
+
+func NewMemorySnapshots() *MemorySnapshots {
+	return &MemorySnapshots{
+		snapshots: map[string]driver.Snapshot{},
+	}
+}
+
+func (s *MemorySnapshots) SaveSnapshot(snapshot driver.Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, ok := s.snapshots[snapshot.Stream]; ok && current.Version >= snapshot.Version {
+		return nil
+	}
+	snapshot.State = append([]byte(nil), snapshot.State...)
+	s.snapshots[snapshot.Stream] = snapshot
+	return nil
+}
+
+func (s *MemorySnapshots) LoadSnapshot(stream string) (*driver.Snapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot, ok := s.snapshots[stream]
+	if !ok {
+		return nil, nil
+	}
+	return &snapshot, nil
+}
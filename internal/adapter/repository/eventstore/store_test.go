@@ -0,0 +1,238 @@
+package eventstore
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+var (
+	loki    = domain.FilmAdded{Name: "Loki", Director: "Marvel", Release: domain.New}
+	dune    = domain.FilmAdded{Name: "Dune", Director: "Villeneuve", Release: domain.New}
+	retired = domain.FilmReleaseChanged{Name: "Loki", From: domain.New, To: domain.Old}
+)
+
+func stores(t *testing.T) map[string]driver.EventStore {
+	file, err := OpenFileStore(filepath.Join(t.TempDir(), "events.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+
+	return map[string]driver.EventStore{
+		"MemoryStore": NewMemoryStore(),
+		"FileStore":   file,
+	}
+}
+
+func TestEventStore_AppendAndRead(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			mustAppend(t, store, domain.FilmStream("Loki"), 0, loki)
+			mustAppend(t, store, domain.FilmStream("Dune"), 0, dune)
+			mustAppend(t, store, domain.FilmStream("Loki"), 1, retired)
+
+			stream, err := store.ReadStream(domain.FilmStream("Loki"), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stream) != 2 || stream[0].Event != loki || stream[1].Event != retired {
+				t.Fatalf("unexpected stream %#v", stream)
+			}
+			if stream[1].Version != 2 || stream[1].Position != 3 {
+				t.Errorf("was expecting version 2 at position 3 but got %d at %d", stream[1].Version, stream[1].Position)
+			}
+
+			if tail, err := store.ReadStream(domain.FilmStream("Loki"), 2); err != nil || len(tail) != 1 {
+				t.Errorf("was expecting to read from version 2 but got %#v, %v", tail, err)
+			}
+
+			all, err := store.ReadAll(2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(all) != 2 || all[0].Event != dune || all[1].Event != retired {
+				t.Errorf("unexpected log from position 2 %#v", all)
+			}
+
+			if head, err := store.Head(); err != nil || head != 3 {
+				t.Errorf("was expecting head at position 3 but got %d, %v", head, err)
+			}
+
+			if beyond, err := store.ReadAll(10); err != nil || len(beyond) != 0 {
+				t.Errorf("was expecting nothing beyond the end of the log but got %#v, %v", beyond, err)
+			}
+		})
+	}
+}
+
+func TestEventStore_StreamConflict(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			mustAppend(t, store, domain.FilmStream("Loki"), 0, loki)
+
+			err := store.Append(domain.FilmStream("Loki"), 0, loki)
+			var conflict *driven.StreamConflictError
+			if !errors.As(err, &conflict) {
+				t.Fatalf("was expecting StreamConflictError but got %#v", err)
+			}
+			if conflict.Expected != 0 || conflict.Actual != 1 {
+				t.Errorf("unexpected conflict %#v", conflict)
+			}
+
+			if all, _ := store.ReadAll(1); len(all) != 1 {
+				t.Errorf("rejected append must not be recorded but log holds %d events", len(all))
+			}
+		})
+	}
+}
+
+func TestEventStore_ConcurrentAppends(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					film := domain.FilmAdded{Name: fmt.Sprintf("Film %d", i), Director: "Dwight", Release: domain.Old}
+					if err := store.Append(domain.FilmStream(film.Name), 0, film); err != nil {
+						t.Error(err)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			all, err := store.ReadAll(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, record := range all {
+				if record.Position != uint64(i+1) {
+					t.Errorf("was expecting gapless positions but found %d at index %d", record.Position, i)
+				}
+			}
+		})
+	}
+}
+
+func TestFileStore_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+
+	store, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustAppend(t, store, domain.FilmStream("Loki"), 0, loki)
+	mustAppend(t, store, domain.RentalStream("r-1"), 0, domain.RentalStarted{RentalID: "r-1", Film: domain.Film{Name: "Loki", Director: "Marvel", Release: domain.New}, Days: 2})
+	store.Close()
+
+	// simulate a crash half way through writing the next event
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"position":3,"stream":"film-Loki"`)
+	f.Close()
+
+	reopened, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+
+	all, err := reopened.ReadAll(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0].Event != loki {
+		t.Fatalf("was expecting the acknowledged events to survive a restart but got %#v", all)
+	}
+	if _, ok := all[1].Event.(domain.RentalStarted); !ok {
+		t.Errorf("was expecting RentalStarted to be decoded but got %#v", all[1].Event)
+	}
+
+	mustAppend(t, reopened, domain.FilmStream("Loki"), 1, retired)
+	if stream, _ := reopened.ReadStream(domain.FilmStream("Loki"), 1); len(stream) != 2 || stream[1].Position != 3 {
+		t.Errorf("was expecting to continue appending after the truncated tail but got %#v", stream)
+	}
+}
+
+// failingSync writes through to the log but fails the next sync
+type failingSync struct {
+	*os.File
+	fail bool
+}
+
+func (f *failingSync) Sync() error {
+	if f.fail {
+		f.fail = false
+		return errors.New("disk full")
+	}
+	return f.File.Sync()
+}
+
+func TestFileStore_FailedAppendIsTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failing := &failingSync{File: file}
+	store, err := openFileStore(path, failing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustAppend(t, store, domain.FilmStream("Loki"), 0, loki)
+
+	failing.fail = true
+	if err := store.Append(domain.FilmStream("Dune"), 0, dune); err == nil {
+		t.Fatal("was expecting the failed sync to fail the append")
+	}
+	mustAppend(t, store, domain.FilmStream("Loki"), 1, retired)
+	store.Close()
+
+	reopened, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	all, _ := reopened.ReadAll(1)
+	if len(all) != 2 || all[0].Event != loki || all[1].Event != retired {
+		t.Errorf("was expecting the failed append to be truncated away but got %#v", all)
+	}
+}
+
+func TestFileStore_Lock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	store, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustAppend(t, store, domain.FilmStream("Loki"), 0, loki)
+
+	if second, err := OpenFileStore(path); err == nil {
+		second.Close()
+		t.Fatal("was expecting the log to be locked against a second writer")
+	}
+
+	store.Close()
+	reopened, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("was expecting the lock to be released on close: %v", err)
+	}
+	reopened.Close()
+}
+
+func mustAppend(t *testing.T, store driver.EventStore, stream string, expected uint64, events ...domain.Event) {
+	t.Helper()
+	if err := store.Append(stream, expected, events...); err != nil {
+		t.Fatal(err)
+	}
+}
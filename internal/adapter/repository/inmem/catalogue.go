@@ -3,14 +3,118 @@ package inmem
 import (
 	"github.com/shawnritchie/go-video-store/internal/domain"
 	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"sort"
+	"sync"
 )
 
 type (
-	StoreCatalogue []domain.Film
+	// StoreCatalogue serialises writers through writeMu so a unit of work can work on a private copy of the
+	// films and publish it on commit, readers only ever observe committed state
+	StoreCatalogue struct {
+		writeMu sync.Mutex
+		mu      sync.RWMutex
+		films   filmList
+	}
+
+	filmList []domain.Film
+
+	txCatalogue struct {
+		films *filmList
+	}
 )
 
+// NewStoreCatalogue catalogues the films at version 1 unless they carry a version of their own
+func NewStoreCatalogue(films ...domain.Film) *StoreCatalogue {
+	seeded := append(filmList(nil), films...)
+	for i := range seeded {
+		if seeded[i].Version == 0 {
+			seeded[i].Version = 1
+		}
+	}
+	return &StoreCatalogue{films: seeded}
+}
+
 func (cat *StoreCatalogue) FindBy(name string) (*domain.Film, error) {
-	for _, film := range *cat {
+	cat.mu.RLock()
+	defer cat.mu.RUnlock()
+	return cat.films.findBy(name)
+}
+
+func (cat *StoreCatalogue) FindAll(names []string) ([]domain.Film, error) {
+	cat.mu.RLock()
+	defer cat.mu.RUnlock()
+	return cat.films.findAll(names), nil
+}
+
+func (cat *StoreCatalogue) InsertIfAbsent(film domain.Film) error {
+	cat.writeMu.Lock()
+	defer cat.writeMu.Unlock()
+
+	cat.mu.Lock()
+	defer cat.mu.Unlock()
+	return cat.films.insertIfAbsent(film)
+}
+
+func (cat *StoreCatalogue) Update(film domain.Film) error {
+	cat.writeMu.Lock()
+	defer cat.writeMu.Unlock()
+
+	cat.mu.Lock()
+	defer cat.mu.Unlock()
+	return cat.films.update(film)
+}
+
+func (cat *StoreCatalogue) List() ([]domain.Film, error) {
+	cat.mu.RLock()
+	defer cat.mu.RUnlock()
+	return cat.films.list(), nil
+}
+
+// begin must be followed by either commit or rollback, other writers are blocked in between
+func (cat *StoreCatalogue) begin() *txCatalogue {
+	cat.writeMu.Lock()
+
+	cat.mu.RLock()
+	working := append(filmList(nil), cat.films...)
+	cat.mu.RUnlock()
+
+	return &txCatalogue{films: &working}
+}
+
+func (cat *StoreCatalogue) commit(tx *txCatalogue) {
+	cat.mu.Lock()
+	cat.films = *tx.films
+	cat.mu.Unlock()
+
+	cat.writeMu.Unlock()
+}
+
+func (cat *StoreCatalogue) rollback(*txCatalogue) {
+	cat.writeMu.Unlock()
+}
+
+func (tx *txCatalogue) FindBy(name string) (*domain.Film, error) {
+	return tx.films.findBy(name)
+}
+
+func (tx *txCatalogue) FindAll(names []string) ([]domain.Film, error) {
+	return tx.films.findAll(names), nil
+}
+
+func (tx *txCatalogue) InsertIfAbsent(film domain.Film) error {
+	return tx.films.insertIfAbsent(film)
+}
+
+func (tx *txCatalogue) Update(film domain.Film) error {
+	return tx.films.update(film)
+}
+
+func (tx *txCatalogue) List() ([]domain.Film, error) {
+	return tx.films.list(), nil
+}
+
+func (f filmList) findBy(name string) (*domain.Film, error) {
+	for _, film := range f {
 		if film.Name == name {
 			return &film, nil
 		}
@@ -19,10 +123,46 @@ func (cat *StoreCatalogue) FindBy(name string) (*domain.Film, error) {
 	return nil, &driven.FilmNotFoundError{Name: name}
 }
 
-func (cat *StoreCatalogue) Insert(film domain.Film) error {
-	/*
-	 *Adapaters should be dumb variance rules should be part of the service
-	 */
-	*cat = append(*cat, film)
+func (f filmList) findAll(names []string) []domain.Film {
+	var films []domain.Film
+	for _, name := range names {
+		if film, err := f.findBy(name); err == nil {
+			films = append(films, *film)
+		}
+	}
+	return films
+}
+
+func (f *filmList) insertIfAbsent(film domain.Film) error {
+	if _, err := f.findBy(film.Name); err == nil {
+		return &driven.FilmAlreadyExistError{Name: film.Name}
+	}
+
+	film.Version = 1
+	*f = append(*f, film)
 	return nil
 }
+
+func (f filmList) update(film domain.Film) error {
+	for i := range f {
+		if f[i].Name != film.Name {
+			continue
+		}
+		if f[i].Version != film.Version {
+			return &driven.FilmVersionConflictError{Name: film.Name, Expected: film.Version, Actual: f[i].Version}
+		}
+		f[i].Director = film.Director
+		f[i].Release = film.Release
+		f[i].Version++
+		return nil
+	}
+	return &driven.FilmNotFoundError{Name: film.Name}
+}
+
+func (f filmList) list() []domain.Film {
+	sorted := append([]domain.Film(nil), f...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
@@ -2,28 +2,29 @@ package inmem
 
 import (
 	"errors"
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/catalogtest"
 	"github.com/shawnritchie/go-video-store/internal/domain"
 	"github.com/shawnritchie/go-video-store/internal/port/driven"
 	"github.com/shawnritchie/go-video-store/internal/port/driver"
 	"testing"
 )
 
-//Array Declaration
-var catalogue = StoreCatalogue{
-	domain.Film{Name: "Matrix 11", Director: "Dwight", Release: domain.New},
-	domain.Film{Name: "Spider Man", Director: "Dwight", Release: domain.Regular},
-	domain.Film{Name: "Spider Man 2", Director: "Dwight", Release: domain.Regular},
-	domain.Film{Name: "Out of Africa", Director: "Dwight", Release: domain.Old},
+// Array Declaration
+var films = []domain.Film{
+	{Name: "Matrix 11", Director: "Dwight", Release: domain.New},
+	{Name: "Spider Man", Director: "Dwight", Release: domain.Regular},
+	{Name: "Spider Man 2", Director: "Dwight", Release: domain.Regular},
+	{Name: "Out of Africa", Director: "Dwight", Release: domain.Old},
 }
 
-var repo driver.Catalogue = &catalogue
+var repo driver.Catalogue = NewStoreCatalogue(films...)
 
 func TestFindFilm(t *testing.T) {
-	var find = catalogue[0]
+	var find = films[0]
 	if found, err := repo.FindBy(find.Name); err != nil {
 		t.Error(err)
-	} else if find != *found {
-		t.Errorf("searched for %q but got %q", find.Name, found.Name)
+	} else if find.Name != found.Name || found.Version != 1 {
+		t.Errorf("searched for %q at version 1 but got %#v", find.Name, *found)
 	}
 }
 
@@ -46,7 +47,7 @@ func TestAddFilm(t *testing.T) {
 		Release:  domain.New,
 	}
 
-	if err := repo.Insert(newFilm); err != nil {
+	if err := repo.InsertIfAbsent(newFilm); err != nil {
 		t.Errorf("was expecting film to be inserted succesfully film: %#v but failed with %v", newFilm, err)
 	}
 
@@ -54,3 +55,9 @@ func TestAddFilm(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestCatalogue(t *testing.T) {
+	catalogtest.Run(t, func(t *testing.T) driver.Catalogue {
+		return NewStoreCatalogue()
+	})
+}
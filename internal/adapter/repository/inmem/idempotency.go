@@ -0,0 +1,103 @@
+package inmem
+
+import (
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"sync"
+	"time"
+)
+
+type (
+	// IdempotencyStore forgets every key once its ttl has passed, expired keys are dropped as new ones are claimed
+	IdempotencyStore struct {
+		mu       sync.Mutex
+		keys     map[string]*idempotencyKey
+		expiries []expiry
+		now      func() time.Time
+	}
+
+	idempotencyKey struct {
+		fingerprint string
+		ttl         time.Duration
+		expires     time.Time
+		response    *driver.IdempotentResponse
+	}
+
+	// expiry queues keys by the time they were claimed or completed, a key claimed or completed again since is
+	// queued again and kept until its latest expiry
+	expiry struct {
+		key     string
+		expires time.Time
+	}
+)
+
+func NewIdempotencyStore() *IdempotencyStore {
+	return &IdempotencyStore{keys: map[string]*idempotencyKey{}, now: time.Now}
+}
+
+func (s *IdempotencyStore) Claim(key string, fingerprint string, ttl time.Duration) (*driver.IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.expire(now)
+	if claimed, ok := s.keys[key]; ok {
+		switch {
+		case claimed.fingerprint != fingerprint:
+			return nil, &driven.IdempotencyKeyReusedError{Key: key}
+		case claimed.response == nil:
+			return nil, &driven.IdempotencyKeyInUseError{Key: key}
+		}
+		response := copyResponse(*claimed.response)
+		return &response, nil
+	}
+
+	claimed := &idempotencyKey{fingerprint: fingerprint, ttl: ttl, expires: now.Add(ttl)}
+	s.keys[key] = claimed
+	s.expiries = append(s.expiries, expiry{key: key, expires: claimed.expires})
+	return nil, nil
+}
+
+func (s *IdempotencyStore) Complete(key string, response driver.IdempotentResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	claimed, ok := s.keys[key]
+	if !ok {
+		return nil
+	}
+	response = copyResponse(response)
+	claimed.response = &response
+	claimed.expires = s.now().Add(claimed.ttl)
+	s.expiries = append(s.expiries, expiry{key: key, expires: claimed.expires})
+	return nil
+}
+
+func (s *IdempotencyStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, key)
+	return nil
+}
+
+// expire drops the keys at the front of the queue whose latest expiry has passed
+func (s *IdempotencyStore) expire(now time.Time) {
+	for len(s.expiries) > 0 && !s.expiries[0].expires.After(now) {
+		next := s.expiries[0]
+		if claimed, ok := s.keys[next.key]; ok && !claimed.expires.After(now) {
+			delete(s.keys, next.key)
+		}
+		s.expiries = s.expiries[1:]
+	}
+}
+
+func copyResponse(response driver.IdempotentResponse) driver.IdempotentResponse {
+	header := make(map[string][]string, len(response.Header))
+	for k, v := range response.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	response.Header = header
+	response.Body = append([]byte(nil), response.Body...)
+	return response
+}
@@ -0,0 +1,89 @@
+package inmem
+
+import (
+	"errors"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyStore_ReplaysCompletedResponse(t *testing.T) {
+	store := NewIdempotencyStore()
+	if response, err := store.Claim("key", "return", time.Hour); response != nil || err != nil {
+		t.Fatalf("was expecting the key to be claimed but got %#v: %v", response, err)
+	}
+	if _, err := store.Claim("key", "return", time.Hour); !errors.As(err, &driven.TypeIdempotencyKeyInUse) {
+		t.Errorf("was expecting the key to be in use until completed but got %v", err)
+	}
+
+	completed := driver.IdempotentResponse{Status: 200, Header: map[string][]string{"Content-Type": {"application/json"}}, Body: []byte(`{}`)}
+	if err := store.Complete("key", completed); err != nil {
+		t.Fatal(err)
+	}
+	completed.Body[0] = '['
+
+	response, err := store.Claim("key", "return", time.Hour)
+	if err != nil || response == nil || response.Status != 200 || string(response.Body) != `{}` || response.Header["Content-Type"][0] != "application/json" {
+		t.Errorf("was expecting the completed response to be replayed but got %#v: %v", response, err)
+	}
+	if _, err := store.Claim("key", "another return", time.Hour); !errors.As(err, &driven.TypeIdempotencyKeyReused) {
+		t.Errorf("was expecting the key to be refused for another request but got %v", err)
+	}
+}
+
+func TestIdempotencyStore_Release(t *testing.T) {
+	store := NewIdempotencyStore()
+	store.Claim("key", "return", time.Hour)
+	if err := store.Release("key"); err != nil {
+		t.Fatal(err)
+	}
+	if response, err := store.Claim("key", "another return", time.Hour); response != nil || err != nil {
+		t.Errorf("was expecting a released key to be claimed again but got %#v: %v", response, err)
+	}
+}
+
+func TestIdempotencyStore_Expires(t *testing.T) {
+	store := NewIdempotencyStore()
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	store.Claim("completed", "return", time.Hour)
+	store.Claim("abandoned", "return", time.Hour)
+	now = now.Add(30 * time.Minute)
+	store.Complete("completed", driver.IdempotentResponse{Status: 200})
+
+	now = now.Add(45 * time.Minute)
+	if response, _ := store.Claim("completed", "return", time.Hour); response == nil {
+		t.Errorf("was expecting the ttl of a response to start once it completed")
+	}
+	if response, err := store.Claim("abandoned", "another return", time.Hour); response != nil || err != nil {
+		t.Errorf("was expecting an abandoned key to expire but got %#v: %v", response, err)
+	}
+
+	now = now.Add(time.Hour)
+	store.Claim("other", "return", time.Hour)
+	if len(store.keys) != 1 {
+		t.Errorf("was expecting every expired key to be dropped but %d are kept", len(store.keys))
+	}
+}
+
+func TestIdempotencyStore_ConcurrentClaims(t *testing.T) {
+	store := NewIdempotencyStore()
+	var wg sync.WaitGroup
+	claimed := make(chan struct{}, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := store.Claim("key", "return", time.Hour); err == nil {
+				claimed <- struct{}{}
+			}
+		}()
+	}
+	wg.Wait()
+	if len(claimed) != 1 {
+		t.Errorf("was expecting a single request to claim the key but %d did", len(claimed))
+	}
+}
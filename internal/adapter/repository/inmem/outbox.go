@@ -0,0 +1,107 @@
+package inmem
+
+import (
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"sync"
+	"time"
+)
+
+type (
+	// Outbox holds the envelopes which have not yet been dispatched, it takes part in a unit of work the same
+	// way StoreCatalogue does
+	Outbox struct {
+		writeMu sync.Mutex
+		mu      sync.RWMutex
+		pending envelopes
+		now     func() time.Time
+	}
+
+	envelopes struct {
+		list     []driver.Envelope
+		sequence uint64
+	}
+
+	txOutbox struct {
+		pending *envelopes
+		now     func() time.Time
+	}
+)
+
+func NewOutbox() *Outbox {
+	return &Outbox{now: time.Now}
+}
+
+func (o *Outbox) Enqueue(events ...domain.Event) error {
+	o.writeMu.Lock()
+	defer o.writeMu.Unlock()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.pending.enqueue(o.now().UTC(), events)
+	return nil
+}
+
+func (o *Outbox) Pending(limit int) ([]driver.Envelope, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if limit > len(o.pending.list) {
+		limit = len(o.pending.list)
+	}
+	return append([]driver.Envelope(nil), o.pending.list[:limit]...), nil
+}
+
+func (o *Outbox) MarkDispatched(sequence uint64) error {
+	o.writeMu.Lock()
+	defer o.writeMu.Unlock()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	remaining := o.pending.list[:0:0]
+	for _, envelope := range o.pending.list {
+		if envelope.Sequence > sequence {
+			remaining = append(remaining, envelope)
+		}
+	}
+	o.pending.list = remaining
+	return nil
+}
+
+func (o *Outbox) begin() *txOutbox {
+	o.writeMu.Lock()
+
+	o.mu.RLock()
+	working := envelopes{
+		list:     append([]driver.Envelope(nil), o.pending.list...),
+		sequence: o.pending.sequence,
+	}
+	o.mu.RUnlock()
+
+	return &txOutbox{pending: &working, now: o.now}
+}
+
+func (o *Outbox) commit(tx *txOutbox) {
+	o.mu.Lock()
+	o.pending = *tx.pending
+	o.mu.Unlock()
+
+	o.writeMu.Unlock()
+}
+
+func (o *Outbox) rollback(*txOutbox) {
+	o.writeMu.Unlock()
+}
+
+func (tx *txOutbox) Enqueue(events ...domain.Event) error {
+	tx.pending.enqueue(tx.now().UTC(), events)
+	return nil
+}
+
+func (e *envelopes) enqueue(occurredAt time.Time, events []domain.Event) {
+	for _, event := range events {
+		e.sequence++
+		e.list = append(e.list, driver.Envelope{Sequence: e.sequence, Event: event, OccurredAt: occurredAt})
+	}
+}
@@ -0,0 +1,267 @@
+package inmem
+
+import (
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"sort"
+	"sync"
+)
+
+type (
+	// Stores keeps every store apart by keying all of its state by store, it takes part in a unit of work the
+	// same way StoreCatalogue does
+	Stores struct {
+		writeMu sync.Mutex
+		mu      sync.RWMutex
+		state   storeState
+	}
+
+	storeState struct {
+		stock    map[domain.StoreID]map[string]int
+		prices   map[domain.StoreID]domain.PriceList
+		invoices map[domain.StoreID][]domain.IssuedInvoice
+		// transfers are kept once, Transfers finds those of a store amongst them
+		transfers map[string]domain.Transfer
+	}
+
+	txStores struct {
+		state *storeState
+	}
+)
+
+func NewStores() *Stores {
+	return &Stores{state: storeState{
+		stock:     map[domain.StoreID]map[string]int{},
+		prices:    map[domain.StoreID]domain.PriceList{},
+		invoices:  map[domain.StoreID][]domain.IssuedInvoice{},
+		transfers: map[string]domain.Transfer{},
+	}}
+}
+
+func (s *Stores) Stock(store domain.StoreID, film string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state.stock[store][film], nil
+}
+
+func (s *Stores) AdjustStock(store domain.StoreID, film string, delta int) (int, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state.adjustStock(store, film, delta)
+}
+
+func (s *Stores) Inventory(store domain.StoreID) ([]domain.Stock, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state.inventory(store), nil
+}
+
+func (s *Stores) PriceList(store domain.StoreID) (domain.PriceList, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state.priceList(store), nil
+}
+
+func (s *Stores) SavePriceList(store domain.StoreID, prices domain.PriceList) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.prices[store] = prices
+	return nil
+}
+
+func (s *Stores) SaveInvoice(invoice domain.IssuedInvoice) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.saveInvoice(invoice)
+	return nil
+}
+
+func (s *Stores) Invoices(store domain.StoreID) ([]domain.IssuedInvoice, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]domain.IssuedInvoice(nil), s.state.invoices[store]...), nil
+}
+
+func (s *Stores) Transfer(id string) (*domain.Transfer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state.transfer(id)
+}
+
+func (s *Stores) SaveTransfer(transfer domain.Transfer) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.transfers[transfer.ID] = transfer
+	return nil
+}
+
+func (s *Stores) Transfers(store domain.StoreID) ([]domain.Transfer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state.transfersOf(store), nil
+}
+
+// begin must be followed by either commit or rollback, other writers are blocked in between
+func (s *Stores) begin() *txStores {
+	s.writeMu.Lock()
+	s.mu.RLock()
+	working := s.state.clone()
+	s.mu.RUnlock()
+	return &txStores{state: &working}
+}
+
+func (s *Stores) commit(tx *txStores) {
+	s.mu.Lock()
+	s.state = *tx.state
+	s.mu.Unlock()
+	s.writeMu.Unlock()
+}
+
+func (s *Stores) rollback(*txStores) {
+	s.writeMu.Unlock()
+}
+
+func (tx *txStores) Stock(store domain.StoreID, film string) (int, error) {
+	return tx.state.stock[store][film], nil
+}
+
+func (tx *txStores) AdjustStock(store domain.StoreID, film string, delta int) (int, error) {
+	return tx.state.adjustStock(store, film, delta)
+}
+
+func (tx *txStores) Inventory(store domain.StoreID) ([]domain.Stock, error) {
+	return tx.state.inventory(store), nil
+}
+
+func (tx *txStores) PriceList(store domain.StoreID) (domain.PriceList, error) {
+	return tx.state.priceList(store), nil
+}
+
+func (tx *txStores) SavePriceList(store domain.StoreID, prices domain.PriceList) error {
+	tx.state.prices[store] = prices
+	return nil
+}
+
+func (tx *txStores) SaveInvoice(invoice domain.IssuedInvoice) error {
+	tx.state.saveInvoice(invoice)
+	return nil
+}
+
+func (tx *txStores) Invoices(store domain.StoreID) ([]domain.IssuedInvoice, error) {
+	return append([]domain.IssuedInvoice(nil), tx.state.invoices[store]...), nil
+}
+
+func (tx *txStores) Transfer(id string) (*domain.Transfer, error) {
+	return tx.state.transfer(id)
+}
+
+func (tx *txStores) SaveTransfer(transfer domain.Transfer) error {
+	tx.state.transfers[transfer.ID] = transfer
+	return nil
+}
+
+func (tx *txStores) Transfers(store domain.StoreID) ([]domain.Transfer, error) {
+	return tx.state.transfersOf(store), nil
+}
+
+func (s *storeState) adjustStock(store domain.StoreID, film string, delta int) (int, error) {
+	copies := s.stock[store][film] + delta
+	if copies < 0 {
+		return 0, &driven.InsufficientStockError{Store: store, Film: film, Available: s.stock[store][film], Requested: -delta}
+	}
+
+	if s.stock[store] == nil {
+		s.stock[store] = map[string]int{}
+	}
+	if copies == 0 {
+		delete(s.stock[store], film)
+	} else {
+		s.stock[store][film] = copies
+	}
+	return copies, nil
+}
+
+func (s *storeState) inventory(store domain.StoreID) []domain.Stock {
+	inventory := make([]domain.Stock, 0, len(s.stock[store]))
+	for film, copies := range s.stock[store] {
+		inventory = append(inventory, domain.Stock{Film: film, Copies: copies})
+	}
+	sort.Slice(inventory, func(i, j int) bool {
+		return inventory[i].Film < inventory[j].Film
+	})
+	return inventory
+}
+
+func (s *storeState) priceList(store domain.StoreID) domain.PriceList {
+	if prices, ok := s.prices[store]; ok {
+		return prices
+	}
+	return domain.DefaultPriceList
+}
+
+func (s *storeState) saveInvoice(invoice domain.IssuedInvoice) {
+	invoice.Rentals = append([]domain.Rental(nil), invoice.Rentals...)
+	invoices := append(s.invoices[invoice.Store], invoice)
+	sort.SliceStable(invoices, func(i, j int) bool {
+		return invoices[i].IssuedAt.Before(invoices[j].IssuedAt)
+	})
+	s.invoices[invoice.Store] = invoices
+}
+
+func (s *storeState) transfer(id string) (*domain.Transfer, error) {
+	transfer, ok := s.transfers[id]
+	if !ok {
+		return nil, &driven.TransferNotFoundError{ID: id}
+	}
+	return &transfer, nil
+}
+
+func (s *storeState) transfersOf(store domain.StoreID) []domain.Transfer {
+	var transfers []domain.Transfer
+	for _, transfer := range s.transfers {
+		if transfer.From == store || transfer.To == store {
+			transfers = append(transfers, transfer)
+		}
+	}
+	sort.Slice(transfers, func(i, j int) bool {
+		if !transfers[i].RequestedAt.Equal(transfers[j].RequestedAt) {
+			return transfers[i].RequestedAt.Before(transfers[j].RequestedAt)
+		}
+		return transfers[i].ID < transfers[j].ID
+	})
+	return transfers
+}
+
+// clone copies every map a unit of work may write to, the invoices themselves are never modified once saved
+func (s storeState) clone() storeState {
+	clone := storeState{
+		stock:     make(map[domain.StoreID]map[string]int, len(s.stock)),
+		prices:    make(map[domain.StoreID]domain.PriceList, len(s.prices)),
+		invoices:  make(map[domain.StoreID][]domain.IssuedInvoice, len(s.invoices)),
+		transfers: make(map[string]domain.Transfer, len(s.transfers)),
+	}
+	for store, films := range s.stock {
+		clone.stock[store] = make(map[string]int, len(films))
+		for film, copies := range films {
+			clone.stock[store][film] = copies
+		}
+	}
+	for store, prices := range s.prices {
+		clone.prices[store] = prices
+	}
+	for store, invoices := range s.invoices {
+		clone.invoices[store] = append([]domain.IssuedInvoice(nil), invoices...)
+	}
+	for id, transfer := range s.transfers {
+		clone.transfers[id] = transfer
+	}
+	return clone
+}
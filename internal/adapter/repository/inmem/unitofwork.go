@@ -0,0 +1,69 @@
+package inmem
+
+import (
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+)
+
+type (
+	// UnitOfWork applies copy-on-write to the repositories it spans, writes are made against private copies
+	// which only replace the shared state once the whole unit has succeeded
+	UnitOfWork struct {
+		catalogue *StoreCatalogue
+		outbox    *Outbox
+		stores    *Stores
+	}
+
+	tx struct {
+		catalogue *txCatalogue
+		outbox    *txOutbox
+		stores    *txStores
+	}
+)
+
+func NewUnitOfWork(catalogue *StoreCatalogue, outbox *Outbox, stores *Stores) *UnitOfWork {
+	return &UnitOfWork{
+		catalogue: catalogue,
+		outbox:    outbox,
+		stores:    stores,
+	}
+}
+
+// Atomically locks the repositories in a fixed order so concurrent units of work cannot deadlock
+func (uow *UnitOfWork) Atomically(fx func(tx driver.Tx) error) error {
+	t := &tx{
+		catalogue: uow.catalogue.begin(),
+		outbox:    uow.outbox.begin(),
+		stores:    uow.stores.begin(),
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			uow.stores.rollback(t.stores)
+			uow.outbox.rollback(t.outbox)
+			uow.catalogue.rollback(t.catalogue)
+		}
+	}()
+
+	if err := fx(t); err != nil {
+		return err
+	}
+
+	uow.catalogue.commit(t.catalogue)
+	uow.outbox.commit(t.outbox)
+	uow.stores.commit(t.stores)
+	committed = true
+	return nil
+}
+
+func (t *tx) Catalogue() driver.Catalogue {
+	return t.catalogue
+}
+
+func (t *tx) Outbox() driver.Outbox {
+	return t.outbox
+}
+
+func (t *tx) Stores() driver.Stores {
+	return t.stores
+}
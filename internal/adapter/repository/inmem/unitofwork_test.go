@@ -0,0 +1,20 @@
+package inmem
+
+import (
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/catalogtest"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"testing"
+)
+
+func TestUnitOfWork(t *testing.T) {
+	catalogtest.RunUnitOfWork(t, func(t *testing.T) (driver.UnitOfWork, driver.Catalogue, driver.OutboxStore, driver.Stores) {
+		cat, outbox, stores := NewStoreCatalogue(), NewOutbox(), NewStores()
+		return NewUnitOfWork(cat, outbox, stores), cat, outbox, stores
+	})
+}
+
+func TestStores(t *testing.T) {
+	catalogtest.RunStores(t, func(t *testing.T) driver.Stores {
+		return NewStores()
+	})
+}
@@ -0,0 +1,199 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"strings"
+)
+
+type (
+	Catalogue struct {
+		db *sql.DB
+		// querier runs the statements which cannot be prepared, such as FindAll, on the database or the
+		// transaction the catalogue is bound to
+		querier querier
+		findBy  *sql.Stmt
+		insert  *sql.Stmt
+		update  *sql.Stmt
+		list    *sql.Stmt
+	}
+
+	querier interface {
+		Query(query string, args ...interface{}) (*sql.Rows, error)
+	}
+)
+
+// maxFindAll bounds the names bound to a single FindAll query, well below the variable limit of sqlite
+const maxFindAll = 500
+
+func NewCatalogue(db *sql.DB) (*Catalogue, error) {
+	findBy, err := db.Prepare("SELECT name, director, release, version FROM films WHERE name = ?")
+	if err != nil {
+		return nil, fmt.Errorf("unable to prepare find statement: %w", err)
+	}
+
+	insert, err := db.Prepare("INSERT INTO films (name, director, release) VALUES (?, ?, ?)")
+	if err != nil {
+		findBy.Close()
+		return nil, fmt.Errorf("unable to prepare insert statement: %w", err)
+	}
+
+	update, err := db.Prepare("UPDATE films SET director = ?, release = ?, version = version + 1 WHERE name = ? AND version = ?")
+	if err != nil {
+		findBy.Close()
+		insert.Close()
+		return nil, fmt.Errorf("unable to prepare update statement: %w", err)
+	}
+
+	list, err := db.Prepare("SELECT name, director, release, version FROM films ORDER BY name")
+	if err != nil {
+		findBy.Close()
+		insert.Close()
+		update.Close()
+		return nil, fmt.Errorf("unable to prepare list statement: %w", err)
+	}
+
+	return &Catalogue{
+		db:      db,
+		querier: db,
+		findBy:  findBy,
+		insert:  insert,
+		update:  update,
+		list:    list,
+	}, nil
+}
+
+func (cat *Catalogue) FindBy(name string) (*domain.Film, error) {
+	film, err := scanFilm(cat.findBy.QueryRow(name))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, &driven.FilmNotFoundError{Name: name}
+	case err != nil:
+		return nil, fmt.Errorf("unable to find film %q: %w", name, err)
+	}
+	return film, nil
+}
+
+// FindAll looks the names up with a query per maxFindAll of them
+func (cat *Catalogue) FindAll(names []string) ([]domain.Film, error) {
+	var films []domain.Film
+	for len(names) > 0 {
+		batch := names
+		if len(batch) > maxFindAll {
+			batch = batch[:maxFindAll]
+		}
+		names = names[len(batch):]
+
+		args := make([]interface{}, len(batch))
+		for i, name := range batch {
+			args[i] = name
+		}
+		query := "SELECT name, director, release, version FROM films WHERE name IN (?" + strings.Repeat(", ?", len(batch)-1) + ")"
+		rows, err := cat.querier.Query(query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to find films: %w", err)
+		}
+		found, err := scanFilms(rows)
+		if err != nil {
+			return nil, fmt.Errorf("unable to find films: %w", err)
+		}
+		films = append(films, found...)
+	}
+	return films, nil
+}
+
+func (cat *Catalogue) List() ([]domain.Film, error) {
+	rows, err := cat.list.Query()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list films: %w", err)
+	}
+	films, err := scanFilms(rows)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list films: %w", err)
+	}
+	return films, nil
+}
+
+func (cat *Catalogue) InsertIfAbsent(film domain.Film) error {
+	if _, err := cat.insert.Exec(film.Name, film.Director, string(film.Release)); err != nil {
+		if isUniqueViolation(err) {
+			return &driven.FilmAlreadyExistError{Name: film.Name}
+		}
+		return fmt.Errorf("unable to insert film %q: %w", film.Name, err)
+	}
+	return nil
+}
+
+// Update tells a missing film apart from a stale version by reading the film back when no row was updated
+func (cat *Catalogue) Update(film domain.Film) error {
+	result, err := cat.update.Exec(film.Director, string(film.Release), film.Name, film.Version)
+	if err != nil {
+		return fmt.Errorf("unable to update film %q: %w", film.Name, err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("unable to update film %q: %w", film.Name, err)
+	}
+	if updated == 1 {
+		return nil
+	}
+
+	current, err := cat.FindBy(film.Name)
+	if err != nil {
+		return err
+	}
+	return &driven.FilmVersionConflictError{Name: film.Name, Expected: film.Version, Actual: current.Version}
+}
+
+// withTx binds the prepared statements to tx, they are released by the driver once tx completes
+func (cat *Catalogue) withTx(tx *sql.Tx) *Catalogue {
+	return &Catalogue{
+		db:      cat.db,
+		querier: tx,
+		findBy:  tx.Stmt(cat.findBy),
+		insert:  tx.Stmt(cat.insert),
+		update:  tx.Stmt(cat.update),
+		list:    tx.Stmt(cat.list),
+	}
+}
+
+// Close releases the prepared statements, the underlying database is owned by the caller
+func (cat *Catalogue) Close() error {
+	return errors.Join(cat.findBy.Close(), cat.insert.Close(), cat.update.Close(), cat.list.Close())
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanFilms reads every row and closes them
+func scanFilms(rows *sql.Rows) ([]domain.Film, error) {
+	defer rows.Close()
+
+	var films []domain.Film
+	for rows.Next() {
+		film, err := scanFilm(rows)
+		if err != nil {
+			return nil, err
+		}
+		films = append(films, *film)
+	}
+	return films, rows.Err()
+}
+
+func scanFilm(row scanner) (*domain.Film, error) {
+	var film domain.Film
+	var release string
+	if err := row.Scan(&film.Name, &film.Director, &release, &film.Version); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if film.Release, err = domain.ParseRelease(release); err != nil {
+		return nil, fmt.Errorf("film %q has been stored with a corrupted release %q: %w", film.Name, release, err)
+	}
+	return &film, nil
+}
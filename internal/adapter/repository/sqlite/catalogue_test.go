@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/catalogtest"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"path/filepath"
+	"testing"
+)
+
+func newCatalogue(t *testing.T) driver.Catalogue {
+	t.Helper()
+	db, err := Open(filepath.Join(t.TempDir(), "videostore.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	cat, err := NewCatalogue(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cat.Close() })
+	return cat
+}
+
+func TestCatalogue(t *testing.T) {
+	catalogtest.Run(t, newCatalogue)
+}
+
+func TestCatalogue_FindAllBeyondOneQuery(t *testing.T) {
+	cat := catalogtest.Seed(t, newCatalogue)
+
+	names := []string{catalogtest.Films[0].Name}
+	for i := 0; i < maxFindAll; i++ {
+		names = append(names, fmt.Sprintf("Unknown %d", i))
+	}
+	names = append(names, catalogtest.Films[3].Name)
+
+	found, err := cat.FindAll(names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || found[0].Name != catalogtest.Films[0].Name || found[1].Name != catalogtest.Films[3].Name {
+		t.Errorf("was expecting the films named in either query but got %#v", found)
+	}
+}
@@ -0,0 +1,6 @@
+CREATE TABLE films (
+    id       INTEGER PRIMARY KEY AUTOINCREMENT,
+    name     TEXT    NOT NULL UNIQUE,
+    director TEXT    NOT NULL,
+    release  TEXT    NOT NULL CHECK (release IN ('New', 'Regular', 'Old'))
+);
@@ -0,0 +1,9 @@
+CREATE TABLE outbox (
+    sequence      INTEGER PRIMARY KEY AUTOINCREMENT,
+    type          TEXT    NOT NULL,
+    data          TEXT    NOT NULL,
+    occurred_at   TEXT    NOT NULL,
+    dispatched_at TEXT
+);
+
+CREATE INDEX outbox_pending ON outbox (dispatched_at, sequence);
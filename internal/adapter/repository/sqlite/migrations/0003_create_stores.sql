@@ -0,0 +1,22 @@
+CREATE TABLE stock (
+    store  TEXT    NOT NULL,
+    film   TEXT    NOT NULL,
+    copies INTEGER NOT NULL CHECK (copies >= 0),
+    PRIMARY KEY (store, film)
+);
+
+CREATE TABLE price_lists (
+    store   TEXT    PRIMARY KEY,
+    premium INTEGER NOT NULL,
+    basic   INTEGER NOT NULL
+);
+
+CREATE TABLE invoices (
+    id        TEXT    PRIMARY KEY,
+    store     TEXT    NOT NULL,
+    rentals   TEXT    NOT NULL,
+    cost      INTEGER NOT NULL,
+    issued_at TEXT    NOT NULL
+);
+
+CREATE INDEX invoices_by_store ON invoices (store, issued_at);
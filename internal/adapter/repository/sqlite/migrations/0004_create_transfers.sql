@@ -0,0 +1,12 @@
+CREATE TABLE transfers (
+    id           TEXT    PRIMARY KEY,
+    film         TEXT    NOT NULL,
+    from_store   TEXT    NOT NULL,
+    to_store     TEXT    NOT NULL,
+    copies       INTEGER NOT NULL CHECK (copies > 0),
+    status       TEXT    NOT NULL CHECK (status IN ('Requested', 'Approved', 'InTransit', 'Received')),
+    requested_at TEXT    NOT NULL
+);
+
+CREATE INDEX transfers_from_store ON transfers (from_store, requested_at);
+CREATE INDEX transfers_to_store ON transfers (to_store, requested_at);
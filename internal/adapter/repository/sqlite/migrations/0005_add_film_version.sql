@@ -0,0 +1 @@
+ALTER TABLE films ADD COLUMN version INTEGER NOT NULL DEFAULT 1 CHECK (version > 0);
@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"time"
+)
+
+type (
+	Outbox struct {
+		enqueue        *sql.Stmt
+		pending        *sql.Stmt
+		markDispatched *sql.Stmt
+		now            func() time.Time
+	}
+)
+
+func NewOutbox(db *sql.DB) (*Outbox, error) {
+	outbox := &Outbox{now: time.Now}
+	statements := []struct {
+		stmt  **sql.Stmt
+		query string
+	}{
+		{&outbox.enqueue, "INSERT INTO outbox (type, data, occurred_at) VALUES (?, ?, ?)"},
+		{&outbox.pending, "SELECT sequence, type, data, occurred_at FROM outbox WHERE dispatched_at IS NULL ORDER BY sequence LIMIT ?"},
+		{&outbox.markDispatched, "UPDATE outbox SET dispatched_at = ? WHERE sequence <= ? AND dispatched_at IS NULL"},
+	}
+
+	for _, s := range statements {
+		stmt, err := db.Prepare(s.query)
+		if err != nil {
+			outbox.Close()
+			return nil, fmt.Errorf("unable to prepare outbox statement: %w", err)
+		}
+		*s.stmt = stmt
+	}
+	return outbox, nil
+}
+
+func (o *Outbox) Enqueue(events ...domain.Event) error {
+	occurredAt := o.now().UTC().Format(time.RFC3339Nano)
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("unable to encode %s event: %w", event.EventType(), err)
+		}
+
+		if _, err := o.enqueue.Exec(event.EventType(), string(data), occurredAt); err != nil {
+			return fmt.Errorf("unable to enqueue %s event: %w", event.EventType(), err)
+		}
+	}
+	return nil
+}
+
+func (o *Outbox) Pending(limit int) ([]driver.Envelope, error) {
+	rows, err := o.pending.Query(limit)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var envelopes []driver.Envelope
+	for rows.Next() {
+		var envelope driver.Envelope
+		var eventType, data, occurredAt string
+		if err := rows.Scan(&envelope.Sequence, &eventType, &data, &occurredAt); err != nil {
+			return nil, fmt.Errorf("unable to read outbox: %w", err)
+		}
+
+		if envelope.Event, err = domain.DecodeEvent(eventType, []byte(data)); err != nil {
+			return nil, fmt.Errorf("outbox entry %d: %w", envelope.Sequence, err)
+		}
+		if envelope.OccurredAt, err = time.Parse(time.RFC3339Nano, occurredAt); err != nil {
+			return nil, fmt.Errorf("outbox entry %d: %w", envelope.Sequence, err)
+		}
+		envelopes = append(envelopes, envelope)
+	}
+	return envelopes, rows.Err()
+}
+
+func (o *Outbox) MarkDispatched(sequence uint64) error {
+	if _, err := o.markDispatched.Exec(o.now().UTC().Format(time.RFC3339Nano), sequence); err != nil {
+		return fmt.Errorf("unable to mark outbox dispatched up to %d: %w", sequence, err)
+	}
+	return nil
+}
+
+func (o *Outbox) withTx(tx *sql.Tx) *Outbox {
+	return &Outbox{
+		enqueue:        tx.Stmt(o.enqueue),
+		pending:        tx.Stmt(o.pending),
+		markDispatched: tx.Stmt(o.markDispatched),
+		now:            o.now,
+	}
+}
+
+// Close releases the prepared statements, the underlying database is owned by the caller
+func (o *Outbox) Close() error {
+	var errs []error
+	for _, stmt := range []*sql.Stmt{o.enqueue, o.pending, o.markDispatched} {
+		if stmt != nil {
+			errs = append(errs, stmt.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
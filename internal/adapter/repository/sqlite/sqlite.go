@@ -0,0 +1,113 @@
+package sqlite
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	msqlite "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Open connects to the SQLite database found at dsn and brings its schema up to date
+func Open(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open sqlite database %q: %w", dsn, err)
+	}
+
+	// SQLite serialises writers, a single connection avoids SQLITE_BUSY and keeps :memory: databases shared
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("PRAGMA foreign_keys = ON; PRAGMA busy_timeout = 5000;"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to configure sqlite database: %w", err)
+	}
+
+	if err := Migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Migrate applies every embedded migration which has not yet been recorded in schema_migrations
+func Migrate(db *sql.DB) error {
+	if _, err := db.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)"); err != nil {
+		return fmt.Errorf("unable to create schema_migrations: %w", err)
+	}
+
+	files, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		version, err := migrationVersion(file)
+		if err != nil {
+			return err
+		}
+
+		if err := migrate(db, version, file); err != nil {
+			return fmt.Errorf("migration %q failed: %w", file, err)
+		}
+	}
+	return nil
+}
+
+func migrate(db *sql.DB, version int, file string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var applied int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = ?", version).Scan(&applied); err != nil {
+		return err
+	}
+	if applied > 0 {
+		return nil
+	}
+
+	script, err := migrations.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(string(script)); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("INSERT INTO schema_migrations (version) VALUES (?)", version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func migrationVersion(file string) (int, error) {
+	name := strings.TrimPrefix(file, "migrations/")
+	prefix := strings.SplitN(name, "_", 2)[0]
+	version, err := strconv.Atoi(prefix)
+	if err != nil {
+		return 0, fmt.Errorf("migration %q must be prefixed with its version number: %w", file, err)
+	}
+	return version, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr *msqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code()
+	return code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
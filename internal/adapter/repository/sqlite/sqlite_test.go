@@ -0,0 +1,41 @@
+package sqlite
+
+import (
+	"io/fs"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpen_MigrationsAreIdempotent(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "videostore.db")
+	files, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		db, err := Open(dsn)
+		if err != nil {
+			t.Fatalf("open %d failed: %v", i, err)
+		}
+
+		var applied int
+		if err := db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&applied); err != nil {
+			t.Fatal(err)
+		}
+		if applied != len(files) {
+			t.Errorf("was expecting %d recorded migrations but got %d", len(files), applied)
+		}
+		db.Close()
+	}
+}
+
+func TestMigrationVersion(t *testing.T) {
+	if version, err := migrationVersion("migrations/0042_add_things.sql"); err != nil || version != 42 {
+		t.Errorf("was expecting version 42 but got %d, %v", version, err)
+	}
+
+	if _, err := migrationVersion("migrations/add_things.sql"); err == nil {
+		t.Errorf("was expecting unversioned migration to be rejected")
+	}
+}
@@ -0,0 +1,253 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"time"
+)
+
+// issuedAtLayout is fixed width so invoices and transfers are ordered by time when ordered by the text column
+const issuedAtLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+const transferColumns = "id, film, from_store, to_store, copies, status, requested_at"
+
+type (
+	// Stores keys every row by store, stock rows are kept at zero copies and left out of the inventory
+	Stores struct {
+		stock         *sql.Stmt
+		addStock      *sql.Stmt
+		removeStock   *sql.Stmt
+		inventory     *sql.Stmt
+		priceList     *sql.Stmt
+		savePriceList *sql.Stmt
+		saveInvoice   *sql.Stmt
+		invoices      *sql.Stmt
+		transfer      *sql.Stmt
+		saveTransfer  *sql.Stmt
+		transfers     *sql.Stmt
+	}
+)
+
+func NewStores(db *sql.DB) (*Stores, error) {
+	stores := &Stores{}
+	statements := []struct {
+		stmt  **sql.Stmt
+		query string
+	}{
+		{&stores.stock, "SELECT copies FROM stock WHERE store = ? AND film = ?"},
+		{&stores.addStock, "INSERT INTO stock (store, film, copies) VALUES (?, ?, ?) ON CONFLICT (store, film) DO UPDATE SET copies = copies + excluded.copies RETURNING copies"},
+		// no row is returned rather than letting the stock drop below zero
+		{&stores.removeStock, "UPDATE stock SET copies = copies - ? WHERE store = ? AND film = ? AND copies >= ? RETURNING copies"},
+		{&stores.inventory, "SELECT film, copies FROM stock WHERE store = ? AND copies > 0 ORDER BY film"},
+		{&stores.priceList, "SELECT premium, basic FROM price_lists WHERE store = ?"},
+		{&stores.savePriceList, "INSERT INTO price_lists (store, premium, basic) VALUES (?, ?, ?) ON CONFLICT (store) DO UPDATE SET premium = excluded.premium, basic = excluded.basic"},
+		{&stores.saveInvoice, "INSERT INTO invoices (id, store, rentals, cost, issued_at) VALUES (?, ?, ?, ?, ?)"},
+		{&stores.invoices, "SELECT id, store, rentals, cost, issued_at FROM invoices WHERE store = ? ORDER BY issued_at, rowid"},
+		{&stores.transfer, "SELECT " + transferColumns + " FROM transfers WHERE id = ?"},
+		{&stores.saveTransfer, "INSERT INTO transfers (" + transferColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?) ON CONFLICT (id) DO UPDATE SET status = excluded.status"},
+		{&stores.transfers, "SELECT " + transferColumns + " FROM transfers WHERE from_store = ? OR to_store = ? ORDER BY requested_at, id"},
+	}
+
+	for _, s := range statements {
+		stmt, err := db.Prepare(s.query)
+		if err != nil {
+			stores.Close()
+			return nil, fmt.Errorf("unable to prepare stores statement: %w", err)
+		}
+		*s.stmt = stmt
+	}
+	return stores, nil
+}
+
+func (s *Stores) Stock(store domain.StoreID, film string) (int, error) {
+	var copies int
+	err := s.stock.QueryRow(string(store), film).Scan(&copies)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, nil
+	case err != nil:
+		return 0, fmt.Errorf("unable to read stock of %q at %q: %w", film, store, err)
+	}
+	return copies, nil
+}
+
+func (s *Stores) AdjustStock(store domain.StoreID, film string, delta int) (int, error) {
+	var copies int
+	var err error
+	if delta >= 0 {
+		err = s.addStock.QueryRow(string(store), film, delta).Scan(&copies)
+	} else {
+		err = s.removeStock.QueryRow(-delta, string(store), film, -delta).Scan(&copies)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		available, err := s.Stock(store, film)
+		if err != nil {
+			return 0, err
+		}
+		return 0, &driven.InsufficientStockError{Store: store, Film: film, Available: available, Requested: -delta}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("unable to adjust stock of %q at %q: %w", film, store, err)
+	}
+	return copies, nil
+}
+
+func (s *Stores) Inventory(store domain.StoreID) ([]domain.Stock, error) {
+	rows, err := s.inventory.Query(string(store))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read inventory of %q: %w", store, err)
+	}
+	defer rows.Close()
+
+	var inventory []domain.Stock
+	for rows.Next() {
+		var stock domain.Stock
+		if err := rows.Scan(&stock.Film, &stock.Copies); err != nil {
+			return nil, fmt.Errorf("unable to read inventory of %q: %w", store, err)
+		}
+		inventory = append(inventory, stock)
+	}
+	return inventory, rows.Err()
+}
+
+func (s *Stores) PriceList(store domain.StoreID) (domain.PriceList, error) {
+	var prices domain.PriceList
+	err := s.priceList.QueryRow(string(store)).Scan(&prices.Premium, &prices.Basic)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return domain.DefaultPriceList, nil
+	case err != nil:
+		return domain.PriceList{}, fmt.Errorf("unable to read price list of %q: %w", store, err)
+	}
+	return prices, nil
+}
+
+func (s *Stores) SavePriceList(store domain.StoreID, prices domain.PriceList) error {
+	if _, err := s.savePriceList.Exec(string(store), prices.Premium, prices.Basic); err != nil {
+		return fmt.Errorf("unable to save price list of %q: %w", store, err)
+	}
+	return nil
+}
+
+func (s *Stores) SaveInvoice(invoice domain.IssuedInvoice) error {
+	rentals, err := json.Marshal(invoice.Rentals)
+	if err != nil {
+		return fmt.Errorf("unable to encode invoice %q: %w", invoice.ID, err)
+	}
+
+	issuedAt := invoice.IssuedAt.UTC().Format(issuedAtLayout)
+	if _, err := s.saveInvoice.Exec(invoice.ID, string(invoice.Store), string(rentals), invoice.Cost, issuedAt); err != nil {
+		return fmt.Errorf("unable to save invoice %q: %w", invoice.ID, err)
+	}
+	return nil
+}
+
+func (s *Stores) Invoices(store domain.StoreID) ([]domain.IssuedInvoice, error) {
+	rows, err := s.invoices.Query(string(store))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read invoices of %q: %w", store, err)
+	}
+	defer rows.Close()
+
+	var invoices []domain.IssuedInvoice
+	for rows.Next() {
+		var invoice domain.IssuedInvoice
+		var rentals, issuedAt string
+		if err := rows.Scan(&invoice.ID, &invoice.Store, &rentals, &invoice.Cost, &issuedAt); err != nil {
+			return nil, fmt.Errorf("unable to read invoices of %q: %w", store, err)
+		}
+
+		if err := json.Unmarshal([]byte(rentals), &invoice.Rentals); err != nil {
+			return nil, fmt.Errorf("invoice %q: %w", invoice.ID, err)
+		}
+		if invoice.IssuedAt, err = time.Parse(issuedAtLayout, issuedAt); err != nil {
+			return nil, fmt.Errorf("invoice %q: %w", invoice.ID, err)
+		}
+		invoices = append(invoices, invoice)
+	}
+	return invoices, rows.Err()
+}
+
+func (s *Stores) Transfer(id string) (*domain.Transfer, error) {
+	transfer, err := scanTransfer(s.transfer.QueryRow(id))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, &driven.TransferNotFoundError{ID: id}
+	case err != nil:
+		return nil, fmt.Errorf("unable to find transfer %q: %w", id, err)
+	}
+	return transfer, nil
+}
+
+// SaveTransfer only ever updates the status of a transfer which has already been saved, the rest of it never changes
+func (s *Stores) SaveTransfer(transfer domain.Transfer) error {
+	requestedAt := transfer.RequestedAt.UTC().Format(issuedAtLayout)
+	_, err := s.saveTransfer.Exec(transfer.ID, transfer.Film, string(transfer.From), string(transfer.To), transfer.Copies, string(transfer.Status), requestedAt)
+	if err != nil {
+		return fmt.Errorf("unable to save transfer %q: %w", transfer.ID, err)
+	}
+	return nil
+}
+
+func (s *Stores) Transfers(store domain.StoreID) ([]domain.Transfer, error) {
+	rows, err := s.transfers.Query(string(store), string(store))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read transfers of %q: %w", store, err)
+	}
+	defer rows.Close()
+
+	var transfers []domain.Transfer
+	for rows.Next() {
+		transfer, err := scanTransfer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read transfers of %q: %w", store, err)
+		}
+		transfers = append(transfers, *transfer)
+	}
+	return transfers, rows.Err()
+}
+
+func scanTransfer(row scanner) (*domain.Transfer, error) {
+	var transfer domain.Transfer
+	var requestedAt string
+	if err := row.Scan(&transfer.ID, &transfer.Film, &transfer.From, &transfer.To, &transfer.Copies, &transfer.Status, &requestedAt); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if transfer.RequestedAt, err = time.Parse(issuedAtLayout, requestedAt); err != nil {
+		return nil, fmt.Errorf("transfer %q: %w", transfer.ID, err)
+	}
+	return &transfer, nil
+}
+
+func (s *Stores) withTx(tx *sql.Tx) *Stores {
+	return &Stores{
+		stock:         tx.Stmt(s.stock),
+		addStock:      tx.Stmt(s.addStock),
+		removeStock:   tx.Stmt(s.removeStock),
+		inventory:     tx.Stmt(s.inventory),
+		priceList:     tx.Stmt(s.priceList),
+		savePriceList: tx.Stmt(s.savePriceList),
+		saveInvoice:   tx.Stmt(s.saveInvoice),
+		invoices:      tx.Stmt(s.invoices),
+		transfer:      tx.Stmt(s.transfer),
+		saveTransfer:  tx.Stmt(s.saveTransfer),
+		transfers:     tx.Stmt(s.transfers),
+	}
+}
+
+// Close releases the prepared statements, the underlying database is owned by the caller
+func (s *Stores) Close() error {
+	var errs []error
+	for _, stmt := range []*sql.Stmt{s.stock, s.addStock, s.removeStock, s.inventory, s.priceList, s.savePriceList, s.saveInvoice, s.invoices, s.transfer, s.saveTransfer, s.transfers} {
+		if stmt != nil {
+			errs = append(errs, stmt.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,67 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+)
+
+type (
+	// UnitOfWork runs every repository operation made through the driver.Tx within a single sql transaction.
+	// The database holds a single connection so repositories outside the transaction must not be used within fx
+	UnitOfWork struct {
+		db        *sql.DB
+		catalogue *Catalogue
+		outbox    *Outbox
+		stores    *Stores
+	}
+
+	tx struct {
+		catalogue *Catalogue
+		outbox    *Outbox
+		stores    *Stores
+	}
+)
+
+func NewUnitOfWork(db *sql.DB, catalogue *Catalogue, outbox *Outbox, stores *Stores) *UnitOfWork {
+	return &UnitOfWork{
+		db:        db,
+		catalogue: catalogue,
+		outbox:    outbox,
+		stores:    stores,
+	}
+}
+
+func (uow *UnitOfWork) Atomically(fx func(tx driver.Tx) error) error {
+	sqlTx, err := uow.db.Begin()
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction: %w", err)
+	}
+	defer sqlTx.Rollback()
+
+	t := &tx{
+		catalogue: uow.catalogue.withTx(sqlTx),
+		outbox:    uow.outbox.withTx(sqlTx),
+		stores:    uow.stores.withTx(sqlTx),
+	}
+	if err := fx(t); err != nil {
+		return err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return fmt.Errorf("unable to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (t *tx) Catalogue() driver.Catalogue {
+	return t.catalogue
+}
+
+func (t *tx) Outbox() driver.Outbox {
+	return t.outbox
+}
+
+func (t *tx) Stores() driver.Stores {
+	return t.stores
+}
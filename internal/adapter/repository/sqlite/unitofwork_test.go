@@ -0,0 +1,54 @@
+package sqlite
+
+import (
+	"database/sql"
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/catalogtest"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnitOfWork(t *testing.T) {
+	catalogtest.RunUnitOfWork(t, func(t *testing.T) (driver.UnitOfWork, driver.Catalogue, driver.OutboxStore, driver.Stores) {
+		db, err := Open(filepath.Join(t.TempDir(), "videostore.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		cat, err := NewCatalogue(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { cat.Close() })
+
+		outbox, err := NewOutbox(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { outbox.Close() })
+
+		stores := newStores(t, db)
+		return NewUnitOfWork(db, cat, outbox, stores), cat, outbox, stores
+	})
+}
+
+func TestStores(t *testing.T) {
+	catalogtest.RunStores(t, func(t *testing.T) driver.Stores {
+		db, err := Open(filepath.Join(t.TempDir(), "videostore.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return newStores(t, db)
+	})
+}
+
+func newStores(t *testing.T, db *sql.DB) *Stores {
+	stores, err := NewStores(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { stores.Close() })
+	return stores
+}
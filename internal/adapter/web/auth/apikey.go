@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type (
+	// APIKey is a kiosk key as it is configured, only the SHA-256 of the key is ever stored. A kiosk stands in
+	// a single store so its key is usually confined to it
+	APIKey struct {
+		Hash    string `json:"hash"`
+		Subject string `json:"subject"`
+		Role    string `json:"role"`
+		Store   string `json:"store,omitempty"`
+	}
+
+	// APIKeys authenticates the key presented in the X-API-Key header
+	APIKeys struct {
+		principals map[[sha256.Size]byte]Principal
+	}
+)
+
+// APIKeyHeader carries the API key of a kiosk
+const APIKeyHeader = "X-API-Key"
+
+// StoreHeader names the store a call acts for when its path does not
+const StoreHeader = "X-Store-ID"
+
+// HashAPIKey returns the hash an API key is configured by
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func NewAPIKeys(keys []APIKey) (*APIKeys, error) {
+	principals := make(map[[sha256.Size]byte]Principal, len(keys))
+	for _, key := range keys {
+		decoded, err := hex.DecodeString(strings.TrimPrefix(key.Hash, "sha256:"))
+		if err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("api key of %q must be a hex encoded SHA-256 hash", key.Subject)
+		}
+		role, err := ParseRole(key.Role)
+		if err != nil {
+			return nil, fmt.Errorf("api key of %q: %w", key.Subject, err)
+		}
+		store, err := parseStore(key.Store)
+		if err != nil {
+			return nil, fmt.Errorf("api key of %q: %w", key.Subject, err)
+		}
+
+		var hash [sha256.Size]byte
+		copy(hash[:], decoded)
+		principals[hash] = Principal{Subject: key.Subject, Role: role, Store: store}
+	}
+	return &APIKeys{principals: principals}, nil
+}
+
+// ParseAPIKeys reads the JSON list of keys [{"hash","subject","role","store"}]
+func ParseAPIKeys(data []byte) (*APIKeys, error) {
+	var keys []APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("unable to parse api keys: %w", err)
+	}
+	return NewAPIKeys(keys)
+}
+
+func (k *APIKeys) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return nil, MissingCredentialsError
+	}
+
+	principal, ok := k.principals[sha256.Sum256([]byte(key))]
+	if !ok {
+		return nil, &InvalidCredentialsError{Reason: "unknown api key"}
+	}
+	return &principal, nil
+}
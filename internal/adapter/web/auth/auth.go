@@ -0,0 +1,168 @@
+// Package auth authenticates the callers of the web adapters. Kiosks present hashed API keys while staff present
+// JWT bearer tokens, both resolve to a Principal holding one of the store roles
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"net/http"
+	"strings"
+)
+
+type (
+	// Role grants every permission of the roles below it
+	Role int
+
+	// Principal is confined to Store when it is set, otherwise it may act for every store
+	Principal struct {
+		Subject string
+		Role    Role
+		Store   domain.StoreID
+	}
+
+	Authenticator interface {
+		// Authenticate returns MissingCredentialsError when the request carries none of the credentials the
+		// authenticator understands
+		Authenticate(r *http.Request) (*Principal, error)
+	}
+
+	// Chain authenticates the request through the first authenticator whose credentials it carries
+	Chain []Authenticator
+
+	InvalidCredentialsError struct {
+		Reason string
+	}
+
+	// ForbiddenError is raised for a principal lacking the Required role, or acting for a Store it is not
+	// confined to
+	ForbiddenError struct {
+		Principal Principal
+		Required  Role
+		Store     domain.StoreID
+	}
+
+	principalKey struct{}
+)
+
+const (
+	// Clerk processes returns
+	Clerk Role = iota + 1
+	// Manager adds and reclassifies films
+	Manager
+	// Admin changes prices
+	Admin
+)
+
+var (
+	MissingCredentialsError = fmt.Errorf("no credentials were presented")
+
+	TypeInvalidCredentials *InvalidCredentialsError
+	TypeForbidden          *ForbiddenError
+
+	roles = map[Role]string{Clerk: "clerk", Manager: "manager", Admin: "admin"}
+)
+
+func ParseRole(role string) (Role, error) {
+	for r, name := range roles {
+		if strings.EqualFold(role, name) {
+			return r, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown role %q must be one of [clerk,manager,admin]", role)
+}
+
+func (r Role) String() string {
+	if name, ok := roles[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("Role(%d)", int(r))
+}
+
+// Allows reports whether the role grants the permissions of required
+func (r Role) Allows(required Role) bool {
+	return r >= required
+}
+
+func (e *InvalidCredentialsError) Error() string {
+	return "invalid credentials: " + e.Reason
+}
+
+func (e *ForbiddenError) Error() string {
+	if e.Store != "" {
+		return fmt.Sprintf("%q is confined to store %q and is not allowed to act for %q", e.Principal.Subject, e.Principal.Store, e.Store)
+	}
+	return fmt.Sprintf("%s %q is not allowed to act as %s", e.Principal.Role, e.Principal.Subject, e.Required)
+}
+
+func (c Chain) Authenticate(r *http.Request) (*Principal, error) {
+	for _, authenticator := range c {
+		principal, err := authenticator.Authenticate(r)
+		if errors.Is(err, MissingCredentialsError) {
+			continue
+		}
+		return principal, err
+	}
+	return nil, MissingCredentialsError
+}
+
+// Authorize authenticates the request and checks the principal holds the required role
+func Authorize(authenticator Authenticator, r *http.Request, required Role) (*Principal, error) {
+	principal, err := authenticator.Authenticate(r)
+	if err != nil {
+		return nil, err
+	}
+	if !principal.Role.Allows(required) {
+		return nil, &ForbiddenError{Principal: *principal, Required: required}
+	}
+	return principal, nil
+}
+
+// ActsFor reports whether the principal may act for store
+func (p Principal) ActsFor(store domain.StoreID) bool {
+	return p.Store == "" || p.Store == store
+}
+
+// ScopeStore resolves the store a call acts for from the store it names, falling back onto the store the principal
+// of ctx is confined to and then onto domain.DefaultStore. A ForbiddenError is returned for a store the principal
+// may not act for
+func ScopeStore(ctx context.Context, named string) (domain.StoreID, error) {
+	principal, authenticated := PrincipalFrom(ctx)
+	store := domain.StoreID(named)
+	switch {
+	case store != "":
+		if err := store.IsValid(); err != nil {
+			return "", err
+		}
+	case authenticated && principal.Store != "":
+		store = principal.Store
+	default:
+		store = domain.DefaultStore
+	}
+
+	if authenticated && !principal.ActsFor(store) {
+		return "", &ForbiddenError{Principal: principal, Store: store}
+	}
+	return store, nil
+}
+
+// parseStore validates the store a principal is confined to, empty confines it to none
+func parseStore(store string) (domain.StoreID, error) {
+	if store == "" {
+		return "", nil
+	}
+	if err := domain.StoreID(store).IsValid(); err != nil {
+		return "", err
+	}
+	return domain.StoreID(store), nil
+}
+
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
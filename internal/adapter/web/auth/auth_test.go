@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var (
+	secret = []byte("a secret of at least thirty two bytes")
+	now    = time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func sign(t *testing.T, algorithm string, claims map[string]interface{}, key interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": algorithm, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	var signature []byte
+	switch key := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(input))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(input))
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func bearer(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+func claims(role string, expires time.Time) map[string]interface{} {
+	return map[string]interface{}{"sub": "alice", "role": role, "exp": expires.Unix(), "iss": "videostore", "aud": []string{"store"}}
+}
+
+func TestJWT(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	verifier := NewJWT(
+		WithHS256(secret),
+		WithRS256(&rsaKey.PublicKey),
+		WithIssuer("videostore"),
+		WithAudience("store"),
+		WithClock(func() time.Time { return now }, time.Minute),
+	)
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"HS256", sign(t, "HS256", claims("manager", now.Add(time.Hour)), secret), true},
+		{"RS256", sign(t, "RS256", claims("manager", now.Add(time.Hour)), rsaKey), true},
+		{"WithinLeeway", sign(t, "HS256", claims("manager", now.Add(-30*time.Second)), secret), true},
+		{"Expired", sign(t, "HS256", claims("manager", now.Add(-time.Hour)), secret), false},
+		{"WrongSecret", sign(t, "HS256", claims("manager", now.Add(time.Hour)), []byte("another secret")), false},
+		{"WrongKey", sign(t, "RS256", claims("manager", now.Add(time.Hour)), otherKey), false},
+		{"None", sign(t, "none", claims("manager", now.Add(time.Hour)), nil), false},
+		{"UnknownRole", sign(t, "HS256", claims("owner", now.Add(time.Hour)), secret), false},
+		{"NoExpiry", sign(t, "HS256", map[string]interface{}{"sub": "alice", "role": "clerk", "iss": "videostore", "aud": "store"}, secret), false},
+		{"WrongIssuer", sign(t, "HS256", map[string]interface{}{"sub": "alice", "role": "clerk", "exp": now.Add(time.Hour).Unix(), "iss": "elsewhere", "aud": "store"}, secret), false},
+		{"Malformed", "not.a-token", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			principal, err := verifier.Authenticate(bearer(test.token))
+			switch {
+			case test.valid && (err != nil || *principal != Principal{Subject: "alice", Role: Manager}):
+				t.Errorf("was expecting alice the manager but got %#v %v", principal, err)
+			case !test.valid && !errors.As(err, &TypeInvalidCredentials):
+				t.Errorf("was expecting the token to be rejected but got %#v %v", principal, err)
+			}
+		})
+	}
+}
+
+func TestJWT_AlgorithmWithoutKey(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	der, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	publicKey, err := ParseRSAPublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an HS256 token signed with the public rsa key must not be verified with it
+	token := sign(t, "HS256", claims("admin", time.Now().Add(time.Hour)), der)
+	if _, err := NewJWT(WithRS256(publicKey)).Authenticate(bearer(token)); !errors.As(err, &TypeInvalidCredentials) {
+		t.Errorf("was expecting the algorithm to be refused but got %v", err)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	keys, err := ParseAPIKeys([]byte(`[{"hash":"` + HashAPIKey("kiosk-secret") + `","subject":"kiosk-1","role":"clerk"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(APIKeyHeader, "kiosk-secret")
+	if principal, err := keys.Authenticate(r); err != nil || *principal != (Principal{Subject: "kiosk-1", Role: Clerk}) {
+		t.Errorf("was expecting the kiosk but got %#v %v", principal, err)
+	}
+
+	r.Header.Set(APIKeyHeader, "guessed")
+	if _, err := keys.Authenticate(r); !errors.As(err, &TypeInvalidCredentials) {
+		t.Errorf("was expecting an unknown key to be rejected but got %v", err)
+	}
+
+	if _, err := ParseAPIKeys([]byte(`[{"hash":"kiosk-secret","subject":"kiosk-1","role":"clerk"}]`)); err == nil {
+		t.Errorf("was expecting a plain text key to be refused")
+	}
+}
+
+func TestStoreConfinement(t *testing.T) {
+	keys, err := ParseAPIKeys([]byte(`[{"hash":"` + HashAPIKey("kiosk-secret") + `","subject":"kiosk-1","role":"clerk","store":"north"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(APIKeyHeader, "kiosk-secret")
+	kiosk, err := keys.Authenticate(r)
+	if err != nil || kiosk.Store != "north" || !kiosk.ActsFor("north") || kiosk.ActsFor("south") {
+		t.Errorf("was expecting the kiosk to be confined to north but got %#v %v", kiosk, err)
+	}
+
+	verifier := NewJWT(WithHS256(secret))
+	token := sign(t, "HS256", map[string]interface{}{"sub": "bob", "role": "manager", "store": "south", "exp": time.Now().Add(time.Hour).Unix()}, secret)
+	if manager, err := verifier.Authenticate(bearer(token)); err != nil || manager.Store != "south" {
+		t.Errorf("was expecting the manager to be confined to south but got %#v %v", manager, err)
+	}
+	if admin := (Principal{Subject: "carol", Role: Admin}); !admin.ActsFor("north") || !admin.ActsFor("south") {
+		t.Errorf("was expecting an unconfined principal to act for every store")
+	}
+
+	token = sign(t, "HS256", map[string]interface{}{"sub": "bob", "role": "manager", "store": "North Side", "exp": time.Now().Add(time.Hour).Unix()}, secret)
+	if _, err := verifier.Authenticate(bearer(token)); !errors.As(err, &TypeInvalidCredentials) {
+		t.Errorf("was expecting an invalid store claim to be rejected but got %v", err)
+	}
+	if _, err := ParseAPIKeys([]byte(`[{"hash":"` + HashAPIKey("kiosk-secret") + `","subject":"kiosk-1","role":"clerk","store":"../"}]`)); err == nil {
+		t.Errorf("was expecting an invalid store to be refused")
+	}
+}
+
+func TestScopeStore(t *testing.T) {
+	kiosk := WithPrincipal(context.Background(), Principal{Subject: "kiosk-1", Role: Clerk, Store: "north"})
+	admin := WithPrincipal(context.Background(), Principal{Subject: "carol", Role: Admin})
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		named     string
+		store     domain.StoreID
+		forbidden bool
+	}{
+		{"Anonymous", context.Background(), "", domain.DefaultStore, false},
+		{"AnonymousNamed", context.Background(), "south", "south", false},
+		{"ConfinedDefault", kiosk, "", "north", false},
+		{"ConfinedOwn", kiosk, "north", "north", false},
+		{"ConfinedOther", kiosk, "main", "", true},
+		{"Unconfined", admin, "south", "south", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store, err := ScopeStore(test.ctx, test.named)
+			if store != test.store || errors.As(err, &TypeForbidden) != test.forbidden {
+				t.Errorf("was expecting %q forbidden %v but got %q %v", test.store, test.forbidden, store, err)
+			}
+		})
+	}
+	if _, err := ScopeStore(context.Background(), "North Side"); err == nil || errors.As(err, &TypeForbidden) {
+		t.Errorf("was expecting an invalid store to be refused but got %v", err)
+	}
+}
+
+func TestChainAndAuthorize(t *testing.T) {
+	keys, _ := NewAPIKeys([]APIKey{{Hash: HashAPIKey("kiosk-secret"), Subject: "kiosk-1", Role: "clerk"}})
+	chain := Chain{keys, NewJWT(WithHS256(secret))}
+
+	token := sign(t, "HS256", claims("admin", time.Now().Add(time.Hour)), secret)
+	if principal, err := Authorize(chain, bearer(token), Manager); err != nil || principal.Role != Admin {
+		t.Errorf("was expecting the admin to act as a manager but got %#v %v", principal, err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(APIKeyHeader, "kiosk-secret")
+	if _, err := Authorize(chain, r, Manager); !errors.As(err, &TypeForbidden) {
+		t.Errorf("was expecting the clerk to be forbidden but got %v", err)
+	}
+
+	if _, err := Authorize(chain, httptest.NewRequest(http.MethodGet, "/", nil), Clerk); !errors.Is(err, MissingCredentialsError) {
+		t.Errorf("was expecting missing credentials but got %v", err)
+	}
+}
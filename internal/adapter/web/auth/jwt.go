@@ -0,0 +1,239 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type (
+	// JWT authenticates the bearer token of the Authorization header. Tokens are accepted when they are signed
+	// with HS256 or RS256 against the locally configured keys, the algorithm a token names is only trusted once
+	// a key has been configured for it
+	JWT struct {
+		hmacKey  []byte
+		rsaKey   *rsa.PublicKey
+		issuer   string
+		audience string
+		leeway   time.Duration
+		now      func() time.Time
+	}
+
+	JWTOption func(j *JWT)
+
+	jwtHeader struct {
+		Algorithm string `json:"alg"`
+	}
+
+	jwtClaims struct {
+		Subject   string   `json:"sub"`
+		Role      string   `json:"role"`
+		Store     string   `json:"store"`
+		Issuer    string   `json:"iss"`
+		Audience  audience `json:"aud"`
+		Expires   *int64   `json:"exp"`
+		NotBefore *int64   `json:"nbf"`
+	}
+
+	// audience is either a single string or a list of them
+	audience []string
+)
+
+func NewJWT(options ...JWTOption) *JWT {
+	j := &JWT{
+		leeway: 30 * time.Second,
+		now:    time.Now,
+	}
+	for _, option := range options {
+		option(j)
+	}
+	return j
+}
+
+// WithHS256 verifies the tokens signed with HMAC SHA-256 against secret
+func WithHS256(secret []byte) JWTOption {
+	return func(j *JWT) {
+		j.hmacKey = secret
+	}
+}
+
+// WithRS256 verifies the tokens signed with RSA PKCS #1 v1.5 SHA-256 against key
+func WithRS256(key *rsa.PublicKey) JWTOption {
+	return func(j *JWT) {
+		j.rsaKey = key
+	}
+}
+
+// WithIssuer only accepts the tokens issued by issuer
+func WithIssuer(issuer string) JWTOption {
+	return func(j *JWT) {
+		j.issuer = issuer
+	}
+}
+
+// WithAudience only accepts the tokens addressed to audience
+func WithAudience(audience string) JWTOption {
+	return func(j *JWT) {
+		j.audience = audience
+	}
+}
+
+// WithClock replaces the clock the expiry of the tokens is checked against, leeway absorbs the clock skew
+func WithClock(now func() time.Time, leeway time.Duration) JWTOption {
+	return func(j *JWT) {
+		j.now = now
+		j.leeway = leeway
+	}
+}
+
+// ParseRSAPublicKey reads a PEM encoded PKIX or PKCS #1 RSA public key
+func ParseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("rsa public key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse rsa public key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is a %T rather than an rsa key", key)
+	}
+	return rsaKey, nil
+}
+
+func (j *JWT) Authenticate(r *http.Request) (*Principal, error) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, MissingCredentialsError
+	}
+
+	claims, err := j.verify(strings.TrimSpace(token))
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := ParseRole(claims.Role)
+	if err != nil {
+		return nil, &InvalidCredentialsError{Reason: err.Error()}
+	}
+	store, err := parseStore(claims.Store)
+	if err != nil {
+		return nil, &InvalidCredentialsError{Reason: err.Error()}
+	}
+	return &Principal{Subject: claims.Subject, Role: role, Store: store}, nil
+}
+
+func (j *JWT) verify(token string) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, &InvalidCredentialsError{Reason: "token is not a JWS compact serialization"}
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, &InvalidCredentialsError{Reason: "signature is not base64url encoded"}
+	}
+	if err := j.verifySignature(header.Algorithm, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if err := j.validate(claims); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+func (j *JWT) verifySignature(algorithm string, signingInput string, signature []byte) error {
+	switch {
+	case algorithm == "HS256" && j.hmacKey != nil:
+		mac := hmac.New(sha256.New, j.hmacKey)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return &InvalidCredentialsError{Reason: "signature does not match"}
+		}
+		return nil
+	case algorithm == "RS256" && j.rsaKey != nil:
+		digest := sha256.Sum256([]byte(signingInput))
+		if err := rsa.VerifyPKCS1v15(j.rsaKey, crypto.SHA256, digest[:], signature); err != nil {
+			return &InvalidCredentialsError{Reason: "signature does not match"}
+		}
+		return nil
+	}
+	return &InvalidCredentialsError{Reason: fmt.Sprintf("algorithm %q is not accepted", algorithm)}
+}
+
+func (j *JWT) validate(claims jwtClaims) error {
+	now := j.now()
+	switch {
+	case claims.Subject == "":
+		return &InvalidCredentialsError{Reason: "token has no subject"}
+	case claims.Expires == nil:
+		return &InvalidCredentialsError{Reason: "token has no expiry"}
+	case now.After(time.Unix(*claims.Expires, 0).Add(j.leeway)):
+		return &InvalidCredentialsError{Reason: "token has expired"}
+	case claims.NotBefore != nil && now.Add(j.leeway).Before(time.Unix(*claims.NotBefore, 0)):
+		return &InvalidCredentialsError{Reason: "token is not valid yet"}
+	case j.issuer != "" && claims.Issuer != j.issuer:
+		return &InvalidCredentialsError{Reason: fmt.Sprintf("token was not issued by %q", j.issuer)}
+	case j.audience != "" && !claims.Audience.contains(j.audience):
+		return &InvalidCredentialsError{Reason: fmt.Sprintf("token is not addressed to %q", j.audience)}
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return &InvalidCredentialsError{Reason: "token segment is not base64url encoded"}
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return &InvalidCredentialsError{Reason: "token segment is not a JSON object"}
+	}
+	return nil
+}
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+func (a audience) contains(value string) bool {
+	for _, v := range a {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
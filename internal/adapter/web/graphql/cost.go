@@ -0,0 +1,101 @@
+package graphql
+
+import (
+	"fmt"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+type (
+	// costLimit rejects queries before they execute when the fields they would resolve exceed max. Every field
+	// costs one and the selection of a list field is counted once per element it is expected to return
+	costLimit struct {
+		max      int
+		listSize map[string]int
+	}
+
+	QueryCostError struct {
+		Cost int
+		Max  int
+	}
+)
+
+var TypeQueryCost *QueryCostError
+
+func (e *QueryCostError) Error() string {
+	return fmt.Sprintf("query: cost %d exceeds the maximum of %d", e.Cost, e.Max)
+}
+
+func newCostLimit(max int, catalogueSize int) *costLimit {
+	return &costLimit{
+		max: max,
+		listSize: map[string]int{
+			"catalogue": catalogueSize,
+			"rentals":   10,
+		},
+	}
+}
+
+// check returns a QueryCostError when the operation costs too much, queries which cannot be parsed are left for
+// the schema to report
+func (c *costLimit) check(query string, operationName string, variables map[string]interface{}) error {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return nil
+	}
+
+	for _, operation := range doc.Operations {
+		if operationName != "" && operation.Name != operationName {
+			continue
+		}
+		if cost := c.cost(doc, operation.SelectionSet, variables, 0); cost > c.max {
+			return &QueryCostError{Cost: cost, Max: c.max}
+		}
+	}
+	return nil
+}
+
+func (c *costLimit) cost(doc *ast.QueryDocument, selections ast.SelectionSet, variables map[string]interface{}, depth int) int {
+	// fragments spreading into themselves are rejected by validation, the depth only guards the walk
+	if depth > 32 {
+		return c.max + 1
+	}
+
+	total := 0
+	for _, selection := range selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			total += 1 + c.elements(s, variables)*c.cost(doc, s.SelectionSet, variables, depth+1)
+		case *ast.InlineFragment:
+			total += c.cost(doc, s.SelectionSet, variables, depth+1)
+		case *ast.FragmentSpread:
+			if fragment := doc.Fragments.ForName(s.Name); fragment != nil {
+				total += c.cost(doc, fragment.SelectionSet, variables, depth+1)
+			}
+		}
+	}
+	return total
+}
+
+// elements is how many times the selection of field is resolved, films returns one film per requested name
+func (c *costLimit) elements(field *ast.Field, variables map[string]interface{}) int {
+	if field.Name == "films" {
+		if names := field.Arguments.ForName("names"); names != nil {
+			return listLength(names.Value, variables)
+		}
+	}
+	if size, ok := c.listSize[field.Name]; ok {
+		return size
+	}
+	return 1
+}
+
+func listLength(value *ast.Value, variables map[string]interface{}) int {
+	if value.Kind == ast.Variable {
+		if list, ok := variables[value.Raw].([]interface{}); ok {
+			return len(list)
+		}
+		return 1
+	}
+	return len(value.Children)
+}
@@ -0,0 +1,35 @@
+package graphql
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCostLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		cost      int
+	}{
+		{"Fields", `{ film(name: "Loki") { name director } }`, nil, 3},
+		{"Catalogue", `{ catalogue { name } }`, nil, 1 + 100},
+		{"NestedLists", `{ catalogue { rentals { id } } }`, nil, 1 + 100*(1+10)},
+		{"FilmsByLiteral", `{ films(names: ["a", "b", "c"]) { name release } }`, nil, 1 + 3*2},
+		{"FilmsByVariable", `query($names: [String!]!) { films(names: $names) { name } }`, map[string]interface{}{"names": []interface{}{"a", "b"}}, 1 + 2},
+		{"Fragments", `{ film(name: "Loki") { ...details ... on Film { release } } } fragment details on Film { name director }`, nil, 4},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := newCostLimit(test.cost-1, 100).check(test.query, "", test.variables)
+			var costErr *QueryCostError
+			if !errors.As(err, &costErr) || costErr.Cost != test.cost {
+				t.Errorf("was expecting a cost of %d but got %v", test.cost, err)
+			}
+
+			if err := newCostLimit(test.cost, 100).check(test.query, "", test.variables); err != nil {
+				t.Errorf("was expecting a cost of %d to be within the limit but got %v", test.cost, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package graphql
+
+import (
+	"context"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"sync"
+	"time"
+)
+
+type (
+	// filmLoader collects the names looked up by resolvers running alongside each other and fetches them with a
+	// single FindAll. It lives for a single request so films are never served stale
+	filmLoader struct {
+		finder driven.FilmBatchFinder
+		wait   time.Duration
+
+		mu      sync.Mutex
+		results map[string]*filmResult
+		batch   []string
+	}
+
+	filmResult struct {
+		done chan struct{}
+		film *domain.Film
+		err  error
+	}
+
+	loaderKey struct{}
+)
+
+func newFilmLoader(finder driven.FilmBatchFinder, wait time.Duration) *filmLoader {
+	return &filmLoader{
+		finder:  finder,
+		wait:    wait,
+		results: map[string]*filmResult{},
+	}
+}
+
+func withLoader(ctx context.Context, loader *filmLoader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, loader)
+}
+
+func loaderFrom(ctx context.Context) *filmLoader {
+	return ctx.Value(loaderKey{}).(*filmLoader)
+}
+
+// Load returns the film, or nil when it is not catalogued
+func (l *filmLoader) Load(ctx context.Context, name string) (*domain.Film, error) {
+	l.mu.Lock()
+	result, ok := l.results[name]
+	if !ok {
+		result = &filmResult{done: make(chan struct{})}
+		l.results[name] = result
+		l.batch = append(l.batch, name)
+		if len(l.batch) == 1 {
+			time.AfterFunc(l.wait, func() { l.dispatch(ctx) })
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-result.done:
+		return result.film, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// LoadAll fetches every name not already loaded in a single batch
+func (l *filmLoader) LoadAll(ctx context.Context, names []string) ([]*domain.Film, error) {
+	l.mu.Lock()
+	var missing []string
+	for _, name := range names {
+		if _, ok := l.results[name]; !ok {
+			l.results[name] = &filmResult{done: make(chan struct{})}
+			missing = append(missing, name)
+		}
+	}
+	l.mu.Unlock()
+
+	if len(missing) > 0 {
+		l.fetch(ctx, missing)
+	}
+
+	films := make([]*domain.Film, len(names))
+	for i, name := range names {
+		film, err := l.Load(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		films[i] = film
+	}
+	return films, nil
+}
+
+func (l *filmLoader) dispatch(ctx context.Context) {
+	l.mu.Lock()
+	names := l.batch
+	l.batch = nil
+	l.mu.Unlock()
+
+	l.fetch(ctx, names)
+}
+
+func (l *filmLoader) fetch(ctx context.Context, names []string) {
+	films, err := l.finder.FindAll(ctx, names)
+
+	found := map[string]*domain.Film{}
+	for i := range films {
+		found[films[i].Name] = &films[i]
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, name := range names {
+		result := l.results[name]
+		result.film, result.err = found[name], err
+		close(result.done)
+	}
+}
@@ -0,0 +1,36 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFilmLoader_BatchesConcurrentLoads(t *testing.T) {
+	store := &spyStore{}
+	loader := newFilmLoader(store, 10*time.Millisecond)
+
+	var wg sync.WaitGroup
+	for _, name := range []string{"Loki", "Spider Man", "Loki", "Unknown"} {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			film, err := loader.Load(context.Background(), name)
+			if err != nil {
+				t.Error(err)
+			} else if (film == nil) != (name == "Unknown") {
+				t.Errorf("received unexpected film %#v for %q", film, name)
+			}
+		}(name)
+	}
+	wg.Wait()
+
+	if len(store.batches) != 1 || len(store.batches[0]) != 3 {
+		t.Errorf("was expecting a single batch of the distinct names but got %v", store.batches)
+	}
+
+	if _, err := loader.Load(context.Background(), "Loki"); err != nil || len(store.batches) != 1 {
+		t.Errorf("was expecting a loaded film to be served without another lookup but got %v", store.batches)
+	}
+}
@@ -0,0 +1,308 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"strings"
+)
+
+type (
+	resolver struct {
+		server *server
+	}
+
+	filmResolver struct {
+		film   domain.Film
+		server *server
+	}
+
+	rentalResolver struct {
+		rental domain.RentalStarted
+		server *server
+	}
+
+	invoiceResolver struct {
+		invoice *domain.RentalInvoice
+		prices  domain.PriceList
+		server  *server
+	}
+
+	invoiceLineResolver struct {
+		rental domain.Rental
+		prices domain.PriceList
+		server *server
+	}
+
+	returnInput struct {
+		Film   string
+		Days   int32
+		Rental *string
+	}
+
+	// userError is reported to the client with its message, anything else is masked as an internal error
+	userError struct {
+		err  error
+		code string
+	}
+)
+
+func (e *userError) Error() string {
+	return e.err.Error()
+}
+
+func (e *userError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+func (r *resolver) Film(ctx context.Context, args struct{ Name string }) (*filmResolver, error) {
+	film, err := loaderFrom(ctx).Load(ctx, args.Name)
+	if err != nil || film == nil {
+		return nil, resolverError(err)
+	}
+	return r.server.film(*film), nil
+}
+
+func (r *resolver) Films(ctx context.Context, args struct{ Names []string }) ([]*filmResolver, error) {
+	films, err := loaderFrom(ctx).LoadAll(ctx, args.Names)
+	if err != nil {
+		return nil, resolverError(err)
+	}
+
+	resolvers := make([]*filmResolver, len(films))
+	for i, film := range films {
+		if film != nil {
+			resolvers[i] = r.server.film(*film)
+		}
+	}
+	return resolvers, nil
+}
+
+func (r *resolver) Catalogue(ctx context.Context) ([]*filmResolver, error) {
+	if r.server.lister == nil {
+		return nil, &userError{err: errors.New("listing the catalogue is not supported"), code: "UNSUPPORTED"}
+	}
+
+	films, err := r.server.lister.List(ctx)
+	if err != nil {
+		return nil, resolverError(err)
+	}
+
+	resolvers := make([]*filmResolver, 0, len(films))
+	for _, film := range films {
+		resolvers = append(resolvers, r.server.film(film))
+	}
+	return resolvers, nil
+}
+
+func (r *resolver) ReturnFilms(ctx context.Context, args struct{ Returns []returnInput }) (*invoiceResolver, error) {
+	var returns []driven.FilmReturn
+	for _, ret := range args.Returns {
+		if ret.Film == "" || ret.Days <= 0 || ret.Days > 0xFFFF {
+			return nil, &userError{err: errors.New("every return needs a film and a positive number of days"), code: "BAD_USER_INPUT"}
+		}
+		filmReturn := driven.FilmReturn{FilmName: ret.Film, Days: uint16(ret.Days)}
+		if ret.Rental != nil {
+			filmReturn.RentalID = *ret.Rental
+		}
+		returns = append(returns, filmReturn)
+	}
+
+	prices, err := r.server.priceList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	invoice, err := r.server.invoicer.Invoice(ctx, returns)
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	return &invoiceResolver{invoice: invoice, prices: prices, server: r.server}, nil
+}
+
+func (r *resolver) RentFilm(ctx context.Context, args struct {
+	Film string
+	Days int32
+}) (*rentalResolver, error) {
+	if r.server.renter == nil {
+		return nil, &userError{err: errors.New("renting films is not supported"), code: "UNSUPPORTED"}
+	}
+	if args.Film == "" || args.Days <= 0 || args.Days > 0xFFFF {
+		return nil, &userError{err: errors.New("a rental needs a film and a positive number of days"), code: "BAD_USER_INPUT"}
+	}
+
+	rental, err := r.server.renter.Rent(ctx, args.Film, uint16(args.Days))
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	return &rentalResolver{rental: *rental, server: r.server}, nil
+}
+
+// priceList returns the prices of the store the request acts for
+func (s *server) priceList(ctx context.Context) (domain.PriceList, error) {
+	if s.prices == nil {
+		return domain.DefaultPriceList, nil
+	}
+	prices, err := s.prices.PriceList(ctx)
+	if err != nil {
+		return domain.PriceList{}, resolverError(err)
+	}
+	return prices, nil
+}
+
+func (s *server) film(film domain.Film) *filmResolver {
+	return &filmResolver{film: film, server: s}
+}
+
+func (f *filmResolver) Name() string {
+	return f.film.Name
+}
+
+func (f *filmResolver) Director() string {
+	return f.film.Director
+}
+
+func (f *filmResolver) Release() string {
+	return strings.ToUpper(string(f.film.Release))
+}
+
+func (f *filmResolver) Quote(ctx context.Context, args struct{ Days int32 }) (int32, error) {
+	if args.Days <= 0 || args.Days > 0xFFFF {
+		return 0, &userError{err: errors.New("days must be positive"), code: "BAD_USER_INPUT"}
+	}
+
+	prices, err := f.server.priceList(ctx)
+	if err != nil {
+		return 0, err
+	}
+	price, err := domain.Rental{Film: f.film, Days: domain.Days(args.Days)}.PriceWith(prices)
+	if err != nil {
+		return 0, resolverError(err)
+	}
+	return int32(price), nil
+}
+
+// Available is true while the store the request acts for stocks more copies of the film than it has rented out
+func (f *filmResolver) Available(ctx context.Context) (bool, error) {
+	if f.server.rentals == nil || f.server.inventory == nil {
+		return false, &userError{err: errors.New("availability is not supported"), code: "UNSUPPORTED"}
+	}
+
+	stock, err := f.server.inventory.Inventory(ctx)
+	if err != nil {
+		return false, resolverError(err)
+	}
+	copies := 0
+	for _, s := range stock {
+		if s.Film == f.film.Name {
+			copies += s.Copies
+		}
+	}
+
+	rentals, err := f.rentals(ctx)
+	if err != nil {
+		return false, err
+	}
+	store := driven.StoreFrom(ctx)
+	for _, rental := range rentals {
+		if rental.Store == store || rental.Store == "" && store == domain.DefaultStore {
+			copies--
+		}
+	}
+	return copies > 0, nil
+}
+
+func (f *filmResolver) RentedCopies(ctx context.Context) (int32, error) {
+	rentals, err := f.rentals(ctx)
+	return int32(len(rentals)), err
+}
+
+func (f *filmResolver) Rentals(ctx context.Context) ([]*rentalResolver, error) {
+	rentals, err := f.rentals(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*rentalResolver, 0, len(rentals))
+	for _, rental := range rentals {
+		resolvers = append(resolvers, &rentalResolver{rental: rental, server: f.server})
+	}
+	return resolvers, nil
+}
+
+// rentals is empty when no rental tracker has been configured
+func (f *filmResolver) rentals(ctx context.Context) ([]domain.RentalStarted, error) {
+	if f.server.rentals == nil {
+		return nil, nil
+	}
+
+	rentals, err := f.server.rentals.CurrentRentals(ctx, f.film.Name)
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	return rentals, nil
+}
+
+func (r *rentalResolver) ID() string {
+	return r.rental.RentalID
+}
+
+func (r *rentalResolver) Days() int32 {
+	return int32(r.rental.Days)
+}
+
+func (r *rentalResolver) Film() *filmResolver {
+	return r.server.film(r.rental.Film)
+}
+
+func (i *invoiceResolver) Rentals() []*invoiceLineResolver {
+	lines := make([]*invoiceLineResolver, 0, len(i.invoice.Rentals))
+	for _, rental := range i.invoice.Rentals {
+		lines = append(lines, &invoiceLineResolver{rental: rental, prices: i.prices, server: i.server})
+	}
+	return lines
+}
+
+func (i *invoiceResolver) Cost() int32 {
+	return int32(i.invoice.Cost)
+}
+
+func (i *invoiceResolver) Currency() string {
+	return "SEK"
+}
+
+func (l *invoiceLineResolver) Film() *filmResolver {
+	return l.server.film(l.rental.Film)
+}
+
+func (l *invoiceLineResolver) Days() int32 {
+	return int32(l.rental.Days)
+}
+
+func (l *invoiceLineResolver) Cost() (int32, error) {
+	price, err := l.rental.PriceWith(l.prices)
+	if err != nil {
+		return 0, resolverError(err)
+	}
+	return int32(price), nil
+}
+
+func resolverError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var (
+		notFound       *driven.FilmNotFoundError
+		invalidRequest *driven.InvalidRentalRequestError
+	)
+	switch {
+	case errors.As(err, &notFound):
+		return &userError{err: notFound, code: "NOT_FOUND"}
+	case errors.As(err, &invalidRequest):
+		return &userError{err: invalidRequest, code: "BAD_USER_INPUT"}
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	}
+	return &userError{err: errors.New("request could not be processed"), code: "INTERNAL"}
+}
@@ -0,0 +1,63 @@
+package graphql
+
+const schema = `
+schema {
+	query: Query
+	mutation: Mutation
+}
+
+type Query {
+	film(name: String!): Film
+	# films returns null for every name which is not catalogued
+	films(names: [String!]!): [Film]!
+	catalogue: [Film!]!
+}
+
+type Mutation {
+	returnFilms(returns: [ReturnInput!]!): Invoice!
+	rentFilm(film: String!, days: Int!): Rental!
+}
+
+enum Release {
+	NEW
+	REGULAR
+	OLD
+}
+
+type Film {
+	name: String!
+	director: String!
+	release: Release!
+	# quote is the price in SEK of renting the film for the given days
+	quote(days: Int!): Int!
+	# available is whether the store the request acts for has a copy left which is not rented out
+	available: Boolean!
+	rentedCopies: Int!
+	rentals: [Rental!]!
+}
+
+type Rental {
+	id: String!
+	days: Int!
+	film: Film!
+}
+
+type Invoice {
+	rentals: [InvoiceLine!]!
+	cost: Int!
+	currency: String!
+}
+
+type InvoiceLine {
+	film: Film!
+	days: Int!
+	cost: Int!
+}
+
+input ReturnInput {
+	film: String!
+	days: Int!
+	# rental is the id of the rental being returned, which is then closed
+	rental: String
+}
+`
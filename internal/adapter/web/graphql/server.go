@@ -0,0 +1,186 @@
+// Package graphql exposes the catalogue, rentals and invoices as a single GraphQL schema
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/auth"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"net/http"
+	"time"
+)
+
+type (
+	server struct {
+		finder    driven.FilmBatchFinder
+		invoicer  driven.FilmInvoicer
+		lister    driven.FilmLister
+		prices    driven.PriceLists
+		rentals   driven.RentalTracker
+		inventory driven.Inventory
+		renter    driven.FilmRenter
+		cost      *costLimit
+		batchWait time.Duration
+		schema    *graphql.Schema
+	}
+
+	Option func(s *server)
+
+	request struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+)
+
+const (
+	defaultMaxCost       = 1000
+	defaultCatalogueSize = 100
+)
+
+func New(finder driven.FilmBatchFinder, invoicer driven.FilmInvoicer, options ...Option) *server {
+	s := &server{
+		finder:    finder,
+		invoicer:  invoicer,
+		cost:      newCostLimit(defaultMaxCost, defaultCatalogueSize),
+		batchWait: time.Millisecond,
+	}
+	for _, option := range options {
+		option(s)
+	}
+	s.schema = graphql.MustParseSchema(schema, &resolver{server: s}, graphql.MaxDepth(10), graphql.MaxParallelism(50))
+	return s
+}
+
+// WithLister resolves the catalogue query, it reports an error otherwise
+func WithLister(lister driven.FilmLister) Option {
+	return func(s *server) {
+		s.lister = lister
+	}
+}
+
+// WithPriceLists prices quotes and invoice lines with the price list of the store the request acts for, every
+// store charges the domain.DefaultPriceList otherwise
+func WithPriceLists(prices driven.PriceLists) Option {
+	return func(s *server) {
+		s.prices = prices
+	}
+}
+
+// WithRentalTracker resolves the current rentals of films, every film is then taken to be rented by nobody
+func WithRentalTracker(tracker driven.RentalTracker) Option {
+	return func(s *server) {
+		s.rentals = tracker
+	}
+}
+
+// WithInventory resolves, along with the rental tracker, whether a copy of a film is left in the store the request
+// acts for. Availability is reported as unsupported without both
+func WithInventory(inventory driven.Inventory) Option {
+	return func(s *server) {
+		s.inventory = inventory
+	}
+}
+
+// WithRenter resolves the rentFilm mutation, it reports an error otherwise
+func WithRenter(renter driven.FilmRenter) Option {
+	return func(s *server) {
+		s.renter = renter
+	}
+}
+
+// WithMaxCost rejects queries expected to resolve more than max fields, catalogueSize is how many films the
+// catalogue query is expected to return
+func WithMaxCost(max int, catalogueSize int) Option {
+	return func(s *server) {
+		s.cost = newCostLimit(max, catalogueSize)
+	}
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				respond(w, http.StatusBadRequest, errorResponse("variables must be a JSON object", "BAD_REQUEST"))
+				return
+			}
+		}
+		// a GET may be sent by a link or an image on any page, only a query is safe to run from one
+		if !queryOnly(req.Query, req.OperationName) {
+			w.Header().Set("Allow", "POST")
+			respond(w, http.StatusMethodNotAllowed, errorResponse("only query operations may be sent with GET", "METHOD_NOT_ALLOWED"))
+			return
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respond(w, http.StatusBadRequest, errorResponse("request body must be a JSON object with a query", "BAD_REQUEST"))
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		respond(w, http.StatusMethodNotAllowed, errorResponse("only GET and POST are supported", "METHOD_NOT_ALLOWED"))
+		return
+	}
+
+	if err := s.cost.check(req.Query, req.OperationName, req.Variables); err != nil {
+		var costErr *QueryCostError
+		errors.As(err, &costErr)
+		response := errorResponse(costErr.Error(), "QUERY_TOO_COSTLY")
+		response.Errors[0].Extensions["cost"] = costErr.Cost
+		response.Errors[0].Extensions["max"] = costErr.Max
+		respond(w, http.StatusBadRequest, response)
+		return
+	}
+
+	// every operation acts for the store named by X-Store-ID, or else the store the principal is confined to
+	store, err := auth.ScopeStore(r.Context(), r.Header.Get(auth.StoreHeader))
+	if errors.As(err, &auth.TypeForbidden) {
+		respond(w, http.StatusForbidden, errorResponse(err.Error(), "FORBIDDEN"))
+		return
+	} else if err != nil {
+		respond(w, http.StatusBadRequest, errorResponse(err.Error(), "BAD_REQUEST"))
+		return
+	}
+
+	ctx := withLoader(driven.WithStore(r.Context(), store), newFilmLoader(s.finder, s.batchWait))
+	respond(w, http.StatusOK, s.schema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+}
+
+// queryOnly reports whether the operation to be executed is a query, every operation of the document is checked
+// when none is named. Documents which cannot be parsed are left for the schema to report
+func queryOnly(query string, operationName string) bool {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return true
+	}
+	for _, operation := range doc.Operations {
+		if operationName != "" && operation.Name != operationName {
+			continue
+		}
+		if operation.Operation != ast.Query {
+			return false
+		}
+	}
+	return true
+}
+
+func errorResponse(message string, code string) *graphql.Response {
+	return &graphql.Response{Errors: []*gqlerrors.QueryError{{
+		Message:    message,
+		Extensions: map[string]interface{}{"code": code},
+	}}}
+}
+
+func respond(w http.ResponseWriter, status int, response *graphql.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
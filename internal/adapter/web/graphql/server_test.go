@@ -0,0 +1,327 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/auth"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+var films = []domain.Film{
+	{Name: "Loki", Director: "Marvel", Release: domain.New},
+	{Name: "Spider Man", Director: "Dwight", Release: domain.Regular},
+	{Name: "Out of Africa", Director: "Dwight", Release: domain.Old},
+}
+
+type spyStore struct {
+	mu      sync.Mutex
+	batches [][]string
+	returns []driven.FilmReturn
+	store   domain.StoreID
+}
+
+func (s *spyStore) FindAll(ctx context.Context, names []string) ([]domain.Film, error) {
+	s.mu.Lock()
+	s.batches = append(s.batches, names)
+	s.mu.Unlock()
+
+	var found []domain.Film
+	for _, film := range films {
+		for _, name := range names {
+			if film.Name == name {
+				found = append(found, film)
+			}
+		}
+	}
+	return found, nil
+}
+
+func (s *spyStore) List(ctx context.Context) ([]domain.Film, error) {
+	return films, nil
+}
+
+func (s *spyStore) CurrentRentals(ctx context.Context, film string) ([]domain.RentalStarted, error) {
+	if film != "Loki" {
+		return nil, nil
+	}
+	return []domain.RentalStarted{{RentalID: "r-1", Film: films[0], Days: 3}}, nil
+}
+
+// Inventory stocks a copy of Loki and two of Out of Africa in the main store and three copies of Loki in north
+func (s *spyStore) Inventory(ctx context.Context) ([]domain.Stock, error) {
+	if driven.StoreFrom(ctx) == "north" {
+		return []domain.Stock{{Film: "Loki", Copies: 3}}, nil
+	}
+	return []domain.Stock{{Film: "Loki", Copies: 1}, {Film: "Out of Africa", Copies: 2}}, nil
+}
+
+func (s *spyStore) AddStock(ctx context.Context, film string, copies int) (*domain.Stock, error) {
+	return nil, nil
+}
+
+func (s *spyStore) Invoice(ctx context.Context, request []driven.FilmReturn) (*domain.RentalInvoice, error) {
+	s.returns = request
+	s.store = driven.StoreFrom(ctx)
+	var req domain.RentalReturn
+	for _, r := range request {
+		found, _ := s.FindAll(ctx, []string{r.FilmName})
+		if len(found) == 0 {
+			return nil, &driven.InvalidRentalRequestError{&driven.FilmNotFoundError{Name: r.FilmName}}
+		}
+		req.AddRental(found[0], domain.Days(r.Days))
+	}
+	invoice, _ := req.Invoice()
+	return &invoice, nil
+}
+
+// PriceList charges premium films 50 SEK in north, every other store charges the domain.DefaultPriceList
+func (s *spyStore) PriceList(ctx context.Context) (domain.PriceList, error) {
+	if driven.StoreFrom(ctx) == "north" {
+		return domain.PriceList{Premium: 50, Basic: domain.BASIC}, nil
+	}
+	return domain.DefaultPriceList, nil
+}
+
+func (s *spyStore) Rent(ctx context.Context, film string, days uint16) (*domain.RentalStarted, error) {
+	found, _ := s.FindAll(ctx, []string{film})
+	if len(found) == 0 {
+		return nil, &driven.FilmNotFoundError{Name: film}
+	}
+	return &domain.RentalStarted{RentalID: "r-2", Film: found[0], Days: domain.Days(days)}, nil
+}
+
+func (s *spyStore) ChangePrices(ctx context.Context, prices domain.PriceList) error {
+	return nil
+}
+
+type response struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func execute(t *testing.T, s *server, query string, variables map[string]interface{}) (int, response) {
+	t.Helper()
+	body, _ := json.Marshal(request{Query: query, Variables: variables})
+	return serve(t, s, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body)))
+}
+
+func serve(t *testing.T, s *server, req *http.Request) (int, response) {
+	t.Helper()
+	res := httptest.NewRecorder()
+	s.ServeHTTP(res, req)
+
+	var decoded response
+	if err := json.Unmarshal(res.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("unable to decode %q: %v", res.Body.String(), err)
+	}
+	return res.Code, decoded
+}
+
+func newServer(store *spyStore, options ...Option) *server {
+	return New(store, store, append([]Option{WithLister(store), WithRentalTracker(store), WithInventory(store)}, options...)...)
+}
+
+func TestGraphQL_FilmWithAvailabilityRentalsAndQuote(t *testing.T) {
+	_, res := execute(t, newServer(&spyStore{}), `{
+		film(name: "Loki") { name director release quote(days: 2) available rentedCopies rentals { id days film { name } } }
+		missing: film(name: "Unknown") { name }
+	}`, nil)
+
+	expected := `{"name":"Loki","director":"Marvel","release":"NEW","quote":80,"available":false,"rentedCopies":1,"rentals":[{"id":"r-1","days":3,"film":{"name":"Loki"}}]}`
+	if string(res.Data["film"]) != expected || string(res.Data["missing"]) != "null" || len(res.Errors) != 0 {
+		t.Errorf("was expecting %s but got %s, %s and %v", expected, res.Data["film"], res.Data["missing"], res.Errors)
+	}
+}
+
+func TestGraphQL_AvailabilityPerStore(t *testing.T) {
+	query, _ := json.Marshal(request{Query: `{ films(names: ["Loki", "Spider Man"]) { name available } }`})
+	as := func(named string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(query))
+		req.Header.Set(auth.StoreHeader, named)
+		return req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Subject: "carol", Role: auth.Admin}))
+	}
+
+	tests := []struct {
+		name     string
+		store    string
+		expected string
+	}{
+		{"AllCopiesRented", "main", `[{"name":"Loki","available":false},{"name":"Spider Man","available":false}]`},
+		{"RentedElsewhere", "north", `[{"name":"Loki","available":true},{"name":"Spider Man","available":false}]`},
+	}
+	for _, test := range tests {
+		if _, res := serve(t, newServer(&spyStore{}), as(test.store)); string(res.Data["films"]) != test.expected || len(res.Errors) != 0 {
+			t.Errorf("%s was expecting %s but got %s %v", test.name, test.expected, res.Data["films"], res.Errors)
+		}
+	}
+
+	_, res := execute(t, New(&spyStore{}, &spyStore{}, WithRentalTracker(&spyStore{})), `{ film(name: "Loki") { available } }`, nil)
+	if len(res.Errors) != 1 || res.Errors[0].Extensions["code"] != "UNSUPPORTED" {
+		t.Errorf("was expecting availability without an inventory to be unsupported but got %v", res.Errors)
+	}
+}
+
+func TestGraphQL_LookupsAreBatched(t *testing.T) {
+	store := &spyStore{}
+	_, res := execute(t, newServer(store), `{
+		a: film(name: "Loki") { name }
+		b: film(name: "Spider Man") { name }
+		films(names: ["Out of Africa", "Loki", "Unknown"]) { name available }
+	}`, nil)
+
+	if string(res.Data["films"]) != `[{"name":"Out of Africa","available":true},{"name":"Loki","available":false},null]` {
+		t.Errorf("received unexpected films %s", res.Data["films"])
+	}
+	if len(store.batches) > 2 {
+		t.Errorf("was expecting the lookups to be batched but got %v", store.batches)
+	}
+
+	looked := map[string]int{}
+	for _, batch := range store.batches {
+		for _, name := range batch {
+			looked[name]++
+		}
+	}
+	for name, count := range looked {
+		if count != 1 {
+			t.Errorf("was expecting %q to be looked up once but it was looked up %d times", name, count)
+		}
+	}
+}
+
+func TestGraphQL_Catalogue(t *testing.T) {
+	_, res := execute(t, newServer(&spyStore{}), `{ catalogue { name release } }`, nil)
+
+	if string(res.Data["catalogue"]) != `[{"name":"Loki","release":"NEW"},{"name":"Spider Man","release":"REGULAR"},{"name":"Out of Africa","release":"OLD"}]` {
+		t.Errorf("received unexpected catalogue %s", res.Data["catalogue"])
+	}
+}
+
+func TestGraphQL_ReturnFilms(t *testing.T) {
+	store := &spyStore{}
+	_, res := execute(t, newServer(store), `mutation Return($returns: [ReturnInput!]!) {
+		returnFilms(returns: $returns) { cost currency rentals { film { name } days cost } }
+	}`, map[string]interface{}{"returns": []map[string]interface{}{{"film": "Loki", "days": 2}, {"film": "Out of Africa", "days": 7}}})
+
+	expected := `{"cost":170,"currency":"SEK","rentals":[{"film":{"name":"Loki"},"days":2,"cost":80},{"film":{"name":"Out of Africa"},"days":7,"cost":90}]}`
+	if string(res.Data["returnFilms"]) != expected {
+		t.Errorf("was expecting %s but got %s %v", expected, res.Data["returnFilms"], res.Errors)
+	}
+	if len(store.returns) != 2 || store.returns[1] != (driven.FilmReturn{FilmName: "Out of Africa", Days: 7}) {
+		t.Errorf("was expecting the returns to be invoiced but got %#v", store.returns)
+	}
+}
+
+func TestGraphQL_RentAndReturnFilm(t *testing.T) {
+	store := &spyStore{}
+	_, res := execute(t, newServer(store, WithRenter(store)), `mutation { rentFilm(film: "Spider Man", days: 2) { id days film { name } } }`, nil)
+
+	if expected := `{"id":"r-2","days":2,"film":{"name":"Spider Man"}}`; string(res.Data["rentFilm"]) != expected {
+		t.Errorf("was expecting %s but got %s %v", expected, res.Data["rentFilm"], res.Errors)
+	}
+
+	_, res = execute(t, newServer(store), `mutation { returnFilms(returns: [{film: "Spider Man", days: 2, rental: "r-2"}]) { cost } }`, nil)
+	if len(store.returns) != 1 || store.returns[0] != (driven.FilmReturn{FilmName: "Spider Man", Days: 2, RentalID: "r-2"}) {
+		t.Errorf("was expecting the return to name its rental but got %#v %v", store.returns, res.Errors)
+	}
+
+	_, res = execute(t, newServer(store), `mutation { rentFilm(film: "Spider Man", days: 2) { id } }`, nil)
+	if len(res.Errors) != 1 || res.Errors[0].Extensions["code"] != "UNSUPPORTED" {
+		t.Errorf("was expecting renting without a renter to be unsupported but got %v", res.Errors)
+	}
+}
+
+func TestGraphQL_GetOnlyRunsQueries(t *testing.T) {
+	const document = `query Find { film(name: "Loki") { name } } mutation Return { returnFilms(returns: [{film: "Loki", days: 1}]) { cost } }`
+	tests := []struct {
+		name          string
+		query         string
+		operationName string
+		status        int
+	}{
+		{"Query", `{ film(name: "Loki") { name } }`, "", http.StatusOK},
+		{"Mutation", `mutation { returnFilms(returns: [{film: "Loki", days: 1}]) { cost } }`, "", http.StatusMethodNotAllowed},
+		{"NamedQuery", document, "Find", http.StatusOK},
+		{"NamedMutation", document, "Return", http.StatusMethodNotAllowed},
+		{"UnnamedAmongstMutations", document, "", http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := &spyStore{}
+			params := url.Values{"query": {test.query}, "operationName": {test.operationName}}
+			req := httptest.NewRequest(http.MethodGet, "/graphql?"+params.Encode(), nil)
+			res := httptest.NewRecorder()
+			newServer(store).ServeHTTP(res, req)
+
+			if res.Code != test.status {
+				t.Errorf("was expecting %d but got %d: %s", test.status, res.Code, res.Body.String())
+			}
+			if test.status == http.StatusMethodNotAllowed && (res.Header().Get("Allow") != "POST" || store.returns != nil) {
+				t.Errorf("was expecting the mutation to be refused without running but got %v and returns %#v", res.Header(), store.returns)
+			}
+		})
+	}
+}
+
+func TestGraphQL_StoreScope(t *testing.T) {
+	store := &spyStore{}
+	s := newServer(store, WithPriceLists(store))
+	query, _ := json.Marshal(request{Query: `mutation {
+		returnFilms(returns: [{film: "Loki", days: 2}]) { rentals { cost } }
+		quote: returnFilms(returns: [{film: "Loki", days: 1}]) { rentals { film { quote(days: 2) } } }
+	}`})
+	as := func(principal auth.Principal, named string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(query))
+		if named != "" {
+			req.Header.Set(auth.StoreHeader, named)
+		}
+		return req.WithContext(auth.WithPrincipal(req.Context(), principal))
+	}
+	kiosk := auth.Principal{Subject: "kiosk-1", Role: auth.Clerk, Store: "north"}
+
+	code, res := serve(t, s, as(kiosk, ""))
+	if code != http.StatusOK || store.store != "north" ||
+		string(res.Data["returnFilms"]) != `{"rentals":[{"cost":100}]}` || string(res.Data["quote"]) != `{"rentals":[{"film":{"quote":100}}]}` {
+		t.Errorf("was expecting the confined clerk to be priced by north but got %d %q %s %v", code, store.store, res.Data, res.Errors)
+	}
+
+	store.store = ""
+	if code, res := serve(t, s, as(kiosk, "main")); code != http.StatusForbidden || store.store != "" || res.Errors[0].Extensions["code"] != "FORBIDDEN" {
+		t.Errorf("was expecting the confined clerk to be refused main but got %d %q %v", code, store.store, res.Errors)
+	}
+	if code, _ := serve(t, s, as(auth.Principal{Subject: "carol", Role: auth.Admin}, "North Side")); code != http.StatusBadRequest {
+		t.Errorf("was expecting an invalid store to be refused but got %d", code)
+	}
+	if _, res := serve(t, s, as(auth.Principal{Subject: "carol", Role: auth.Admin}, "south")); store.store != "south" || string(res.Data["returnFilms"]) != `{"rentals":[{"cost":80}]}` {
+		t.Errorf("was expecting the admin to be priced by south but got %q %s", store.store, res.Data)
+	}
+}
+
+func TestGraphQL_ReturnUnknownFilm(t *testing.T) {
+	_, res := execute(t, newServer(&spyStore{}), `mutation { returnFilms(returns: [{film: "Unknown", days: 1}]) { cost } }`, nil)
+
+	if len(res.Errors) != 1 || res.Errors[0].Extensions["code"] != "BAD_USER_INPUT" {
+		t.Errorf("was expecting a BAD_USER_INPUT error but got %v", res.Errors)
+	}
+}
+
+func TestGraphQL_CostLimit(t *testing.T) {
+	status, res := execute(t, newServer(&spyStore{}, WithMaxCost(50, 100)), `{ catalogue { name rentals { id } } }`, nil)
+
+	if status != http.StatusBadRequest || len(res.Errors) != 1 || res.Errors[0].Extensions["code"] != "QUERY_TOO_COSTLY" {
+		t.Errorf("was expecting the query to be rejected but got %d %v", status, res.Errors)
+	}
+	if res.Data != nil {
+		t.Errorf("was expecting nothing to be resolved but got %v", res.Data)
+	}
+}
@@ -0,0 +1,99 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/auth"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/grpc/pb"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"strings"
+)
+
+// methodRoles maps every method onto the role its HTTP route requires, a method missing from it is refused
+var methodRoles = map[string]auth.Role{
+	pb.VideoStore_FindFilm_FullMethodName:  auth.Clerk,
+	pb.VideoStore_ListFilms_FullMethodName: auth.Clerk,
+	pb.VideoStore_Invoice_FullMethodName:   auth.Clerk,
+	pb.VideoStore_AddFilm_FullMethodName:   auth.Manager,
+}
+
+// WithAuthenticator requires every method to be called by a principal holding the role its HTTP route is mapped
+// to, the credentials are read from the metadata under the same names as the HTTP headers
+func WithAuthenticator(authenticator auth.Authenticator) Option {
+	return func(s *server) {
+		s.authenticator = authenticator
+	}
+}
+
+func (s *server) unaryAuth(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := s.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *server) streamAuth(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authorize(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authorizedStream{ServerStream: stream, ctx: ctx})
+}
+
+// authorize lets every call through when no authenticator has been configured, otherwise the principal becomes
+// the actor of every service call it makes. Either way the call acts for the store named in its metadata, or else
+// the store the principal is confined to
+func (s *server) authorize(ctx context.Context, method string) (context.Context, error) {
+	r := metadataRequest(ctx)
+	if s.authenticator != nil {
+		role, ok := methodRoles[method]
+		if !ok {
+			return nil, status.Errorf(codes.PermissionDenied, "%s is not mapped to a role", method)
+		}
+
+		principal, err := auth.Authorize(s.authenticator, r, role)
+		if err != nil {
+			return nil, statusError(err)
+		}
+		ctx = auth.WithPrincipal(driven.WithActor(ctx, principal.Subject), *principal)
+	}
+
+	store, err := auth.ScopeStore(ctx, r.Header.Get(auth.StoreHeader))
+	if errors.As(err, &auth.TypeForbidden) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	} else if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return driven.WithStore(ctx, store), nil
+}
+
+// metadataRequest carries the metadata of the call as the headers of a request, so the authenticators of the
+// HTTP adapter can read the API key and bearer token from it
+func metadataRequest(ctx context.Context) *http.Request {
+	r := &http.Request{Header: http.Header{}}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for key, values := range md {
+		if strings.HasSuffix(key, "-bin") {
+			continue
+		}
+		for _, value := range values {
+			r.Header.Add(key, value)
+		}
+	}
+	return r
+}
+
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authorizedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,84 @@
+package grpc
+
+import (
+	"context"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/auth"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/grpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"io"
+	"testing"
+)
+
+func newKeys(t *testing.T) auth.Authenticator {
+	t.Helper()
+	keys, err := auth.NewAPIKeys([]auth.APIKey{
+		{Hash: auth.HashAPIKey("kiosk"), Subject: "kiosk-1", Role: "clerk"},
+		{Hash: auth.HashAPIKey("office"), Subject: "manager-1", Role: "manager"},
+		{Hash: auth.HashAPIKey("north"), Subject: "kiosk-2", Role: "clerk", Store: "north"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func withKey(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), auth.APIKeyHeader, key)
+}
+
+func TestServer_Authorization(t *testing.T) {
+	store := &spyStore{}
+	client := newClient(t, store, WithAuthenticator(newKeys(t)), WithLister(store))
+	add := &pb.AddFilmRequest{Name: "Thor", Director: "Marvel", Release: pb.Release_RELEASE_NEW}
+
+	_, err := client.FindFilm(context.Background(), &pb.FindFilmRequest{Name: "Loki"})
+	assertCode(t, err, codes.Unauthenticated)
+	_, err = client.FindFilm(withKey("stolen"), &pb.FindFilmRequest{Name: "Loki"})
+	assertCode(t, err, codes.Unauthenticated)
+	_, err = client.FindFilm(withKey("kiosk"), &pb.FindFilmRequest{Name: "Loki"})
+	assertCode(t, err, codes.OK)
+
+	_, err = client.AddFilm(withKey("kiosk"), add)
+	assertCode(t, err, codes.PermissionDenied)
+	_, err = client.Invoice(context.Background(), &pb.InvoiceRequest{Returns: []*pb.FilmReturn{{FilmName: "Loki", Days: 1}}})
+	assertCode(t, err, codes.Unauthenticated)
+	if len(store.added) != 0 || store.returns != nil {
+		t.Fatalf("was expecting refused calls to reach no service but got %#v", store)
+	}
+	_, err = client.AddFilm(withKey("office"), add)
+	assertCode(t, err, codes.OK)
+
+	stream, err := client.ListFilms(context.Background(), &pb.ListFilmsRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	assertCode(t, err, codes.Unauthenticated)
+	stream, err = client.ListFilms(withKey("kiosk"), &pb.ListFilmsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for err == nil {
+		_, err = stream.Recv()
+	}
+	if err != io.EOF {
+		t.Errorf("was expecting the clerk to list the catalogue but got %v", err)
+	}
+}
+
+func TestServer_StoreScope(t *testing.T) {
+	store := &spyStore{}
+	client := newClient(t, store, WithAuthenticator(newKeys(t)))
+	req := &pb.InvoiceRequest{Returns: []*pb.FilmReturn{{FilmName: "Loki", Days: 1}}}
+
+	if _, err := client.Invoice(withKey("north"), req); err != nil || store.store != "north" {
+		t.Errorf("was expecting the confined clerk to invoice against north but got %q %v", store.store, err)
+	}
+	_, err := client.Invoice(metadata.AppendToOutgoingContext(withKey("north"), auth.StoreHeader, "main"), req)
+	assertCode(t, err, codes.PermissionDenied)
+	if _, err := client.Invoice(metadata.AppendToOutgoingContext(withKey("kiosk"), auth.StoreHeader, "south"), req); err != nil || store.store != "south" {
+		t.Errorf("was expecting the clerk to invoice against south but got %q %v", store.store, err)
+	}
+	_, err = client.Invoice(metadata.AppendToOutgoingContext(withKey("kiosk"), auth.StoreHeader, "North Side"), req)
+	assertCode(t, err, codes.InvalidArgument)
+}
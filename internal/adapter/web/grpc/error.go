@@ -0,0 +1,53 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/auth"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusError maps the errors returned through the driven ports onto gRPC status codes, anything unexpected is
+// reported as Internal without leaking its detail. Stock missing for a rental is reported with the rest of the
+// invalid rental request, stock missing for anything else is a failed precondition
+func statusError(err error) error {
+	var (
+		notFound        *driven.FilmNotFoundError
+		alreadyExist    *driven.FilmAlreadyExistError
+		invalidRequest  *driven.InvalidRentalRequestError
+		invalidFilm     *domain.InvalidFilmError
+		conflict        *driven.StreamConflictError
+		versionConflict *driven.FilmVersionConflictError
+		insufficient    *driven.InsufficientStockError
+		forbidden       *auth.ForbiddenError
+	)
+
+	switch {
+	case errors.As(err, &notFound):
+		return status.Error(codes.NotFound, notFound.Error())
+	case errors.As(err, &alreadyExist):
+		return status.Error(codes.AlreadyExists, alreadyExist.Error())
+	case errors.As(err, &invalidRequest):
+		return status.Error(codes.InvalidArgument, invalidRequest.Error())
+	case errors.As(err, &invalidFilm):
+		return status.Error(codes.InvalidArgument, invalidFilm.Error())
+	case errors.Is(err, domain.InvalidStoreIDError):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.As(err, &conflict):
+		return status.Error(codes.Aborted, conflict.Error())
+	case errors.As(err, &versionConflict):
+		return status.Error(codes.Aborted, versionConflict.Error())
+	case errors.As(err, &insufficient):
+		return status.Error(codes.FailedPrecondition, insufficient.Error())
+	case errors.As(err, &forbidden):
+		return status.Error(codes.PermissionDenied, forbidden.Error())
+	case errors.Is(err, auth.MissingCredentialsError), errors.As(err, &auth.TypeInvalidCredentials):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Internal, "request could not be processed")
+}
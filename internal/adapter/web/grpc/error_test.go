@@ -0,0 +1,45 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/auth"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestStatusError(t *testing.T) {
+	insufficient := &driven.InsufficientStockError{Store: "north", Film: "Loki", Available: 1, Requested: 2}
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{"FilmNotFound", &driven.FilmNotFoundError{Name: "Loki"}, codes.NotFound},
+		{"FilmAlreadyExists", &driven.FilmAlreadyExistError{Name: "Loki"}, codes.AlreadyExists},
+		{"InvalidRentalRequest", &driven.InvalidRentalRequestError{errors.New("invalid film")}, codes.InvalidArgument},
+		{"InsufficientStockOfARental", &driven.InvalidRentalRequestError{insufficient}, codes.InvalidArgument},
+		{"InvalidFilm", &domain.InvalidFilmError{domain.EmptyFilmNameError}, codes.InvalidArgument},
+		{"InvalidStoreID", fmt.Errorf("store %q: %w", "North!", domain.InvalidStoreIDError), codes.InvalidArgument},
+		{"StreamConflict", &driven.StreamConflictError{Stream: "film-Loki", Expected: 1, Actual: 2}, codes.Aborted},
+		{"FilmVersionConflict", &driven.FilmVersionConflictError{Name: "Loki", Expected: 1, Actual: 2}, codes.Aborted},
+		{"InsufficientStock", insufficient, codes.FailedPrecondition},
+		{"Forbidden", &auth.ForbiddenError{Principal: auth.Principal{Subject: "kiosk-1", Role: auth.Clerk}, Required: auth.Manager}, codes.PermissionDenied},
+		{"MissingCredentials", auth.MissingCredentialsError, codes.Unauthenticated},
+		{"InvalidCredentials", &auth.InvalidCredentialsError{Reason: "unknown api key"}, codes.Unauthenticated},
+		{"Canceled", context.Canceled, codes.Canceled},
+		{"DeadlineExceeded", context.DeadlineExceeded, codes.DeadlineExceeded},
+		{"Unexpected", errors.New("disk on fire"), codes.Internal},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if code := status.Code(statusError(test.err)); code != test.code {
+				t.Errorf("was expecting %v to be mapped to %v but got %v", test.err, test.code, code)
+			}
+		})
+	}
+}
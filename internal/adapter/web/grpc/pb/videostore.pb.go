@@ -0,0 +1,634 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: videostore.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Release int32
+
+const (
+	Release_RELEASE_UNSPECIFIED Release = 0
+	Release_RELEASE_NEW         Release = 1
+	Release_RELEASE_REGULAR     Release = 2
+	Release_RELEASE_OLD         Release = 3
+)
+
+// Enum value maps for Release.
+var (
+	Release_name = map[int32]string{
+		0: "RELEASE_UNSPECIFIED",
+		1: "RELEASE_NEW",
+		2: "RELEASE_REGULAR",
+		3: "RELEASE_OLD",
+	}
+	Release_value = map[string]int32{
+		"RELEASE_UNSPECIFIED": 0,
+		"RELEASE_NEW":         1,
+		"RELEASE_REGULAR":     2,
+		"RELEASE_OLD":         3,
+	}
+)
+
+func (x Release) Enum() *Release {
+	p := new(Release)
+	*p = x
+	return p
+}
+
+func (x Release) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Release) Descriptor() protoreflect.EnumDescriptor {
+	return file_videostore_proto_enumTypes[0].Descriptor()
+}
+
+func (Release) Type() protoreflect.EnumType {
+	return &file_videostore_proto_enumTypes[0]
+}
+
+func (x Release) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Release.Descriptor instead.
+func (Release) EnumDescriptor() ([]byte, []int) {
+	return file_videostore_proto_rawDescGZIP(), []int{0}
+}
+
+type Film struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Director      string                 `protobuf:"bytes,2,opt,name=director,proto3" json:"director,omitempty"`
+	Release       Release                `protobuf:"varint,3,opt,name=release,proto3,enum=videostore.v1.Release" json:"release,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Film) Reset() {
+	*x = Film{}
+	mi := &file_videostore_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Film) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Film) ProtoMessage() {}
+
+func (x *Film) ProtoReflect() protoreflect.Message {
+	mi := &file_videostore_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Film.ProtoReflect.Descriptor instead.
+func (*Film) Descriptor() ([]byte, []int) {
+	return file_videostore_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Film) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Film) GetDirector() string {
+	if x != nil {
+		return x.Director
+	}
+	return ""
+}
+
+func (x *Film) GetRelease() Release {
+	if x != nil {
+		return x.Release
+	}
+	return Release_RELEASE_UNSPECIFIED
+}
+
+type FindFilmRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindFilmRequest) Reset() {
+	*x = FindFilmRequest{}
+	mi := &file_videostore_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindFilmRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindFilmRequest) ProtoMessage() {}
+
+func (x *FindFilmRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_videostore_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindFilmRequest.ProtoReflect.Descriptor instead.
+func (*FindFilmRequest) Descriptor() ([]byte, []int) {
+	return file_videostore_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FindFilmRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type AddFilmRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Director      string                 `protobuf:"bytes,2,opt,name=director,proto3" json:"director,omitempty"`
+	Release       Release                `protobuf:"varint,3,opt,name=release,proto3,enum=videostore.v1.Release" json:"release,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddFilmRequest) Reset() {
+	*x = AddFilmRequest{}
+	mi := &file_videostore_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddFilmRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddFilmRequest) ProtoMessage() {}
+
+func (x *AddFilmRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_videostore_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddFilmRequest.ProtoReflect.Descriptor instead.
+func (*AddFilmRequest) Descriptor() ([]byte, []int) {
+	return file_videostore_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AddFilmRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *AddFilmRequest) GetDirector() string {
+	if x != nil {
+		return x.Director
+	}
+	return ""
+}
+
+func (x *AddFilmRequest) GetRelease() Release {
+	if x != nil {
+		return x.Release
+	}
+	return Release_RELEASE_UNSPECIFIED
+}
+
+type AddFilmResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Film          *Film                  `protobuf:"bytes,1,opt,name=film,proto3" json:"film,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddFilmResponse) Reset() {
+	*x = AddFilmResponse{}
+	mi := &file_videostore_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddFilmResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddFilmResponse) ProtoMessage() {}
+
+func (x *AddFilmResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_videostore_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddFilmResponse.ProtoReflect.Descriptor instead.
+func (*AddFilmResponse) Descriptor() ([]byte, []int) {
+	return file_videostore_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AddFilmResponse) GetFilm() *Film {
+	if x != nil {
+		return x.Film
+	}
+	return nil
+}
+
+type FilmReturn struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FilmName      string                 `protobuf:"bytes,1,opt,name=film_name,json=filmName,proto3" json:"film_name,omitempty"`
+	Days          uint32                 `protobuf:"varint,2,opt,name=days,proto3" json:"days,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FilmReturn) Reset() {
+	*x = FilmReturn{}
+	mi := &file_videostore_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FilmReturn) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FilmReturn) ProtoMessage() {}
+
+func (x *FilmReturn) ProtoReflect() protoreflect.Message {
+	mi := &file_videostore_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FilmReturn.ProtoReflect.Descriptor instead.
+func (*FilmReturn) Descriptor() ([]byte, []int) {
+	return file_videostore_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *FilmReturn) GetFilmName() string {
+	if x != nil {
+		return x.FilmName
+	}
+	return ""
+}
+
+func (x *FilmReturn) GetDays() uint32 {
+	if x != nil {
+		return x.Days
+	}
+	return 0
+}
+
+type InvoiceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Returns       []*FilmReturn          `protobuf:"bytes,1,rep,name=returns,proto3" json:"returns,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InvoiceRequest) Reset() {
+	*x = InvoiceRequest{}
+	mi := &file_videostore_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InvoiceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InvoiceRequest) ProtoMessage() {}
+
+func (x *InvoiceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_videostore_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InvoiceRequest.ProtoReflect.Descriptor instead.
+func (*InvoiceRequest) Descriptor() ([]byte, []int) {
+	return file_videostore_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *InvoiceRequest) GetReturns() []*FilmReturn {
+	if x != nil {
+		return x.Returns
+	}
+	return nil
+}
+
+type Rental struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Film          *Film                  `protobuf:"bytes,1,opt,name=film,proto3" json:"film,omitempty"`
+	Days          uint32                 `protobuf:"varint,2,opt,name=days,proto3" json:"days,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Rental) Reset() {
+	*x = Rental{}
+	mi := &file_videostore_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rental) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rental) ProtoMessage() {}
+
+func (x *Rental) ProtoReflect() protoreflect.Message {
+	mi := &file_videostore_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rental.ProtoReflect.Descriptor instead.
+func (*Rental) Descriptor() ([]byte, []int) {
+	return file_videostore_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Rental) GetFilm() *Film {
+	if x != nil {
+		return x.Film
+	}
+	return nil
+}
+
+func (x *Rental) GetDays() uint32 {
+	if x != nil {
+		return x.Days
+	}
+	return 0
+}
+
+type InvoiceResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Rentals []*Rental              `protobuf:"bytes,1,rep,name=rentals,proto3" json:"rentals,omitempty"`
+	// cost is expressed in SEK
+	Cost          uint64 `protobuf:"varint,2,opt,name=cost,proto3" json:"cost,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InvoiceResponse) Reset() {
+	*x = InvoiceResponse{}
+	mi := &file_videostore_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InvoiceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InvoiceResponse) ProtoMessage() {}
+
+func (x *InvoiceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_videostore_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InvoiceResponse.ProtoReflect.Descriptor instead.
+func (*InvoiceResponse) Descriptor() ([]byte, []int) {
+	return file_videostore_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *InvoiceResponse) GetRentals() []*Rental {
+	if x != nil {
+		return x.Rentals
+	}
+	return nil
+}
+
+func (x *InvoiceResponse) GetCost() uint64 {
+	if x != nil {
+		return x.Cost
+	}
+	return 0
+}
+
+type ListFilmsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFilmsRequest) Reset() {
+	*x = ListFilmsRequest{}
+	mi := &file_videostore_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFilmsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFilmsRequest) ProtoMessage() {}
+
+func (x *ListFilmsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_videostore_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFilmsRequest.ProtoReflect.Descriptor instead.
+func (*ListFilmsRequest) Descriptor() ([]byte, []int) {
+	return file_videostore_proto_rawDescGZIP(), []int{8}
+}
+
+var File_videostore_proto protoreflect.FileDescriptor
+
+const file_videostore_proto_rawDesc = "" +
+	"\n" +
+	"\x10videostore.proto\x12\rvideostore.v1\"h\n" +
+	"\x04Film\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
+	"\bdirector\x18\x02 \x01(\tR\bdirector\x120\n" +
+	"\arelease\x18\x03 \x01(\x0e2\x16.videostore.v1.ReleaseR\arelease\"%\n" +
+	"\x0fFindFilmRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"r\n" +
+	"\x0eAddFilmRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
+	"\bdirector\x18\x02 \x01(\tR\bdirector\x120\n" +
+	"\arelease\x18\x03 \x01(\x0e2\x16.videostore.v1.ReleaseR\arelease\":\n" +
+	"\x0fAddFilmResponse\x12'\n" +
+	"\x04film\x18\x01 \x01(\v2\x13.videostore.v1.FilmR\x04film\"=\n" +
+	"\n" +
+	"FilmReturn\x12\x1b\n" +
+	"\tfilm_name\x18\x01 \x01(\tR\bfilmName\x12\x12\n" +
+	"\x04days\x18\x02 \x01(\rR\x04days\"E\n" +
+	"\x0eInvoiceRequest\x123\n" +
+	"\areturns\x18\x01 \x03(\v2\x19.videostore.v1.FilmReturnR\areturns\"E\n" +
+	"\x06Rental\x12'\n" +
+	"\x04film\x18\x01 \x01(\v2\x13.videostore.v1.FilmR\x04film\x12\x12\n" +
+	"\x04days\x18\x02 \x01(\rR\x04days\"V\n" +
+	"\x0fInvoiceResponse\x12/\n" +
+	"\arentals\x18\x01 \x03(\v2\x15.videostore.v1.RentalR\arentals\x12\x12\n" +
+	"\x04cost\x18\x02 \x01(\x04R\x04cost\"\x12\n" +
+	"\x10ListFilmsRequest*Y\n" +
+	"\aRelease\x12\x17\n" +
+	"\x13RELEASE_UNSPECIFIED\x10\x00\x12\x0f\n" +
+	"\vRELEASE_NEW\x10\x01\x12\x13\n" +
+	"\x0fRELEASE_REGULAR\x10\x02\x12\x0f\n" +
+	"\vRELEASE_OLD\x10\x032\xa6\x02\n" +
+	"\n" +
+	"VideoStore\x12?\n" +
+	"\bFindFilm\x12\x1e.videostore.v1.FindFilmRequest\x1a\x13.videostore.v1.Film\x12H\n" +
+	"\aAddFilm\x12\x1d.videostore.v1.AddFilmRequest\x1a\x1e.videostore.v1.AddFilmResponse\x12H\n" +
+	"\aInvoice\x12\x1d.videostore.v1.InvoiceRequest\x1a\x1e.videostore.v1.InvoiceResponse\x12C\n" +
+	"\tListFilms\x12\x1f.videostore.v1.ListFilmsRequest\x1a\x13.videostore.v1.Film0\x01BEZCgithub.com/shawnritchie/go-video-store/internal/adapter/web/grpc/pbb\x06proto3"
+
+var (
+	file_videostore_proto_rawDescOnce sync.Once
+	file_videostore_proto_rawDescData []byte
+)
+
+func file_videostore_proto_rawDescGZIP() []byte {
+	file_videostore_proto_rawDescOnce.Do(func() {
+		file_videostore_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_videostore_proto_rawDesc), len(file_videostore_proto_rawDesc)))
+	})
+	return file_videostore_proto_rawDescData
+}
+
+var file_videostore_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_videostore_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_videostore_proto_goTypes = []any{
+	(Release)(0),             // 0: videostore.v1.Release
+	(*Film)(nil),             // 1: videostore.v1.Film
+	(*FindFilmRequest)(nil),  // 2: videostore.v1.FindFilmRequest
+	(*AddFilmRequest)(nil),   // 3: videostore.v1.AddFilmRequest
+	(*AddFilmResponse)(nil),  // 4: videostore.v1.AddFilmResponse
+	(*FilmReturn)(nil),       // 5: videostore.v1.FilmReturn
+	(*InvoiceRequest)(nil),   // 6: videostore.v1.InvoiceRequest
+	(*Rental)(nil),           // 7: videostore.v1.Rental
+	(*InvoiceResponse)(nil),  // 8: videostore.v1.InvoiceResponse
+	(*ListFilmsRequest)(nil), // 9: videostore.v1.ListFilmsRequest
+}
+var file_videostore_proto_depIdxs = []int32{
+	0,  // 0: videostore.v1.Film.release:type_name -> videostore.v1.Release
+	0,  // 1: videostore.v1.AddFilmRequest.release:type_name -> videostore.v1.Release
+	1,  // 2: videostore.v1.AddFilmResponse.film:type_name -> videostore.v1.Film
+	5,  // 3: videostore.v1.InvoiceRequest.returns:type_name -> videostore.v1.FilmReturn
+	1,  // 4: videostore.v1.Rental.film:type_name -> videostore.v1.Film
+	7,  // 5: videostore.v1.InvoiceResponse.rentals:type_name -> videostore.v1.Rental
+	2,  // 6: videostore.v1.VideoStore.FindFilm:input_type -> videostore.v1.FindFilmRequest
+	3,  // 7: videostore.v1.VideoStore.AddFilm:input_type -> videostore.v1.AddFilmRequest
+	6,  // 8: videostore.v1.VideoStore.Invoice:input_type -> videostore.v1.InvoiceRequest
+	9,  // 9: videostore.v1.VideoStore.ListFilms:input_type -> videostore.v1.ListFilmsRequest
+	1,  // 10: videostore.v1.VideoStore.FindFilm:output_type -> videostore.v1.Film
+	4,  // 11: videostore.v1.VideoStore.AddFilm:output_type -> videostore.v1.AddFilmResponse
+	8,  // 12: videostore.v1.VideoStore.Invoice:output_type -> videostore.v1.InvoiceResponse
+	1,  // 13: videostore.v1.VideoStore.ListFilms:output_type -> videostore.v1.Film
+	10, // [10:14] is the sub-list for method output_type
+	6,  // [6:10] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_videostore_proto_init() }
+func file_videostore_proto_init() {
+	if File_videostore_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_videostore_proto_rawDesc), len(file_videostore_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_videostore_proto_goTypes,
+		DependencyIndexes: file_videostore_proto_depIdxs,
+		EnumInfos:         file_videostore_proto_enumTypes,
+		MessageInfos:      file_videostore_proto_msgTypes,
+	}.Build()
+	File_videostore_proto = out.File
+	file_videostore_proto_goTypes = nil
+	file_videostore_proto_depIdxs = nil
+}
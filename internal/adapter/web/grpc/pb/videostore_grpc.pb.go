@@ -0,0 +1,241 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: videostore.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	VideoStore_FindFilm_FullMethodName  = "/videostore.v1.VideoStore/FindFilm"
+	VideoStore_AddFilm_FullMethodName   = "/videostore.v1.VideoStore/AddFilm"
+	VideoStore_Invoice_FullMethodName   = "/videostore.v1.VideoStore/Invoice"
+	VideoStore_ListFilms_FullMethodName = "/videostore.v1.VideoStore/ListFilms"
+)
+
+// VideoStoreClient is the client API for VideoStore service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type VideoStoreClient interface {
+	FindFilm(ctx context.Context, in *FindFilmRequest, opts ...grpc.CallOption) (*Film, error)
+	AddFilm(ctx context.Context, in *AddFilmRequest, opts ...grpc.CallOption) (*AddFilmResponse, error)
+	Invoice(ctx context.Context, in *InvoiceRequest, opts ...grpc.CallOption) (*InvoiceResponse, error)
+	// ListFilms streams the whole catalogue ordered by name
+	ListFilms(ctx context.Context, in *ListFilmsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Film], error)
+}
+
+type videoStoreClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewVideoStoreClient(cc grpc.ClientConnInterface) VideoStoreClient {
+	return &videoStoreClient{cc}
+}
+
+func (c *videoStoreClient) FindFilm(ctx context.Context, in *FindFilmRequest, opts ...grpc.CallOption) (*Film, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Film)
+	err := c.cc.Invoke(ctx, VideoStore_FindFilm_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoStoreClient) AddFilm(ctx context.Context, in *AddFilmRequest, opts ...grpc.CallOption) (*AddFilmResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddFilmResponse)
+	err := c.cc.Invoke(ctx, VideoStore_AddFilm_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoStoreClient) Invoice(ctx context.Context, in *InvoiceRequest, opts ...grpc.CallOption) (*InvoiceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InvoiceResponse)
+	err := c.cc.Invoke(ctx, VideoStore_Invoice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoStoreClient) ListFilms(ctx context.Context, in *ListFilmsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Film], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VideoStore_ServiceDesc.Streams[0], VideoStore_ListFilms_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListFilmsRequest, Film]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoStore_ListFilmsClient = grpc.ServerStreamingClient[Film]
+
+// VideoStoreServer is the server API for VideoStore service.
+// All implementations must embed UnimplementedVideoStoreServer
+// for forward compatibility.
+type VideoStoreServer interface {
+	FindFilm(context.Context, *FindFilmRequest) (*Film, error)
+	AddFilm(context.Context, *AddFilmRequest) (*AddFilmResponse, error)
+	Invoice(context.Context, *InvoiceRequest) (*InvoiceResponse, error)
+	// ListFilms streams the whole catalogue ordered by name
+	ListFilms(*ListFilmsRequest, grpc.ServerStreamingServer[Film]) error
+	mustEmbedUnimplementedVideoStoreServer()
+}
+
+// UnimplementedVideoStoreServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedVideoStoreServer struct{}
+
+func (UnimplementedVideoStoreServer) FindFilm(context.Context, *FindFilmRequest) (*Film, error) {
+	return nil, status.Error(codes.Unimplemented, "method FindFilm not implemented")
+}
+func (UnimplementedVideoStoreServer) AddFilm(context.Context, *AddFilmRequest) (*AddFilmResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method AddFilm not implemented")
+}
+func (UnimplementedVideoStoreServer) Invoice(context.Context, *InvoiceRequest) (*InvoiceResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Invoice not implemented")
+}
+func (UnimplementedVideoStoreServer) ListFilms(*ListFilmsRequest, grpc.ServerStreamingServer[Film]) error {
+	return status.Error(codes.Unimplemented, "method ListFilms not implemented")
+}
+func (UnimplementedVideoStoreServer) mustEmbedUnimplementedVideoStoreServer() {}
+func (UnimplementedVideoStoreServer) testEmbeddedByValue()                    {}
+
+// UnsafeVideoStoreServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to VideoStoreServer will
+// result in compilation errors.
+type UnsafeVideoStoreServer interface {
+	mustEmbedUnimplementedVideoStoreServer()
+}
+
+func RegisterVideoStoreServer(s grpc.ServiceRegistrar, srv VideoStoreServer) {
+	// If the following call panics, it indicates UnimplementedVideoStoreServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&VideoStore_ServiceDesc, srv)
+}
+
+func _VideoStore_FindFilm_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindFilmRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoStoreServer).FindFilm(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoStore_FindFilm_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoStoreServer).FindFilm(ctx, req.(*FindFilmRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoStore_AddFilm_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddFilmRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoStoreServer).AddFilm(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoStore_AddFilm_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoStoreServer).AddFilm(ctx, req.(*AddFilmRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoStore_Invoice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InvoiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoStoreServer).Invoice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoStore_Invoice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoStoreServer).Invoice(ctx, req.(*InvoiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoStore_ListFilms_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListFilmsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VideoStoreServer).ListFilms(m, &grpc.GenericServerStream[ListFilmsRequest, Film]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoStore_ListFilmsServer = grpc.ServerStreamingServer[Film]
+
+// VideoStore_ServiceDesc is the grpc.ServiceDesc for VideoStore service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var VideoStore_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "videostore.v1.VideoStore",
+	HandlerType: (*VideoStoreServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "FindFilm",
+			Handler:    _VideoStore_FindFilm_Handler,
+		},
+		{
+			MethodName: "AddFilm",
+			Handler:    _VideoStore_AddFilm_Handler,
+		},
+		{
+			MethodName: "Invoice",
+			Handler:    _VideoStore_Invoice_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListFilms",
+			Handler:       _VideoStore_ListFilms_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "videostore.proto",
+}
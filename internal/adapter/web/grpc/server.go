@@ -0,0 +1,156 @@
+// Package grpc exposes the store over gRPC, the protobuf definitions live in pb
+package grpc
+
+//go:generate protoc -I pb --go_out=pb --go_opt=paths=source_relative --go-grpc_out=pb --go-grpc_opt=paths=source_relative videostore.proto
+
+import (
+	"context"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/auth"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/grpc/pb"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type (
+	server struct {
+		pb.UnimplementedVideoStoreServer
+		finder   driven.FilmFinder
+		appender driven.FilmAppender
+		invoicer driven.FilmInvoicer
+		lister   driven.FilmLister
+		// authenticator is nil when calls are not authenticated
+		authenticator auth.Authenticator
+	}
+
+	Option func(s *server)
+)
+
+func New(finder driven.FilmFinder, appender driven.FilmAppender, invoicer driven.FilmInvoicer, options ...Option) *server {
+	s := &server{
+		finder:   finder,
+		appender: appender,
+		invoicer: invoicer,
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// WithLister serves ListFilms, it is left unimplemented otherwise
+func WithLister(lister driven.FilmLister) Option {
+	return func(s *server) {
+		s.lister = lister
+	}
+}
+
+// Server returns a grpc.Server with the video store registered on it, every call is authorized before it is served
+func (s *server) Server(options ...grpc.ServerOption) *grpc.Server {
+	options = append(options, grpc.ChainUnaryInterceptor(s.unaryAuth), grpc.ChainStreamInterceptor(s.streamAuth))
+	grpcServer := grpc.NewServer(options...)
+	pb.RegisterVideoStoreServer(grpcServer, s)
+	return grpcServer
+}
+
+func (s *server) FindFilm(ctx context.Context, req *pb.FindFilmRequest) (*pb.Film, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+
+	film, err := s.finder.Find(ctx, req.GetName())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toFilm(*film), nil
+}
+
+func (s *server) AddFilm(ctx context.Context, req *pb.AddFilmRequest) (*pb.AddFilmResponse, error) {
+	if req.GetName() == "" || req.GetDirector() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name and director are required")
+	}
+
+	var fx func(ctx context.Context, name string, director string) error
+	switch req.GetRelease() {
+	case pb.Release_RELEASE_NEW:
+		fx = s.appender.AddNew
+	case pb.Release_RELEASE_REGULAR:
+		fx = s.appender.AddRegular
+	case pb.Release_RELEASE_OLD:
+		fx = s.appender.AddOld
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "release %v is not supported", req.GetRelease())
+	}
+
+	if err := fx(ctx, req.GetName(), req.GetDirector()); err != nil {
+		return nil, statusError(err)
+	}
+	return &pb.AddFilmResponse{
+		Film: &pb.Film{Name: req.GetName(), Director: req.GetDirector(), Release: req.GetRelease()},
+	}, nil
+}
+
+func (s *server) Invoice(ctx context.Context, req *pb.InvoiceRequest) (*pb.InvoiceResponse, error) {
+	if len(req.GetReturns()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one return is required")
+	}
+
+	var returns []driven.FilmReturn
+	for _, r := range req.GetReturns() {
+		if r.GetFilmName() == "" || r.GetDays() == 0 || r.GetDays() > 0xFFFF {
+			return nil, status.Errorf(codes.InvalidArgument, "return of %q for %d days is not valid", r.GetFilmName(), r.GetDays())
+		}
+		returns = append(returns, driven.FilmReturn{FilmName: r.GetFilmName(), Days: uint16(r.GetDays())})
+	}
+
+	invoice, err := s.invoicer.Invoice(ctx, returns)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	res := &pb.InvoiceResponse{Cost: uint64(invoice.Cost)}
+	for _, rental := range invoice.Rentals {
+		res.Rentals = append(res.Rentals, &pb.Rental{Film: toFilm(rental.Film), Days: uint32(rental.Days)})
+	}
+	return res, nil
+}
+
+func (s *server) ListFilms(req *pb.ListFilmsRequest, stream grpc.ServerStreamingServer[pb.Film]) error {
+	if s.lister == nil {
+		return status.Error(codes.Unimplemented, "listing the catalogue is not supported")
+	}
+
+	films, err := s.lister.List(stream.Context())
+	if err != nil {
+		return statusError(err)
+	}
+
+	for _, film := range films {
+		if err := stream.Send(toFilm(film)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toFilm(film domain.Film) *pb.Film {
+	return &pb.Film{
+		Name:     film.Name,
+		Director: film.Director,
+		Release:  toRelease(film),
+	}
+}
+
+func toRelease(film domain.Film) pb.Release {
+	switch film.Release {
+	case domain.New:
+		return pb.Release_RELEASE_NEW
+	case domain.Regular:
+		return pb.Release_RELEASE_REGULAR
+	case domain.Old:
+		return pb.Release_RELEASE_OLD
+	}
+	return pb.Release_RELEASE_UNSPECIFIED
+}
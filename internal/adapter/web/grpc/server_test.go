@@ -0,0 +1,218 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/grpc/pb"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io"
+	"net"
+	"testing"
+)
+
+var films = []domain.Film{
+	{Name: "Loki", Director: "Marvel", Release: domain.New},
+	{Name: "Spider Man", Director: "Dwight", Release: domain.Regular},
+	{Name: "Out of Africa", Director: "Dwight", Release: domain.Old},
+}
+
+type spyStore struct {
+	added   []domain.Film
+	addErr  error
+	returns []driven.FilmReturn
+	store   domain.StoreID
+}
+
+func (s *spyStore) Find(ctx context.Context, name string) (*domain.Film, error) {
+	for _, film := range films {
+		if film.Name == name {
+			return &film, nil
+		}
+	}
+	return nil, &driven.FilmNotFoundError{Name: name}
+}
+
+func (s *spyStore) List(ctx context.Context) ([]domain.Film, error) {
+	return films, nil
+}
+
+func (s *spyStore) AddNew(ctx context.Context, name string, director string) error {
+	return s.add(domain.Film{Name: name, Director: director, Release: domain.New})
+}
+
+func (s *spyStore) AddRegular(ctx context.Context, name string, director string) error {
+	return s.add(domain.Film{Name: name, Director: director, Release: domain.Regular})
+}
+
+func (s *spyStore) AddOld(ctx context.Context, name string, director string) error {
+	return s.add(domain.Film{Name: name, Director: director, Release: domain.Old})
+}
+
+func (s *spyStore) add(film domain.Film) error {
+	s.added = append(s.added, film)
+	return s.addErr
+}
+
+func (s *spyStore) Invoice(ctx context.Context, request []driven.FilmReturn) (*domain.RentalInvoice, error) {
+	s.returns = request
+	s.store = driven.StoreFrom(ctx)
+	var req domain.RentalReturn
+	for _, r := range request {
+		film, err := s.Find(ctx, r.FilmName)
+		if err != nil {
+			return nil, &driven.InvalidRentalRequestError{err}
+		}
+		req.AddRental(*film, domain.Days(r.Days))
+	}
+	invoice, _ := req.Invoice()
+	return &invoice, nil
+}
+
+func newClient(t *testing.T, store *spyStore, options ...Option) pb.VideoStoreClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := New(store, store, store, options...).Server()
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewVideoStoreClient(conn)
+}
+
+func assertCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	if status.Code(err) != code {
+		t.Errorf("was expecting status %v but got %v", code, err)
+	}
+}
+
+func TestServer_FindFilm(t *testing.T) {
+	client := newClient(t, &spyStore{})
+
+	film, err := client.FindFilm(context.Background(), &pb.FindFilmRequest{Name: "Spider Man"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if film.GetDirector() != "Dwight" || film.GetRelease() != pb.Release_RELEASE_REGULAR {
+		t.Errorf("received unexpected film %v", film)
+	}
+
+	_, err = client.FindFilm(context.Background(), &pb.FindFilmRequest{Name: "Unknown"})
+	assertCode(t, err, codes.NotFound)
+
+	_, err = client.FindFilm(context.Background(), &pb.FindFilmRequest{})
+	assertCode(t, err, codes.InvalidArgument)
+}
+
+func TestServer_AddFilm(t *testing.T) {
+	tests := []struct {
+		release pb.Release
+		added   domain.Film
+	}{
+		{pb.Release_RELEASE_NEW, domain.Film{Name: "Loki", Director: "Marvel", Release: domain.New}},
+		{pb.Release_RELEASE_REGULAR, domain.Film{Name: "Loki", Director: "Marvel", Release: domain.Regular}},
+		{pb.Release_RELEASE_OLD, domain.Film{Name: "Loki", Director: "Marvel", Release: domain.Old}},
+	}
+	for _, test := range tests {
+		t.Run(test.release.String(), func(t *testing.T) {
+			store := &spyStore{}
+			res, err := newClient(t, store).AddFilm(context.Background(), &pb.AddFilmRequest{Name: "Loki", Director: "Marvel", Release: test.release})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(store.added) != 1 || store.added[0] != test.added || res.GetFilm().GetRelease() != test.release {
+				t.Errorf("was expecting %#v to be added but got %#v", test.added, store.added)
+			}
+		})
+	}
+}
+
+func TestServer_AddFilmErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		request *pb.AddFilmRequest
+		addErr  error
+		code    codes.Code
+	}{
+		{"AlreadyExists", &pb.AddFilmRequest{Name: "Loki", Director: "Marvel", Release: pb.Release_RELEASE_NEW}, &driven.FilmAlreadyExistError{Name: "Loki"}, codes.AlreadyExists},
+		{"InvalidFilm", &pb.AddFilmRequest{Name: "Loki", Director: "Marvel", Release: pb.Release_RELEASE_NEW}, &domain.InvalidFilmError{domain.EmptyFilmNameError}, codes.InvalidArgument},
+		{"Internal", &pb.AddFilmRequest{Name: "Loki", Director: "Marvel", Release: pb.Release_RELEASE_NEW}, errors.New("disk on fire"), codes.Internal},
+		{"MissingRelease", &pb.AddFilmRequest{Name: "Loki", Director: "Marvel"}, nil, codes.InvalidArgument},
+		{"MissingDirector", &pb.AddFilmRequest{Name: "Loki", Release: pb.Release_RELEASE_NEW}, nil, codes.InvalidArgument},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := newClient(t, &spyStore{addErr: test.addErr}).AddFilm(context.Background(), test.request)
+			assertCode(t, err, test.code)
+			if test.code == codes.Internal && status.Convert(err).Message() != "request could not be processed" {
+				t.Errorf("was expecting the cause not to leak but got %q", status.Convert(err).Message())
+			}
+		})
+	}
+}
+
+func TestServer_Invoice(t *testing.T) {
+	store := &spyStore{}
+	client := newClient(t, store)
+
+	res, err := client.Invoice(context.Background(), &pb.InvoiceRequest{Returns: []*pb.FilmReturn{
+		{FilmName: "Loki", Days: 2},
+		{FilmName: "Out of Africa", Days: 7},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.GetCost() != 170 || len(res.GetRentals()) != 2 || res.GetRentals()[1].GetDays() != 7 {
+		t.Errorf("received unexpected invoice %v", res)
+	}
+
+	_, err = client.Invoice(context.Background(), &pb.InvoiceRequest{Returns: []*pb.FilmReturn{{FilmName: "Unknown", Days: 1}}})
+	assertCode(t, err, codes.InvalidArgument)
+
+	_, err = client.Invoice(context.Background(), &pb.InvoiceRequest{Returns: []*pb.FilmReturn{{FilmName: "Loki"}}})
+	assertCode(t, err, codes.InvalidArgument)
+}
+
+func TestServer_ListFilms(t *testing.T) {
+	store := &spyStore{}
+	stream, err := newClient(t, store, WithLister(store)).ListFilms(context.Background(), &pb.ListFilmsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var received []string
+	for {
+		film, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		received = append(received, film.GetName())
+	}
+
+	if len(received) != len(films) || received[0] != films[0].Name {
+		t.Errorf("was expecting every film to be streamed but got %v", received)
+	}
+}
+
+func TestServer_ListFilmsWithoutLister(t *testing.T) {
+	stream, err := newClient(t, &spyStore{}).ListFilms(context.Background(), &pb.ListFilmsRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	assertCode(t, err, codes.Unimplemented)
+}
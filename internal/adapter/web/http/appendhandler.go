@@ -1,10 +1,12 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
+	"github.com/shawnritchie/go-video-store/api"
 	"github.com/shawnritchie/go-video-store/internal/domain"
 	"github.com/shawnritchie/go-video-store/internal/port/driven"
 	"io/ioutil"
@@ -12,22 +14,10 @@ import (
 )
 
 type (
-	appendRequest struct {
-		Name     string `json:"name"`
-		Director string `json:"director"`
-	}
-
-	appendResponse struct {
-		Name     string `json:"name"`
-		Director string `json:"director"`
-		Release  string `json:"release"`
-	}
+	appendRequest  = api.AddFilmRequest
+	appendResponse = api.Film
 )
 
-func (ap *appendRequest) validate() bool {
-	return ap.Name == "" || ap.Director == ""
-}
-
 func (s *server) addNewFilm(w http.ResponseWriter, r *http.Request) {
 	ct := r.Header.Get("Content-Type")
 	if ct != contentType {
@@ -44,13 +34,13 @@ func (s *server) addNewFilm(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var film appendRequest
-	if err := json.Unmarshal(reqBody, &film); err != nil || film.validate() {
+	if err := json.Unmarshal(reqBody, &film); err != nil || !film.IsValid() {
 		//Handle Corrupted Payload
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
-	if err := s.appender.AddNew(film.Name, film.Director); err != nil {
+	if err := s.appender.AddNew(r.Context(), film.Name, film.Director); err != nil {
 		switch {
 		case errors.As(err, &driven.TypeFilmAlreadyExist):
 			w.WriteHeader(http.StatusConflict)
@@ -70,18 +60,12 @@ func (s *server) addNewFilm(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *server) addRegularFilm(w http.ResponseWriter, r *http.Request) error {
-	defer r.Body.Close()
-	reqBody, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		return fmt.Errorf("request body read error : %w", err)
-	}
-
 	var film appendRequest
-	if err := json.Unmarshal(reqBody, &film); err != nil || film.validate() {
+	if err := decode(r, &film); err != nil || !film.IsValid() {
 		return NewClientError(err, http.StatusBadRequest, "Bad Request: Post payload cannot be deserialized")
 	}
 
-	if err := s.appender.AddRegular(film.Name, film.Director); err != nil {
+	if err := s.appender.AddRegular(r.Context(), film.Name, film.Director); err != nil {
 		switch {
 		case errors.As(err, &driven.TypeFilmAlreadyExist):
 			return NewClientError(err, http.StatusConflict, "Status Conflict: Film Already Exist. Name must be unique!")
@@ -90,31 +74,23 @@ func (s *server) addRegularFilm(w http.ResponseWriter, r *http.Request) error {
 		}
 	}
 
-	setHeaders(w)
-	json.NewEncoder(w).Encode(appendResponse{
+	return respond(w, r, appendResponse{
 		Name:     film.Name,
 		Director: film.Director,
 		Release:  string(domain.Regular),
 	})
-	return nil
 }
 
 func (s *server) addFilm(w http.ResponseWriter, r *http.Request) error {
-	defer r.Body.Close()
-	reqBody, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		return fmt.Errorf("request body read error : %w", err)
-	}
-
 	var film appendRequest
-	if err := json.Unmarshal(reqBody, &film); err != nil || film.validate() {
+	if err := decode(r, &film); err != nil || !film.IsValid() {
 		return NewClientError(err, http.StatusBadRequest, "Bad Request: Post payload cannot be deserialized")
 	}
 
 	pathParams := mux.Vars(r)
 	strRelease, ok := pathParams["release"]
 	if !ok {
-		return NewClientError(err, http.StatusBadRequest, "Bad Request: missing release type within request. example: \"/catalogue/film/[new,regular,old]\"")
+		return NewClientError(nil, http.StatusBadRequest, "Bad Request: missing release type within request. example: \"/catalogue/film/[new,regular,old]\"")
 	}
 
 	release, err := domain.ParseRelease(strRelease)
@@ -122,7 +98,7 @@ func (s *server) addFilm(w http.ResponseWriter, r *http.Request) error {
 		return NewClientError(err, http.StatusBadRequest, "Bad Request: supported release types \"[new,regular,old]\"")
 	}
 
-	var fx func(name string, director string) error
+	var fx func(ctx context.Context, name string, director string) error
 
 	switch release {
 	case domain.New:
@@ -133,20 +109,39 @@ func (s *server) addFilm(w http.ResponseWriter, r *http.Request) error {
 		fx = s.appender.AddOld
 	}
 
-	if err := fx(film.Name, film.Director); err != nil {
+	if err := fx(r.Context(), film.Name, film.Director); err != nil {
+		var invalid *domain.InvalidFilmError
 		switch {
 		case errors.As(err, &driven.TypeFilmAlreadyExist):
 			return NewClientError(err, http.StatusConflict, "Status Conflict: Film Already Exist. Name must be unique!")
+		case errors.As(err, &invalid):
+			return NewValidationError(err, "Bad Request: film cannot be catalogued", filmErrors(*invalid))
 		default:
 			return fmt.Errorf("unable to add film: %w", err)
 		}
 	}
 
-	setHeaders(w)
-	json.NewEncoder(w).Encode(appendResponse{
+	return respond(w, r, appendResponse{
 		Name:     film.Name,
 		Director: film.Director,
 		Release:  string(release),
 	})
-	return nil
+}
+
+// filmErrors names the field of the film every error was raised for
+func filmErrors(invalid domain.InvalidFilmError) []FieldError {
+	fields := make([]FieldError, 0, len(invalid))
+	for _, err := range invalid {
+		var field string
+		switch err {
+		case domain.EmptyFilmNameError:
+			field = "name"
+		case domain.EmptyFilmDirectorError:
+			field = "director"
+		case domain.UnknownReleaseError:
+			field = "release"
+		}
+		fields = append(fields, FieldError{Field: field, Message: err.Error()})
+	}
+	return fields
 }
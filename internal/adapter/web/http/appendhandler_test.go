@@ -1,6 +1,8 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"github.com/gorilla/mux"
 	"github.com/shawnritchie/go-video-store/internal/domain"
 	"github.com/shawnritchie/go-video-store/internal/port/driven"
@@ -25,15 +27,15 @@ func (s *spyFilmAppender) invoke(name string, director string) error {
 	return s.throw
 }
 
-func (s *spyFilmAppender) AddNew(name string, director string) error {
+func (s *spyFilmAppender) AddNew(ctx context.Context, name string, director string) error {
 	return s.invoke(name, director)
 }
 
-func (s *spyFilmAppender) AddRegular(name string, director string) error {
+func (s *spyFilmAppender) AddRegular(ctx context.Context, name string, director string) error {
 	return s.invoke(name, director)
 }
 
-func (s *spyFilmAppender) AddOld(name string, director string) error {
+func (s *spyFilmAppender) AddOld(ctx context.Context, name string, director string) error {
 	return s.invoke(name, director)
 }
 
@@ -158,3 +160,21 @@ func TestAddFilm_AlreadyExists(t *testing.T) {
 		t.Errorf("got status %d but wanted %d", status, http.StatusConflict)
 	}
 }
+
+func TestAddFilm_InvalidFilm(t *testing.T) {
+	spyAppender := newSpyFilmAppender(&domain.InvalidFilmError{domain.EmptyFilmDirectorError, domain.UnknownReleaseError})
+	server := New(nil, spyAppender, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/catalogue/film/regular", toJSON(appendRequest{Name: FilmName, Director: FilmDirector}))
+	req = mux.SetURLVars(req, map[string]string{"release": string(domain.Regular)})
+
+	err := server.addFilm(httptest.NewRecorder(), req)
+
+	var problem *Error
+	switch {
+	case !errors.As(err, &problem) || problem.Status != http.StatusBadRequest:
+		t.Fatalf("was expecting a bad request but got %#v", err)
+	case len(problem.Errors) != 2 || problem.Errors[0].Field != "director" || problem.Errors[1].Field != "release":
+		t.Errorf("was expecting the director and release to be listed but got %#v", problem.Errors)
+	}
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"fmt"
+	"github.com/shawnritchie/go-video-store/api"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"net/http"
+	"time"
+)
+
+type (
+	auditEntry    = api.AuditEntry
+	auditResponse = api.AuditResponse
+)
+
+func (s *server) findAuditEntries(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	auditQuery := domain.AuditQuery{Entity: query.Get("entity")}
+
+	var err error
+	if auditQuery.From, err = parseTime(query.Get("from")); err != nil {
+		return NewClientError(err, http.StatusBadRequest, "Bad Request: query parameter \"from\" must be an RFC 3339 timestamp")
+	}
+	if auditQuery.To, err = parseTime(query.Get("to")); err != nil {
+		return NewClientError(err, http.StatusBadRequest, "Bad Request: query parameter \"to\" must be an RFC 3339 timestamp")
+	}
+
+	entries, err := s.auditTrail.AuditTrail(r.Context(), auditQuery)
+	if err != nil {
+		return fmt.Errorf("unable to query audit trail: %w", err)
+	}
+
+	response := auditResponse{Entries: []auditEntry{}}
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, auditEntry{
+			Sequence:  entry.Sequence,
+			Timestamp: entry.Timestamp,
+			Actor:     entry.Actor,
+			Operation: entry.Operation,
+			Entity:    entry.Entity,
+			Inputs:    entry.Inputs,
+			Outcome:   entry.Outcome,
+			Error:     entry.Error,
+			Hash:      entry.Hash,
+		})
+	}
+
+	return respond(w, r, response)
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"context"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type spyAuditTrail struct {
+	queries []domain.AuditQuery
+	entries []domain.AuditEntry
+}
+
+func (s *spyAuditTrail) AuditTrail(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEntry, error) {
+	s.queries = append(s.queries, query)
+	return s.entries, nil
+}
+
+func TestAudit_Query(t *testing.T) {
+	recorded := time.Date(2021, 6, 9, 12, 0, 0, 0, time.UTC)
+	spy := &spyAuditTrail{entries: []domain.AuditEntry{
+		{Sequence: 1, Timestamp: recorded, Actor: "clerk", Operation: "AddFilm", Entity: "film:Loki", Outcome: domain.OutcomeSuccess},
+	}}
+	server := New(nil, nil, nil, WithAuditTrail(spy))
+
+	req, err := http.NewRequest(http.MethodGet, "/audit?entity=film:Loki&from=2021-06-01T00:00:00Z&to=2021-07-01T00:00:00Z", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	res := httptest.NewRecorder()
+	server.Router().ServeHTTP(res, req)
+
+	var auditRes auditResponse
+	unmarshalBody(t, res, &auditRes)
+
+	switch {
+	case res.Code != http.StatusOK:
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusOK)
+	case len(spy.queries) != 1:
+		t.Fatalf("was expecting a single query of the audit trail")
+	case spy.queries[0].Entity != "film:Loki" || spy.queries[0].From.Month() != time.June || spy.queries[0].To.Month() != time.July:
+		t.Errorf("unexpected query %#v", spy.queries[0])
+	case len(auditRes.Entries) != 1 || auditRes.Entries[0].Actor != "clerk" || !auditRes.Entries[0].Timestamp.Equal(recorded):
+		t.Errorf("received unexpected response %#v", auditRes)
+	}
+}
+
+func TestAudit_InvalidTimestamp(t *testing.T) {
+	server := New(nil, nil, nil, WithAuditTrail(&spyAuditTrail{}))
+
+	req, err := http.NewRequest(http.MethodGet, "/audit?from=yesterday", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = server.findAuditEntries(httptest.NewRecorder(), req)
+
+	clientError, ok := err.(ClientError)
+	if !ok {
+		t.Fatalf("expected Client error but got %#v", err)
+	}
+	if status, _ := clientError.ResponseHeaders(); status != http.StatusBadRequest {
+		t.Errorf("got status %d but wanted %d", status, http.StatusBadRequest)
+	}
+}
+
+func TestAudit_NotRoutedWithoutTrail(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "/audit", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := httptest.NewRecorder()
+	New(nil, nil, nil).Router().ServeHTTP(res, req)
+
+	if res.Code != http.StatusNotFound {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusNotFound)
+	}
+}
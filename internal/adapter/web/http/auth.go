@@ -0,0 +1,61 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/auth"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"net/http"
+)
+
+// WithAuthenticator requires every route but /openapi.json to be called by a principal holding the role the
+// route is mapped to, the principal is recorded as the actor of every service call it makes
+func WithAuthenticator(authenticator auth.Authenticator) Option {
+	return func(s *server) {
+		s.authenticator = authenticator
+	}
+}
+
+// authorized lets every request through when no authenticator has been configured, the rate limit is taken once
+// the principal is known
+func (s *server) authorized(role auth.Role, next http.Handler) http.Handler {
+	if s.authenticator == nil {
+		return s.limited(next)
+	}
+	return Authorized(s.authenticator, role, s.limited(next))
+}
+
+// Authorized guards handlers which are served next to the router, such as the graphql endpoint, with the same
+// authentication as the routes
+func Authorized(authenticator auth.Authenticator, role auth.Role, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := auth.Authorize(authenticator, r, role)
+		if err != nil {
+			r = withRequestID(w, r)
+			writeError(w, r, authError(w, err))
+			return
+		}
+
+		ctx := auth.WithPrincipal(driven.WithActor(r.Context(), principal.Subject), *principal)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func authError(w http.ResponseWriter, err error) error {
+	var invalid *auth.InvalidCredentialsError
+	var forbidden *auth.ForbiddenError
+	switch {
+	case errors.Is(err, auth.MissingCredentialsError):
+		w.Header().Set("WWW-Authenticate", `Bearer realm="videostore"`)
+		return NewClientError(err, http.StatusUnauthorized,
+			fmt.Sprintf("Unauthorized: present an API key through %s or a bearer token", auth.APIKeyHeader))
+	case errors.As(err, &invalid):
+		w.Header().Set("WWW-Authenticate", `Bearer realm="videostore", error="invalid_token"`)
+		return NewClientError(err, http.StatusUnauthorized, "Unauthorized: "+invalid.Error())
+	case errors.As(err, &forbidden) && forbidden.Store != "":
+		return NewClientError(err, http.StatusForbidden, fmt.Sprintf("Forbidden: not allowed to act for store %q", forbidden.Store))
+	case errors.As(err, &forbidden):
+		return NewClientError(err, http.StatusForbidden, fmt.Sprintf("Forbidden: the %s role is required", forbidden.Required))
+	}
+	return err
+}
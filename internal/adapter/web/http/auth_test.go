@@ -0,0 +1,85 @@
+package http
+
+import (
+	"context"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/auth"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type actorRecordingAppender struct {
+	spyFilmAppender
+	actors []string
+}
+
+func (a *actorRecordingAppender) AddNew(ctx context.Context, name string, director string) error {
+	a.actors = append(a.actors, driven.ActorFrom(ctx))
+	return nil
+}
+
+func TestAuthorization(t *testing.T) {
+	keys, err := auth.NewAPIKeys([]auth.APIKey{
+		{Hash: auth.HashAPIKey("kiosk"), Subject: "kiosk-1", Role: "clerk"},
+		{Hash: auth.HashAPIKey("office"), Subject: "alice", Role: "manager"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	finder := newSpyFilmFinder(func() (*domain.Film, error) {
+		return &domain.Film{Name: FilmName, Director: FilmDirector, Release: domain.New}, nil
+	})
+	appender := &actorRecordingAppender{}
+	router := New(finder, appender, NewSpyFilmInvoicer(20, nil), WithAuthenticator(keys)).Router()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		status int
+	}{
+		{"Anonymous", http.MethodGet, "/catalogue/film?name=Loki", "", http.StatusUnauthorized},
+		{"UnknownKey", http.MethodGet, "/catalogue/film?name=Loki", "guessed", http.StatusUnauthorized},
+		{"ClerkFinds", http.MethodGet, "/catalogue/film?name=Loki", "kiosk", http.StatusOK},
+		{"ClerkReturns", http.MethodPost, "/store/return", "kiosk", http.StatusOK},
+		{"ClerkAdds", http.MethodPost, "/catalogue/film/new", "kiosk", http.StatusForbidden},
+		{"ManagerAdds", http.MethodPost, "/catalogue/film/new", "office", http.StatusOK},
+		{"ManagerReturns", http.MethodPost, "/store/return", "office", http.StatusOK},
+		{"PublicDocument", http.MethodGet, "/openapi.json", "", http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var body string
+			switch test.path {
+			case "/store/return":
+				body = `{"return":[{"name":"Loki","days":1}]}`
+			case "/catalogue/film/new":
+				body = `{"name":"Loki","director":"Marvel"}`
+			}
+			req := httptest.NewRequest(test.method, test.path, strings.NewReader(body))
+			if body != "" {
+				req.Header.Set("Content-Type", contentType)
+			}
+			if test.key != "" {
+				req.Header.Set(auth.APIKeyHeader, test.key)
+			}
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			if res.Code != test.status {
+				t.Errorf("was expecting %d but got %d %s", test.status, res.Code, res.Body.String())
+			}
+			if res.Code == http.StatusUnauthorized && res.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("was expecting the client to be told how to authenticate")
+			}
+		})
+	}
+
+	if len(appender.actors) != 1 || appender.actors[0] != "alice" {
+		t.Errorf("was expecting the manager to be recorded as the actor but got %v", appender.actors)
+	}
+}
@@ -0,0 +1,280 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type (
+	// Codec encodes responses to and decodes requests from a single media type, codecs are registered through
+	// WithCodec and picked through the Accept and Content-Type headers of every request
+	Codec interface {
+		// MediaType is the media type without its parameters, such as text/csv
+		MediaType() string
+		// Encode returns UnrepresentableError when v has no representation in the media type
+		Encode(w io.Writer, v interface{}) error
+		Decode(r io.Reader, v interface{}) error
+	}
+
+	jsonCodec struct{}
+	xmlCodec  struct{}
+	csvCodec  struct{}
+
+	csvMarshaler interface {
+		MarshalCSV() [][]string
+	}
+
+	csvUnmarshaler interface {
+		UnmarshalCSV(records [][]string) error
+	}
+
+	codecsKey struct{}
+)
+
+var (
+	UnrepresentableError = fmt.Errorf("value cannot be represented in the media type")
+
+	defaultCodecs = []Codec{jsonCodec{}, csvCodec{}, xmlCodec{}}
+)
+
+func (jsonCodec) MediaType() string {
+	return "application/json"
+}
+
+func (jsonCodec) Encode(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (jsonCodec) Decode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+func (xmlCodec) MediaType() string {
+	return "application/xml"
+}
+
+func (xmlCodec) Encode(w io.Writer, v interface{}) error {
+	err := xml.NewEncoder(w).Encode(v)
+	var unsupported *xml.UnsupportedTypeError
+	if errors.As(err, &unsupported) {
+		return fmt.Errorf("%w: %v", UnrepresentableError, err)
+	}
+	return err
+}
+
+func (xmlCodec) Decode(r io.Reader, v interface{}) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+func (csvCodec) MediaType() string {
+	return "text/csv"
+}
+
+func (csvCodec) Encode(w io.Writer, v interface{}) error {
+	marshaler, ok := v.(csvMarshaler)
+	if !ok {
+		return fmt.Errorf("%w: %T has no csv representation", UnrepresentableError, v)
+	}
+	return csv.NewWriter(w).WriteAll(marshaler.MarshalCSV())
+}
+
+func (csvCodec) Decode(r io.Reader, v interface{}) error {
+	unmarshaler, ok := v.(csvUnmarshaler)
+	if !ok {
+		return fmt.Errorf("%w: %T has no csv representation", UnrepresentableError, v)
+	}
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return err
+	}
+	return unmarshaler.UnmarshalCSV(records)
+}
+
+// withCodecs makes the codecs of the server available to handler, requests which bypass it fall back onto
+// the default codecs
+func (s *server) withCodecs(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), codecsKey{}, s.codecs)))
+	})
+}
+
+func codecsOf(r *http.Request) []Codec {
+	if codecs, ok := r.Context().Value(codecsKey{}).([]Codec); ok {
+		return codecs
+	}
+	return defaultCodecs
+}
+
+// requestCodec returns the codec of the request body, a body without a Content-Type is taken to be JSON. Only
+// UTF-8 bodies are accepted whatever the media type
+func requestCodec(r *http.Request) (Codec, error) {
+	header := r.Header.Get("Content-Type")
+	if header == "" {
+		return jsonCodec{}, nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header)
+	if err != nil {
+		return nil, NewClientError(err, http.StatusUnsupportedMediaType, fmt.Sprintf("Unsupported Media Type: Content-Type %q cannot be parsed", header))
+	}
+	if charset, ok := params["charset"]; ok && !strings.EqualFold(charset, "utf-8") {
+		return nil, NewClientError(nil, http.StatusUnsupportedMediaType, fmt.Sprintf("Unsupported Media Type: charset %q is not supported, use utf-8", charset))
+	}
+	for _, codec := range codecsOf(r) {
+		if codec.MediaType() == mediaType {
+			return codec, nil
+		}
+	}
+	return nil, NewClientError(nil, http.StatusUnsupportedMediaType,
+		fmt.Sprintf("Unsupported Media Type: Content-Type must be one of %s", strings.Join(mediaTypes(codecsOf(r)), ", ")))
+}
+
+// hasBody reports whether the request carries a body, a body of unknown length is assumed to be present
+func hasBody(r *http.Request) bool {
+	return r.ContentLength > 0 || (r.ContentLength < 0 && r.Body != nil && r.Body != http.NoBody)
+}
+
+// decode reads the request body into v through the codec of its Content-Type
+func decode(r *http.Request, v interface{}) error {
+	codec, err := requestCodec(r)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	span := traceDecoding(r)
+	defer span.End()
+	return codec.Decode(r.Body, v)
+}
+
+// acceptable orders the codecs by the preference the Accept header gives them, the codecs the client does not
+// accept are left out. Ties keep the order the codecs were registered in so JSON is served by default
+func acceptable(r *http.Request) []Codec {
+	codecs := codecsOf(r)
+	header := r.Header.Get("Accept")
+	if strings.TrimSpace(header) == "" {
+		return codecs
+	}
+
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType, q})
+	}
+
+	// the most specific range matching a codec decides its preference
+	quality := func(mediaType string) float64 {
+		q, specificity := 0.0, -1
+		for _, accepted := range ranges {
+			s := -1
+			switch {
+			case accepted.mediaType == mediaType:
+				s = 2
+			case strings.HasSuffix(accepted.mediaType, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(accepted.mediaType, "*")):
+				s = 1
+			case accepted.mediaType == "*/*":
+				s = 0
+			}
+			if s > specificity {
+				q, specificity = accepted.q, s
+			}
+		}
+		return q
+	}
+
+	type candidate struct {
+		codec Codec
+		q     float64
+	}
+	var candidates []candidate
+	for _, codec := range codecs {
+		if q := quality(codec.MediaType()); q > 0 {
+			candidates = append(candidates, candidate{codec, q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+
+	accepted := make([]Codec, 0, len(candidates))
+	for _, candidate := range candidates {
+		accepted = append(accepted, candidate.codec)
+	}
+	return accepted
+}
+
+func notAcceptable(r *http.Request) error {
+	return NewClientError(nil, http.StatusNotAcceptable,
+		fmt.Sprintf("Not Acceptable: the response can be served as %s", strings.Join(mediaTypes(codecsOf(r)), ", ")))
+}
+
+// respond encodes v through the most preferred codec able to represent it
+func respond(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	codec, body, err := negotiate(r, v)
+	if err != nil {
+		return err
+	}
+	return write(w, codec, body)
+}
+
+// negotiate encodes v through the most preferred codec able to represent it without writing it, so headers which
+// depend on the representation can be set first
+func negotiate(r *http.Request, v interface{}) (Codec, *bytes.Buffer, error) {
+	for _, codec := range acceptable(r) {
+		var body bytes.Buffer
+		err := codec.Encode(&body, v)
+		if errors.Is(err, UnrepresentableError) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to encode response as %s: %w", codec.MediaType(), err)
+		}
+		return codec, &body, nil
+	}
+	return nil, nil, notAcceptable(r)
+}
+
+// write serves body as negotiated through codec
+func write(w http.ResponseWriter, codec Codec, body *bytes.Buffer) error {
+	contentType := codec.MediaType()
+	if strings.HasPrefix(contentType, "text/") {
+		contentType += "; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Vary", "Accept")
+	_, err := body.WriteTo(w)
+	return err
+}
+
+func mediaTypes(codecs []Codec) []string {
+	types := make([]string, 0, len(codecs))
+	for _, codec := range codecs {
+		types = append(types, codec.MediaType())
+	}
+	return types
+}
@@ -0,0 +1,140 @@
+package http
+
+import (
+	"encoding/xml"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serve(method string, path string, headers map[string]string, body string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	res := httptest.NewRecorder()
+	newSpecServer().Router().ServeHTTP(res, req)
+	return res
+}
+
+func TestCodec_Negotiation(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		status      int
+		contentType string
+	}{
+		{"NoAccept", "", http.StatusOK, "application/json"},
+		{"Anything", "*/*", http.StatusOK, "application/json"},
+		{"CSV", "text/csv", http.StatusOK, "text/csv; charset=utf-8"},
+		{"TextRange", "text/*", http.StatusOK, "text/csv; charset=utf-8"},
+		{"XML", "application/xml", http.StatusOK, "application/xml"},
+		{"Preference", "application/json;q=0.5, application/xml;q=0.9, */*;q=0.1", http.StatusOK, "application/xml"},
+		{"Excluded", "text/csv;q=0, */*", http.StatusOK, "application/json"},
+		{"Unsupported", "image/png", http.StatusNotAcceptable, problemContentType},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := serve(http.MethodGet, "/catalogue/film?name=Loki", map[string]string{"Accept": test.accept}, "")
+			if res.Code != test.status || res.Header().Get("Content-Type") != test.contentType {
+				t.Errorf("was expecting %d %q but got %d %q", test.status, test.contentType, res.Code, res.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestCodec_RequestMediaTypes(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"JSON", "application/json", `{"return":[{"name":"Loki","days":2}]}`, http.StatusOK},
+		{"Charset", "application/json; charset=UTF-8", `{"return":[{"name":"Loki","days":2}]}`, http.StatusOK},
+		{"CSV", "text/csv", "name,days\nLoki,2\n", http.StatusOK},
+		{"XML", "application/xml", "<ReturnRequest><return><name>Loki</name><days>2</days></return></ReturnRequest>", http.StatusOK},
+		{"InvalidCSV", "text/csv", "name\nLoki\n", http.StatusBadRequest},
+		{"Missing", "", `{"return":[{"name":"Loki","days":2}]}`, http.StatusUnsupportedMediaType},
+		{"Unsupported", "text/plain", "Loki 2", http.StatusUnsupportedMediaType},
+		{"UnsupportedCharset", "application/json; charset=latin1", `{"return":[{"name":"Loki","days":2}]}`, http.StatusUnsupportedMediaType},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := serve(http.MethodPost, "/store/return", map[string]string{"Content-Type": test.contentType}, test.body)
+			if res.Code != test.status {
+				t.Errorf("was expecting %d but got %d %s", test.status, res.Code, res.Body.String())
+			}
+		})
+	}
+}
+
+func TestCodec_CSVResponses(t *testing.T) {
+	res := serve(http.MethodPost, "/store/return", map[string]string{"Content-Type": "text/csv", "Accept": "text/csv"}, "name,days\nLoki,2\nThor,1\n")
+	if expected := "name,days,price,currency\nLoki,2,40,SEK\nThor,1,40,SEK\n"; res.Body.String() != expected {
+		t.Errorf("was expecting invoice\n%s\nbut got\n%s", expected, res.Body.String())
+	}
+
+	res = serve(http.MethodGet, "/catalogue/films", map[string]string{"Accept": "text/csv"}, "")
+	if expected := "name,director,release\nLoki,Marvel,New\n"; res.Body.String() != expected {
+		t.Errorf("was expecting catalogue\n%s\nbut got\n%s", expected, res.Body.String())
+	}
+}
+
+func TestCodec_XMLResponse(t *testing.T) {
+	res := serve(http.MethodGet, "/catalogue/film?name=Loki", map[string]string{"Accept": "application/xml"}, "")
+
+	var film findResponse
+	if err := xml.Unmarshal(res.Body.Bytes(), &film); err != nil {
+		t.Fatal(err)
+	}
+	if film != (findResponse{Name: FilmName, Director: FilmDirector, Release: string(domain.New)}) {
+		t.Errorf("received unexpected film %#v", film)
+	}
+}
+
+func TestCodec_UnrepresentableResponse(t *testing.T) {
+	res := serve(http.MethodGet, "/audit", map[string]string{"Accept": "text/csv"}, "")
+	if res.Code != http.StatusNotAcceptable {
+		t.Errorf("was expecting the audit trail to be refused as csv but got %d", res.Code)
+	}
+
+	res = serve(http.MethodGet, "/audit", map[string]string{"Accept": "text/csv, application/json;q=0.5"}, "")
+	if res.Code != http.StatusOK || res.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("was expecting the audit trail to fall back onto json but got %d %q", res.Code, res.Header().Get("Content-Type"))
+	}
+}
+
+type upperCodec struct{ jsonCodec }
+
+func (upperCodec) MediaType() string { return "application/vnd.upper+json" }
+
+func (c upperCodec) Encode(w io.Writer, v interface{}) error {
+	var body strings.Builder
+	if err := c.jsonCodec.Encode(&body, v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, strings.ToUpper(body.String()))
+	return err
+}
+
+func TestCodec_WithCodec(t *testing.T) {
+	finder := newSpyFilmFinder(func() (*domain.Film, error) {
+		return &domain.Film{Name: FilmName, Director: FilmDirector, Release: domain.New}, nil
+	})
+	req := httptest.NewRequest(http.MethodGet, "/catalogue/film?name=Loki", nil)
+	req.Header.Set("Accept", "application/vnd.upper+json")
+	res := httptest.NewRecorder()
+	New(finder, nil, nil, WithCodec(upperCodec{})).Router().ServeHTTP(res, req)
+
+	if !strings.Contains(res.Body.String(), `"NAME":"LOKI"`) {
+		t.Errorf("was expecting the registered codec to encode the response but got %q", res.Body.String())
+	}
+}
@@ -0,0 +1,100 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const keepAliveInterval = 15 * time.Second
+
+// streamEvents pushes the published events as server-sent events. It is served outside of handler since
+// EventSource clients cannot set a Content-Type, a client which is dropped for falling behind reconnects with
+// Last-Event-ID and resumes from the replay buffer
+func (s *server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	r = withRequestID(w, r)
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, r, fmt.Errorf("streaming is not supported by %T", w))
+		return
+	}
+
+	lastID, err := lastEventID(r)
+	if err != nil {
+		writeError(w, r, NewClientError(err, http.StatusBadRequest, "Bad Request: Last-Event-ID must be the id of a previously received event"))
+		return
+	}
+	types := eventTypes(r.URL.Query().Get("types"))
+
+	// when the events following lastID have been evicted the client is told to reset and sent everything retained
+	var expired *driven.EventsExpiredError
+	events, err := s.eventStream.Follow(r.Context(), lastID)
+	reset := errors.As(err, &expired)
+	if reset {
+		events, err = s.eventStream.Follow(r.Context(), 0)
+	}
+	if err != nil {
+		writeError(w, r, fmt.Errorf("unable to follow events: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if reset {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if len(types) > 0 && !types[event.Event.EventType()] {
+				continue
+			}
+
+			data, err := json.Marshal(event.Event)
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Event.EventType(), data)
+		}
+		flusher.Flush()
+	}
+}
+
+func lastEventID(r *http.Request) (uint64, error) {
+	id := r.Header.Get("Last-Event-ID")
+	if id == "" {
+		id = r.URL.Query().Get("lastEventId")
+	}
+	if id == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(id, 10, 64)
+}
+
+func eventTypes(param string) map[string]bool {
+	types := map[string]bool{}
+	for _, t := range strings.Split(param, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types[t] = true
+		}
+	}
+	return types
+}
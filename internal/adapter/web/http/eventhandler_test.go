@@ -0,0 +1,119 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type spyEventStream struct {
+	lastIDs []uint64
+	expired bool
+	events  chan driven.PublishedEvent
+}
+
+func (s *spyEventStream) Follow(ctx context.Context, lastID uint64) (<-chan driven.PublishedEvent, error) {
+	s.lastIDs = append(s.lastIDs, lastID)
+	if s.expired && lastID != 0 {
+		return nil, &driven.EventsExpiredError{LastID: lastID}
+	}
+	return s.events, nil
+}
+
+func newSpyEventStream(events ...driven.PublishedEvent) *spyEventStream {
+	spy := &spyEventStream{events: make(chan driven.PublishedEvent, len(events))}
+	for _, event := range events {
+		spy.events <- event
+	}
+	close(spy.events)
+	return spy
+}
+
+func streamEvents(t *testing.T, spy *spyEventStream, path string, lastEventID string) (*http.Response, []string) {
+	t.Helper()
+	server := httptest.NewServer(New(nil, nil, nil, WithEventStream(spy)).Router())
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return res, lines
+}
+
+func TestEventStream_PushesEvents(t *testing.T) {
+	spy := newSpyEventStream(
+		driven.PublishedEvent{ID: 8, Event: domain.FilmAdded{Name: "Loki", Director: "Marvel", Release: domain.New}},
+		driven.PublishedEvent{ID: 9, Event: domain.RentalReturned{RentalID: "a-1", Film: "Loki", Days: 2, Cost: 80}},
+	)
+
+	res, lines := streamEvents(t, spy, "/events/stream", "7")
+
+	expected := []string{
+		"id: 8", "event: FilmAdded", `data: {"name":"Loki","director":"Marvel","release":"New"}`, "",
+		"id: 9", "event: RentalReturned", `data: {"rentalId":"a-1","film":"Loki","days":2,"cost":80}`, "",
+	}
+	switch {
+	case res.Header.Get("Content-Type") != "text/event-stream":
+		t.Errorf("was expecting an event stream but got %q", res.Header.Get("Content-Type"))
+	case len(spy.lastIDs) != 1 || spy.lastIDs[0] != 7:
+		t.Errorf("was expecting to resume after the Last-Event-ID but followed from %v", spy.lastIDs)
+	case strings.Join(lines, "\n") != strings.Join(expected, "\n"):
+		t.Errorf("was expecting\n%s\nbut got\n%s", strings.Join(expected, "\n"), strings.Join(lines, "\n"))
+	}
+}
+
+func TestEventStream_FiltersByType(t *testing.T) {
+	spy := newSpyEventStream(
+		driven.PublishedEvent{ID: 1, Event: domain.FilmAdded{Name: "Loki", Director: "Marvel", Release: domain.New}},
+		driven.PublishedEvent{ID: 2, Event: domain.RentalReturned{RentalID: "a-1", Film: "Loki", Days: 2, Cost: 80}},
+	)
+
+	_, lines := streamEvents(t, spy, "/events/stream?types=RentalReturned", "")
+
+	if len(lines) != 4 || lines[0] != "id: 2" {
+		t.Errorf("was expecting only the RentalReturned event but got %q", lines)
+	}
+}
+
+func TestEventStream_ResetWhenExpired(t *testing.T) {
+	spy := newSpyEventStream(driven.PublishedEvent{ID: 50, Event: domain.FilmAdded{Name: "Loki", Director: "Marvel", Release: domain.New}})
+	spy.expired = true
+
+	_, lines := streamEvents(t, spy, "/events/stream", "3")
+
+	if len(lines) < 3 || lines[0] != "event: reset" || lines[3] != "id: 50" {
+		t.Errorf("was expecting a reset followed by the retained events but got %q", lines)
+	}
+	if len(spy.lastIDs) != 2 || spy.lastIDs[1] != 0 {
+		t.Errorf("was expecting to follow again from the start but followed from %v", spy.lastIDs)
+	}
+}
+
+func TestEventStream_InvalidLastEventID(t *testing.T) {
+	res, _ := streamEvents(t, newSpyEventStream(), "/events/stream", "yesterday")
+
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("got status %d but wanted %d", res.StatusCode, http.StatusBadRequest)
+	}
+}
@@ -1,19 +1,16 @@
 package http
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/shawnritchie/go-video-store/api"
 	"github.com/shawnritchie/go-video-store/internal/port/driven"
 	"net/http"
 )
 
 type (
-	findResponse struct {
-		Name     string `json:"name"`
-		Director string `json:"director"`
-		Release  string `json:"release"`
-	}
+	findResponse = api.Film
+	listResponse = api.FilmList
 )
 
 func (s *server) findFilm(w http.ResponseWriter, r *http.Request) error {
@@ -24,18 +21,45 @@ func (s *server) findFilm(w http.ResponseWriter, r *http.Request) error {
 		return NewClientError(nil, http.StatusBadRequest, "Bad Request: Expected query parameter \"name\" in url")
 	}
 
-	film, err := s.finder.Find(filmName)
+	film, err := s.finder.Find(r.Context(), filmName)
 	if errors.As(err, &driven.TypeFilmNotFound) {
 		return NewClientError(nil, http.StatusNotFound, fmt.Sprintf("Film Not Found: Film %q not found", filmName))
 	} else if err != nil {
 		return err
 	}
 
-	setHeaders(w)
-	json.NewEncoder(w).Encode(findResponse{
+	codec, body, err := negotiate(r, findResponse{
 		Name:     film.Name,
 		Director: film.Director,
 		Release:  string(film.Release),
 	})
-	return nil
+	if err != nil {
+		return err
+	}
+
+	tag := etag(film.Version, codec.MediaType())
+	w.Header().Set("ETag", tag)
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && noneMatch(ifNoneMatch, tag) {
+		w.Header().Add("Vary", "Accept")
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+	return write(w, codec, body)
+}
+
+func (s *server) listFilms(w http.ResponseWriter, r *http.Request) error {
+	films, err := s.lister.List(r.Context())
+	if err != nil {
+		return fmt.Errorf("unable to list films: %w", err)
+	}
+
+	response := listResponse{Films: make([]api.Film, 0, len(films))}
+	for _, film := range films {
+		response.Films = append(response.Films, api.Film{
+			Name:     film.Name,
+			Director: film.Director,
+			Release:  string(film.Release),
+		})
+	}
+	return respond(w, r, response)
 }
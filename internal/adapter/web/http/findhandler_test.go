@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"fmt"
 	"github.com/shawnritchie/go-video-store/internal/domain"
 	"github.com/shawnritchie/go-video-store/internal/port/driven"
@@ -15,7 +16,7 @@ type spyFilmFinder struct {
 	returnFx       func() (*domain.Film, error)
 }
 
-func (spy *spyFilmFinder) Find(name string) (*domain.Film, error) {
+func (spy *spyFilmFinder) Find(ctx context.Context, name string) (*domain.Film, error) {
 	spy.findInvocation++
 	spy.findParams = append(spy.findParams, name)
 	return spy.returnFx()
@@ -29,6 +30,12 @@ func newSpyFilmFinder(fx func() (*domain.Film, error)) *spyFilmFinder {
 	}
 }
 
+type spyFilmLister []domain.Film
+
+func (spy spyFilmLister) List(ctx context.Context) ([]domain.Film, error) {
+	return spy, nil
+}
+
 const (
 	FilmName     = "Loki"
 	FilmDirector = "Marvel"
@@ -125,3 +132,21 @@ func TestFindRequest_NoneCataloguedFilm(t *testing.T) {
 		t.Errorf("got status %d but wanted %d", status, http.StatusNotFound)
 	}
 }
+
+func TestListFilms(t *testing.T) {
+	server := New(nil, nil, nil, WithLister(spyFilmLister{
+		{Name: FilmName, Director: FilmDirector, Release: FilmRelease},
+		{Name: "Out of Africa", Director: "Pollack", Release: domain.Old},
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/catalogue/films", nil)
+	res := httptest.NewRecorder()
+	server.Router().ServeHTTP(res, req)
+
+	var list listResponse
+	unmarshalBody(t, res, &list)
+
+	if res.Code != http.StatusOK || len(list.Films) != 2 || list.Films[1].Release != string(domain.Old) {
+		t.Errorf("received unexpected catalogue %d %#v", res.Code, list)
+	}
+}
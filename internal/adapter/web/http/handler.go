@@ -1,8 +1,14 @@
 package http
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/shawnritchie/go-video-store/api"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"log/slog"
 	"net/http"
 )
 
@@ -13,20 +19,39 @@ type ClientError interface {
 	ResponseHeaders() (int, map[string]string)
 }
 
+// Error is served as an RFC 7807 problem, Instance and RequestID are filled in once the error reaches handler
 type Error struct {
-	Cause  error  `json:"-"`
-	Detail string `json:"detail"`
-	Status int    `json:"-"`
+	Cause     error        `json:"-"`
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestID string       `json:"requestId,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
 }
 
+// FieldError is shared with the client through api.Error, Error marshals to the same body
+type FieldError = api.FieldError
+
 var (
-	contentType = "application/json"
-	httpHeader  = map[string]string{
+	contentType        = "application/json"
+	problemContentType = "application/problem+json"
+	httpHeader         = map[string]string{
 		"Content-Type": contentType,
 	}
+	problemHeader = map[string]string{
+		"Content-Type": problemContentType,
+	}
 	TypeClientError *Error
 )
 
+// problemType is the RFC 7807 type of problems whose status code explains them sufficiently
+const problemType = "about:blank"
+
+// requestIDHeader carries the id correlating a request with its problem and its log lines, an incoming id is kept
+const requestIDHeader = "X-Request-ID"
+
 func (e *Error) Error() string {
 	if e.Cause == nil {
 		return e.Detail
@@ -43,48 +68,90 @@ func (e *Error) ResponseBody() ([]byte, error) {
 }
 
 func (e *Error) ResponseHeaders() (int, map[string]string) {
-	return e.Status, httpHeader
+	return e.Status, problemHeader
 }
 
 func NewClientError(err error, status int, detail string) error {
 	return &Error{
 		Cause:  err,
+		Type:   problemType,
+		Title:  http.StatusText(status),
 		Detail: detail,
 		Status: status,
 	}
 }
 
+// NewValidationError is a 400 listing every field the request was rejected for
+func NewValidationError(err error, detail string, fields []FieldError) error {
+	return &Error{
+		Cause:  err,
+		Type:   problemType,
+		Title:  http.StatusText(http.StatusBadRequest),
+		Detail: detail,
+		Status: http.StatusBadRequest,
+		Errors: fields,
+	}
+}
+
 type handler func(http.ResponseWriter, *http.Request) error
 
 func (fn handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r = withRequestID(w, r)
+
 	var err error
-	ct := r.Header.Get("Content-Type")
-	if ct != contentType {
-		err = NewClientError(nil,
-			http.StatusBadRequest,
-			fmt.Sprintf("Bad Request: Content-Type must be set to %q", contentType),
-		)
-	} else {
-		err = fn(w, r)
+	switch {
+	case hasBody(r) && r.Header.Get("Content-Type") == "":
+		err = NewClientError(nil, http.StatusUnsupportedMediaType, "Unsupported Media Type: Content-Type must be set on a request with a body")
+	case len(acceptable(r)) == 0:
+		err = notAcceptable(r)
+	default:
+		if _, err = requestCodec(r); err == nil {
+			err = fn(w, r)
+		}
 	}
 
 	if err == nil {
 		return
 	}
+	writeError(w, r, err)
+}
 
-	clientError, ok := err.(ClientError)
-	if !ok {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+// writeError serves err as a problem, an error which is not a ClientError is served as a 500 without its cause.
+// err is logged by the access log of the request, or under the request id when it is not logged
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	requestID := r.Header.Get(requestIDHeader)
+	logged := failed(r, err)
+
+	var problem Error
+	var clientError *Error
+	if errors.As(err, &clientError) {
+		problem = *clientError
+	} else {
+		if !logged {
+			slog.ErrorContext(r.Context(), "request failed", "request_id", requestID, "method", r.Method, "path", r.URL.Path, "error", err)
+		}
+		problem = Error{
+			Cause:  err,
+			Detail: "Internal Server Error: request could not be processed, quote request id " + requestID,
+			Status: http.StatusInternalServerError,
+		}
+	}
+	if problem.Type == "" {
+		problem.Type = problemType
 	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+	problem.Instance = r.URL.Path
+	problem.RequestID = requestID
 
-	body, err := clientError.ResponseBody()
+	body, err := problem.ResponseBody()
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	status, headers := clientError.ResponseHeaders()
+	status, headers := problem.ResponseHeaders()
 	for k, v := range headers {
 		w.Header().Set(k, v)
 	}
@@ -92,6 +159,24 @@ func (fn handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Write(body)
 }
 
+// withRequestID keeps the request id the client sent or assigns a new one, the id is echoed on the response and
+// carried by the context of the request
+func withRequestID(w http.ResponseWriter, r *http.Request) *http.Request {
+	requestID := r.Header.Get(requestIDHeader)
+	if requestID == "" {
+		id := make([]byte, 8)
+		rand.Read(id)
+		requestID = hex.EncodeToString(id)
+		r = r.Clone(r.Context())
+		r.Header.Set(requestIDHeader, requestID)
+	}
+	w.Header().Set(requestIDHeader, requestID)
+	if driven.RequestIDFrom(r.Context()) != requestID {
+		r = r.WithContext(driven.WithRequestID(r.Context(), requestID))
+	}
+	return r
+}
+
 func setHeaders(w http.ResponseWriter) {
 	for k, v := range httpHeader {
 		w.Header().Set(k, v)
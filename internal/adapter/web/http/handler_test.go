@@ -6,13 +6,16 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"log"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 )
 
 func TestHandler_MissingContentType(t *testing.T) {
-	req, err := http.NewRequest(http.MethodGet, "catalogue/film", nil)
+	req, err := http.NewRequest(http.MethodPost, "catalogue/film/new", strings.NewReader(`{"name":"Loki"}`))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -25,8 +28,8 @@ func TestHandler_MissingContentType(t *testing.T) {
 	var errorResponse Error
 	unmarshalBody(t, res, &errorResponse)
 
-	if res.Code != http.StatusBadRequest {
-		t.Errorf("got status %d but wanted %d", res.Code, http.StatusBadRequest)
+	if res.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusUnsupportedMediaType)
 	}
 
 	if errorResponse.Detail == "" {
@@ -54,14 +57,66 @@ func TestHandler_UnhandledError(t *testing.T) {
 	}
 }
 
+func TestHandler_ProblemDetails(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/catalogue/film", nil)
+	req.Header.Add("Content-Type", contentType)
+	req.Header.Add(requestIDHeader, "abc123")
+
+	res := httptest.NewRecorder()
+	handler(func(writer http.ResponseWriter, request *http.Request) error {
+		return NewClientError(nil, http.StatusNotFound, "Film Not Found")
+	}).ServeHTTP(res, req)
+
+	var problem Error
+	unmarshalBody(t, res, &problem)
+
+	switch {
+	case res.Header().Get("Content-Type") != problemContentType:
+		t.Errorf("was expecting a problem but got Content-Type %q", res.Header().Get("Content-Type"))
+	case res.Header().Get(requestIDHeader) != "abc123":
+		t.Errorf("was expecting the request id to be echoed but got %q", res.Header().Get(requestIDHeader))
+	case problem.Type != "about:blank" || problem.Title != "Not Found" || problem.Status != http.StatusNotFound:
+		t.Errorf("received unexpected problem %#v", problem)
+	case problem.Instance != "/catalogue/film" || problem.RequestID != "abc123":
+		t.Errorf("was expecting the problem to point at the request but got %#v", problem)
+	}
+}
+
+func TestHandler_InternalErrorIsLoggedNotServed(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	req := httptest.NewRequest(http.MethodGet, "/catalogue/film", nil)
+	req.Header.Add("Content-Type", contentType)
+
+	res := httptest.NewRecorder()
+	handler(func(writer http.ResponseWriter, request *http.Request) error {
+		return fmt.Errorf("connection refused")
+	}).ServeHTTP(res, req)
+
+	var problem Error
+	unmarshalBody(t, res, &problem)
+	requestID := res.Header().Get(requestIDHeader)
+
+	switch {
+	case requestID == "" || problem.RequestID != requestID:
+		t.Errorf("was expecting a request id to be assigned but got %q and %#v", requestID, problem)
+	case strings.Contains(res.Body.String(), "connection refused"):
+		t.Errorf("was not expecting the cause to be served %q", res.Body.String())
+	case !strings.Contains(logged.String(), requestID) || !strings.Contains(logged.String(), "connection refused"):
+		t.Errorf("was expecting the cause to be logged under the request id but got %q", logged.String())
+	}
+}
+
 func unmarshalBody(t *testing.T, w *httptest.ResponseRecorder, res interface{}) {
 	reqBody, err := ioutil.ReadAll(w.Body)
 	if err != nil {
-		t.Errorf("request body cannot be read : %w", err)
+		t.Errorf("request body cannot be read : %v", err)
 	}
 
 	if err := json.Unmarshal(reqBody, &res); err != nil {
-		t.Errorf("Post response cannot be deserialized. %w", err)
+		t.Errorf("Post response cannot be deserialized. %v", err)
 	}
 }
 
@@ -0,0 +1,142 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/auth"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type (
+	// recorder keeps a copy of the response it writes through so it can be replayed
+	recorder struct {
+		http.ResponseWriter
+		status int
+		body   bytes.Buffer
+	}
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	// replayedHeader marks a response which was replayed rather than served by the handler
+	replayedHeader = "Idempotent-Replayed"
+	// maxIdempotencyKey bounds what a client can make the store keep
+	maxIdempotencyKey = 255
+)
+
+// WithIdempotency replays the response to a POST made under an Idempotency-Key to every retry of it for ttl, so
+// a clerk retrying a return over flaky Wi-Fi is invoiced once. Requests without the header are served as usual
+func WithIdempotency(store driver.IdempotencyStore, ttl time.Duration) Option {
+	return func(s *server) {
+		s.idempotency = store
+		s.replayTTL = ttl
+	}
+}
+
+// idempotent claims the key for the principal making the request, so clients cannot replay each other's responses.
+// A retry which differs from the request in its path, store or body is refused with 422, a retry sent while the
+// request is in flight with 409. Responses which failed with a 5xx are forgotten so they can be retried
+func (s *server) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if s.idempotency == nil || key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		r = withRequestID(w, r)
+		if len(key) > maxIdempotencyKey {
+			writeError(w, r, NewValidationError(nil, "Bad Request: idempotency key is too long",
+				[]FieldError{{Field: idempotencyKeyHeader, Message: fmt.Sprintf("must not exceed %d characters", maxIdempotencyKey)}}))
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, r, NewClientError(err, http.StatusBadRequest, "Bad Request: body cannot be read"))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		if principal, ok := auth.PrincipalFrom(r.Context()); ok {
+			key = principal.Subject + ":" + key
+		}
+		replay, err := s.idempotency.Claim(key, fingerprint(r, body), s.replayTTL)
+		switch {
+		case errors.As(err, &driven.TypeIdempotencyKeyReused):
+			writeError(w, r, NewClientError(err, http.StatusUnprocessableEntity,
+				"Unprocessable Entity: the idempotency key was used for another request"))
+			return
+		case errors.As(err, &driven.TypeIdempotencyKeyInUse):
+			writeError(w, r, NewClientError(err, http.StatusConflict,
+				"Status Conflict: a request with the same idempotency key is being processed"))
+			return
+		case err != nil:
+			writeError(w, r, fmt.Errorf("unable to claim idempotency key: %w", err))
+			return
+		case replay != nil:
+			for k, v := range replay.Header {
+				w.Header()[k] = v
+			}
+			w.Header().Set(replayedHeader, "true")
+			w.WriteHeader(replay.Status)
+			w.Write(replay.Body)
+			return
+		}
+
+		rec := &recorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		if rec.status >= http.StatusInternalServerError {
+			err = s.idempotency.Release(key)
+		} else {
+			err = s.idempotency.Complete(key, driver.IdempotentResponse{Status: rec.status, Header: replayable(w.Header()), Body: rec.body.Bytes()})
+		}
+		if err != nil {
+			s.logger.ErrorContext(r.Context(), "unable to keep the response to an idempotency key", "key", key, "error", err)
+		}
+	})
+}
+
+// fingerprint tells a retry apart from another request, a retry repeats the path, store and body
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n%s\n%s\n", r.Method, r.URL.RequestURI(), r.Header.Get(storeHeader), r.Header.Get("Content-Type"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replayable drops the headers which belong to a single request, such as its id and rate limit
+func replayable(header http.Header) map[string][]string {
+	kept := header.Clone()
+	for k := range kept {
+		if k == http.CanonicalHeaderKey(requestIDHeader) || k == "Retry-After" || strings.HasPrefix(k, "Ratelimit-") {
+			delete(kept, k)
+		}
+	}
+	return kept
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
@@ -0,0 +1,126 @@
+package http
+
+import (
+	"errors"
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/inmem"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/auth"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const idempotentReturn = `{"return":[{"name":"Loki","days":1}]}`
+
+func newIdempotentServer(invoicer *spyFilmInvoicer, options ...Option) (*inmem.IdempotencyStore, http.Handler) {
+	store := inmem.NewIdempotencyStore()
+	return store, New(nil, nil, invoicer, append([]Option{WithIdempotency(store, time.Hour)}, options...)...).Router()
+}
+
+func idempotentRequest(router http.Handler, key string, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := storeRequest(http.MethodPost, "/store/return", headers, body)
+	if key != "" {
+		req.Header.Set(idempotencyKeyHeader, key)
+	}
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	return res
+}
+
+func TestIdempotency_ReplaysRetries(t *testing.T) {
+	invoicer := NewSpyFilmInvoicer(domain.SEK(40), nil)
+	_, router := newIdempotentServer(invoicer)
+
+	first := idempotentRequest(router, "return-1", idempotentReturn, nil)
+	retry := idempotentRequest(router, "return-1", idempotentReturn, nil)
+	if first.Code != http.StatusOK || retry.Code != http.StatusOK {
+		t.Fatalf("was expecting both attempts to succeed but got %d and %d", first.Code, retry.Code)
+	}
+	if len(invoicer.requests) != 1 {
+		t.Errorf("was expecting the return to be invoiced once but it was invoiced %d times", len(invoicer.requests))
+	}
+	if retry.Body.String() != first.Body.String() || retry.Header().Get("Content-Type") != first.Header().Get("Content-Type") {
+		t.Errorf("was expecting the retry to replay %s but got %s", first.Body.String(), retry.Body.String())
+	}
+	if first.Header().Get(replayedHeader) != "" || retry.Header().Get(replayedHeader) != "true" {
+		t.Errorf("was expecting only the retry to be marked as replayed but got %v and %v", first.Header(), retry.Header())
+	}
+	if retry.Header().Get(requestIDHeader) == first.Header().Get(requestIDHeader) {
+		t.Errorf("was expecting the retry to carry its own request id")
+	}
+
+	idempotentRequest(router, "", idempotentReturn, nil)
+	idempotentRequest(router, "", idempotentReturn, nil)
+	if len(invoicer.requests) != 3 {
+		t.Errorf("was expecting returns without a key to be invoiced every time but got %d invoices", len(invoicer.requests))
+	}
+}
+
+func TestIdempotency_RefusesReusedKey(t *testing.T) {
+	invoicer := NewSpyFilmInvoicer(domain.SEK(40), nil)
+	store, router := newIdempotentServer(invoicer)
+	idempotentRequest(router, "return-1", idempotentReturn, nil)
+
+	tests := []struct {
+		name    string
+		key     string
+		body    string
+		headers map[string]string
+		status  int
+	}{
+		{"OtherBody", "return-1", `{"return":[{"name":"Loki","days":2}]}`, nil, http.StatusUnprocessableEntity},
+		{"OtherStore", "return-1", idempotentReturn, map[string]string{storeHeader: "north"}, http.StatusUnprocessableEntity},
+		{"InFlight", "return-2", idempotentReturn, nil, http.StatusConflict},
+		{"TooLong", strings.Repeat("k", maxIdempotencyKey+1), idempotentReturn, nil, http.StatusBadRequest},
+	}
+	inFlight := storeRequest(http.MethodPost, "/store/return", nil, idempotentReturn)
+	store.Claim("return-2", fingerprint(inFlight, []byte(idempotentReturn)), time.Hour)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if res := idempotentRequest(router, test.key, test.body, test.headers); res.Code != test.status {
+				t.Errorf("was expecting %d but got %d %s", test.status, res.Code, res.Body.String())
+			}
+		})
+	}
+	if len(invoicer.requests) != 1 {
+		t.Errorf("was expecting refused requests not to be invoiced but got %d invoices", len(invoicer.requests))
+	}
+}
+
+func TestIdempotency_ForgetsServerErrors(t *testing.T) {
+	invoicer := NewSpyFilmInvoicer(domain.SEK(40), errors.New("database is down"))
+	_, router := newIdempotentServer(invoicer)
+
+	if res := idempotentRequest(router, "return-1", idempotentReturn, nil); res.Code != http.StatusInternalServerError {
+		t.Fatalf("was expecting the return to fail but got %d", res.Code)
+	}
+	invoicer.err = nil
+	res := idempotentRequest(router, "return-1", idempotentReturn, nil)
+	if res.Code != http.StatusOK || res.Header().Get(replayedHeader) != "" || len(invoicer.requests) != 2 {
+		t.Errorf("was expecting the retry to be processed again but got %d %v after %d attempts", res.Code, res.Header(), len(invoicer.requests))
+	}
+}
+
+func TestIdempotency_KeysBelongToPrincipal(t *testing.T) {
+	keys, err := auth.NewAPIKeys([]auth.APIKey{
+		{Hash: auth.HashAPIKey("kiosk-1"), Subject: "kiosk-1", Role: "clerk"},
+		{Hash: auth.HashAPIKey("kiosk-2"), Subject: "kiosk-2", Role: "clerk"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	invoicer := NewSpyFilmInvoicer(domain.SEK(40), nil)
+	_, router := newIdempotentServer(invoicer, WithAuthenticator(keys))
+
+	for _, key := range []string{"kiosk-1", "kiosk-2"} {
+		res := idempotentRequest(router, "return-1", idempotentReturn, map[string]string{auth.APIKeyHeader: key})
+		if res.Code != http.StatusOK || res.Header().Get(replayedHeader) != "" {
+			t.Errorf("was expecting %s to be served its own response but got %d %v", key, res.Code, res.Header())
+		}
+	}
+	if len(invoicer.requests) != 2 {
+		t.Errorf("was expecting every kiosk to be invoiced but got %d invoices", len(invoicer.requests))
+	}
+}
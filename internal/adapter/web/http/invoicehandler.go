@@ -1,79 +1,83 @@
 package http
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/shawnritchie/go-video-store/api"
 	"github.com/shawnritchie/go-video-store/internal/port/driven"
-	"io/ioutil"
 	"net/http"
 )
 
 type (
-	rental struct {
-		Name string `json:"name"`
-		Days uint16 `json:"days"`
-	}
-
-	returnRequest struct {
-		Return []rental `json:"return"`
-	}
-
-	invoiceResponse struct {
-		Return       []rental
-		Price        uint64
-		Currency     string
-		MonetaryUnit string
-	}
+	rental          = api.Rental
+	returnRequest   = api.ReturnRequest
+	invoiceResponse = api.Invoice
 )
 
-func (r *rental) isValid() bool {
-	return !(r.Name == "" || r.Days <= 0)
-}
-
-func (r returnRequest) isValid() bool {
-	for _, rental := range r.Return {
-		if !rental.isValid() {
-			return false
-		}
-	}
-
-	return len(r.Return) > 0
-}
-
 func (s *server) processReturn(w http.ResponseWriter, r *http.Request) error {
-	defer r.Body.Close()
-	reqBody, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		return fmt.Errorf("request body read error : %w", err)
-	}
-
 	var request returnRequest
-	if err := json.Unmarshal(reqBody, &request); err != nil || !request.isValid() {
+	if err := decode(r, &request); err != nil || !request.IsValid() {
 		return NewClientError(err, http.StatusBadRequest, "Bad Request: Post payload cannot be deserialized")
 	}
 
 	var returns []driven.FilmReturn
 	for _, ele := range request.Return {
-		returns = append(returns, driven.FilmReturn{FilmName: ele.Name, Days: ele.Days})
+		returns = append(returns, driven.FilmReturn{FilmName: ele.Name, Days: ele.Days, RentalID: ele.RentalID})
 	}
 
-	invoice, err := s.invoicer.Invoice(returns)
+	invoice, err := s.invoicer.Invoice(r.Context(), returns)
 	if err != nil {
+		var invalid *driven.InvalidRentalRequestError
 		switch {
-		case errors.As(err, &driven.TypeInvalidRentalRequest):
-			return NewClientError(err, http.StatusBadRequest, "Bad Request: submitted request cannot be processed!")
+		case errors.As(err, &invalid):
+			return NewValidationError(err, "Bad Request: submitted request cannot be processed!", rentalErrors(request, *invalid))
 		default:
 			return fmt.Errorf("error generating invoice: %w", err)
 		}
 	}
 
-	setHeaders(w)
-	json.NewEncoder(w).Encode(invoiceResponse{
+	return respond(w, r, invoiceResponse{
 		Return:       request.Return,
 		Price:        uint64(invoice.Cost),
 		Currency:     "SEK",
 		MonetaryUnit: "Kr",
 	})
-	return nil
+}
+
+func (s *server) rentFilm(w http.ResponseWriter, r *http.Request) error {
+	var request api.RentRequest
+	if err := decode(r, &request); err != nil || !request.IsValid() {
+		return NewClientError(err, http.StatusBadRequest, "Bad Request: Post payload cannot be deserialized")
+	}
+
+	rental, err := s.renter.Rent(r.Context(), request.Name, request.Days)
+	if err != nil {
+		if errors.As(err, &driven.TypeFilmNotFound) {
+			return NewValidationError(err, "Bad Request: only catalogued films can be rented", []FieldError{{Field: "name", Message: err.Error()}})
+		}
+		return fmt.Errorf("unable to rent film: %w", err)
+	}
+	return respond(w, r, api.StartedRental{RentalID: rental.RentalID, Name: rental.Film.Name, Days: uint16(rental.Days)})
+}
+
+// rentalErrors points every error at the rental it was raised for, such as a film which is not catalogued, errors
+// which cannot be traced back to a single rental are reported against the whole return
+func rentalErrors(request returnRequest, invalid driven.InvalidRentalRequestError) []FieldError {
+	fields := make([]FieldError, 0, len(invalid))
+	for _, err := range invalid {
+		field := "return"
+		var notFound *driven.FilmNotFoundError
+		var film string
+		if errors.As(err, &notFound) {
+			film = notFound.Name
+		}
+		for i, rental := range request.Return {
+			if film != "" && rental.Name == film {
+				field = fmt.Sprintf("return.%d.name", i)
+				break
+			}
+		}
+		fields = append(fields, FieldError{Field: field, Message: err.Error()})
+	}
+	return fields
 }
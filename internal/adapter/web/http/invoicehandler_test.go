@@ -1,7 +1,9 @@
 package http
 
 import (
+	"context"
 	"fmt"
+	"github.com/shawnritchie/go-video-store/api"
 	"github.com/shawnritchie/go-video-store/internal/domain"
 	"github.com/shawnritchie/go-video-store/internal/port/driven"
 	"net/http"
@@ -15,7 +17,7 @@ type spyFilmInvoicer struct {
 	err      error
 }
 
-func (s *spyFilmInvoicer) Invoice(request []driven.FilmReturn) (*domain.RentalInvoice, error) {
+func (s *spyFilmInvoicer) Invoice(ctx context.Context, request []driven.FilmReturn) (*domain.RentalInvoice, error) {
 	s.requests = append(s.requests, request)
 
 	var rentals []domain.Rental
@@ -46,6 +48,24 @@ func NewSpyFilmInvoicer(cost domain.SEK, err error) *spyFilmInvoicer {
 	}
 }
 
+type spyFilmRenter struct {
+	rented []domain.RentalStarted
+	err    error
+}
+
+func (s *spyFilmRenter) Rent(ctx context.Context, film string, days uint16) (*domain.RentalStarted, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	started := domain.RentalStarted{
+		RentalID: fmt.Sprintf("r-%d", len(s.rented)+1),
+		Film:     domain.Film{Name: film, Director: FilmDirector, Release: domain.New},
+		Days:     domain.Days(days),
+	}
+	s.rented = append(s.rented, started)
+	return &started, nil
+}
+
 func TestInvoicer_SuccessfullyProcessedReturn(t *testing.T) {
 	totalCost := domain.SEK(20)
 	spyInvoicer := NewSpyFilmInvoicer(totalCost, nil)
@@ -161,3 +181,100 @@ func TestInvoicer_InvalidRentalRequest(t *testing.T) {
 		t.Errorf("got status %d but wanted %d", status, http.StatusBadRequest)
 	}
 }
+
+func TestInvoicer_UnknownFilmsAreListed(t *testing.T) {
+	spyInvoicer := NewSpyFilmInvoicer(0, &driven.InvalidRentalRequestError{
+		&driven.FilmNotFoundError{Name: "Doctor Strange"},
+		domain.FilmNotCataloguedError,
+	})
+	server := New(nil, nil, spyInvoicer)
+
+	returnReq := returnRequest{
+		Return: []rental{
+			{Name: "Loki", Days: 5},
+			{Name: "Doctor Strange", Days: 3},
+		},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/store/return", toJSON(returnReq))
+	req.Header.Set("Content-Type", contentType)
+
+	res := httptest.NewRecorder()
+	handler(server.processReturn).ServeHTTP(res, req)
+
+	var problem Error
+	unmarshalBody(t, res, &problem)
+
+	expected := []FieldError{
+		{Field: "return.1.name", Message: `film: "Doctor Strange" was not found`},
+		{Field: "return", Message: domain.FilmNotCataloguedError.Error()},
+	}
+	if res.Code != http.StatusBadRequest || len(problem.Errors) != len(expected) {
+		t.Fatalf("was expecting every rejected rental to be listed but got %d %#v", res.Code, problem)
+	}
+	for i, field := range problem.Errors {
+		if field != expected[i] {
+			t.Errorf("was expecting %#v but got %#v", expected[i], field)
+		}
+	}
+}
+
+func TestRenter_RentFilm(t *testing.T) {
+	renter := &spyFilmRenter{}
+	server := New(nil, nil, NewSpyFilmInvoicer(0, nil), WithRenter(renter))
+
+	req, err := http.NewRequest(http.MethodPost, "/store/rent", toJSON(api.RentRequest{Name: "Loki", Days: 3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := httptest.NewRecorder()
+	handler(server.rentFilm)(res, req)
+
+	var started api.StartedRental
+	unmarshalBody(t, res, &started)
+
+	switch {
+	case res.Code != http.StatusOK:
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusOK)
+	case len(renter.rented) != 1:
+		t.Errorf("was expecting a single rental to be started but got %d", len(renter.rented))
+	case started != api.StartedRental{RentalID: "r-1", Name: "Loki", Days: 3}:
+		t.Errorf("received unexpected rental %#v", started)
+	}
+}
+
+func TestRenter_UnknownFilmIsInvalid(t *testing.T) {
+	renter := &spyFilmRenter{err: &driven.FilmNotFoundError{Name: "Loki"}}
+	server := New(nil, nil, NewSpyFilmInvoicer(0, nil), WithRenter(renter))
+
+	req, err := http.NewRequest(http.MethodPost, "/store/rent", toJSON(api.RentRequest{Name: "Loki", Days: 3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = server.rentFilm(httptest.NewRecorder(), req)
+	clientError, ok := err.(ClientError)
+	if !ok {
+		t.Fatalf("expected Client error but got %#v", err)
+	}
+	if status, _ := clientError.ResponseHeaders(); status != http.StatusBadRequest {
+		t.Errorf("got status %d but wanted %d", status, http.StatusBadRequest)
+	}
+}
+
+func TestInvoicer_ReturnNamesItsRental(t *testing.T) {
+	spyInvoicer := NewSpyFilmInvoicer(domain.SEK(40), nil)
+	server := New(nil, nil, spyInvoicer)
+
+	returnReq := returnRequest{Return: []rental{{Name: "Loki", Days: 1, RentalID: "r-1"}}}
+	req, err := http.NewRequest(http.MethodPost, "/store/return", toJSON(returnReq))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler(server.processReturn)(httptest.NewRecorder(), req)
+
+	if len(spyInvoicer.requests) != 1 || spyInvoicer.requests[0][0].RentalID != "r-1" {
+		t.Errorf("was expecting the return to name rental r-1 but got %#v", spyInvoicer.requests)
+	}
+}
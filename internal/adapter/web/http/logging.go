@@ -0,0 +1,141 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type (
+	// accessRecorder keeps the status and size of the response for the access log
+	accessRecorder struct {
+		http.ResponseWriter
+		status int
+		bytes  int
+	}
+
+	// failure holds the error writeError served the request, so the access log can report its cause
+	failure struct {
+		err error
+	}
+
+	failureKey struct{}
+)
+
+// WithLogger logs an access line for every request and the cause of every error served, through slog.Default
+// without it
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *server) {
+		s.logger = logger
+	}
+}
+
+// Logged assigns the request its id, which every line logged with its context carries, traces it and logs its
+// method, path, status, size and latency once served. Handlers served next to the router, such as the graphql endpoint, are
+// logged by wrapping them, their path then stands in for the route
+func (s *server) Logged(next http.Handler) http.Handler {
+	return s.logged("", next)
+}
+
+// logged reports requests which matched no route under route rather than under their path
+func (s *server) logged(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		r = withRequestID(w, r)
+		failed := &failure{}
+		r = r.WithContext(context.WithValue(r.Context(), failureKey{}, failed))
+
+		label := route
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				label = template
+			}
+		}
+		if label == "" {
+			label = r.URL.Path
+		}
+		r, span := s.startSpan(r, label)
+
+		rec := &accessRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		latency := time.Since(start)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		endSpan(span, rec.status, failed.err)
+		s.logAccess(r, rec, label, latency, failed.err)
+		if s.metrics != nil {
+			s.metrics.ObserveRequest(label, r.Method, rec.status, latency)
+		}
+	})
+}
+
+// logAccess logs server errors at error level and every other request at info level, the error a request failed
+// with is logged along with the cause it wraps
+func (s *server) logAccess(r *http.Request, rec *accessRecorder, route string, latency time.Duration, err error) {
+	attrs := []slog.Attr{
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.String("route", route),
+		slog.Int("status", rec.status),
+		slog.Int("bytes", rec.bytes),
+		slog.Duration("latency", latency),
+		slog.String("remote", r.RemoteAddr),
+	}
+	var problem *Error
+	switch {
+	case errors.As(err, &problem):
+		attrs = append(attrs, slog.String("error", problem.Detail))
+		if problem.Cause != nil {
+			attrs = append(attrs, slog.String("cause", problem.Cause.Error()))
+		}
+	case err != nil:
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	level := slog.LevelInfo
+	if rec.status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	s.logger.LogAttrs(r.Context(), level, "request", attrs...)
+}
+
+// failed hands err to the access log of the request, false when the request is not logged
+func failed(r *http.Request, err error) bool {
+	f, ok := r.Context().Value(failureKey{}).(*failure)
+	if ok {
+		f.err = err
+	}
+	return ok
+}
+
+func (rec *accessRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *accessRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// Flush lets server-sent events through the recorder
+func (rec *accessRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (rec *accessRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
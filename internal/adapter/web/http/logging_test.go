@@ -0,0 +1,122 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/adapter/logging"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLogged_AccessLog(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := logging.New(&out, "info", logging.JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	finder := newSpyFilmFinder(func() (*domain.Film, error) {
+		return &domain.Film{Name: FilmName, Director: FilmDirector, Release: FilmRelease}, nil
+	})
+	server := New(finder, newSpyFilmAppender(nil), nil, WithLogger(logger))
+
+	tests := []struct {
+		name   string
+		req    *http.Request
+		status int
+		level  string
+		cause  string
+	}{
+		{"Served", storeRequest(http.MethodGet, "/catalogue/film?name=Loki", map[string]string{requestIDHeader: "req-1"}, ""), http.StatusOK, "INFO", ""},
+		{"ClientError", storeRequest(http.MethodPost, "/catalogue/film/new", map[string]string{requestIDHeader: "req-2"}, "{"), http.StatusBadRequest, "INFO", "unexpected EOF"},
+		{"NotRouted", storeRequest(http.MethodGet, "/catalogue/vhs", map[string]string{requestIDHeader: "req-3"}, ""), http.StatusNotFound, "INFO", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out.Reset()
+			res := httptest.NewRecorder()
+			server.Router().ServeHTTP(res, test.req)
+
+			var line map[string]interface{}
+			if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+				t.Fatalf("was expecting a single access line but got %q", out.String())
+			}
+			switch {
+			case res.Code != test.status || line["status"] != float64(test.status):
+				t.Errorf("was expecting status %d to be logged but served %d and logged %v", test.status, res.Code, line)
+			case line["level"] != test.level || line["msg"] != "request" || line["method"] != test.req.Method || line["path"] != test.req.URL.Path:
+				t.Errorf("received unexpected access line %v", line)
+			case line["request_id"] != test.req.Header.Get(requestIDHeader):
+				t.Errorf("was expecting the access line to carry the request id but got %v", line)
+			case line["latency"] == nil:
+				t.Errorf("was expecting the latency to be logged but got %v", line)
+			case test.cause != "" && !strings.Contains(fmt.Sprint(line["cause"]), test.cause):
+				t.Errorf("was expecting cause %q to be logged but got %v", test.cause, line)
+			}
+		})
+	}
+}
+
+func TestLogged_InternalErrorCause(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := logging.New(&out, "info", logging.Text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	finder := newSpyFilmFinder(func() (*domain.Film, error) {
+		return nil, fmt.Errorf("connection refused")
+	})
+	server := New(finder, nil, nil, WithLogger(logger))
+
+	res := httptest.NewRecorder()
+	server.Router().ServeHTTP(res, storeRequest(http.MethodGet, "/catalogue/film?name=Loki", nil, ""))
+
+	requestID := res.Header().Get(requestIDHeader)
+	line := out.String()
+	switch {
+	case res.Code != http.StatusInternalServerError:
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusInternalServerError)
+	case strings.Count(line, "\n") != 1 || !strings.Contains(line, "level=ERROR") || !strings.Contains(line, "status=500"):
+		t.Errorf("was expecting a single access line at error level but got %q", line)
+	case !strings.Contains(line, "request_id="+requestID) || !strings.Contains(line, "connection refused"):
+		t.Errorf("was expecting the cause to be logged under request id %q but got %q", requestID, line)
+	}
+}
+
+func TestLogged_ConcurrentRoutes(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := logging.New(&out, "info", logging.JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := New(newSpyFilmFinder(nil), newSpyFilmAppender(nil), nil, WithLogger(logger))
+	handler := server.Logged(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, fmt.Sprintf("/graphql/%d", i), nil))
+		}(i)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 20 {
+		t.Fatalf("was expecting 20 access lines but got %d", len(lines))
+	}
+	for _, l := range lines {
+		var line map[string]interface{}
+		if err := json.Unmarshal([]byte(l), &line); err != nil {
+			t.Fatal(err)
+		}
+		if line["route"] != line["path"] {
+			t.Errorf("was expecting every request to be logged under its own path but got %v", line)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package http
+
+import "time"
+
+// unmatchedRoute stands in for the route of requests no route matched, so their paths are not counted one by one
+const unmatchedRoute = "unmatched"
+
+// RequestObserver records every request served by the template of the route it matched
+type RequestObserver interface {
+	ObserveRequest(route string, method string, status int, latency time.Duration)
+}
+
+// WithRequestMetrics counts the requests served and times them by route
+func WithRequestMetrics(observer RequestObserver) Option {
+	return func(s *server) {
+		s.metrics = observer
+	}
+}
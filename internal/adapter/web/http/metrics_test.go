@@ -0,0 +1,54 @@
+package http
+
+import (
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type (
+	observedRequest struct {
+		route  string
+		method string
+		status int
+	}
+
+	spyRequestObserver struct {
+		requests []observedRequest
+	}
+)
+
+func (spy *spyRequestObserver) ObserveRequest(route string, method string, status int, latency time.Duration) {
+	spy.requests = append(spy.requests, observedRequest{route: route, method: method, status: status})
+}
+
+func TestRequestMetrics_ObservedByRoute(t *testing.T) {
+	finder := newSpyFilmFinder(func() (*domain.Film, error) {
+		return &domain.Film{Name: FilmName, Director: FilmDirector, Release: FilmRelease}, nil
+	})
+	observer := &spyRequestObserver{}
+	server := New(finder, newSpyFilmAppender(nil), nil, WithRequestMetrics(observer))
+
+	tests := []struct {
+		req  *http.Request
+		want observedRequest
+	}{
+		{storeRequest(http.MethodGet, "/catalogue/film?name=Loki", nil, ""), observedRequest{"/catalogue/film", http.MethodGet, http.StatusOK}},
+		{storeRequest(http.MethodPost, "/catalogue/film/new", nil, "{"), observedRequest{"/catalogue/film/{release}", http.MethodPost, http.StatusBadRequest}},
+		{storeRequest(http.MethodGet, "/catalogue/vhs/1", nil, ""), observedRequest{unmatchedRoute, http.MethodGet, http.StatusNotFound}},
+	}
+	for _, test := range tests {
+		server.Router().ServeHTTP(httptest.NewRecorder(), test.req)
+	}
+
+	if len(observer.requests) != len(tests) {
+		t.Fatalf("was expecting every request to be observed once but got %#v", observer.requests)
+	}
+	for i, test := range tests {
+		if observer.requests[i] != test.want {
+			t.Errorf("was expecting %#v to be observed but got %#v", test.want, observer.requests[i])
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strings"
+)
+
+//go:embed openapi.json
+var openAPIDocument []byte
+
+var spec = mustLoadSpec()
+
+func mustLoadSpec() *openapi3.T {
+	doc, err := openapi3.NewLoader().LoadFromData(openAPIDocument)
+	if err != nil {
+		panic(fmt.Sprintf("openapi.json cannot be loaded: %v", err))
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		panic(fmt.Sprintf("openapi.json is not a valid OpenAPI document: %v", err))
+	}
+	return doc
+}
+
+func serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w)
+	w.Write(openAPIDocument)
+}
+
+// validated checks the query parameters and body of the request against the operation the router matched it to
+// before fn runs, every mismatch is reported as a FieldError
+func validated(fn handler) handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if err := validateRequest(r); err != nil {
+			return err
+		}
+		return fn(w, r)
+	}
+}
+
+func validateRequest(r *http.Request) error {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return nil
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return nil
+	}
+
+	pathItem := spec.Paths.Find(template)
+	if pathItem == nil || pathItem.GetOperation(r.Method) == nil {
+		return fmt.Errorf("route %s %s is missing from openapi.json", r.Method, template)
+	}
+
+	err = openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+		Request:    r,
+		PathParams: mux.Vars(r),
+		Route: &routers.Route{
+			Spec:      spec,
+			Path:      template,
+			PathItem:  pathItem,
+			Method:    r.Method,
+			Operation: pathItem.GetOperation(r.Method),
+		},
+		// only JSON bodies are checked against the document, the other media types are checked once decoded.
+		// Credentials have already been checked by authorized
+		Options: &openapi3filter.Options{
+			MultiError:         true,
+			ExcludeRequestBody: !isJSON(r),
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		},
+	})
+	if err == nil {
+		return nil
+	}
+
+	return NewValidationError(err, "Bad Request: request does not match the API specification", fieldErrors("", err))
+}
+
+func isJSON(r *http.Request) bool {
+	codec, err := requestCodec(r)
+	return err == nil && codec.MediaType() == (jsonCodec{}).MediaType()
+}
+
+func fieldErrors(field string, err error) []FieldError {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var fields []FieldError
+		for _, err := range e {
+			fields = append(fields, fieldErrors(field, err)...)
+		}
+		return fields
+	case *openapi3filter.RequestError:
+		if e.Parameter != nil {
+			field = e.Parameter.Name
+		}
+		if e.Err == nil {
+			return []FieldError{{Field: field, Message: e.Reason}}
+		}
+		return fieldErrors(field, e.Err)
+	case *openapi3.SchemaError:
+		path := e.JSONPointer()
+		if field != "" {
+			path = append([]string{field}, path...)
+		}
+		return []FieldError{{Field: strings.Join(path, "."), Message: e.Reason}}
+	case *openapi3filter.ParseError:
+		return []FieldError{{Field: field, Message: e.Reason}}
+	}
+	return []FieldError{{Field: field, Message: err.Error()}}
+}
@@ -0,0 +1,170 @@
+package http
+
+import (
+	"context"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gorilla/mux"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// newSpecServer serves every optional route so the whole specification is covered
+func newSpecServer() *server {
+	finder := newSpyFilmFinder(func() (*domain.Film, error) {
+		return &domain.Film{Name: FilmName, Director: FilmDirector, Release: domain.New}, nil
+	})
+	stores, transfers := newSpyStores(), newSpyTransfers()
+	transfers.RequestTransfer(context.Background(), FilmName, "north", "south", 1)
+	return New(finder, newSpyFilmAppender(nil), NewSpyFilmInvoicer(domain.SEK(40), nil),
+		WithLister(spyFilmLister{{Name: FilmName, Director: FilmDirector, Release: domain.New}}),
+		WithUpdater(newSpyFilmEditor()),
+		WithStores(stores, stores, stores),
+		WithTransfers(transfers),
+		WithAuditTrail(&spyAuditTrail{}),
+		WithEventStream(newSpyEventStream()),
+		WithRenter(&spyFilmRenter{}),
+	)
+}
+
+func TestOpenAPI_RouterInSync(t *testing.T) {
+	var routed []string
+	err := newSpecServer().Router().Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+		for _, method := range methods {
+			routed = append(routed, method+" "+template)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var specified []string
+	for path, item := range spec.Paths.Map() {
+		for method := range item.Operations() {
+			specified = append(specified, method+" "+path)
+		}
+	}
+
+	sort.Strings(routed)
+	sort.Strings(specified)
+	if strings.Join(routed, "\n") != strings.Join(specified, "\n") {
+		t.Errorf("the router serves\n%s\nbut openapi.json specifies\n%s", strings.Join(routed, "\n"), strings.Join(specified, "\n"))
+	}
+}
+
+func TestOpenAPI_ValidationErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		fields []string
+	}{
+		{"MissingQueryParameter", http.MethodGet, "/catalogue/film", "", []string{"name"}},
+		{"UnknownRelease", http.MethodPost, "/catalogue/film/vintage", `{"name":"Loki","director":"Marvel"}`, []string{"release"}},
+		{"MissingDirector", http.MethodPost, "/catalogue/film/new", `{"name":"Loki"}`, []string{"director"}},
+		{"EmptyReturn", http.MethodPost, "/store/return", `{"return":[]}`, []string{"return"}},
+		{"InvalidRentals", http.MethodPost, "/store/return", `{"return":[{"name":"","days":1},{"name":"Loki","days":0}]}`, []string{"return.0.name", "return.1.days"}},
+		{"InvalidTimestamp", http.MethodGet, "/audit?from=yesterday", "", []string{"from"}},
+		{"InvalidStore", http.MethodGet, "/stores/North/inventory", "", []string{"store"}},
+		{"NoCopies", http.MethodPost, "/stores/north/inventory", `{"film":"Loki","copies":0}`, []string{"copies"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+			req.Header.Set("Content-Type", contentType)
+			res := httptest.NewRecorder()
+			newSpecServer().Router().ServeHTTP(res, req)
+
+			var errorResponse Error
+			unmarshalBody(t, res, &errorResponse)
+
+			var fields []string
+			for _, field := range errorResponse.Errors {
+				fields = append(fields, field.Field)
+				if field.Message == "" {
+					t.Errorf("was expecting field %q to be explained", field.Field)
+				}
+			}
+			sort.Strings(fields)
+
+			if res.Code != http.StatusBadRequest || strings.Join(fields, ",") != strings.Join(test.fields, ",") {
+				t.Errorf("was expecting 400 for %v but got %d with %#v", test.fields, res.Code, errorResponse)
+			}
+		})
+	}
+}
+
+func TestOpenAPI_ResponsesMatchSpec(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, "/catalogue/film?name=Loki", ""},
+		{http.MethodGet, "/catalogue/films", ""},
+		{http.MethodPut, "/catalogue/film?name=Loki", `{"director":"Disney","release":"Old"}`},
+		{http.MethodPut, "/catalogue/film", `{"director":"Disney"}`},
+		{http.MethodPost, "/catalogue/film/Regular", `{"name":"Loki","director":"Marvel"}`},
+		{http.MethodPost, "/store/return", `{"return":[{"name":"Loki","days":2}]}`},
+		{http.MethodGet, "/audit?entity=film:Loki", ""},
+		{http.MethodPost, "/catalogue/film/new", `{"name":"Loki"}`},
+		{http.MethodPost, "/stores/north/returns", `{"return":[{"name":"Loki","days":2}]}`},
+		{http.MethodGet, "/stores/north/inventory", ""},
+		{http.MethodPost, "/stores/north/inventory", `{"film":"Loki","copies":2}`},
+		{http.MethodGet, "/stores/north/prices", ""},
+		{http.MethodPut, "/stores/north/prices", `{"premium":45,"basic":35}`},
+		{http.MethodGet, "/stores/north/invoices", ""},
+		{http.MethodPost, "/transfers", `{"film":"Loki","from":"north","to":"south","copies":2}`},
+		{http.MethodGet, "/transfers/1", ""},
+		{http.MethodPost, "/transfers/1/approve", ""},
+		{http.MethodPost, "/transfers/1/ship", ""},
+		{http.MethodPost, "/transfers/1/receive", ""},
+		{http.MethodGet, "/stores/north/transfers", ""},
+		{http.MethodGet, "/openapi.json", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.method+" "+test.path, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+			req.Header.Set("Content-Type", contentType)
+			res := httptest.NewRecorder()
+			router := newSpecServer().Router()
+			router.ServeHTTP(res, req)
+
+			var match mux.RouteMatch
+			if !router.Match(req, &match) {
+				t.Fatalf("%s %s is not routed", test.method, test.path)
+			}
+			template, _ := match.Route.GetPathTemplate()
+			pathItem := spec.Paths.Find(template)
+
+			err := openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: &openapi3filter.RequestValidationInput{
+					Request:    req,
+					PathParams: match.Vars,
+					Route:      &routers.Route{Spec: spec, Path: template, PathItem: pathItem, Method: test.method, Operation: pathItem.GetOperation(test.method)},
+				},
+				Status: res.Code,
+				Header: res.Header(),
+				Body:   io.NopCloser(res.Body),
+			})
+			if err != nil {
+				t.Errorf("response %d does not match openapi.json: %v", res.Code, err)
+			}
+		})
+	}
+}
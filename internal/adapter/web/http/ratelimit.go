@@ -0,0 +1,212 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/auth"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// RateLimit lets a client send Burst requests at once to the route, refilling at Rate requests a second. Route
+	// is the method and path template of the route, such as "POST /store/return", or "*" for every other route
+	RateLimit struct {
+		Route string  `json:"route"`
+		Rate  float64 `json:"rate"`
+		Burst int     `json:"burst"`
+	}
+
+	// RateLimiter keeps a token bucket per route and client, a client being identified by the principal it has
+	// been authenticated as or otherwise by its IP address. Routes without a limit are not limited
+	RateLimiter struct {
+		limits  map[string]RateLimit
+		idle    time.Duration
+		now     func() time.Time
+		mu      sync.Mutex
+		buckets map[bucketKey]*bucket
+	}
+
+	bucketKey struct {
+		route  string
+		client string
+	}
+
+	bucket struct {
+		tokens float64
+		last   time.Time
+	}
+)
+
+// anyRoute limits every route which has no limit of its own
+const anyRoute = "*"
+
+// NewRateLimiter forgets the bucket of a client once it has been idle for idle and has filled up again, forgetting
+// it then lets nothing more through than keeping it would have
+func NewRateLimiter(limits []RateLimit, idle time.Duration) (*RateLimiter, error) {
+	l := &RateLimiter{
+		limits:  make(map[string]RateLimit, len(limits)),
+		idle:    idle,
+		now:     time.Now,
+		buckets: map[bucketKey]*bucket{},
+	}
+	for _, limit := range limits {
+		switch {
+		case limit.Route != anyRoute && len(strings.Fields(limit.Route)) != 2:
+			return nil, fmt.Errorf("rate limit route %q must be a method and a path template or %q", limit.Route, anyRoute)
+		case limit.Rate <= 0 || limit.Burst < 1:
+			return nil, fmt.Errorf("rate limit of %q must refill at a positive rate and let at least one request through", limit.Route)
+		}
+		if _, ok := l.limits[limit.Route]; ok {
+			return nil, fmt.Errorf("rate limit of %q is configured twice", limit.Route)
+		}
+		l.limits[limit.Route] = limit
+	}
+	return l, nil
+}
+
+// ParseRateLimits reads the JSON list of limits [{"route","rate","burst"}]
+func ParseRateLimits(data []byte, idle time.Duration) (*RateLimiter, error) {
+	var limits []RateLimit
+	if err := json.Unmarshal(data, &limits); err != nil {
+		return nil, fmt.Errorf("unable to parse rate limits: %w", err)
+	}
+	return NewRateLimiter(limits, idle)
+}
+
+// WithRateLimiter refuses requests beyond the limit of their route with 429 Too Many Requests, once they have been
+// authenticated so every principal gets a bucket of its own
+func WithRateLimiter(limiter *RateLimiter) Option {
+	return func(s *server) {
+		s.limiter = limiter
+	}
+}
+
+// limited lets every request through when no rate limiter has been configured
+func (s *server) limited(next http.Handler) http.Handler {
+	if s.limiter == nil {
+		return next
+	}
+	return s.limiter.Limit(next)
+}
+
+// Limit serves the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers on every limited request and
+// a problem carrying Retry-After on the requests which are refused. Handlers served next to the router, such as
+// the graphql endpoint, are limited by their method and path
+func (l *RateLimiter) Limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.Method + " " + r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = r.Method + " " + template
+			}
+		}
+		limit, ok := l.limits[route]
+		if !ok {
+			if limit, ok = l.limits[anyRoute]; !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		allowed, remaining, reset, retry := l.take(bucketKey{route: limit.Route, client: client(r)}, limit)
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(reset)))
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(seconds(retry)))
+			r = withRequestID(w, r)
+			writeError(w, r, NewClientError(nil, http.StatusTooManyRequests,
+				fmt.Sprintf("Too Many Requests: %s allows %d requests at once refilling at %g a second", limit.Route, limit.Burst, limit.Rate)))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// take spends a token of the bucket, reset is how long the bucket takes to fill up again and retry how long
+// until the next token when none is left
+func (l *RateLimiter) take(key bucketKey, limit RateLimit) (allowed bool, remaining int, reset time.Duration, retry time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.refill(now, limit)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		allowed = true
+	} else {
+		retry = refillTime(1-b.tokens, limit.Rate)
+	}
+	return allowed, int(b.tokens), refillTime(float64(limit.Burst)-b.tokens, limit.Rate), retry
+}
+
+// Run forgets idle buckets every interval until ctx is done
+func (l *RateLimiter) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			l.sweep()
+		}
+	}
+}
+
+func (l *RateLimiter) sweep() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for key, b := range l.buckets {
+		limit, idle := l.limits[key.route], now.Sub(b.last)
+		if idle >= l.idle && b.tokens+idle.Seconds()*limit.Rate >= float64(limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func (b *bucket) refill(now time.Time, limit RateLimit) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed.Seconds()*limit.Rate)
+		b.last = now
+	}
+}
+
+// client identifies the caller by the principal it has been authenticated as, so kiosks sharing an address do not
+// share a bucket. Unauthenticated callers are identified by the address they connect from, X-Forwarded-For is not
+// trusted since any client may set it
+func client(r *http.Request) string {
+	if principal, ok := auth.PrincipalFrom(r.Context()); ok {
+		return principal.Subject
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return host
+}
+
+func refillTime(tokens float64, rate float64) time.Duration {
+	return time.Duration(tokens / rate * float64(time.Second))
+}
+
+// seconds rounds up so a client waiting for them is not refused again
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
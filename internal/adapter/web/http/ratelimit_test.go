@@ -0,0 +1,190 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/shawnritchie/go-video-store/api"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/auth"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newLimitedServer(t *testing.T, limits ...RateLimit) (*RateLimiter, *fakeClock, http.Handler) {
+	t.Helper()
+	limiter, err := NewRateLimiter(limits, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &fakeClock{now: time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)}
+	limiter.now = clock.Now
+
+	finder := newSpyFilmFinder(func() (*domain.Film, error) {
+		return &domain.Film{Name: FilmName, Director: FilmDirector, Release: domain.New}, nil
+	})
+	s := New(finder, newSpyFilmAppender(nil), NewSpyFilmInvoicer(domain.SEK(40), nil), WithRateLimiter(limiter))
+	return limiter, clock, s.Router()
+}
+
+func limitedRequest(router http.Handler, method string, path string, remoteAddr string, key string) *httptest.ResponseRecorder {
+	body := ""
+	if method == http.MethodPost {
+		body = `{"return":[{"name":"Loki","days":1}]}`
+	}
+	req := storeRequest(method, path, nil, body)
+	req.RemoteAddr = remoteAddr
+	if key != "" {
+		req.Header.Set(auth.APIKeyHeader, key)
+	}
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	return res
+}
+
+func TestRateLimit_RefusesBeyondBurst(t *testing.T) {
+	_, clock, router := newLimitedServer(t, RateLimit{Route: "POST /store/return", Rate: 0.5, Burst: 2})
+
+	for i, remaining := range []string{"1", "0"} {
+		res := limitedRequest(router, http.MethodPost, "/store/return", "10.0.0.1:4000", "")
+		if res.Code != http.StatusOK || res.Header().Get("RateLimit-Limit") != "2" || res.Header().Get("RateLimit-Remaining") != remaining {
+			t.Fatalf("request %d was expecting 200 with %s remaining but got %d %v", i, remaining, res.Code, res.Header())
+		}
+	}
+
+	res := limitedRequest(router, http.MethodPost, "/store/return", "10.0.0.1:4000", "")
+	if res.Code != http.StatusTooManyRequests || res.Header().Get("Retry-After") != "2" || res.Header().Get("RateLimit-Reset") != "4" {
+		t.Fatalf("was expecting 429 retrying after 2s but got %d %v", res.Code, res.Header())
+	}
+	var problem api.Error
+	if err := json.Unmarshal(res.Body.Bytes(), &problem); err != nil || problem.Status != http.StatusTooManyRequests || problem.RequestID == "" {
+		t.Errorf("was expecting a problem carrying a request id but got %s: %v", res.Body.String(), err)
+	}
+
+	clock.now = clock.now.Add(2 * time.Second)
+	if res := limitedRequest(router, http.MethodPost, "/store/return", "10.0.0.1:4000", ""); res.Code != http.StatusOK {
+		t.Errorf("was expecting a token to have been refilled but got %d", res.Code)
+	}
+}
+
+func TestRateLimit_BucketPerClientAndRoute(t *testing.T) {
+	_, _, router := newLimitedServer(t,
+		RateLimit{Route: "POST /store/return", Rate: 1, Burst: 1},
+		RateLimit{Route: anyRoute, Rate: 1, Burst: 1},
+	)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		remoteAddr string
+		key        string
+		status     int
+	}{
+		{"First", http.MethodPost, "/store/return", "10.0.0.1:4000", "", http.StatusOK},
+		{"SameAddressOtherPort", http.MethodPost, "/store/return", "10.0.0.1:4001", "", http.StatusTooManyRequests},
+		{"OtherAddress", http.MethodPost, "/store/return", "10.0.0.2:4000", "", http.StatusOK},
+		{"UnverifiedKeyBehindSameAddress", http.MethodPost, "/store/return", "10.0.0.1:4000", "forged-1", http.StatusTooManyRequests},
+		{"SameKeyOtherAddress", http.MethodPost, "/store/return", "10.0.0.3:4000", "forged-1", http.StatusOK},
+		{"OtherRoute", http.MethodGet, "/catalogue/film?name=Loki", "10.0.0.1:4000", "", http.StatusOK},
+		{"OtherRouteSharesFallback", http.MethodGet, "/openapi.json", "10.0.0.1:4000", "", http.StatusTooManyRequests},
+	}
+	for _, test := range tests {
+		if res := limitedRequest(router, test.method, test.path, test.remoteAddr, test.key); res.Code != test.status {
+			t.Errorf("%s was expecting %d but got %d %s", test.name, test.status, res.Code, res.Body.String())
+		}
+	}
+}
+
+func TestRateLimit_BucketPerPrincipal(t *testing.T) {
+	keys, err := auth.NewAPIKeys([]auth.APIKey{
+		{Hash: auth.HashAPIKey("kiosk-1"), Subject: "kiosk-1", Role: "clerk"},
+		{Hash: auth.HashAPIKey("kiosk-2"), Subject: "kiosk-2", Role: "clerk"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter, err := NewRateLimiter([]RateLimit{{Route: "POST /store/return", Rate: 1, Burst: 1}}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := New(newSpyFilmFinder(nil), newSpyFilmAppender(nil), NewSpyFilmInvoicer(domain.SEK(40), nil),
+		WithAuthenticator(keys), WithRateLimiter(limiter)).Router()
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		key        string
+		status     int
+	}{
+		{"First", "10.0.0.1:4000", "kiosk-1", http.StatusOK},
+		{"OtherKeySameAddress", "10.0.0.1:4001", "kiosk-2", http.StatusOK},
+		{"SameKeySameAddress", "10.0.0.1:4000", "kiosk-1", http.StatusTooManyRequests},
+		{"SameKeyOtherAddress", "10.0.0.2:4000", "kiosk-2", http.StatusTooManyRequests},
+		{"UnknownKeyIsNotLimited", "10.0.0.1:4000", "forged", http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		if res := limitedRequest(router, http.MethodPost, "/store/return", test.remoteAddr, test.key); res.Code != test.status {
+			t.Errorf("%s was expecting %d but got %d %s", test.name, test.status, res.Code, res.Body.String())
+		}
+	}
+}
+
+func TestRateLimit_UnlimitedRoutes(t *testing.T) {
+	_, _, router := newLimitedServer(t, RateLimit{Route: "POST /store/return", Rate: 1, Burst: 1})
+
+	for i := 0; i < 3; i++ {
+		res := limitedRequest(router, http.MethodGet, "/catalogue/film?name=Loki", "10.0.0.1:4000", "")
+		if res.Code != http.StatusOK || res.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("was expecting a route without a limit to be served without headers but got %d %v", res.Code, res.Header())
+		}
+	}
+}
+
+func TestRateLimit_SweepsIdleBuckets(t *testing.T) {
+	limiter, clock, router := newLimitedServer(t, RateLimit{Route: "POST /store/return", Rate: 0.01, Burst: 2})
+	limitedRequest(router, http.MethodPost, "/store/return", "10.0.0.1:4000", "")
+	limitedRequest(router, http.MethodPost, "/store/return", "10.0.0.2:4000", "")
+	limitedRequest(router, http.MethodPost, "/store/return", "10.0.0.2:4000", "")
+
+	clock.now = clock.now.Add(2 * time.Minute)
+	limiter.sweep()
+	if len(limiter.buckets) != 1 {
+		t.Fatalf("was expecting the full bucket to be forgotten and the empty one kept but got %d buckets", len(limiter.buckets))
+	}
+
+	clock.now = clock.now.Add(3 * time.Minute)
+	limiter.sweep()
+	if len(limiter.buckets) != 0 {
+		t.Errorf("was expecting every bucket to be forgotten once refilled but got %d buckets", len(limiter.buckets))
+	}
+}
+
+func TestRateLimit_InvalidConfiguration(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{"NotJSON", `{`},
+		{"NoPath", `[{"route":"POST","rate":1,"burst":1}]`},
+		{"NoRate", `[{"route":"*","rate":0,"burst":1}]`},
+		{"NoBurst", `[{"route":"*","rate":1,"burst":0}]`},
+		{"Twice", `[{"route":"*","rate":1,"burst":1},{"route":"*","rate":2,"burst":2}]`},
+	}
+	for _, test := range tests {
+		if _, err := ParseRateLimits([]byte(test.config), time.Minute); err == nil {
+			t.Errorf("%s was expecting the configuration to be refused", test.name)
+		}
+	}
+	if _, err := ParseRateLimits([]byte(`[{"route":"POST /store/return","rate":0.5,"burst":5},{"route":"*","rate":10,"burst":20}]`), time.Minute); err != nil {
+		t.Errorf("was expecting the configuration to be accepted but got %v", err)
+	}
+}
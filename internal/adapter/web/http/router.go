@@ -2,29 +2,125 @@ package http
 
 import (
 	"github.com/gorilla/mux"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/auth"
 	"net/http"
 )
 
 /*
-curl -X GET http://localhost:8080/catalogue/film?name=Loki -H "Content-Type: application/json"
+Every route is described by openapi.json, served on /openapi.json
 
-curl -X POST http://localhost:8080/catalogue/film/new -H "Content-Type: application/json" -d '{"name":"Loki", "director":"Marvel"}'
+Once an authenticator is configured kiosks present their API key through X-API-Key and staff their JWT through
+Authorization: Bearer. Clerks find films and process returns, managers add films and stock and read the audit
+trail, invoices and event stream, admins change prices and hold every role
+
+The catalogue is shared while stock, prices and invoices belong to a store. Store routes name it in their path,
+/store/return reads it from X-Store-ID, the main store serves every request which names none
+
+Once rentals are configured a film rented out through /store/rent stays rented until a return names its rentalId
+
+Copies move between stores through /transfers. The receiving store requests a transfer, the sending store approves
+and ships it and the receiving store receives it, shipped copies are stocked by neither store until received
+
+Once rate limits are configured every principal, or every IP address when calling unauthenticated, gets a token
+bucket per route. Limited responses carry RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset, refused requests
+are answered 429 Too Many Requests with Retry-After
+
+A POST sent with an Idempotency-Key is answered once, its retries are replayed that response with
+Idempotent-Replayed: true. Reusing the key for another request is refused with 422 Unprocessable Entity
+
+A film is served with its version and media type as ETag, such as "1-application/json", so every representation is
+tagged apart. Managers update it with PUT naming the ETag of any representation in If-Match, an update made to a
+film which has changed since is refused with 412 Precondition Failed and one without If-Match with 428
+
+Responses are negotiated through Accept amongst application/json, text/csv and application/xml, request bodies
+may be sent in any of them
+
+curl -X GET http://localhost:8080/catalogue/film?name=Loki -H "X-API-Key: $KIOSK_KEY"
+curl -X GET http://localhost:8080/catalogue/films -H "Accept: text/csv"
+curl -X GET http://localhost:8080/catalogue/film?name=Loki -H 'If-None-Match: "1-application/json"'
+curl -X PUT http://localhost:8080/catalogue/film?name=Loki -H 'If-Match: "1-application/json"' -H "Content-Type: application/json" -d '{"director":"Marvel", "release":"Regular"}'
+
+curl -X POST http://localhost:8080/catalogue/film/new -H "Authorization: Bearer $TOKEN" -H "Content-Type: application/json" -d '{"name":"Loki", "director":"Marvel"}'
 curl -X POST http://localhost:8080/catalogue/film/regular -H "Content-Type: application/json" -d '{"name":"Black Widow", "director":"Marvel"}'
 curl -X POST http://localhost:8080/catalogue/film/old -H "Content-Type: application/json" -d '{"name":"Morbius", "director":"Marvel"}'
 
 curl -X POST http://localhost:8080/store/return -H "Content-Type: application/json" -d '{"return":[{"name": "Loki", "days": 1}]}'
+curl -X POST http://localhost:8080/store/rent -H "Content-Type: application/json" -d '{"name": "Loki", "days": 3}'
+curl -X POST http://localhost:8080/store/return -H "Content-Type: application/json" -d '{"return":[{"name": "Loki", "days": 2, "rentalId": "'$RENTAL'"}]}'
+curl -X POST http://localhost:8080/store/return -H "Idempotency-Key: $(uuidgen)" -H "Content-Type: application/json" -d '{"return":[{"name": "Loki", "days": 1}]}'
+curl -X POST http://localhost:8080/store/return -H "Content-Type: text/csv" -H "Accept: text/csv" --data-binary $'name,days\nLoki,1\n'
+
+curl -X POST http://localhost:8080/stores/north/inventory -H "Content-Type: application/json" -d '{"film":"Loki", "copies":3}'
+curl -X PUT http://localhost:8080/stores/north/prices -H "Content-Type: application/json" -d '{"premium":45, "basic":35}'
+curl -X POST http://localhost:8080/stores/north/returns -H "Content-Type: application/json" -d '{"return":[{"name": "Loki", "days": 1}]}'
+curl -X GET http://localhost:8080/stores/north/invoices
+
+curl -X POST http://localhost:8080/transfers -H "Content-Type: application/json" -d '{"film":"Loki", "from":"north", "to":"south", "copies":2}'
+curl -X POST http://localhost:8080/transfers/$TRANSFER/approve
+curl -X POST http://localhost:8080/transfers/$TRANSFER/ship
+curl -X POST http://localhost:8080/transfers/$TRANSFER/receive
+curl -X GET http://localhost:8080/stores/south/transfers
+
+curl -X GET "http://localhost:8080/audit?entity=film:Loki&from=2021-06-01T00:00:00Z&to=2021-07-01T00:00:00Z"
+
+curl -N "http://localhost:8080/events/stream?types=FilmAdded,RentalReturned" -H "Last-Event-ID: 42"
 */
 
 func (s *server) Router() (r *mux.Router) {
 	s.once.Do(func() {
 		r = mux.NewRouter()
+		r.Use(s.Logged)
+		r.NotFoundHandler = s.logged(unmatchedRoute, http.NotFoundHandler())
+		r.MethodNotAllowedHandler = s.logged(unmatchedRoute, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}))
+		r.Use(s.withCodecs)
 		//r.HandleFunc("/catalogue/film/new", s.addNewFilm).Methods(http.MethodPost)
 		//r.Handle("/catalogue/film/regular", handler(s.addRegularFilm)).Methods(http.MethodPost)
 		//r.Handle("/catalogue/film/old", handler(s.addOldFilm)).Methods(http.MethodPost)
-		r.Handle("/catalogue/film/{release}", handler(s.addFilm)).Methods(http.MethodPost)
+		r.Handle("/catalogue/film/{release}", s.authorized(auth.Manager, s.idempotent(validated(s.addFilm)))).Methods(http.MethodPost)
+
+		r.Handle("/catalogue/film", s.authorized(auth.Clerk, validated(s.findFilm))).Methods(http.MethodGet)
+		if s.updater != nil {
+			r.Handle("/catalogue/film", s.authorized(auth.Manager, validated(s.updateFilm))).Methods(http.MethodPut)
+		}
+		if s.lister != nil {
+			r.Handle("/catalogue/films", s.authorized(auth.Clerk, validated(s.listFilms))).Methods(http.MethodGet)
+		}
+		r.Handle("/store/return", s.authorized(auth.Clerk, s.idempotent(s.scoped(validated(s.processReturn))))).Methods(http.MethodPost)
+		r.Handle("/stores/{store}/returns", s.authorized(auth.Clerk, s.idempotent(s.scoped(validated(s.processReturn))))).Methods(http.MethodPost)
+		if s.renter != nil {
+			r.Handle("/store/rent", s.authorized(auth.Clerk, s.idempotent(s.scoped(validated(s.rentFilm))))).Methods(http.MethodPost)
+			r.Handle("/stores/{store}/rentals", s.authorized(auth.Clerk, s.idempotent(s.scoped(validated(s.rentFilm))))).Methods(http.MethodPost)
+		}
+		if s.inventory != nil {
+			r.Handle("/stores/{store}/inventory", s.authorized(auth.Clerk, s.scoped(validated(s.listInventory)))).Methods(http.MethodGet)
+			r.Handle("/stores/{store}/inventory", s.authorized(auth.Manager, s.idempotent(s.scoped(validated(s.addStock))))).Methods(http.MethodPost)
+		}
+		if s.prices != nil {
+			r.Handle("/stores/{store}/prices", s.authorized(auth.Clerk, s.scoped(validated(s.findPrices)))).Methods(http.MethodGet)
+			r.Handle("/stores/{store}/prices", s.authorized(auth.Admin, s.scoped(validated(s.changePrices)))).Methods(http.MethodPut)
+		}
+		if s.invoices != nil {
+			r.Handle("/stores/{store}/invoices", s.authorized(auth.Manager, s.scoped(validated(s.listInvoices)))).Methods(http.MethodGet)
+		}
+
+		if s.transfers != nil {
+			r.Handle("/transfers", s.authorized(auth.Manager, s.idempotent(validated(s.requestTransfer)))).Methods(http.MethodPost)
+			r.Handle("/transfers/{id}", s.authorized(auth.Clerk, validated(s.findTransfer))).Methods(http.MethodGet)
+			r.Handle("/transfers/{id}/approve", s.authorized(auth.Manager, s.idempotent(validated(s.approveTransfer)))).Methods(http.MethodPost)
+			r.Handle("/transfers/{id}/ship", s.authorized(auth.Clerk, s.idempotent(validated(s.shipTransfer)))).Methods(http.MethodPost)
+			r.Handle("/transfers/{id}/receive", s.authorized(auth.Clerk, s.idempotent(validated(s.receiveTransfer)))).Methods(http.MethodPost)
+			r.Handle("/stores/{store}/transfers", s.authorized(auth.Clerk, s.scoped(validated(s.listTransfers)))).Methods(http.MethodGet)
+		}
 
-		r.Handle("/catalogue/film", handler(s.findFilm)).Methods(http.MethodGet)
-		r.Handle("/store/return", handler(s.processReturn)).Methods(http.MethodPost)
+		if s.auditTrail != nil {
+			r.Handle("/audit", s.authorized(auth.Manager, validated(s.findAuditEntries))).Methods(http.MethodGet)
+		}
+		if s.eventStream != nil {
+			r.Handle("/events/stream", s.authorized(auth.Manager, http.HandlerFunc(s.streamEvents))).Methods(http.MethodGet)
+		}
+		r.Handle("/openapi.json", s.limited(http.HandlerFunc(serveOpenAPI))).Methods(http.MethodGet)
 		s.router = r
 	})
 	return s.router
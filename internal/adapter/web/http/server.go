@@ -2,23 +2,96 @@ package http
 
 import (
 	"github.com/gorilla/mux"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/auth"
 	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
 	"sync"
+	"time"
 )
 
 type server struct {
-	finder   driven.FilmFinder
-	appender driven.FilmAppender
-	invoicer driven.FilmInvoicer
-	once     sync.Once
-	router   *mux.Router
+	finder        driven.FilmFinder
+	appender      driven.FilmAppender
+	invoicer      driven.FilmInvoicer
+	renter        driven.FilmRenter
+	lister        driven.FilmLister
+	updater       driven.FilmUpdater
+	auditTrail    driven.AuditTrail
+	eventStream   driven.EventStream
+	inventory     driven.Inventory
+	prices        driven.PriceLists
+	invoices      driven.InvoiceHistory
+	transfers     driven.Transfers
+	authenticator auth.Authenticator
+	logger        *slog.Logger
+	metrics       RequestObserver
+	tracer        trace.Tracer
+	limiter       *RateLimiter
+	idempotency   driver.IdempotencyStore
+	replayTTL     time.Duration
+	codecs        []Codec
+	once          sync.Once
+	router        *mux.Router
 }
 
-func New(finder driven.FilmFinder, appender driven.FilmAppender, invoicer driven.FilmInvoicer) *server {
-	return &server{
+type Option func(s *server)
+
+func New(finder driven.FilmFinder, appender driven.FilmAppender, invoicer driven.FilmInvoicer, options ...Option) *server {
+	s := &server{
 		finder:   finder,
 		appender: appender,
 		invoicer: invoicer,
+		logger:   slog.Default(),
+		tracer:   otel.Tracer(tracerName),
+		codecs:   append([]Codec{}, defaultCodecs...),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// WithLister exposes the whole catalogue on GET /catalogue/films
+func WithLister(lister driven.FilmLister) Option {
+	return func(s *server) {
+		s.lister = lister
+	}
+}
+
+// WithCodec serves and accepts another media type, a codec replaces the one registered for the same media type
+func WithCodec(codec Codec) Option {
+	return func(s *server) {
+		for i, registered := range s.codecs {
+			if registered.MediaType() == codec.MediaType() {
+				s.codecs[i] = codec
+				return
+			}
+		}
+		s.codecs = append(s.codecs, codec)
+	}
+}
+
+// WithAuditTrail exposes the audit trail on GET /audit
+func WithAuditTrail(trail driven.AuditTrail) Option {
+	return func(s *server) {
+		s.auditTrail = trail
+	}
+}
+
+// WithRenter rents films out on POST /store/rent and /stores/{store}/rentals, returns then name the rental id
+func WithRenter(renter driven.FilmRenter) Option {
+	return func(s *server) {
+		s.renter = renter
+	}
+}
+
+// WithEventStream pushes the published events as server-sent events on GET /events/stream
+func WithEventStream(stream driven.EventStream) Option {
+	return func(s *server) {
+		s.eventStream = stream
 	}
 }
 
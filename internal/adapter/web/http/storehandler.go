@@ -0,0 +1,148 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/shawnritchie/go-video-store/api"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/auth"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"net/http"
+)
+
+type (
+	inventoryResponse = api.Inventory
+	addStockRequest   = api.AddStockRequest
+	priceListBody     = api.PriceList
+	invoicesResponse  = api.InvoiceHistory
+)
+
+// storeHeader names the store of the routes which are not scoped through their path, such as /store/return
+const storeHeader = auth.StoreHeader
+
+// WithStores exposes the inventory, prices and invoices of every store under /stores/{store}
+func WithStores(inventory driven.Inventory, prices driven.PriceLists, invoices driven.InvoiceHistory) Option {
+	return func(s *server) {
+		s.inventory = inventory
+		s.prices = prices
+		s.invoices = invoices
+	}
+}
+
+// scoped resolves the store of the request from its path, falling back onto the X-Store-ID header and then onto
+// domain.DefaultStore, and scopes every service call to it. A principal confined to another store is refused
+func (s *server) scoped(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		store, err := requestStore(r)
+		if principal, ok := auth.PrincipalFrom(r.Context()); err == nil && ok && !principal.ActsFor(store) {
+			err = authError(w, &auth.ForbiddenError{Principal: principal, Store: store})
+		}
+		if err != nil {
+			r = withRequestID(w, r)
+			writeError(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(driven.WithStore(r.Context(), store)))
+	})
+}
+
+func requestStore(r *http.Request) (domain.StoreID, error) {
+	path, header := domain.StoreID(mux.Vars(r)["store"]), domain.StoreID(r.Header.Get(storeHeader))
+	store := path
+	switch {
+	case path != "" && header != "" && path != header:
+		return "", NewClientError(nil, http.StatusBadRequest,
+			fmt.Sprintf("Bad Request: %s %q contradicts the store %q of the path", storeHeader, header, path))
+	case store == "":
+		store = header
+	}
+	if store == "" {
+		return domain.DefaultStore, nil
+	}
+
+	if err := store.IsValid(); err != nil {
+		return "", NewValidationError(err, fmt.Sprintf("Bad Request: %q is not a store", store), []FieldError{{Field: "store", Message: err.Error()}})
+	}
+	return store, nil
+}
+
+func (s *server) listInventory(w http.ResponseWriter, r *http.Request) error {
+	inventory, err := s.inventory.Inventory(r.Context())
+	if err != nil {
+		return fmt.Errorf("unable to read inventory: %w", err)
+	}
+
+	response := inventoryResponse{Stock: make([]api.Stock, 0, len(inventory))}
+	for _, stock := range inventory {
+		response.Stock = append(response.Stock, api.Stock{Film: stock.Film, Copies: stock.Copies})
+	}
+	return respond(w, r, response)
+}
+
+func (s *server) addStock(w http.ResponseWriter, r *http.Request) error {
+	var request addStockRequest
+	if err := decode(r, &request); err != nil || !request.IsValid() {
+		return NewClientError(err, http.StatusBadRequest, "Bad Request: Post payload cannot be deserialized")
+	}
+
+	stock, err := s.inventory.AddStock(r.Context(), request.Film, request.Copies)
+	if err != nil {
+		switch {
+		case errors.As(err, &driven.TypeFilmNotFound):
+			return NewValidationError(err, "Bad Request: only catalogued films can be stocked", []FieldError{{Field: "film", Message: err.Error()}})
+		case errors.Is(err, domain.InvalidCopiesError):
+			return NewValidationError(err, "Bad Request: stock cannot be added", []FieldError{{Field: "copies", Message: err.Error()}})
+		default:
+			return fmt.Errorf("unable to add stock: %w", err)
+		}
+	}
+	return respond(w, r, api.Stock{Film: stock.Film, Copies: stock.Copies})
+}
+
+func (s *server) findPrices(w http.ResponseWriter, r *http.Request) error {
+	prices, err := s.prices.PriceList(r.Context())
+	if err != nil {
+		return fmt.Errorf("unable to read price list: %w", err)
+	}
+	return respond(w, r, priceListBody{Premium: uint64(prices.Premium), Basic: uint64(prices.Basic)})
+}
+
+func (s *server) changePrices(w http.ResponseWriter, r *http.Request) error {
+	var request priceListBody
+	if err := decode(r, &request); err != nil || !request.IsValid() {
+		return NewClientError(err, http.StatusBadRequest, "Bad Request: Put payload cannot be deserialized")
+	}
+
+	prices := domain.PriceList{Premium: domain.SEK(request.Premium), Basic: domain.SEK(request.Basic)}
+	if err := s.prices.ChangePrices(r.Context(), prices); err != nil {
+		if errors.Is(err, domain.InvalidPriceListError) {
+			return NewClientError(err, http.StatusBadRequest, "Bad Request: "+err.Error())
+		}
+		return fmt.Errorf("unable to change prices: %w", err)
+	}
+	return respond(w, r, request)
+}
+
+func (s *server) listInvoices(w http.ResponseWriter, r *http.Request) error {
+	invoices, err := s.invoices.Invoices(r.Context())
+	if err != nil {
+		return fmt.Errorf("unable to read invoices: %w", err)
+	}
+
+	response := invoicesResponse{Invoices: make([]api.IssuedInvoice, 0, len(invoices))}
+	for _, invoice := range invoices {
+		returns := make([]rental, 0, len(invoice.Rentals))
+		for _, r := range invoice.Rentals {
+			returns = append(returns, rental{Name: r.Film.Name, Days: uint16(r.Days)})
+		}
+		response.Invoices = append(response.Invoices, api.IssuedInvoice{
+			ID:       invoice.ID,
+			Store:    string(invoice.Store),
+			Return:   returns,
+			Price:    uint64(invoice.Cost),
+			IssuedAt: invoice.IssuedAt,
+		})
+	}
+	return respond(w, r, response)
+}
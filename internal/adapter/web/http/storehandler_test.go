@@ -0,0 +1,255 @@
+package http
+
+import (
+	"context"
+	"github.com/shawnritchie/go-video-store/api"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/auth"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// spyStores keeps everything by the store the context is scoped to, as the service does
+type spyStores struct {
+	stock    map[domain.StoreID]map[string]int
+	prices   map[domain.StoreID]domain.PriceList
+	invoices map[domain.StoreID][]domain.IssuedInvoice
+	scopes   []domain.StoreID
+}
+
+func newSpyStores() *spyStores {
+	return &spyStores{
+		stock:    map[domain.StoreID]map[string]int{},
+		prices:   map[domain.StoreID]domain.PriceList{},
+		invoices: map[domain.StoreID][]domain.IssuedInvoice{},
+	}
+}
+
+func (s *spyStores) Inventory(ctx context.Context) ([]domain.Stock, error) {
+	var inventory []domain.Stock
+	for film, copies := range s.stock[driven.StoreFrom(ctx)] {
+		inventory = append(inventory, domain.Stock{Film: film, Copies: copies})
+	}
+	return inventory, nil
+}
+
+func (s *spyStores) AddStock(ctx context.Context, film string, copies int) (*domain.Stock, error) {
+	if film != FilmName {
+		return nil, &driven.FilmNotFoundError{Name: film}
+	}
+	store := driven.StoreFrom(ctx)
+	if s.stock[store] == nil {
+		s.stock[store] = map[string]int{}
+	}
+	s.stock[store][film] += copies
+	return &domain.Stock{Film: film, Copies: s.stock[store][film]}, nil
+}
+
+func (s *spyStores) PriceList(ctx context.Context) (domain.PriceList, error) {
+	if prices, ok := s.prices[driven.StoreFrom(ctx)]; ok {
+		return prices, nil
+	}
+	return domain.DefaultPriceList, nil
+}
+
+func (s *spyStores) ChangePrices(ctx context.Context, prices domain.PriceList) error {
+	s.prices[driven.StoreFrom(ctx)] = prices
+	return nil
+}
+
+func (s *spyStores) Invoices(ctx context.Context) ([]domain.IssuedInvoice, error) {
+	return s.invoices[driven.StoreFrom(ctx)], nil
+}
+
+// Invoice records the store every return is made to and keeps the invoice in its history
+func (s *spyStores) Invoice(ctx context.Context, request []driven.FilmReturn) (*domain.RentalInvoice, error) {
+	store := driven.StoreFrom(ctx)
+	s.scopes = append(s.scopes, store)
+	invoice, _ := NewSpyFilmInvoicer(40, nil).Invoice(ctx, request)
+	s.invoices[store] = append(s.invoices[store], domain.IssuedInvoice{
+		ID:       "invoice",
+		Store:    store,
+		Rentals:  invoice.Rentals,
+		Cost:     invoice.Cost,
+		IssuedAt: time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+	})
+	return invoice, nil
+}
+
+func newStoresServer(stores *spyStores, options ...Option) *server {
+	return New(nil, nil, stores, append([]Option{WithStores(stores, stores, stores)}, options...)...)
+}
+
+func storeRequest(method string, path string, headers map[string]string, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return req
+}
+
+func TestStores_ResolvesStore(t *testing.T) {
+	const body = `{"return":[{"name":"Loki","days":1}]}`
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		status int
+		store  domain.StoreID
+	}{
+		{"Path", "/stores/north/returns", "", http.StatusOK, "north"},
+		{"Header", "/store/return", "south", http.StatusOK, "south"},
+		{"Default", "/store/return", "", http.StatusOK, domain.DefaultStore},
+		{"PathAndHeaderAgree", "/stores/north/returns", "north", http.StatusOK, "north"},
+		{"PathAndHeaderDisagree", "/stores/north/returns", "south", http.StatusBadRequest, ""},
+		{"InvalidPath", "/stores/North/returns", "", http.StatusBadRequest, ""},
+		{"InvalidHeader", "/store/return", "../main", http.StatusBadRequest, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stores := newSpyStores()
+			headers := map[string]string{}
+			if test.header != "" {
+				headers[storeHeader] = test.header
+			}
+			res := httptest.NewRecorder()
+			newStoresServer(stores).Router().ServeHTTP(res, storeRequest(http.MethodPost, test.path, headers, body))
+
+			switch {
+			case res.Code != test.status:
+				t.Errorf("was expecting %d but got %d %s", test.status, res.Code, res.Body.String())
+			case test.store != "" && (len(stores.scopes) != 1 || stores.scopes[0] != test.store):
+				t.Errorf("was expecting the return to be made to %q but got %v", test.store, stores.scopes)
+			case test.store == "" && len(stores.scopes) != 0:
+				t.Errorf("was expecting no return to be made but got %v", stores.scopes)
+			}
+		})
+	}
+}
+
+func TestStores_StockAndInvoicesAreIsolated(t *testing.T) {
+	router := newStoresServer(newSpyStores()).Router()
+	serve := func(method string, path string, body string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, storeRequest(method, path, nil, body))
+		if res.Code != http.StatusOK {
+			t.Fatalf("%s %s responded %d %s", method, path, res.Code, res.Body.String())
+		}
+		return res
+	}
+
+	serve(http.MethodPost, "/stores/north/inventory", `{"film":"Loki","copies":2}`)
+	serve(http.MethodPost, "/stores/north/returns", `{"return":[{"name":"Loki","days":1}]}`)
+
+	var north, south api.Inventory
+	unmarshalBody(t, serve(http.MethodGet, "/stores/north/inventory", ""), &north)
+	unmarshalBody(t, serve(http.MethodGet, "/stores/south/inventory", ""), &south)
+	if len(north.Stock) != 1 || north.Stock[0] != (api.Stock{Film: FilmName, Copies: 2}) || len(south.Stock) != 0 {
+		t.Errorf("was expecting only north to hold stock but got %#v and %#v", north, south)
+	}
+
+	var northInvoices, southInvoices api.InvoiceHistory
+	unmarshalBody(t, serve(http.MethodGet, "/stores/north/invoices", ""), &northInvoices)
+	unmarshalBody(t, serve(http.MethodGet, "/stores/south/invoices", ""), &southInvoices)
+	if len(northInvoices.Invoices) != 1 || northInvoices.Invoices[0].Store != "north" || southInvoices.Invoices == nil || len(southInvoices.Invoices) != 0 {
+		t.Errorf("was expecting only north to have issued an invoice but got %#v and %#v", northInvoices, southInvoices)
+	}
+}
+
+func TestStores_Prices(t *testing.T) {
+	router := newStoresServer(newSpyStores()).Router()
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, storeRequest(http.MethodPut, "/stores/north/prices", nil, `{"premium":45,"basic":35}`))
+	if res.Code != http.StatusOK {
+		t.Fatalf("was expecting the prices to change but got %d %s", res.Code, res.Body.String())
+	}
+
+	for store, expected := range map[string]api.PriceList{"north": {Premium: 45, Basic: 35}, "south": {Premium: 40, Basic: 30}} {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, storeRequest(http.MethodGet, "/stores/"+store+"/prices", nil, ""))
+		var prices api.PriceList
+		unmarshalBody(t, res, &prices)
+		if prices != expected {
+			t.Errorf("was expecting %s to charge %#v but got %#v", store, expected, prices)
+		}
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, storeRequest(http.MethodPut, "/stores/north/prices", nil, `{"premium":0,"basic":35}`))
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("was expecting free rentals to be refused but got %d", res.Code)
+	}
+}
+
+func TestStores_AddStockOfUnknownFilm(t *testing.T) {
+	res := httptest.NewRecorder()
+	newStoresServer(newSpyStores()).Router().ServeHTTP(res, storeRequest(http.MethodPost, "/stores/north/inventory", nil, `{"film":"Unknown","copies":1}`))
+
+	var problem Error
+	unmarshalBody(t, res, &problem)
+	if res.Code != http.StatusBadRequest || len(problem.Errors) != 1 || problem.Errors[0].Field != "film" {
+		t.Errorf("was expecting the film to be named as the offending field but got %d %#v", res.Code, problem)
+	}
+}
+
+func TestStores_ConfinedPrincipal(t *testing.T) {
+	keys, err := auth.NewAPIKeys([]auth.APIKey{
+		{Hash: auth.HashAPIKey("north-kiosk"), Subject: "kiosk-1", Role: "clerk", Store: "north"},
+		{Hash: auth.HashAPIKey("north-manager"), Subject: "bob", Role: "manager", Store: "north"},
+		{Hash: auth.HashAPIKey("admin"), Subject: "carol", Role: "admin"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := newStoresServer(newSpyStores(), WithAuthenticator(keys)).Router()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		header string
+		status int
+	}{
+		{"OwnStore", http.MethodGet, "/stores/north/inventory", "north-kiosk", "", http.StatusOK},
+		{"OtherStore", http.MethodGet, "/stores/south/inventory", "north-kiosk", "", http.StatusForbidden},
+		{"OwnStoreThroughHeader", http.MethodPost, "/store/return", "north-kiosk", "north", http.StatusOK},
+		{"OtherStoreThroughHeader", http.MethodPost, "/store/return", "north-kiosk", "south", http.StatusForbidden},
+		{"DefaultStore", http.MethodPost, "/store/return", "north-kiosk", "", http.StatusForbidden},
+		{"ClerkAddsStock", http.MethodPost, "/stores/north/inventory", "north-kiosk", "", http.StatusForbidden},
+		{"ManagerAddsStock", http.MethodPost, "/stores/north/inventory", "north-manager", "", http.StatusOK},
+		{"ManagerChangesPrices", http.MethodPut, "/stores/north/prices", "north-manager", "", http.StatusForbidden},
+		{"AdminChangesPricesAnywhere", http.MethodPut, "/stores/south/prices", "admin", "", http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var body string
+			switch {
+			case strings.HasSuffix(test.path, "return"):
+				body = `{"return":[{"name":"Loki","days":1}]}`
+			case strings.HasSuffix(test.path, "inventory") && test.method == http.MethodPost:
+				body = `{"film":"Loki","copies":1}`
+			case strings.HasSuffix(test.path, "prices"):
+				body = `{"premium":45,"basic":35}`
+			}
+			headers := map[string]string{auth.APIKeyHeader: test.key}
+			if test.header != "" {
+				headers[storeHeader] = test.header
+			}
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, storeRequest(test.method, test.path, headers, body))
+			if res.Code != test.status {
+				t.Errorf("was expecting %d but got %d %s", test.status, res.Code, res.Body.String())
+			}
+		})
+	}
+}
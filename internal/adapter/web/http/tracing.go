@@ -0,0 +1,55 @@
+package http
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+const tracerName = "github.com/shawnritchie/go-video-store/internal/adapter/web/http"
+
+// traceContext reads the trace a request belongs to from its W3C traceparent and tracestate headers
+var traceContext = propagation.TraceContext{}
+
+// WithTracerProvider traces every request served, continuing the trace the client started when it sent one,
+// through the global provider without it
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(s *server) {
+		s.tracer = provider.Tracer(tracerName)
+	}
+}
+
+// startSpan opens the server span of a request served on route, the spans of the service are opened within it
+func (s *server) startSpan(r *http.Request, route string) (*http.Request, trace.Span) {
+	ctx := traceContext.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := s.tracer.Start(ctx, r.Method+" "+route,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("http.route", route),
+			attribute.String("url.path", r.URL.Path),
+		))
+	return r.WithContext(ctx), span
+}
+
+// endSpan marks the span as failed when the request was answered with a server error, client errors are the
+// fault of the client and not of the server
+func endSpan(span trace.Span, status int, err error) {
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+	if status >= http.StatusInternalServerError {
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+	span.End()
+}
+
+// traceDecoding times the decoding of the request body under the span of the request
+func traceDecoding(r *http.Request) trace.Span {
+	tracer := trace.SpanFromContext(r.Context()).TracerProvider().Tracer(tracerName)
+	_, span := tracer.Start(r.Context(), "decode", trace.WithAttributes(attribute.String("http.request.header.content-type", r.Header.Get("Content-Type"))))
+	return span
+}
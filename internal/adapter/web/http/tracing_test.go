@@ -0,0 +1,108 @@
+package http
+
+import (
+	"errors"
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/inmem"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/service"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	traceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+)
+
+func newTracerProvider() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	return sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)), exporter
+}
+
+// spansByName indexes the spans ended by their name, the last span ended under a name is kept
+func spansByName(spans tracetest.SpanStubs) map[string]tracetest.SpanStub {
+	named := map[string]tracetest.SpanStub{}
+	for _, span := range spans {
+		named[span.Name] = span
+	}
+	return named
+}
+
+func TestTracing_ReturnIsTracedThroughTheService(t *testing.T) {
+	provider, exporter := newTracerProvider()
+	catalogue := inmem.NewStoreCatalogue(domain.Film{Name: FilmName, Director: FilmDirector, Release: domain.New})
+	svc := service.New(catalogue, catalogue, service.WithTracerProvider(provider))
+	server := New(svc, svc, svc, WithTracerProvider(provider))
+
+	res := httptest.NewRecorder()
+	server.Router().ServeHTTP(res, storeRequest(http.MethodPost, "/store/return", map[string]string{"traceparent": traceParent},
+		`{"return":[{"name":"Loki","days":2}]}`))
+	if res.Code != http.StatusOK {
+		t.Fatalf("got status %d but wanted %d: %s", res.Code, http.StatusOK, res.Body.String())
+	}
+
+	spans := spansByName(exporter.GetSpans())
+	request, decode, invoice, lookup := spans["POST /store/return"], spans["decode"], spans["StoreService.Invoice"], spans["Queryable.FindBy"]
+	switch {
+	case request.SpanContext.TraceID().String() != traceID || !request.Parent.IsRemote():
+		t.Errorf("was expecting the request to continue the trace of its traceparent but got %v", request.SpanContext.TraceID())
+	case decode.Parent.SpanID() != request.SpanContext.SpanID() || invoice.Parent.SpanID() != request.SpanContext.SpanID():
+		t.Errorf("was expecting the decoding and the invoice to be traced within the request")
+	case lookup.SpanContext.TraceID().String() != traceID || !lookup.SpanContext.IsValid():
+		t.Errorf("was expecting the film lookup to be traced within the request but got %#v", lookup)
+	}
+}
+
+func TestTracing_RequestSpans(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    *http.Request
+		err    error
+		span   string
+		status int
+		failed bool
+	}{
+		{"Served", storeRequest(http.MethodGet, "/catalogue/film?name=Loki", nil, ""), nil, "GET /catalogue/film", http.StatusOK, false},
+		{"ServerError", storeRequest(http.MethodGet, "/catalogue/film?name=Loki", nil, ""), errors.New("connection refused"), "GET /catalogue/film", http.StatusInternalServerError, true},
+		{"ClientError", storeRequest(http.MethodGet, "/catalogue/film", nil, ""), nil, "GET /catalogue/film", http.StatusBadRequest, false},
+		{"NotRouted", storeRequest(http.MethodGet, "/catalogue/vhs/1", nil, ""), nil, "GET " + unmatchedRoute, http.StatusNotFound, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider, exporter := newTracerProvider()
+			finder := newSpyFilmFinder(func() (*domain.Film, error) {
+				if test.err != nil {
+					return nil, test.err
+				}
+				return &domain.Film{Name: FilmName, Director: FilmDirector, Release: FilmRelease}, nil
+			})
+			server := New(finder, nil, nil, WithTracerProvider(provider))
+
+			server.Router().ServeHTTP(httptest.NewRecorder(), test.req)
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("was expecting a single span but got %d", len(spans))
+			}
+			span := spans[0]
+			var status int64
+			for _, attr := range span.Attributes {
+				if attr.Key == "http.response.status_code" {
+					status = attr.Value.AsInt64()
+				}
+			}
+			switch {
+			case span.Name != test.span || status != int64(test.status):
+				t.Errorf("was expecting span %q with status %d but got %q with %d", test.span, test.status, span.Name, status)
+			case (span.Status.Code == codes.Error) != test.failed:
+				t.Errorf("was expecting the span to be failed %v but got %v", test.failed, span.Status)
+			case span.Parent.IsValid():
+				t.Errorf("was expecting a request without a traceparent to start a new trace")
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/shawnritchie/go-video-store/api"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/auth"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"net/http"
+)
+
+type (
+	transferRequest   = api.TransferRequest
+	transferResponse  = api.Transfer
+	transfersResponse = api.TransferList
+)
+
+// WithTransfers exposes the transfer of copies between stores under /transfers, the store a transfer is requested
+// for and received by is its destination while approving and shipping it is left to its source
+func WithTransfers(transfers driven.Transfers) Option {
+	return func(s *server) {
+		s.transfers = transfers
+	}
+}
+
+func (s *server) requestTransfer(w http.ResponseWriter, r *http.Request) error {
+	var request transferRequest
+	if err := decode(r, &request); err != nil || !request.IsValid() {
+		return NewClientError(err, http.StatusBadRequest, "Bad Request: Post payload cannot be deserialized")
+	}
+
+	from, to := domain.StoreID(request.From), domain.StoreID(request.To)
+	var fields []FieldError
+	for _, store := range []struct {
+		field string
+		id    domain.StoreID
+	}{{"from", from}, {"to", to}} {
+		if err := store.id.IsValid(); err != nil {
+			fields = append(fields, FieldError{Field: store.field, Message: err.Error()})
+		}
+	}
+	if len(fields) > 0 {
+		return NewValidationError(domain.InvalidStoreIDError, "Bad Request: transfers move copies between stores", fields)
+	}
+	if err := actFor(w, r, to); err != nil {
+		return err
+	}
+
+	transfer, err := s.transfers.RequestTransfer(driven.WithStore(r.Context(), to), request.Film, from, to, request.Copies)
+	if err != nil {
+		return transferError(err, "unable to request transfer")
+	}
+	return respond(w, r, toTransferResponse(*transfer))
+}
+
+func (s *server) findTransfer(w http.ResponseWriter, r *http.Request) error {
+	transfer, err := s.transfers.Transfer(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		return transferError(err, "unable to find transfer")
+	}
+	if err := actFor(w, r, transfer.From, transfer.To); err != nil {
+		return err
+	}
+	return respond(w, r, toTransferResponse(*transfer))
+}
+
+func (s *server) listTransfers(w http.ResponseWriter, r *http.Request) error {
+	transfers, err := s.transfers.Transfers(r.Context())
+	if err != nil {
+		return fmt.Errorf("unable to read transfers: %w", err)
+	}
+
+	response := transfersResponse{Transfers: make([]api.Transfer, 0, len(transfers))}
+	for _, transfer := range transfers {
+		response.Transfers = append(response.Transfers, toTransferResponse(transfer))
+	}
+	return respond(w, r, response)
+}
+
+func (s *server) approveTransfer(w http.ResponseWriter, r *http.Request) error {
+	return s.advanceTransfer(w, r, fromSource, s.transfers.ApproveTransfer)
+}
+
+func (s *server) shipTransfer(w http.ResponseWriter, r *http.Request) error {
+	return s.advanceTransfer(w, r, fromSource, s.transfers.ShipTransfer)
+}
+
+func (s *server) receiveTransfer(w http.ResponseWriter, r *http.Request) error {
+	return s.advanceTransfer(w, r, toDestination, s.transfers.ReceiveTransfer)
+}
+
+func fromSource(t *domain.Transfer) domain.StoreID    { return t.From }
+func toDestination(t *domain.Transfer) domain.StoreID { return t.To }
+
+// advanceTransfer refuses principals confined to another store than the one the step is taken by, the step is
+// scoped to that store
+func (s *server) advanceTransfer(w http.ResponseWriter, r *http.Request, by func(*domain.Transfer) domain.StoreID,
+	step func(ctx context.Context, id string) (*domain.Transfer, error)) error {
+	id := mux.Vars(r)["id"]
+	transfer, err := s.transfers.Transfer(r.Context(), id)
+	if err != nil {
+		return transferError(err, "unable to find transfer")
+	}
+	store := by(transfer)
+	if err := actFor(w, r, store); err != nil {
+		return err
+	}
+
+	if transfer, err = step(driven.WithStore(r.Context(), store), id); err != nil {
+		return transferError(err, "unable to advance transfer")
+	}
+	return respond(w, r, toTransferResponse(*transfer))
+}
+
+// actFor refuses a principal confined to a store other than any of stores, every principal is let through when
+// no authenticator has been configured
+func actFor(w http.ResponseWriter, r *http.Request, stores ...domain.StoreID) error {
+	principal, ok := auth.PrincipalFrom(r.Context())
+	if !ok {
+		return nil
+	}
+	for _, store := range stores {
+		if principal.ActsFor(store) {
+			return nil
+		}
+	}
+	return authError(w, &auth.ForbiddenError{Principal: principal, Store: stores[0]})
+}
+
+func transferError(err error, message string) error {
+	var notFound *driven.TransferNotFoundError
+	switch {
+	case errors.As(err, &notFound):
+		return NewClientError(err, http.StatusNotFound, fmt.Sprintf("Transfer Not Found: Transfer %q not found", notFound.ID))
+	case errors.As(err, &domain.TypeTransferStatus), errors.As(err, &driven.TypeInsufficientStock):
+		return NewClientError(err, http.StatusConflict, "Status Conflict: "+err.Error())
+	case errors.As(err, &driven.TypeFilmNotFound), errors.Is(err, domain.EmptyFilmNameError):
+		return NewValidationError(err, "Bad Request: only catalogued films can be transferred", []FieldError{{Field: "film", Message: err.Error()}})
+	case errors.Is(err, domain.InvalidCopiesError):
+		return NewValidationError(err, "Bad Request: transfer cannot be requested", []FieldError{{Field: "copies", Message: err.Error()}})
+	case errors.Is(err, domain.SameStoreTransferError):
+		return NewValidationError(err, "Bad Request: transfer cannot be requested", []FieldError{{Field: "to", Message: err.Error()}})
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+func toTransferResponse(t domain.Transfer) transferResponse {
+	return transferResponse{
+		ID:          t.ID,
+		Film:        t.Film,
+		From:        string(t.From),
+		To:          string(t.To),
+		Copies:      t.Copies,
+		Status:      string(t.Status),
+		RequestedAt: t.RequestedAt,
+	}
+}
@@ -0,0 +1,235 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/shawnritchie/go-video-store/api"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/auth"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// spyTransfers steps transfers through the domain and records the store every step is scoped to, approval is
+// refused when the sending store holds fewer than available copies
+type spyTransfers struct {
+	transfers map[string]*domain.Transfer
+	available int
+	scopes    []domain.StoreID
+}
+
+func newSpyTransfers() *spyTransfers {
+	return &spyTransfers{transfers: map[string]*domain.Transfer{}, available: 10}
+}
+
+func (s *spyTransfers) RequestTransfer(ctx context.Context, film string, from domain.StoreID, to domain.StoreID, copies int) (*domain.Transfer, error) {
+	s.scopes = append(s.scopes, driven.StoreFrom(ctx))
+	if film != FilmName {
+		return nil, &driven.FilmNotFoundError{Name: film}
+	}
+	requested, err := domain.RequestTransfer(strconv.Itoa(len(s.transfers)+1), film, from, to, copies)
+	if err != nil {
+		return nil, err
+	}
+	transfer := &domain.Transfer{RequestedAt: time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)}
+	transfer.Apply(requested)
+	s.transfers[transfer.ID] = transfer
+	return transfer, nil
+}
+
+func (s *spyTransfers) ApproveTransfer(ctx context.Context, id string) (*domain.Transfer, error) {
+	return s.step(ctx, id, func(t *domain.Transfer) (domain.Event, error) {
+		if t.Copies > s.available {
+			return nil, &driven.InsufficientStockError{Store: t.From, Film: t.Film, Available: s.available, Requested: t.Copies}
+		}
+		return t.Approve()
+	})
+}
+
+func (s *spyTransfers) ShipTransfer(ctx context.Context, id string) (*domain.Transfer, error) {
+	return s.step(ctx, id, (*domain.Transfer).Ship)
+}
+
+func (s *spyTransfers) ReceiveTransfer(ctx context.Context, id string) (*domain.Transfer, error) {
+	return s.step(ctx, id, (*domain.Transfer).Receive)
+}
+
+func (s *spyTransfers) Transfer(_ context.Context, id string) (*domain.Transfer, error) {
+	transfer, ok := s.transfers[id]
+	if !ok {
+		return nil, &driven.TransferNotFoundError{ID: id}
+	}
+	found := *transfer
+	return &found, nil
+}
+
+func (s *spyTransfers) Transfers(ctx context.Context) ([]domain.Transfer, error) {
+	var transfers []domain.Transfer
+	for _, transfer := range s.transfers {
+		if store := driven.StoreFrom(ctx); transfer.From == store || transfer.To == store {
+			transfers = append(transfers, *transfer)
+		}
+	}
+	return transfers, nil
+}
+
+func (s *spyTransfers) step(ctx context.Context, id string, step func(*domain.Transfer) (domain.Event, error)) (*domain.Transfer, error) {
+	s.scopes = append(s.scopes, driven.StoreFrom(ctx))
+	transfer, err := s.Transfer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	event, err := step(transfer)
+	if err != nil {
+		return nil, err
+	}
+	transfer.Apply(event)
+	s.transfers[id] = transfer
+	return transfer, nil
+}
+
+func newTransfersServer(transfers *spyTransfers, options ...Option) *server {
+	return New(nil, nil, nil, append([]Option{WithTransfers(transfers)}, options...)...)
+}
+
+func TestTransfers_Workflow(t *testing.T) {
+	transfers := newSpyTransfers()
+	router := newTransfersServer(transfers).Router()
+	serve := func(method string, path string, body string) api.Transfer {
+		t.Helper()
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, storeRequest(method, path, nil, body))
+		var transfer api.Transfer
+		if res.Code != http.StatusOK || json.Unmarshal(res.Body.Bytes(), &transfer) != nil {
+			t.Fatalf("%s %s was expecting 200 but got %d %s", method, path, res.Code, res.Body.String())
+		}
+		return transfer
+	}
+
+	transfer := serve(http.MethodPost, "/transfers", `{"film":"Loki","from":"north","to":"south","copies":2}`)
+	expected := api.Transfer{ID: "1", Film: FilmName, From: "north", To: "south", Copies: 2, Status: "Requested",
+		RequestedAt: time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)}
+	if transfer != expected {
+		t.Errorf("was expecting %#v but got %#v", expected, transfer)
+	}
+	for _, step := range []struct {
+		action string
+		status string
+	}{{"approve", "Approved"}, {"ship", "InTransit"}, {"receive", "Received"}} {
+		if transfer = serve(http.MethodPost, "/transfers/1/"+step.action, ""); transfer.Status != step.status {
+			t.Errorf("was expecting %s to leave the transfer %s but got %s", step.action, step.status, transfer.Status)
+		}
+	}
+	if transfer = serve(http.MethodGet, "/transfers/1", ""); transfer.Status != "Received" {
+		t.Errorf("was expecting the transfer to be received but got %#v", transfer)
+	}
+
+	expectedScopes := []domain.StoreID{"south", "north", "north", "south"}
+	for i, store := range expectedScopes {
+		if len(transfers.scopes) != len(expectedScopes) || transfers.scopes[i] != store {
+			t.Fatalf("was expecting the steps to be taken by %v but got %v", expectedScopes, transfers.scopes)
+		}
+	}
+
+	for _, store := range []string{"north", "south", "east"} {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, storeRequest(http.MethodGet, "/stores/"+store+"/transfers", nil, ""))
+		var list api.TransferList
+		if err := json.Unmarshal(res.Body.Bytes(), &list); err != nil || res.Code != http.StatusOK {
+			t.Fatalf("was expecting the transfers of %q but got %d %s", store, res.Code, res.Body.String())
+		}
+		if expected := map[string]int{"north": 1, "south": 1}[store]; len(list.Transfers) != expected {
+			t.Errorf("was expecting %q to see %d transfers but got %#v", store, expected, list.Transfers)
+		}
+	}
+}
+
+func TestTransfers_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		field  string
+	}{
+		{"UnknownFilm", http.MethodPost, "/transfers", `{"film":"Thor","from":"north","to":"south","copies":1}`, http.StatusBadRequest, "film"},
+		{"SameStore", http.MethodPost, "/transfers", `{"film":"Loki","from":"north","to":"north","copies":1}`, http.StatusBadRequest, "to"},
+		{"InvalidStore", http.MethodPost, "/transfers", `{"film":"Loki","from":"North","to":"south","copies":1}`, http.StatusBadRequest, "from"},
+		{"NoCopies", http.MethodPost, "/transfers", `{"film":"Loki","from":"north","to":"south","copies":0}`, http.StatusBadRequest, ""},
+		{"UnknownTransfer", http.MethodPost, "/transfers/9/approve", "", http.StatusNotFound, ""},
+		{"SkippedStep", http.MethodPost, "/transfers/1/ship", "", http.StatusConflict, ""},
+		{"InsufficientStock", http.MethodPost, "/transfers/2/approve", "", http.StatusConflict, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			transfers := newSpyTransfers()
+			transfers.available = 2
+			transfers.RequestTransfer(context.Background(), FilmName, "north", "south", 1)
+			transfers.RequestTransfer(context.Background(), FilmName, "north", "south", 3)
+
+			res := httptest.NewRecorder()
+			newTransfersServer(transfers).Router().ServeHTTP(res, storeRequest(test.method, test.path, nil, test.body))
+			if res.Code != test.status {
+				t.Fatalf("was expecting %d but got %d %s", test.status, res.Code, res.Body.String())
+			}
+			var problem api.Error
+			if err := json.Unmarshal(res.Body.Bytes(), &problem); err != nil {
+				t.Fatal(err)
+			}
+			if test.field != "" && (len(problem.Errors) != 1 || problem.Errors[0].Field != test.field) {
+				t.Errorf("was expecting the request to be refused for %q but got %#v", test.field, problem.Errors)
+			}
+		})
+	}
+}
+
+func TestTransfers_ConfinedPrincipal(t *testing.T) {
+	keys, err := auth.NewAPIKeys([]auth.APIKey{
+		{Hash: auth.HashAPIKey("north-kiosk"), Subject: "kiosk-1", Role: "clerk", Store: "north"},
+		{Hash: auth.HashAPIKey("north-manager"), Subject: "bob", Role: "manager", Store: "north"},
+		{Hash: auth.HashAPIKey("south-manager"), Subject: "dave", Role: "manager", Store: "south"},
+		{Hash: auth.HashAPIKey("east-kiosk"), Subject: "kiosk-2", Role: "clerk", Store: "east"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		status int
+	}{
+		{"ReceivingStoreRequests", http.MethodPost, "/transfers", "south-manager", http.StatusOK},
+		{"SendingStoreRequests", http.MethodPost, "/transfers", "north-manager", http.StatusForbidden},
+		{"SendingStoreApproves", http.MethodPost, "/transfers/1/approve", "north-manager", http.StatusOK},
+		{"ReceivingStoreApproves", http.MethodPost, "/transfers/1/approve", "south-manager", http.StatusForbidden},
+		{"ClerkApproves", http.MethodPost, "/transfers/1/approve", "north-kiosk", http.StatusForbidden},
+		{"EitherEndFinds", http.MethodGet, "/transfers/1", "north-kiosk", http.StatusOK},
+		{"OtherStoreFinds", http.MethodGet, "/transfers/1", "east-kiosk", http.StatusForbidden},
+		{"OtherStoreLists", http.MethodGet, "/stores/north/transfers", "east-kiosk", http.StatusForbidden},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			transfers := newSpyTransfers()
+			transfers.RequestTransfer(context.Background(), FilmName, "north", "south", 1)
+			var body string
+			if test.path == "/transfers" {
+				body = `{"film":"Loki","from":"north","to":"south","copies":1}`
+			}
+
+			res := httptest.NewRecorder()
+			router := newTransfersServer(transfers, WithAuthenticator(keys)).Router()
+			router.ServeHTTP(res, storeRequest(test.method, test.path, map[string]string{auth.APIKeyHeader: test.key}, body))
+			if res.Code != test.status {
+				t.Errorf("was expecting %d but got %d %s", test.status, res.Code, res.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/api"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type (
+	updateRequest  = api.UpdateFilmRequest
+	updateResponse = api.Film
+)
+
+// WithUpdater serves PUT /catalogue/film, an update must name the version of the film it was made to in If-Match
+func WithUpdater(updater driven.FilmUpdater) Option {
+	return func(s *server) {
+		s.updater = updater
+	}
+}
+
+// updateFilm only replaces the film its If-Match names, so an update made to a film another manager has changed
+// since it was read is refused with 412 rather than silently overwriting theirs
+func (s *server) updateFilm(w http.ResponseWriter, r *http.Request) error {
+	filmName := r.URL.Query().Get("name")
+	if filmName == "" {
+		return NewClientError(nil, http.StatusBadRequest, "Bad Request: Expected query parameter \"name\" in url")
+	}
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		return NewClientError(nil, http.StatusPreconditionRequired,
+			"Precondition Required: the ETag of the film being updated must be sent in If-Match")
+	}
+
+	var update updateRequest
+	if err := decode(r, &update); err != nil || !update.IsValid() {
+		return NewClientError(err, http.StatusBadRequest, "Bad Request: Put payload cannot be deserialized")
+	}
+	release, err := domain.ParseRelease(update.Release)
+	if err != nil {
+		return NewValidationError(err, "Bad Request: film cannot be updated", []FieldError{{Field: "release", Message: err.Error()}})
+	}
+
+	current, err := s.finder.Find(r.Context(), filmName)
+	if errors.As(err, &driven.TypeFilmNotFound) {
+		return NewClientError(nil, http.StatusNotFound, fmt.Sprintf("Film Not Found: Film %q not found", filmName))
+	} else if err != nil {
+		return err
+	}
+	if !match(ifMatch, current.Version) {
+		return preconditionFailed(filmName, current.Version, nil)
+	}
+
+	film := domain.Film{Name: filmName, Director: update.Director, Release: release, Version: current.Version}
+	updated, err := s.updater.UpdateFilm(r.Context(), film)
+	if err != nil {
+		var conflict *driven.FilmVersionConflictError
+		var invalid *domain.InvalidFilmError
+		switch {
+		case errors.As(err, &conflict):
+			return preconditionFailed(filmName, conflict.Actual, err)
+		case errors.As(err, &driven.TypeFilmNotFound):
+			return NewClientError(err, http.StatusNotFound, fmt.Sprintf("Film Not Found: Film %q not found", filmName))
+		case errors.As(err, &invalid):
+			return NewValidationError(err, "Bad Request: film cannot be updated", filmErrors(*invalid))
+		default:
+			return fmt.Errorf("unable to update film: %w", err)
+		}
+	}
+
+	codec, body, err := negotiate(r, updateResponse{
+		Name:     updated.Name,
+		Director: updated.Director,
+		Release:  string(updated.Release),
+	})
+	if err != nil {
+		return err
+	}
+	w.Header().Set("ETag", etag(updated.Version, codec.MediaType()))
+	return write(w, codec, body)
+}
+
+func preconditionFailed(film string, version uint64, err error) error {
+	return NewClientError(err, http.StatusPreconditionFailed,
+		fmt.Sprintf("Precondition Failed: film %q has changed and is now at version %d", film, version))
+}
+
+// etag is the strong entity tag of the representation of a film at version in mediaType. Every representation is
+// tagged apart since they are served from the same URL
+func etag(version uint64, mediaType string) string {
+	return `"` + strconv.FormatUint(version, 10) + "-" + mediaType + `"`
+}
+
+// noneMatch compares the entity tags of an If-None-Match header weakly, ignoring the W/ prefix, with the tag of
+// the representation about to be served
+func noneMatch(header string, current string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
+
+// match compares the entity tags of an If-Match header strongly with the film at version, the tag of any of its
+// representations names the version an update was made to
+func match(header string, version uint64) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) || len(tag) < 2 {
+			continue
+		}
+		tagged, _, _ := strings.Cut(tag[1:len(tag)-1], "-")
+		if tagged == strconv.FormatUint(version, 10) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package http
+
+import (
+	"context"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// spyFilmEditor finds and updates a single film the way a repository would, bumping its version on every update
+type spyFilmEditor struct {
+	film    domain.Film
+	updates []domain.Film
+	// changeBeforeUpdate simulates another manager updating the film between its read and its update
+	changeBeforeUpdate bool
+}
+
+func (spy *spyFilmEditor) Find(ctx context.Context, name string) (*domain.Film, error) {
+	if name != spy.film.Name {
+		return nil, &driven.FilmNotFoundError{Name: name}
+	}
+	film := spy.film
+	return &film, nil
+}
+
+func (spy *spyFilmEditor) UpdateFilm(ctx context.Context, film domain.Film) (*domain.Film, error) {
+	spy.updates = append(spy.updates, film)
+	if spy.changeBeforeUpdate {
+		spy.film.Version++
+	}
+	if film.Version != spy.film.Version {
+		return nil, &driven.FilmVersionConflictError{Name: film.Name, Expected: film.Version, Actual: spy.film.Version}
+	}
+	film.Version++
+	spy.film = film
+	return &film, nil
+}
+
+func newSpyFilmEditor() *spyFilmEditor {
+	return &spyFilmEditor{film: domain.Film{Name: FilmName, Director: FilmDirector, Release: FilmRelease, Version: 3}}
+}
+
+func TestFindRequest_ETag(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		ifNoneMatch string
+		etag        string
+		status      int
+	}{
+		{"Unconditional", "", "", `"3-application/json"`, http.StatusOK},
+		{"Matching", "", `"3-application/json"`, `"3-application/json"`, http.StatusNotModified},
+		{"MatchingWeakly", "", `W/"3-application/json"`, `"3-application/json"`, http.StatusNotModified},
+		{"AmongstOthers", "", `"1-application/json", "3-application/json"`, `"3-application/json"`, http.StatusNotModified},
+		{"Any", "", "*", `"3-application/json"`, http.StatusNotModified},
+		{"Stale", "", `"2-application/json"`, `"3-application/json"`, http.StatusOK},
+		{"OtherRepresentation", "text/csv", `"3-application/json"`, `"3-text/csv"`, http.StatusOK},
+		{"MatchingRepresentation", "text/csv", `"3-text/csv"`, `"3-text/csv"`, http.StatusNotModified},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			editor := newSpyFilmEditor()
+			server := New(editor, nil, nil, WithUpdater(editor))
+
+			res := httptest.NewRecorder()
+			headers := map[string]string{"If-None-Match": test.ifNoneMatch, "Accept": test.accept}
+			server.Router().ServeHTTP(res, storeRequest(http.MethodGet, "/catalogue/film?name=Loki", headers, ""))
+
+			if res.Code != test.status || res.Header().Get("ETag") != test.etag {
+				t.Errorf("was expecting %d with ETag %s but got %d with %q", test.status, test.etag, res.Code, res.Header().Get("ETag"))
+			}
+			if test.status == http.StatusNotModified && (res.Body.Len() != 0 || res.Header().Get("Vary") != "Accept") {
+				t.Errorf("was expecting 304 to vary by Accept without a body but got %q %v", res.Body.String(), res.Header())
+			}
+		})
+	}
+}
+
+func TestUpdateRequest_Success(t *testing.T) {
+	editor := newSpyFilmEditor()
+	server := New(editor, nil, nil, WithUpdater(editor))
+
+	res := httptest.NewRecorder()
+	server.Router().ServeHTTP(res, storeRequest(http.MethodPut, "/catalogue/film?name=Loki",
+		map[string]string{"If-Match": `"3-application/json"`}, `{"director":"Disney","release":"Regular"}`))
+
+	var response updateResponse
+	unmarshalBody(t, res, &response)
+
+	want := domain.Film{Name: FilmName, Director: "Disney", Release: domain.Regular, Version: 3}
+	switch {
+	case res.Code != http.StatusOK:
+		t.Errorf("got status %d but wanted %d", res.Code, http.StatusOK)
+	case len(editor.updates) != 1 || editor.updates[0] != want:
+		t.Errorf("was expecting %#v to be updated but got %#v", want, editor.updates)
+	case res.Header().Get("ETag") != `"4-application/json"`:
+		t.Errorf("was expecting the ETag of the updated film but got %q", res.Header().Get("ETag"))
+	case response.Director != "Disney" || response.Release != string(domain.Regular):
+		t.Errorf("received unexpected response %#v", response)
+	}
+}
+
+func TestUpdateRequest_Preconditions(t *testing.T) {
+	const body = `{"director":"Disney","release":"Old"}`
+	tests := []struct {
+		name               string
+		path               string
+		ifMatch            string
+		body               string
+		changeBeforeUpdate bool
+		status             int
+	}{
+		{"MissingIfMatch", "/catalogue/film?name=Loki", "", body, false, http.StatusPreconditionRequired},
+		{"StaleIfMatch", "/catalogue/film?name=Loki", `"2-application/json"`, body, false, http.StatusPreconditionFailed},
+		{"WeakIfMatch", "/catalogue/film?name=Loki", `W/"3-application/json"`, body, false, http.StatusPreconditionFailed},
+		{"OtherRepresentation", "/catalogue/film?name=Loki", `"3-text/csv"`, body, false, http.StatusOK},
+		{"ChangedWhileUpdating", "/catalogue/film?name=Loki", `"3-application/json"`, body, true, http.StatusPreconditionFailed},
+		{"AnyVersion", "/catalogue/film?name=Loki", "*", body, false, http.StatusOK},
+		{"UnknownFilm", "/catalogue/film?name=Thor", `"3"`, body, false, http.StatusNotFound},
+		{"UnknownRelease", "/catalogue/film?name=Loki", `"3"`, `{"director":"Disney","release":"Vintage"}`, false, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			editor := newSpyFilmEditor()
+			editor.changeBeforeUpdate = test.changeBeforeUpdate
+			server := New(editor, nil, nil, WithUpdater(editor))
+
+			res := httptest.NewRecorder()
+			server.Router().ServeHTTP(res, storeRequest(http.MethodPut, test.path, map[string]string{"If-Match": test.ifMatch}, test.body))
+
+			if res.Code != test.status {
+				t.Errorf("was expecting %d but got %d: %s", test.status, res.Code, res.Body.String())
+			}
+			if test.status != http.StatusOK && !test.changeBeforeUpdate && editor.film.Director != FilmDirector {
+				t.Errorf("was expecting the film to be left as it was but got %#v", editor.film)
+			}
+		})
+	}
+}
@@ -0,0 +1,437 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Videostore-Signature"
+	TimestampHeader = "X-Videostore-Timestamp"
+	EventHeader     = "X-Videostore-Event"
+	DeliveryHeader  = "X-Videostore-Delivery"
+)
+
+type (
+	// Endpoint receives the events listed in EventTypes, every event when it is empty
+	Endpoint struct {
+		URL        string   `json:"url"`
+		Secret     string   `json:"secret"`
+		EventTypes []string `json:"events,omitempty"`
+	}
+
+	// Payload is the body posted to an endpoint, ID stays the same across retries so receivers can deduplicate
+	Payload struct {
+		ID         string          `json:"id"`
+		Type       string          `json:"type"`
+		OccurredAt time.Time       `json:"occurredAt"`
+		Data       json.RawMessage `json:"data"`
+	}
+
+	// DeadLetter is a delivery which has been given up on
+	DeadLetter struct {
+		Endpoint string
+		Payload  Payload
+		Attempts int
+		Error    string
+	}
+
+	Dispatcher struct {
+		endpoints   []Endpoint
+		client      *http.Client
+		maxAttempts int
+		backoff     time.Duration
+		maxBackoff  time.Duration
+		workers     int
+		queue       chan delivery
+		now         func() time.Time
+		logger      *slog.Logger
+		// spool is nil when deliveries and dead letters are only kept in memory
+		spool          *spool
+		maxDeadLetters int
+		// replay is signalled once spooled deliveries did not fit in the queue, Run then queues them from the spool
+		replay chan struct{}
+
+		mu          sync.Mutex
+		deadLetters []DeadLetter
+		// queued names the spooled deliveries which are queued or being made, so replaying does not queue them twice
+		queued map[string]struct{}
+	}
+
+	Option func(d *Dispatcher)
+
+	delivery struct {
+		endpoint Endpoint
+		payload  Payload
+		body     []byte
+		// name identifies the delivery in the spool
+		name string
+	}
+
+	// permanentError is a response which retrying cannot fix
+	permanentError struct {
+		err error
+	}
+)
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func NewDispatcher(endpoints []Endpoint, options ...Option) *Dispatcher {
+	d := &Dispatcher{
+		endpoints:   endpoints,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		maxAttempts: 5,
+		backoff:     time.Second,
+		maxBackoff:  time.Minute,
+		workers:     4,
+		queue:       make(chan delivery, 256),
+		now:         time.Now,
+		logger:      slog.Default(),
+		replay:      make(chan struct{}, 1),
+		queued:      map[string]struct{}{},
+
+		maxDeadLetters: 1000,
+	}
+	for _, option := range options {
+		option(d)
+	}
+	return d
+}
+
+func WithClient(client *http.Client) Option {
+	return func(d *Dispatcher) {
+		d.client = client
+	}
+}
+
+// WithRetries makes up to maxAttempts deliveries, waiting backoff before the first retry and doubling it after
+// every failure up to maxBackoff
+func WithRetries(maxAttempts int, backoff time.Duration, maxBackoff time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.maxAttempts = maxAttempts
+		d.backoff = backoff
+		d.maxBackoff = maxBackoff
+	}
+}
+
+// WithQueue bounds how many deliveries may wait for a worker, deliveries beyond it are dead-lettered without a spool
+// and left in the spool to be queued once there is room otherwise
+func WithQueue(size int, workers int) Option {
+	return func(d *Dispatcher) {
+		d.queue = make(chan delivery, size)
+		d.workers = workers
+	}
+}
+
+// WithSpool keeps every queued delivery and dead letter in dir until it is made or dropped, so the deliveries of
+// events the relay has acknowledged survive a restart. Deliveries are only kept in memory otherwise
+func WithSpool(dir string) Option {
+	return func(d *Dispatcher) {
+		d.spool = &spool{dir: dir}
+	}
+}
+
+// WithDeadLetterLimit keeps only the newest max dead letters
+func WithDeadLetterLimit(max int) Option {
+	return func(d *Dispatcher) {
+		d.maxDeadLetters = max
+	}
+}
+
+// WithLogger logs the events which cannot be encoded and the spool failures, through slog.Default otherwise
+func WithLogger(logger *slog.Logger) Option {
+	return func(d *Dispatcher) {
+		d.logger = logger
+	}
+}
+
+// Open creates the spool and recovers the dead letters kept in it, it must be called before Handle when a spool
+// has been configured
+func (d *Dispatcher) Open() error {
+	if d.spool == nil {
+		return nil
+	}
+	spool, err := openSpool(d.spool.dir)
+	if err != nil {
+		return err
+	}
+	deadLetters, err := spool.deadLetters()
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.spool = spool
+	d.deadLetters = deadLetters
+	d.trimDeadLetters()
+	return nil
+}
+
+// Handle queues the envelope for every interested endpoint without blocking, it is meant to be subscribed to
+// the event bus. With a spool the deliveries are on disk by the time it returns
+func (d *Dispatcher) Handle(envelope driver.Envelope) {
+	data, err := json.Marshal(envelope.Event)
+	if err != nil {
+		d.logger.Error("unable to encode webhook event", "sequence", envelope.Sequence, "type", envelope.Event.EventType(), "error", err)
+		return
+	}
+
+	payload := Payload{
+		ID:         strconv.FormatUint(envelope.Sequence, 10),
+		Type:       envelope.Event.EventType(),
+		OccurredAt: envelope.OccurredAt,
+		Data:       data,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		d.logger.Error("unable to encode webhook payload", "sequence", envelope.Sequence, "type", payload.Type, "error", err)
+		return
+	}
+
+	for _, endpoint := range d.endpoints {
+		if !endpoint.subscribes(payload.Type) {
+			continue
+		}
+
+		next := delivery{endpoint: endpoint, payload: payload, body: body, name: spoolName(endpoint, envelope.Sequence)}
+		if d.spool == nil {
+			select {
+			case d.queue <- next:
+			default:
+				d.deadLetter(next, 0, fmt.Errorf("delivery queue is full"))
+			}
+			continue
+		}
+
+		if err := d.spool.add(next); err != nil {
+			d.deadLetter(next, 0, err)
+			continue
+		}
+		if !d.claim(next.name) {
+			continue
+		}
+		select {
+		case d.queue <- next:
+		default:
+			d.release(next.name)
+			d.replaySpool()
+		}
+	}
+}
+
+// Run delivers the deliveries left in the spool by a previous run and then every queued event, until ctx is
+// cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	if d.spool != nil {
+		d.replaySpool()
+		go d.replayPending(ctx)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < d.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case next := <-d.queue:
+					d.deliver(ctx, next)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// replayPending queues the spooled deliveries which are not queued yet every time the spool is replayed, waiting
+// for room in the queue
+func (d *Dispatcher) replayPending(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.replay:
+		}
+
+		pending, err := d.spool.pending(d.endpoints)
+		if err != nil {
+			d.logger.Error("unable to recover webhook deliveries", "error", err)
+		}
+		for _, next := range pending {
+			if !d.claim(next.name) {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case d.queue <- next:
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) replaySpool() {
+	select {
+	case d.replay <- struct{}{}:
+	default:
+	}
+}
+
+// claim marks the spooled delivery as queued, false when it already is
+func (d *Dispatcher) claim(name string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.queued[name]; ok {
+		return false
+	}
+	d.queued[name] = struct{}{}
+	return true
+}
+
+func (d *Dispatcher) release(name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.queued, name)
+}
+
+func (d *Dispatcher) DeadLetters() []DeadLetter {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]DeadLetter(nil), d.deadLetters...)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, next delivery) {
+	wait := d.backoff
+	for attempt := 1; ; attempt++ {
+		err := d.post(ctx, next)
+		if err == nil {
+			d.delivered(next)
+			return
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) || attempt >= d.maxAttempts {
+			d.deadLetter(next, attempt, err)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			// the delivery stays in the spool and is made again on the next run
+			if d.spool == nil {
+				d.deadLetter(next, attempt, ctx.Err())
+			}
+			return
+		case <-time.After(wait):
+		}
+
+		if wait *= 2; wait > d.maxBackoff {
+			wait = d.maxBackoff
+		}
+	}
+}
+
+func (d *Dispatcher) post(ctx context.Context, next delivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, next.endpoint.URL, bytes.NewReader(next.body))
+	if err != nil {
+		return &permanentError{err: err}
+	}
+
+	timestamp := strconv.FormatInt(d.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, next.payload.Type)
+	req.Header.Set(DeliveryHeader, next.payload.ID)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(next.endpoint.Secret, timestamp, next.body))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		return nil
+	case res.StatusCode == http.StatusRequestTimeout, res.StatusCode == http.StatusTooManyRequests, res.StatusCode >= 500:
+		return fmt.Errorf("endpoint responded with status %d", res.StatusCode)
+	}
+	return &permanentError{err: fmt.Errorf("endpoint rejected the delivery with status %d", res.StatusCode)}
+}
+
+func (d *Dispatcher) delivered(next delivery) {
+	if d.spool == nil {
+		return
+	}
+	if err := d.spool.remove(next.name); err != nil {
+		d.logger.Error("unable to remove webhook delivery", "endpoint", next.endpoint.URL, "id", next.payload.ID, "error", err)
+	}
+	d.release(next.name)
+}
+
+// deadLetter gives up on next keeping only the newest dead letters, in the spool too when there is one
+func (d *Dispatcher) deadLetter(next delivery, attempts int, err error) {
+	letter := DeadLetter{
+		Endpoint: next.endpoint.URL,
+		Payload:  next.payload,
+		Attempts: attempts,
+		Error:    err.Error(),
+	}
+	if d.spool != nil {
+		if err := d.spool.bury(next.name, letter, d.maxDeadLetters); err != nil {
+			d.logger.Error("unable to keep webhook dead letter", "endpoint", next.endpoint.URL, "id", next.payload.ID, "error", err)
+		}
+		d.release(next.name)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.deadLetters = append(d.deadLetters, letter)
+	d.trimDeadLetters()
+}
+
+func (d *Dispatcher) trimDeadLetters() {
+	if excess := len(d.deadLetters) - d.maxDeadLetters; excess > 0 {
+		d.deadLetters = append(d.deadLetters[:0:0], d.deadLetters[excess:]...)
+	}
+}
+
+func (e Endpoint) subscribes(eventType string) bool {
+	if len(e.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range e.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign returns the signature header value receivers recompute over the timestamp header and the raw body
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
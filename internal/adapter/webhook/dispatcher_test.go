@@ -0,0 +1,272 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+const secret = "s3cr3t"
+
+var filmAdded = driver.Envelope{
+	Sequence:   7,
+	Event:      domain.FilmAdded{Name: "Alien", Director: "Scott", Release: domain.Old},
+	OccurredAt: time.Date(2021, 5, 4, 10, 0, 0, 0, time.UTC),
+}
+
+// receiver records every delivery and answers with the next status in statuses, 200 once they run out
+type receiver struct {
+	mu         sync.Mutex
+	statuses   []int
+	deliveries []*http.Request
+	bodies     [][]byte
+	delivered  chan struct{}
+}
+
+func newReceiver(t *testing.T, statuses ...int) (*receiver, *httptest.Server) {
+	r := &receiver{statuses: statuses, delivered: make(chan struct{}, 16)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+
+		r.mu.Lock()
+		r.deliveries = append(r.deliveries, req)
+		r.bodies = append(r.bodies, body)
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		r.mu.Unlock()
+
+		w.WriteHeader(status)
+		r.delivered <- struct{}{}
+	}))
+	t.Cleanup(server.Close)
+	return r, server
+}
+
+func (r *receiver) await(t *testing.T, deliveries int) {
+	t.Helper()
+	for i := 0; i < deliveries; i++ {
+		select {
+		case <-r.delivered:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("was expecting %d deliveries but only received %d", deliveries, i)
+		}
+	}
+}
+
+func run(t *testing.T, d *Dispatcher) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestDispatcher_SignedDelivery(t *testing.T) {
+	r, server := newReceiver(t)
+	d := NewDispatcher([]Endpoint{{URL: server.URL, Secret: secret}})
+	run(t, d)
+
+	d.Handle(filmAdded)
+	r.await(t, 1)
+
+	req, body := r.deliveries[0], r.bodies[0]
+	if signature := Sign(secret, req.Header.Get(TimestampHeader), body); req.Header.Get(SignatureHeader) != signature {
+		t.Errorf("was expecting signature %q but got %q", signature, req.Header.Get(SignatureHeader))
+	}
+	if req.Header.Get(EventHeader) != "FilmAdded" || req.Header.Get(DeliveryHeader) != "7" {
+		t.Errorf("was expecting the event and delivery headers but got %v", req.Header)
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	var data domain.FilmAdded
+	if err := json.Unmarshal(payload.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if payload.ID != "7" || payload.Type != "FilmAdded" || !payload.OccurredAt.Equal(filmAdded.OccurredAt) || data != filmAdded.Event {
+		t.Errorf("was expecting the payload to describe %#v but got %s", filmAdded, body)
+	}
+}
+
+func TestDispatcher_RetriesWithBackoff(t *testing.T) {
+	r, server := newReceiver(t, http.StatusInternalServerError, http.StatusTooManyRequests)
+	d := NewDispatcher([]Endpoint{{URL: server.URL, Secret: secret}}, WithRetries(3, 10*time.Millisecond, time.Second))
+	run(t, d)
+
+	start := time.Now()
+	d.Handle(filmAdded)
+	r.await(t, 3)
+
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("was expecting the retries to back off 10ms then 20ms but they completed within %v", elapsed)
+	}
+	if deadLetters := d.DeadLetters(); len(deadLetters) != 0 {
+		t.Errorf("was expecting the delivery to succeed on its last attempt but got %#v", deadLetters)
+	}
+}
+
+func TestDispatcher_DeadLetters(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		attempts int
+	}{
+		{"RetriesExhausted", []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}, 3},
+		{"RejectedByEndpoint", []int{http.StatusBadRequest}, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, server := newReceiver(t, test.statuses...)
+			d := NewDispatcher([]Endpoint{{URL: server.URL, Secret: secret}}, WithRetries(3, time.Millisecond, time.Millisecond))
+			run(t, d)
+
+			d.Handle(filmAdded)
+			r.await(t, test.attempts)
+
+			deadline := time.Now().Add(5 * time.Second)
+			for len(d.DeadLetters()) == 0 && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+
+			deadLetters := d.DeadLetters()
+			if len(deadLetters) != 1 || deadLetters[0].Attempts != test.attempts || deadLetters[0].Payload.ID != "7" {
+				t.Errorf("was expecting a dead letter after %d attempts but got %#v", test.attempts, deadLetters)
+			}
+		})
+	}
+}
+
+func TestDispatcher_FiltersEventTypes(t *testing.T) {
+	r, server := newReceiver(t)
+	d := NewDispatcher([]Endpoint{
+		{URL: server.URL + "/invoices", Secret: secret, EventTypes: []string{"InvoiceIssued"}},
+		{URL: server.URL + "/films", Secret: secret, EventTypes: []string{"FilmAdded"}},
+	})
+	run(t, d)
+
+	d.Handle(filmAdded)
+	r.await(t, 1)
+
+	if path := r.deliveries[0].URL.Path; path != "/films" {
+		t.Errorf("was expecting only the films endpoint to be called but %q was", path)
+	}
+}
+
+func TestDispatcher_FullQueueIsDeadLettered(t *testing.T) {
+	d := NewDispatcher([]Endpoint{{URL: "http://127.0.0.1:0", Secret: secret}}, WithQueue(1, 1))
+
+	d.Handle(filmAdded)
+	d.Handle(filmAdded)
+
+	if deadLetters := d.DeadLetters(); len(deadLetters) != 1 || deadLetters[0].Attempts != 0 {
+		t.Errorf("was expecting the delivery which did not fit to be dead-lettered but got %#v", deadLetters)
+	}
+}
+
+func TestDispatcher_FullQueueStaysSpooled(t *testing.T) {
+	r, server := newReceiver(t)
+	d := NewDispatcher([]Endpoint{{URL: server.URL, Secret: secret}}, WithQueue(1, 1), WithSpool(t.TempDir()))
+	if err := d.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	for sequence := uint64(1); sequence <= 3; sequence++ {
+		envelope := filmAdded
+		envelope.Sequence = sequence
+		d.Handle(envelope)
+	}
+	if deadLetters := d.DeadLetters(); len(deadLetters) != 0 {
+		t.Fatalf("was expecting the spooled deliveries which did not fit to be kept but got %#v", deadLetters)
+	}
+
+	run(t, d)
+	r.await(t, 3)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delivered := map[string]bool{}
+	for _, req := range r.deliveries {
+		delivered[req.Header.Get(DeliveryHeader)] = true
+	}
+	if len(r.deliveries) != 3 || !delivered["1"] || !delivered["2"] || !delivered["3"] {
+		t.Errorf("was expecting every delivery to be made once but got %v", delivered)
+	}
+}
+
+func TestDispatcher_SpoolSurvivesRestart(t *testing.T) {
+	r, server := newReceiver(t)
+	dir := t.TempDir()
+	endpoints := []Endpoint{{URL: server.URL, Secret: secret}}
+
+	stopped := NewDispatcher(endpoints, WithSpool(dir))
+	if err := stopped.Open(); err != nil {
+		t.Fatal(err)
+	}
+	stopped.Handle(filmAdded)
+
+	restarted := NewDispatcher(endpoints, WithSpool(dir))
+	if err := restarted.Open(); err != nil {
+		t.Fatal(err)
+	}
+	run(t, restarted)
+	r.await(t, 1)
+
+	if id := r.deliveries[0].Header.Get(DeliveryHeader); id != "7" {
+		t.Errorf("was expecting the spooled delivery to be made after the restart but got %q", id)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for pending, _ := restarted.spool.pending(endpoints); len(pending) > 0 && time.Now().Before(deadline); pending, _ = restarted.spool.pending(endpoints) {
+		time.Sleep(time.Millisecond)
+	}
+	if pending, err := restarted.spool.pending(endpoints); err != nil || len(pending) != 0 {
+		t.Errorf("was expecting the delivery to leave the spool but got %#v %v", pending, err)
+	}
+}
+
+func TestDispatcher_DeadLettersAreKeptAndBounded(t *testing.T) {
+	r, server := newReceiver(t, http.StatusBadRequest, http.StatusBadRequest, http.StatusBadRequest)
+	dir := t.TempDir()
+	endpoints := []Endpoint{{URL: server.URL, Secret: secret}}
+
+	d := NewDispatcher(endpoints, WithSpool(dir), WithQueue(3, 1), WithDeadLetterLimit(2))
+	if err := d.Open(); err != nil {
+		t.Fatal(err)
+	}
+	for sequence := uint64(1); sequence <= 3; sequence++ {
+		d.Handle(driver.Envelope{Sequence: sequence, Event: filmAdded.Event, OccurredAt: filmAdded.OccurredAt})
+	}
+	run(t, d)
+	r.await(t, 3)
+	deadline := time.Now().Add(5 * time.Second)
+	for buried := d.DeadLetters(); (len(buried) == 0 || buried[len(buried)-1].Payload.ID != "3") && time.Now().Before(deadline); buried = d.DeadLetters() {
+		time.Sleep(time.Millisecond)
+	}
+
+	restarted := NewDispatcher(endpoints, WithSpool(dir), WithDeadLetterLimit(2))
+	if err := restarted.Open(); err != nil {
+		t.Fatal(err)
+	}
+	deadLetters := restarted.DeadLetters()
+	if len(deadLetters) != 2 || deadLetters[0].Payload.ID != "2" || deadLetters[1].Payload.ID != "3" {
+		t.Errorf("was expecting the newest two dead letters to survive the restart but got %#v", deadLetters)
+	}
+	if pending, err := restarted.spool.pending(endpoints); err != nil || len(pending) != 0 {
+		t.Errorf("was expecting dead letters to leave the spool but got %#v %v", pending, err)
+	}
+}
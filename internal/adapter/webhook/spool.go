@@ -0,0 +1,188 @@
+package webhook
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type (
+	// spool keeps every queued delivery in dir until it has been delivered and every dead letter in dir/dead, so
+	// neither is lost when the process stops. A delivery is named after its event sequence and endpoint, an event
+	// handled again after a restart replaces its own delivery rather than adding another
+	spool struct {
+		dir string
+	}
+
+	// spooled is a delivery as it is kept on disk, the endpoint is looked up by URL again so its secret is never
+	// written out
+	spooled struct {
+		Endpoint string  `json:"endpoint"`
+		Payload  Payload `json:"payload"`
+	}
+)
+
+func openSpool(dir string) (*spool, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "dead"), 0700); err != nil {
+		return nil, fmt.Errorf("unable to open webhook spool %q: %w", dir, err)
+	}
+	return &spool{dir: dir}, nil
+}
+
+func spoolName(endpoint Endpoint, sequence uint64) string {
+	sum := sha256.Sum256([]byte(endpoint.URL))
+	return fmt.Sprintf("%020d-%x.json", sequence, sum[:8])
+}
+
+// add keeps next on disk before it is queued
+func (s *spool) add(next delivery) error {
+	return s.write(filepath.Join(s.dir, next.name), spooled{Endpoint: next.endpoint.URL, Payload: next.payload})
+}
+
+// remove forgets a delivery which has been made
+func (s *spool) remove(name string) error {
+	if err := os.Remove(filepath.Join(s.dir, name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("unable to remove delivery %q from the webhook spool: %w", name, err)
+	}
+	return nil
+}
+
+// bury moves a delivery which has been given up on into the dead letters, keeping only the newest limit of them
+func (s *spool) bury(name string, letter DeadLetter, limit int) error {
+	if err := s.write(filepath.Join(s.dir, "dead", name), letter); err != nil {
+		return err
+	}
+	if err := s.remove(name); err != nil {
+		return err
+	}
+
+	names, err := s.list(filepath.Join(s.dir, "dead"))
+	if err != nil {
+		return err
+	}
+	for len(names) > limit {
+		if err := os.Remove(filepath.Join(s.dir, "dead", names[0])); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("unable to drop dead letter %q: %w", names[0], err)
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+// pending returns the deliveries which have not been made yet in sequence order, those for an endpoint which is
+// no longer configured are dropped
+func (s *spool) pending(endpoints []Endpoint) ([]delivery, error) {
+	names, err := s.list(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byURL := make(map[string]Endpoint, len(endpoints))
+	for _, endpoint := range endpoints {
+		byURL[endpoint.URL] = endpoint
+	}
+
+	var deliveries []delivery
+	for _, name := range names {
+		var entry spooled
+		if err := s.read(filepath.Join(s.dir, name), &entry); err != nil {
+			return nil, err
+		}
+		endpoint, ok := byURL[entry.Endpoint]
+		if !ok {
+			if err := s.remove(name); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		body, err := json.Marshal(entry.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode delivery %q: %w", name, err)
+		}
+		deliveries = append(deliveries, delivery{endpoint: endpoint, payload: entry.Payload, body: body, name: name})
+	}
+	return deliveries, nil
+}
+
+// deadLetters returns the dead letters kept on disk, oldest first
+func (s *spool) deadLetters() ([]DeadLetter, error) {
+	dir := filepath.Join(s.dir, "dead")
+	names, err := s.list(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	letters := make([]DeadLetter, 0, len(names))
+	for _, name := range names {
+		var letter DeadLetter
+		if err := s.read(filepath.Join(dir, name), &letter); err != nil {
+			return nil, err
+		}
+		letters = append(letters, letter)
+	}
+	return letters, nil
+}
+
+// list returns the names of the json files in dir, which sort by sequence
+func (s *spool) list(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read webhook spool %q: %w", dir, err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// write replaces path with v through a synced temporary file, a crash leaves either the old or the new content
+func (s *spool) write(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("unable to encode %q: %w", path, err)
+	}
+
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to write %q: %w", path, err)
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("unable to write %q: %w", path, err)
+	}
+	return nil
+}
+
+func (s *spool) read(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read %q: %w", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("unable to decode %q: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package domain
+
+type (
+	// FilmAggregate is the catalogue entry rebuilt from its FilmAdded, FilmReleaseChanged and FilmUpdated events
+	FilmAggregate struct {
+		Film    Film   `json:"film"`
+		Version uint64 `json:"version"`
+	}
+
+	// RentalAggregate follows a single rental from the moment it is started until the copy is returned
+	RentalAggregate struct {
+		ID       string `json:"id"`
+		Film     Film   `json:"film"`
+		Days     Days   `json:"days"`
+		Returned bool   `json:"returned"`
+		Version  uint64 `json:"version"`
+	}
+)
+
+func AddFilm(film Film) (Event, error) {
+	if err := film.IsValid(); err != nil {
+		return nil, err
+	}
+	return FilmAdded{Name: film.Name, Director: film.Director, Release: film.Release}, nil
+}
+
+// UpdateFilm records film as the update of the catalogued film at film.Version
+func UpdateFilm(film Film) (Event, error) {
+	if err := film.IsValid(); err != nil {
+		return nil, err
+	}
+	return FilmUpdated{Name: film.Name, Director: film.Director, Release: film.Release, Version: film.Version + 1}, nil
+}
+
+func (a *FilmAggregate) Reclassify(to release) (Event, error) {
+	if a.Version == 0 {
+		return nil, FilmNotCataloguedError
+	}
+	if err := to.isValid(); err != nil {
+		return nil, err
+	}
+	if a.Film.Release == to {
+		return nil, ReleaseUnchangedError
+	}
+	return FilmReleaseChanged{Name: a.Film.Name, From: a.Film.Release, To: to}, nil
+}
+
+func (a *FilmAggregate) Apply(e Event) {
+	switch event := e.(type) {
+	case FilmAdded:
+		a.Film = Film{Name: event.Name, Director: event.Director, Release: event.Release}
+	case FilmReleaseChanged:
+		a.Film.Release = event.To
+	case FilmUpdated:
+		a.Film.Director = event.Director
+		a.Film.Release = event.Release
+	}
+	a.Version++
+	a.Film.Version = a.Version
+}
+
+func StartRental(rentalID string, film Film, days Days) (Event, error) {
+	if rentalID == "" {
+		return nil, EmptyRentalIDError
+	}
+	if err := film.IsValid(); err != nil {
+		return nil, err
+	}
+	return RentalStarted{RentalID: rentalID, Film: film, Days: days}, nil
+}
+
+// Return prices the rental for the number of days the copy was actually kept
+func (a *RentalAggregate) Return(days Days) (Event, error) {
+	return a.ReturnWith(days, DefaultPriceList)
+}
+
+// ReturnWith prices the rental with prices for the number of days the copy was actually kept
+func (a *RentalAggregate) ReturnWith(days Days, prices PriceList) (Event, error) {
+	switch {
+	case a.Version == 0:
+		return nil, RentalNotStartedError
+	case a.Returned:
+		return nil, RentalAlreadyReturnedError
+	}
+
+	cost, err := Rental{Film: a.Film, Days: days}.PriceWith(prices)
+	if err != nil {
+		return nil, err
+	}
+	return RentalReturned{RentalID: a.ID, Film: a.Film.Name, Days: days, Cost: cost}, nil
+}
+
+func (a *RentalAggregate) Apply(e Event) {
+	switch event := e.(type) {
+	case RentalStarted:
+		a.ID = event.RentalID
+		a.Film = event.Film
+		a.Days = event.Days
+	case RentalReturned:
+		a.Days = event.Days
+		a.Returned = true
+	}
+	a.Version++
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestFilmAggregate_Rehydration(t *testing.T) {
+	added, err := AddFilm(Film{Name: "Loki", Director: "Marvel", Release: New})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var film FilmAggregate
+	film.Apply(added)
+
+	changed, err := film.Reclassify(Regular)
+	if err != nil {
+		t.Fatal(err)
+	}
+	film.Apply(changed)
+
+	if film.Film != (Film{Name: "Loki", Director: "Marvel", Release: Regular, Version: 2}) || film.Version != 2 {
+		t.Errorf("unexpected aggregate state %#v", film)
+	}
+
+	if _, err := film.Reclassify(Regular); err != ReleaseUnchangedError {
+		t.Errorf("was expecting ReleaseUnchangedError but got %v", err)
+	}
+
+	updated, err := UpdateFilm(Film{Name: "Loki", Director: "Disney", Release: Old, Version: film.Version})
+	if err != nil {
+		t.Fatal(err)
+	}
+	film.Apply(updated)
+
+	if film.Film != (Film{Name: "Loki", Director: "Disney", Release: Old, Version: 3}) || film.Version != 3 {
+		t.Errorf("unexpected aggregate state after update %#v", film)
+	}
+}
+
+func TestFilmAggregate_InvalidCommands(t *testing.T) {
+	if _, err := AddFilm(Film{Name: "Loki"}); err == nil {
+		t.Errorf("was expecting invalid film to be rejected")
+	}
+	if _, err := UpdateFilm(Film{Name: "Loki", Director: "Marvel", Version: 1}); err == nil {
+		t.Errorf("was expecting invalid update to be rejected")
+	}
+
+	var film FilmAggregate
+	if _, err := film.Reclassify(Old); err != FilmNotCataloguedError {
+		t.Errorf("was expecting FilmNotCataloguedError but got %v", err)
+	}
+}
+
+func TestRentalAggregate_Return(t *testing.T) {
+	started, err := StartRental("r-1", regularFilm, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rental RentalAggregate
+	if _, err := rental.Return(1); err != RentalNotStartedError {
+		t.Errorf("was expecting RentalNotStartedError but got %v", err)
+	}
+
+	rental.Apply(started)
+	returned, err := rental.Return(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if event := returned.(RentalReturned); event.Cost != BASIC*2 || event.Days != 4 {
+		t.Errorf("was expecting the rental to be priced on the days kept but got %#v", event)
+	}
+
+	rental.Apply(returned)
+	if _, err := rental.Return(4); err != RentalAlreadyReturnedError {
+		t.Errorf("was expecting RentalAlreadyReturnedError but got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+type (
+	// AuditEntry records a single mutating operation, Hash chains the entry to the one recorded before it
+	AuditEntry struct {
+		Sequence  uint64            `json:"sequence"`
+		Timestamp time.Time         `json:"timestamp"`
+		Actor     string            `json:"actor"`
+		Operation string            `json:"operation"`
+		Entity    string            `json:"entity"`
+		Inputs    map[string]string `json:"inputs"`
+		Outcome   string            `json:"outcome"`
+		Error     string            `json:"error,omitempty"`
+		PrevHash  string            `json:"prevHash"`
+		Hash      string            `json:"hash"`
+	}
+
+	// AuditQuery matches every entry when left empty, From is inclusive and To exclusive
+	AuditQuery struct {
+		Entity string
+		From   time.Time
+		To     time.Time
+	}
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+func FilmEntity(name string) string {
+	return "film:" + name
+}
+
+func (q AuditQuery) Matches(entry AuditEntry) bool {
+	switch {
+	case q.Entity != "" && q.Entity != entry.Entity:
+		return false
+	case !q.From.IsZero() && entry.Timestamp.Before(q.From):
+		return false
+	case !q.To.IsZero() && !entry.Timestamp.Before(q.To):
+		return false
+	}
+	return true
+}
@@ -10,6 +10,19 @@ var (
 	UnknownReleaseError    = fmt.Errorf("unknown release type must be one of the following releases, %v", releaseTypes)
 	EmptyFilmNameError     = fmt.Errorf("film name cannot be empty")
 	EmptyFilmDirectorError = fmt.Errorf("film director cannot be empty")
+	FilmNotCataloguedError = fmt.Errorf("film has not been catalogued")
+	ReleaseUnchangedError  = fmt.Errorf("film already belongs to the release")
+	EmptyRentalIDError     = fmt.Errorf("rental id cannot be empty")
+	RentalNotStartedError  = fmt.Errorf("rental has not been started")
+
+	RentalAlreadyReturnedError = fmt.Errorf("rental has already been returned")
+
+	InvalidStoreIDError   = fmt.Errorf("store id must be 1 to 63 lowercase letters, digits or dashes")
+	InvalidPriceListError = fmt.Errorf("prices must be greater than zero")
+	InvalidCopiesError    = fmt.Errorf("copies must be greater than zero")
+
+	EmptyTransferIDError   = fmt.Errorf("transfer id cannot be empty")
+	SameStoreTransferError = fmt.Errorf("a transfer must move copies between two different stores")
 
 	TypeInvalidFilm *InvalidFilmError
 )
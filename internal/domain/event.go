@@ -0,0 +1,161 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type (
+	Event interface {
+		EventType() string
+	}
+
+	FilmAdded struct {
+		Name     string  `json:"name"`
+		Director string  `json:"director"`
+		Release  release `json:"release"`
+	}
+
+	FilmReleaseChanged struct {
+		Name string  `json:"name"`
+		From release `json:"from"`
+		To   release `json:"to"`
+	}
+
+	// FilmUpdated records the director and release of the film as of Version
+	FilmUpdated struct {
+		Name     string  `json:"name"`
+		Director string  `json:"director"`
+		Release  release `json:"release"`
+		Version  uint64  `json:"version"`
+	}
+
+	RentalStarted struct {
+		RentalID string  `json:"rentalId"`
+		Store    StoreID `json:"store,omitempty"`
+		Film     Film    `json:"film"`
+		Days     Days    `json:"days"`
+	}
+
+	RentalReturned struct {
+		RentalID string  `json:"rentalId"`
+		Store    StoreID `json:"store,omitempty"`
+		Film     string  `json:"film"`
+		Days     Days    `json:"days"`
+		Cost     SEK     `json:"cost"`
+	}
+
+	InvoiceIssued struct {
+		InvoiceID string   `json:"invoiceId"`
+		Store     StoreID  `json:"store,omitempty"`
+		Rentals   []Rental `json:"rentals"`
+		Cost      SEK      `json:"cost"`
+	}
+
+	// StockAdjusted records a change of Delta copies leaving the store with Copies
+	StockAdjusted struct {
+		Store  StoreID `json:"store"`
+		Film   string  `json:"film"`
+		Delta  int     `json:"delta"`
+		Copies int     `json:"copies"`
+	}
+
+	PriceListChanged struct {
+		Store  StoreID   `json:"store"`
+		Prices PriceList `json:"prices"`
+	}
+
+	TransferRequested struct {
+		TransferID string  `json:"transferId"`
+		Film       string  `json:"film"`
+		From       StoreID `json:"from"`
+		To         StoreID `json:"to"`
+		Copies     int     `json:"copies"`
+	}
+
+	TransferApproved struct {
+		TransferID string  `json:"transferId"`
+		From       StoreID `json:"from"`
+		To         StoreID `json:"to"`
+	}
+
+	// TransferShipped records the copies leaving the stock of From
+	TransferShipped struct {
+		TransferID string  `json:"transferId"`
+		Film       string  `json:"film"`
+		From       StoreID `json:"from"`
+		To         StoreID `json:"to"`
+		Copies     int     `json:"copies"`
+	}
+
+	// TransferReceived records the copies joining the stock of To
+	TransferReceived struct {
+		TransferID string  `json:"transferId"`
+		Film       string  `json:"film"`
+		From       StoreID `json:"from"`
+		To         StoreID `json:"to"`
+		Copies     int     `json:"copies"`
+	}
+)
+
+var decoders = map[string]func(data []byte) (Event, error){
+	FilmAdded{}.EventType():          decodeAs[FilmAdded],
+	FilmReleaseChanged{}.EventType(): decodeAs[FilmReleaseChanged],
+	FilmUpdated{}.EventType():        decodeAs[FilmUpdated],
+	RentalStarted{}.EventType():      decodeAs[RentalStarted],
+	RentalReturned{}.EventType():     decodeAs[RentalReturned],
+	InvoiceIssued{}.EventType():      decodeAs[InvoiceIssued],
+	StockAdjusted{}.EventType():      decodeAs[StockAdjusted],
+	PriceListChanged{}.EventType():   decodeAs[PriceListChanged],
+	TransferRequested{}.EventType():  decodeAs[TransferRequested],
+	TransferApproved{}.EventType():   decodeAs[TransferApproved],
+	TransferShipped{}.EventType():    decodeAs[TransferShipped],
+	TransferReceived{}.EventType():   decodeAs[TransferReceived],
+}
+
+func (FilmAdded) EventType() string          { return "FilmAdded" }
+func (FilmReleaseChanged) EventType() string { return "FilmReleaseChanged" }
+func (FilmUpdated) EventType() string        { return "FilmUpdated" }
+func (RentalStarted) EventType() string      { return "RentalStarted" }
+func (RentalReturned) EventType() string     { return "RentalReturned" }
+func (InvoiceIssued) EventType() string      { return "InvoiceIssued" }
+func (StockAdjusted) EventType() string      { return "StockAdjusted" }
+func (PriceListChanged) EventType() string   { return "PriceListChanged" }
+func (TransferRequested) EventType() string  { return "TransferRequested" }
+func (TransferApproved) EventType() string   { return "TransferApproved" }
+func (TransferShipped) EventType() string    { return "TransferShipped" }
+func (TransferReceived) EventType() string   { return "TransferReceived" }
+
+func FilmStream(name string) string {
+	return "film-" + name
+}
+
+func RentalStream(rentalID string) string {
+	return "rental-" + rentalID
+}
+
+func RentalEntity(rentalID string) string {
+	return "rental:" + rentalID
+}
+
+// DecodeEvent restores an event from its type and the JSON it was marshalled to
+func DecodeEvent(eventType string, data []byte) (Event, error) {
+	decode, ok := decoders[eventType]
+	if !ok {
+		return nil, fmt.Errorf("unknown event type %q", eventType)
+	}
+
+	event, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode %s event: %w", eventType, err)
+	}
+	return event, nil
+}
+
+func decodeAs[T Event](data []byte) (Event, error) {
+	var event T
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
@@ -5,10 +5,12 @@ import "strings"
 type (
 	release string
 
+	// Film is versioned by the repository keeping it, Version is 1 once catalogued and counts every change since
 	Film struct {
 		Name     string
 		Director string
 		Release  release
+		Version  uint64
 	}
 )
 
@@ -31,7 +33,7 @@ func (f *Film) IsValid() error {
 	}
 
 	if err := f.Release.isValid(); err != nil {
-		errors = append(errors, EmptyFilmDirectorError)
+		errors = append(errors, err)
 	}
 
 	if len(errors) == 0 {
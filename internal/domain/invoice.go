@@ -14,8 +14,8 @@ type (
 	Calculator func(days Days) SEK
 
 	Rental struct {
-		Film Film
-		Days Days
+		Film Film `json:"film"`
+		Days Days `json:"days"`
 	}
 
 	RentalReturn struct {
@@ -26,23 +26,32 @@ type (
 		RentalReturn
 		Cost SEK
 	}
+
+	// PriceList holds what a store charges. New releases cost Premium a day, the other releases cost Basic for
+	// their grace period and Basic for every day after it
+	PriceList struct {
+		Premium SEK `json:"premium"`
+		Basic   SEK `json:"basic"`
+	}
 )
 
+// DefaultPriceList applies to every store which has not set prices of its own
+var DefaultPriceList = PriceList{Premium: PREMIUM, Basic: BASIC}
+
 func (req *RentalReturn) AddRental(film Film, days Days) {
 	req.Rentals = append(req.Rentals, Rental{film, days})
 }
 
-var pricingStrategies = map[release]Calculator{
-	New:     newRelease,
-	Regular: regularRelease,
-	Old:     oldRelease,
+// Invoice prices the rentals with the DefaultPriceList
+func (req RentalReturn) Invoice() (i RentalInvoice, e []error) {
+	return req.InvoiceWith(DefaultPriceList)
 }
 
-func (req RentalReturn) Invoice() (i RentalInvoice, e []error) {
+func (req RentalReturn) InvoiceWith(prices PriceList) (i RentalInvoice, e []error) {
 	var cost = SEK(0)
 
 	for _, r := range req.Rentals {
-		if calc, err := getReleaseCalculator(r.Film.Release); err != nil {
+		if calc, err := prices.calculator(r.Film.Release); err != nil {
 			e = append(e, err)
 		} else {
 			cost += calc(r.Days)
@@ -57,36 +66,56 @@ func (req RentalReturn) Invoice() (i RentalInvoice, e []error) {
 	return i, e
 }
 
-func getReleaseCalculator(release release) (Calculator, error) {
-	if err := release.isValid(); err != nil {
-		return nil, err
-	}
-	return pricingStrategies[release], nil
+// Price returns what the rental costs on its own with the DefaultPriceList
+func (r Rental) Price() (SEK, error) {
+	return r.PriceWith(DefaultPriceList)
 }
 
-func newRelease(days Days) SEK {
-	return SEK(uint64(days) * uint64(PREMIUM))
+func (r Rental) PriceWith(prices PriceList) (SEK, error) {
+	calc, err := prices.calculator(r.Film.Release)
+	if err != nil {
+		return 0, err
+	}
+	return calc(r.Days), nil
 }
 
-func regularRelease(days Days) SEK {
-	var gracePeriod = Days(3)
-	return calculatePrice(days, gracePeriod)
+func (p PriceList) IsValid() error {
+	if p.Premium == 0 || p.Basic == 0 {
+		return InvalidPriceListError
+	}
+	return nil
 }
 
-func oldRelease(days Days) SEK {
-	var gracePeriod = Days(5)
-	return calculatePrice(days, gracePeriod)
+func (p PriceList) calculator(release release) (Calculator, error) {
+	if err := release.isValid(); err != nil {
+		return nil, err
+	}
+
+	switch release {
+	case New:
+		return func(days Days) SEK {
+			return SEK(uint64(days) * uint64(p.Premium))
+		}, nil
+	case Regular:
+		return func(days Days) SEK {
+			return calculatePrice(days, Days(3), p.Basic)
+		}, nil
+	default:
+		return func(days Days) SEK {
+			return calculatePrice(days, Days(5), p.Basic)
+		}, nil
+	}
 }
 
-func calculatePrice(days Days, gracePeriod Days) SEK {
+func calculatePrice(days Days, gracePeriod Days, basic SEK) SEK {
 	switch {
 	case days == 0:
 		return 0
 	case days <= gracePeriod:
-		return BASIC
+		return basic
 	default:
-		var excess = SEK(days.subtract(gracePeriod)) * BASIC
-		return BASIC + excess
+		var excess = SEK(days.subtract(gracePeriod)) * basic
+		return basic + excess
 	}
 }
 
@@ -4,9 +4,9 @@ import (
 	"testing"
 )
 
-var newFilm = Film{"Loki", "Marvel", New}
-var regularFilm = Film{"Loki", "Marvel", Regular}
-var oldFilm = Film{"Loki", "Marvel", Old}
+var newFilm = Film{Name: "Loki", Director: "Marvel", Release: New}
+var regularFilm = Film{Name: "Loki", Director: "Marvel", Release: Regular}
+var oldFilm = Film{Name: "Loki", Director: "Marvel", Release: Old}
 
 func TestNewFilmPricing(t *testing.T) {
 	tests := []struct {
@@ -32,13 +32,10 @@ func TestNewFilmPricing(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run("Pricing Test New release", func(t *testing.T) {
-			if calc, err := getReleaseCalculator(test.film.Release); err != nil {
+			if cost, err := (Rental{Film: test.film, Days: test.days}).PriceWith(DefaultPriceList); err != nil {
 				t.Error(err)
-			} else {
-				var cost = calc(test.days)
-				if cost != test.expectedPrice {
-					t.Errorf("calculated cost of %d didn't match expect price %d", cost, test.expectedPrice)
-				}
+			} else if cost != test.expectedPrice {
+				t.Errorf("calculated cost of %d didn't match expect price %d", cost, test.expectedPrice)
 			}
 
 		})
@@ -67,7 +64,7 @@ func TestInvoicing(t *testing.T) {
 }
 
 func TestCorruptedRentalRequest(t *testing.T) {
-	var corruptedFilm = Film{"Boki", "DC", release("Corrupted")}
+	var corruptedFilm = Film{Name: "Boki", Director: "DC", Release: release("Corrupted")}
 	var duration = Days(5)
 
 	var request = RentalReturn{
@@ -82,3 +79,25 @@ func TestCorruptedRentalRequest(t *testing.T) {
 		t.Errorf("was expeecting 3 errors for 3 none existant release types")
 	}
 }
+
+func TestInvoicingWithPriceList(t *testing.T) {
+	prices := PriceList{Premium: 50, Basic: 20}
+	request := RentalReturn{
+		Rentals: []Rental{
+			{newFilm, 2},
+			{regularFilm, 4},
+			{oldFilm, 5},
+		},
+	}
+
+	invoice, err := request.InvoiceWith(prices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := SEK(50*2 + 20*2 + 20); invoice.Cost != expected {
+		t.Errorf("calculated cost of %d didn't match expect price %d", invoice.Cost, expected)
+	}
+	if err := (PriceList{Premium: 50}).IsValid(); err != InvalidPriceListError {
+		t.Errorf("was expecting a price list without a basic price to be invalid but got %v", err)
+	}
+}
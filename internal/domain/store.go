@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"regexp"
+	"time"
+)
+
+type (
+	// StoreID identifies a branch. Titles are catalogued once for every store while stock, prices and invoices
+	// belong to a single store
+	StoreID string
+
+	// Stock is how many copies of a film a store holds
+	Stock struct {
+		Film   string `json:"film"`
+		Copies int    `json:"copies"`
+	}
+
+	// IssuedInvoice is an invoice as it is kept by the store which issued it
+	IssuedInvoice struct {
+		ID       string    `json:"id"`
+		Store    StoreID   `json:"store"`
+		Rentals  []Rental  `json:"rentals"`
+		Cost     SEK       `json:"cost"`
+		IssuedAt time.Time `json:"issuedAt"`
+	}
+)
+
+// DefaultStore is the store of every call which does not name one
+const DefaultStore StoreID = "main"
+
+var storeIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+func (s StoreID) IsValid() error {
+	if !storeIDPattern.MatchString(string(s)) {
+		return InvalidStoreIDError
+	}
+	return nil
+}
+
+func StoreEntity(store StoreID) string {
+	return "store:" + string(store)
+}
@@ -0,0 +1,16 @@
+package domain
+
+import "testing"
+
+func TestStoreID(t *testing.T) {
+	for _, valid := range []StoreID{DefaultStore, "stockholm", "branch-42"} {
+		if err := valid.IsValid(); err != nil {
+			t.Errorf("was expecting %q to be valid but got %v", valid, err)
+		}
+	}
+	for _, invalid := range []StoreID{"", "Stockholm", "-north", "../main", "a b"} {
+		if err := invalid.IsValid(); err != InvalidStoreIDError {
+			t.Errorf("was expecting %q to be invalid but got %v", invalid, err)
+		}
+	}
+}
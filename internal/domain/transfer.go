@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+type (
+	TransferStatus string
+
+	// Transfer moves copies of a film from one store to another. Copies leave the stock of From once shipped and
+	// only join the stock of To once received, while in transit they are available nowhere
+	Transfer struct {
+		ID          string         `json:"id"`
+		Film        string         `json:"film"`
+		From        StoreID        `json:"from"`
+		To          StoreID        `json:"to"`
+		Copies      int            `json:"copies"`
+		Status      TransferStatus `json:"status"`
+		RequestedAt time.Time      `json:"requestedAt"`
+	}
+
+	TransferStatusError struct {
+		ID   string
+		From TransferStatus
+		To   TransferStatus
+	}
+)
+
+const (
+	TransferRequestedStatus TransferStatus = "Requested"
+	TransferApprovedStatus  TransferStatus = "Approved"
+	TransferInTransitStatus TransferStatus = "InTransit"
+	TransferReceivedStatus  TransferStatus = "Received"
+)
+
+// transferSteps maps every status onto the single status it may move to
+var transferSteps = map[TransferStatus]TransferStatus{
+	TransferRequestedStatus: TransferApprovedStatus,
+	TransferApprovedStatus:  TransferInTransitStatus,
+	TransferInTransitStatus: TransferReceivedStatus,
+}
+
+var TypeTransferStatus *TransferStatusError
+
+func (e *TransferStatusError) Error() string {
+	return fmt.Sprintf("transfer %q is %s and cannot become %s", e.ID, e.From, e.To)
+}
+
+func RequestTransfer(id string, film string, from StoreID, to StoreID, copies int) (Event, error) {
+	switch {
+	case id == "":
+		return nil, EmptyTransferIDError
+	case film == "":
+		return nil, EmptyFilmNameError
+	case copies <= 0:
+		return nil, InvalidCopiesError
+	case from == to:
+		return nil, SameStoreTransferError
+	}
+	for _, store := range []StoreID{from, to} {
+		if err := store.IsValid(); err != nil {
+			return nil, err
+		}
+	}
+	return TransferRequested{TransferID: id, Film: film, From: from, To: to, Copies: copies}, nil
+}
+
+// Approve is granted by the store the copies are taken from
+func (t *Transfer) Approve() (Event, error) {
+	if err := t.step(TransferApprovedStatus); err != nil {
+		return nil, err
+	}
+	return TransferApproved{TransferID: t.ID, From: t.From, To: t.To}, nil
+}
+
+func (t *Transfer) Ship() (Event, error) {
+	if err := t.step(TransferInTransitStatus); err != nil {
+		return nil, err
+	}
+	return TransferShipped{TransferID: t.ID, Film: t.Film, From: t.From, To: t.To, Copies: t.Copies}, nil
+}
+
+func (t *Transfer) Receive() (Event, error) {
+	if err := t.step(TransferReceivedStatus); err != nil {
+		return nil, err
+	}
+	return TransferReceived{TransferID: t.ID, Film: t.Film, From: t.From, To: t.To, Copies: t.Copies}, nil
+}
+
+func (t *Transfer) Apply(e Event) {
+	switch event := e.(type) {
+	case TransferRequested:
+		t.ID, t.Film, t.From, t.To, t.Copies = event.TransferID, event.Film, event.From, event.To, event.Copies
+		t.Status = TransferRequestedStatus
+	case TransferApproved:
+		t.Status = TransferApprovedStatus
+	case TransferShipped:
+		t.Status = TransferInTransitStatus
+	case TransferReceived:
+		t.Status = TransferReceivedStatus
+	}
+}
+
+func (t *Transfer) step(to TransferStatus) error {
+	if transferSteps[t.Status] != to {
+		return &TransferStatusError{ID: t.ID, From: t.Status, To: to}
+	}
+	return nil
+}
+
+func TransferEntity(id string) string {
+	return "transfer:" + id
+}
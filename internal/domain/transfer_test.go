@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransfer_Workflow(t *testing.T) {
+	requested, err := RequestTransfer("t-1", "Loki", "north", "south", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var transfer Transfer
+	transfer.Apply(requested)
+
+	steps := []struct {
+		step   func() (Event, error)
+		status TransferStatus
+	}{
+		{transfer.Approve, TransferApprovedStatus},
+		{transfer.Ship, TransferInTransitStatus},
+		{transfer.Receive, TransferReceivedStatus},
+	}
+	for _, step := range steps {
+		event, err := step.step()
+		if err != nil {
+			t.Fatalf("was expecting the transfer to become %s but got %v", step.status, err)
+		}
+		transfer.Apply(event)
+		if transfer.Status != step.status {
+			t.Errorf("was expecting the transfer to be %s but got %s", step.status, transfer.Status)
+		}
+	}
+
+	expected := Transfer{ID: "t-1", Film: "Loki", From: "north", To: "south", Copies: 2, Status: TransferReceivedStatus}
+	if transfer != expected {
+		t.Errorf("was expecting %#v but got %#v", expected, transfer)
+	}
+}
+
+func TestTransfer_StepsCannotBeSkippedOrRepeated(t *testing.T) {
+	transfer := Transfer{ID: "t-1", Film: "Loki", From: "north", To: "south", Copies: 2, Status: TransferRequestedStatus}
+	for name, step := range map[string]func() (Event, error){"Ship": transfer.Ship, "Receive": transfer.Receive} {
+		if _, err := step(); !errors.As(err, &TypeTransferStatus) {
+			t.Errorf("was expecting a requested transfer to refuse %s but got %v", name, err)
+		}
+	}
+
+	transfer.Status = TransferReceivedStatus
+	for name, step := range map[string]func() (Event, error){"Approve": transfer.Approve, "Ship": transfer.Ship, "Receive": transfer.Receive} {
+		if _, err := step(); !errors.As(err, &TypeTransferStatus) {
+			t.Errorf("was expecting a received transfer to refuse %s but got %v", name, err)
+		}
+	}
+}
+
+func TestTransfer_InvalidRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		film     string
+		from, to StoreID
+		copies   int
+		err      error
+	}{
+		{"NoID", "", "Loki", "north", "south", 1, EmptyTransferIDError},
+		{"NoFilm", "t-1", "", "north", "south", 1, EmptyFilmNameError},
+		{"NoCopies", "t-1", "Loki", "north", "south", 0, InvalidCopiesError},
+		{"SameStore", "t-1", "Loki", "north", "north", 1, SameStoreTransferError},
+		{"InvalidStore", "t-1", "Loki", "north", "South", 1, InvalidStoreIDError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := RequestTransfer(test.id, test.film, test.from, test.to, test.copies); err != test.err {
+				t.Errorf("was expecting %v but got %v", test.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package driven
+
+import "context"
+
+type actorKey struct{}
+
+// Anonymous is the actor recorded when a call has not been attributed to anyone
+const Anonymous = "anonymous"
+
+// WithActor attributes every service call made with the returned context to actor
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFrom(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return Anonymous
+}
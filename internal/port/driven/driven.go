@@ -1,28 +1,105 @@
 package driven
 
 import (
+	"context"
 	"github.com/shawnritchie/go-video-store/internal/domain"
+	"time"
 )
 
 type (
+	// FilmReturn names the rental the copy was rented out under when it has been started through FilmRenter
 	FilmReturn struct {
 		FilmName string
 		Days     uint16
+		RentalID string
+	}
+
+	// PublishedEvent is a domain event which has been committed by the store, ID increases with every event
+	PublishedEvent struct {
+		ID         uint64
+		Event      domain.Event
+		OccurredAt time.Time
 	}
 )
 
 type (
 	FilmFinder interface {
-		Find(name string) (*domain.Film, error)
+		Find(ctx context.Context, name string) (*domain.Film, error)
+	}
+
+	FilmBatchFinder interface {
+		// FindAll returns the catalogued films among names in a single lookup, unknown names are left out
+		FindAll(ctx context.Context, names []string) ([]domain.Film, error)
+	}
+
+	FilmLister interface {
+		// List returns the whole catalogue ordered by film name
+		List(ctx context.Context) ([]domain.Film, error)
 	}
 
 	FilmAppender interface {
-		AddNew(name string, director string) error
-		AddRegular(name string, director string) error
-		AddOld(name string, director string) error
+		AddNew(ctx context.Context, name string, director string) error
+		AddRegular(ctx context.Context, name string, director string) error
+		AddOld(ctx context.Context, name string, director string) error
+	}
+
+	FilmUpdater interface {
+		// UpdateFilm replaces the director and release of the film read at film.Version and returns it as updated,
+		// a FilmVersionConflictError is returned when it has changed since
+		UpdateFilm(ctx context.Context, film domain.Film) (*domain.Film, error)
 	}
 
 	FilmInvoicer interface {
-		Invoice(request []FilmReturn) (*domain.RentalInvoice, error)
+		Invoice(ctx context.Context, request []FilmReturn) (*domain.RentalInvoice, error)
+	}
+
+	FilmRenter interface {
+		// Rent rents a copy of a catalogued film out for days, the rental stays open until a return names its id
+		Rent(ctx context.Context, film string, days uint16) (*domain.RentalStarted, error)
+	}
+
+	// Inventory manages the stock of the store the context is scoped to
+	Inventory interface {
+		Inventory(ctx context.Context) ([]domain.Stock, error)
+		// AddStock adds copies of a catalogued film to the store
+		AddStock(ctx context.Context, film string, copies int) (*domain.Stock, error)
+	}
+
+	// PriceLists manages the prices of the store the context is scoped to
+	PriceLists interface {
+		PriceList(ctx context.Context) (domain.PriceList, error)
+		ChangePrices(ctx context.Context, prices domain.PriceList) error
+	}
+
+	// InvoiceHistory lists the invoices issued by the store the context is scoped to
+	InvoiceHistory interface {
+		Invoices(ctx context.Context) ([]domain.IssuedInvoice, error)
+	}
+
+	// Transfers moves copies of a film between stores. The copies leave the stock of the sending store once
+	// shipped and only join the stock of the receiving store once received
+	Transfers interface {
+		RequestTransfer(ctx context.Context, film string, from domain.StoreID, to domain.StoreID, copies int) (*domain.Transfer, error)
+		ApproveTransfer(ctx context.Context, id string) (*domain.Transfer, error)
+		ShipTransfer(ctx context.Context, id string) (*domain.Transfer, error)
+		ReceiveTransfer(ctx context.Context, id string) (*domain.Transfer, error)
+		Transfer(ctx context.Context, id string) (*domain.Transfer, error)
+		// Transfers returns every transfer from or to the store the context is scoped to
+		Transfers(ctx context.Context) ([]domain.Transfer, error)
+	}
+
+	AuditTrail interface {
+		AuditTrail(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEntry, error)
+	}
+
+	RentalTracker interface {
+		// CurrentRentals returns the rentals of film which have not yet been returned
+		CurrentRentals(ctx context.Context, film string) ([]domain.RentalStarted, error)
+	}
+
+	EventStream interface {
+		// Follow delivers the events published after lastID and then every new one until ctx is done. The channel
+		// is closed early when the follower falls too far behind, it may follow again from the last event it read
+		Follow(ctx context.Context, lastID uint64) (<-chan PublishedEvent, error)
 	}
 )
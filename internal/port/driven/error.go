@@ -1,6 +1,9 @@
 package driven
 
-import "fmt"
+import (
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+)
 
 type (
 	FilmNotFoundError struct {
@@ -11,13 +14,56 @@ type (
 		Name string
 	}
 
+	// FilmVersionConflictError refuses an update made to a film which has changed since it was read at Expected
+	FilmVersionConflictError struct {
+		Name     string
+		Expected uint64
+		Actual   uint64
+	}
+
 	InvalidRentalRequestError []error
+
+	StreamConflictError struct {
+		Stream   string
+		Expected uint64
+		Actual   uint64
+	}
+
+	EventsExpiredError struct {
+		LastID uint64
+	}
+
+	TransferNotFoundError struct {
+		ID string
+	}
+
+	IdempotencyKeyInUseError struct {
+		Key string
+	}
+
+	IdempotencyKeyReusedError struct {
+		Key string
+	}
+
+	InsufficientStockError struct {
+		Store     domain.StoreID
+		Film      string
+		Available int
+		Requested int
+	}
 )
 
 var (
 	TypeInvalidRentalRequest *InvalidRentalRequestError
 	TypeFilmNotFound         *FilmNotFoundError
 	TypeFilmAlreadyExist     *FilmAlreadyExistError
+	TypeFilmVersionConflict  *FilmVersionConflictError
+	TypeStreamConflict       *StreamConflictError
+	TypeEventsExpired        *EventsExpiredError
+	TypeInsufficientStock    *InsufficientStockError
+	TypeTransferNotFound     *TransferNotFoundError
+	TypeIdempotencyKeyInUse  *IdempotencyKeyInUseError
+	TypeIdempotencyKeyReused *IdempotencyKeyReusedError
 )
 
 func (e *FilmNotFoundError) Error() string {
@@ -28,6 +74,37 @@ func (e *FilmAlreadyExistError) Error() string {
 	return fmt.Sprintf("film: %q already exists", e.Name)
 }
 
+func (e *FilmVersionConflictError) Error() string {
+	return fmt.Sprintf("film: %q was expected at version %d but is at version %d", e.Name, e.Expected, e.Actual)
+}
+
+func (e *StreamConflictError) Error() string {
+	return fmt.Sprintf("stream: %q was expected at version %d but is at version %d", e.Stream, e.Expected, e.Actual)
+}
+
+func (e *EventsExpiredError) Error() string {
+	return fmt.Sprintf("events: the events following %d are no longer retained", e.LastID)
+}
+
+func (e *TransferNotFoundError) Error() string {
+	return fmt.Sprintf("transfer: %q was not found", e.ID)
+}
+
+func (e *IdempotencyKeyInUseError) Error() string {
+	return fmt.Sprintf("idempotency key: %q is in use by a request which has not been answered yet", e.Key)
+}
+
+func (e *IdempotencyKeyReusedError) Error() string {
+	return fmt.Sprintf("idempotency key: %q was used for another request", e.Key)
+}
+
+func (e *InsufficientStockError) Error() string {
+	if e.Available == 0 {
+		return fmt.Sprintf("film: %q is not stocked by store %q", e.Film, e.Store)
+	}
+	return fmt.Sprintf("film: %q has %d copies at store %q but %d were requested", e.Film, e.Available, e.Store, e.Requested)
+}
+
 func (e *InvalidRentalRequestError) Error() (errMsg string) {
 	errMsg = fmt.Sprintf("%d errors encountered\n", len(*e))
 	for _, err := range *e {
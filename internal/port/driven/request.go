@@ -0,0 +1,16 @@
+package driven
+
+import "context"
+
+type requestIDKey struct{}
+
+// WithRequestID correlates every service call made with the returned context with the request id
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFrom returns the id of the request the call is made for, empty when it was not made for one
+func RequestIDFrom(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
@@ -0,0 +1,21 @@
+package driven
+
+import (
+	"context"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+)
+
+type storeKey struct{}
+
+// WithStore scopes every service call made with the returned context to store
+func WithStore(ctx context.Context, store domain.StoreID) context.Context {
+	return context.WithValue(ctx, storeKey{}, store)
+}
+
+// StoreFrom returns the store the call is scoped to, domain.DefaultStore when it has not been scoped
+func StoreFrom(ctx context.Context) domain.StoreID {
+	if store, ok := ctx.Value(storeKey{}).(domain.StoreID); ok && store != "" {
+		return store
+	}
+	return domain.DefaultStore
+}
@@ -2,6 +2,7 @@ package driver
 
 import (
 	"github.com/shawnritchie/go-video-store/internal/domain"
+	"time"
 )
 
 type (
@@ -9,12 +10,108 @@ type (
 		FindBy(name string) (*domain.Film, error)
 	}
 
+	BatchQueryable interface {
+		// FindAll returns the catalogued films among the distinct names in a single lookup, unknown names are
+		// left out
+		FindAll(names []string) ([]domain.Film, error)
+	}
+
 	Insertable interface {
-		Insert(film domain.Film) error
+		// InsertIfAbsent atomically stores the film unless one with the same name is already catalogued,
+		// in which case a driven.FilmAlreadyExistError is returned
+		InsertIfAbsent(film domain.Film) error
+	}
+
+	Listable interface {
+		// List returns every catalogued film ordered by name
+		List() ([]domain.Film, error)
+	}
+
+	Updatable interface {
+		// Update replaces the director and release of the film unless it has changed since film.Version, in which
+		// case a driven.FilmVersionConflictError is returned. The stored film is then at film.Version+1
+		Update(film domain.Film) error
 	}
 
 	Catalogue interface {
 		Queryable
+		BatchQueryable
 		Insertable
+		Listable
+		Updatable
+	}
+
+	// Stores holds what belongs to a single store, every operation is scoped to the store it names and never
+	// observes the data of another
+	Stores interface {
+		// Stock returns how many copies of film the store holds, zero when it has never stocked it
+		Stock(store domain.StoreID, film string) (int, error)
+		// AdjustStock adds delta copies of film to the store and returns the copies it then holds. Stock never
+		// drops below zero, a driven.InsufficientStockError is returned instead
+		AdjustStock(store domain.StoreID, film string, delta int) (int, error)
+		// Inventory returns every film the store holds a copy of ordered by film name
+		Inventory(store domain.StoreID) ([]domain.Stock, error)
+
+		// PriceList returns the prices of the store, domain.DefaultPriceList until it sets its own
+		PriceList(store domain.StoreID) (domain.PriceList, error)
+		SavePriceList(store domain.StoreID, prices domain.PriceList) error
+
+		SaveInvoice(invoice domain.IssuedInvoice) error
+		// Invoices returns the invoices issued by the store ordered by issue time
+		Invoices(store domain.StoreID) ([]domain.IssuedInvoice, error)
+
+		// Transfer returns a driven.TransferNotFoundError when no transfer has the id
+		Transfer(id string) (*domain.Transfer, error)
+		// SaveTransfer records the transfer or replaces the one with the same id
+		SaveTransfer(transfer domain.Transfer) error
+		// Transfers returns every transfer from or to the store ordered by request time and then id
+		Transfers(store domain.StoreID) ([]domain.Transfer, error)
+	}
+
+	// Tx exposes the repositories taking part in a unit of work, they must not be used once it has completed
+	Tx interface {
+		Catalogue() Catalogue
+		Outbox() Outbox
+		Stores() Stores
+	}
+
+	UnitOfWork interface {
+		// Atomically commits every write made through tx when fx returns nil, otherwise none of them are kept
+		Atomically(fx func(tx Tx) error) error
+	}
+)
+
+type (
+	AuditSink interface {
+		// Record appends the entry to the trail, the sink assigns its sequence number and hashes
+		Record(entry domain.AuditEntry) error
+	}
+
+	AuditLog interface {
+		AuditSink
+		Query(query domain.AuditQuery) ([]domain.AuditEntry, error)
+	}
+)
+
+type (
+	// Envelope is an event which has been recorded in the outbox, Sequence increases with every enqueued event
+	Envelope struct {
+		Sequence   uint64
+		Event      domain.Event
+		OccurredAt time.Time
+	}
+
+	// Outbox records events alongside the writes that caused them so they are published if and only if
+	// the unit of work commits
+	Outbox interface {
+		Enqueue(events ...domain.Event) error
+	}
+
+	OutboxStore interface {
+		Outbox
+		// Pending returns up to limit undispatched envelopes ordered by sequence
+		Pending(limit int) ([]Envelope, error)
+		// MarkDispatched acknowledges every envelope up to and including sequence
+		MarkDispatched(sequence uint64) error
 	}
 )
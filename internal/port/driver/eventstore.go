@@ -0,0 +1,65 @@
+package driver
+
+import (
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"time"
+)
+
+type (
+	RecordedEvent struct {
+		// Position orders the event within the whole log starting at 1
+		Position uint64
+		// Version orders the event within its stream starting at 1
+		Version    uint64
+		Stream     string
+		Event      domain.Event
+		RecordedAt time.Time
+	}
+
+	EventStore interface {
+		// Append adds the events to the end of the stream failing with a driven.StreamConflictError
+		// unless the stream currently holds exactly expectedVersion events
+		Append(stream string, expectedVersion uint64, events ...domain.Event) error
+		ReadStream(stream string, fromVersion uint64) ([]RecordedEvent, error)
+		ReadAll(fromPosition uint64) ([]RecordedEvent, error)
+		// Head returns the position of the latest event, zero while the log is empty
+		Head() (uint64, error)
+	}
+
+	Snapshot struct {
+		Stream  string
+		Version uint64
+		State   []byte
+	}
+
+	SnapshotStore interface {
+		SaveSnapshot(snapshot Snapshot) error
+		// LoadSnapshot returns nil when no snapshot has been taken of the stream
+		LoadSnapshot(stream string) (*Snapshot, error)
+	}
+
+	// Rentals keeps every rental as the stream of its events
+	Rentals interface {
+		// Rental rehydrates the rental from its events, it is at version zero when it has not been started
+		Rental(rentalID string) (*domain.RentalAggregate, error)
+		// SaveRental appends events to the stream of the rental at its current version and applies them
+		SaveRental(rental *domain.RentalAggregate, events ...domain.Event) error
+	}
+)
+
+type (
+	// Checkpoints remembers the log position up to which each projection has been applied
+	Checkpoints interface {
+		Checkpoint(projection string) (uint64, error)
+		SaveCheckpoint(projection string, position uint64) error
+	}
+
+	// StatefulCheckpoints keeps the read model of a projection with its checkpoint, so a projection whose read
+	// model is held in memory resumes from it after a restart. SaveCheckpoint drops the state saved before it
+	StatefulCheckpoints interface {
+		Checkpoints
+		// State is nil when no state has been saved with the checkpoint of the projection
+		State(projection string) ([]byte, error)
+		SaveState(projection string, position uint64, state []byte) error
+	}
+)
@@ -0,0 +1,26 @@
+package driver
+
+import "time"
+
+type (
+	// IdempotentResponse is the response to the first request made under an idempotency key, it is replayed to
+	// every retry of that request
+	IdempotentResponse struct {
+		Status int
+		Header map[string][]string
+		Body   []byte
+	}
+
+	// IdempotencyStore remembers the requests made under an idempotency key until their ttl has passed. The
+	// fingerprint tells a retry of the request apart from another request reusing its key
+	IdempotencyStore interface {
+		// Claim reserves key for the request and returns nil, or the response to replay once the request has been
+		// answered. A driven.IdempotencyKeyInUseError is returned while the request is in flight and a
+		// driven.IdempotencyKeyReusedError when the key was claimed for another fingerprint
+		Claim(key string, fingerprint string, ttl time.Duration) (*IdempotentResponse, error)
+		// Complete keeps the response to the request which claimed key for the ttl it was claimed with
+		Complete(key string, response IdempotentResponse) error
+		// Release forgets a claimed key whose request could not be answered so it may be retried
+		Release(key string) error
+	}
+)
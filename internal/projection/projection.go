@@ -0,0 +1,47 @@
+package projection
+
+import (
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"sync"
+)
+
+type (
+	// Projection folds events into a denormalised read model, Handle is only ever called from a single goroutine
+	Projection interface {
+		Name() string
+		Handle(record driver.RecordedEvent) error
+		// Reset discards the read model so it can be rebuilt from the start of the log
+		Reset()
+	}
+
+	// Stateful projections can have their read model saved with their checkpoint and restored from it
+	Stateful interface {
+		Projection
+		State() ([]byte, error)
+		Restore(state []byte) error
+	}
+
+	MemoryCheckpoints struct {
+		mu          sync.RWMutex
+		checkpoints map[string]uint64
+	}
+)
+
+func NewMemoryCheckpoints() *MemoryCheckpoints {
+	return &MemoryCheckpoints{
+		checkpoints: map[string]uint64{},
+	}
+}
+
+func (c *MemoryCheckpoints) Checkpoint(projection string) (uint64, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.checkpoints[projection], nil
+}
+
+func (c *MemoryCheckpoints) SaveCheckpoint(projection string, position uint64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checkpoints[projection] = position
+	return nil
+}
@@ -0,0 +1,244 @@
+package projection
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"sort"
+	"sync"
+)
+
+type (
+	FilmsByDirector struct {
+		mu        sync.RWMutex
+		directors map[string]map[string]domain.Film
+	}
+
+	CurrentlyRented struct {
+		mu      sync.RWMutex
+		rentals map[string]domain.RentalStarted
+	}
+
+	RevenuePerDay struct {
+		mu      sync.RWMutex
+		revenue map[string]domain.SEK
+	}
+)
+
+// DayLayout is the format of the days RevenuePerDay is keyed by
+const DayLayout = "2006-01-02"
+
+func NewFilmsByDirector() *FilmsByDirector {
+	return &FilmsByDirector{directors: map[string]map[string]domain.Film{}}
+}
+
+func (p *FilmsByDirector) Name() string {
+	return "films_by_director"
+}
+
+func (p *FilmsByDirector) Handle(record driver.RecordedEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch event := record.Event.(type) {
+	case domain.FilmAdded:
+		if p.directors[event.Director] == nil {
+			p.directors[event.Director] = map[string]domain.Film{}
+		}
+		p.directors[event.Director][event.Name] = domain.Film{Name: event.Name, Director: event.Director, Release: event.Release}
+	case domain.FilmReleaseChanged:
+		for _, films := range p.directors {
+			if film, ok := films[event.Name]; ok {
+				film.Release = event.To
+				films[event.Name] = film
+			}
+		}
+	case domain.FilmUpdated:
+		for _, films := range p.directors {
+			delete(films, event.Name)
+		}
+		if p.directors[event.Director] == nil {
+			p.directors[event.Director] = map[string]domain.Film{}
+		}
+		p.directors[event.Director][event.Name] = domain.Film{Name: event.Name, Director: event.Director, Release: event.Release}
+	}
+	return nil
+}
+
+func (p *FilmsByDirector) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.directors = map[string]map[string]domain.Film{}
+}
+
+func (p *FilmsByDirector) State() ([]byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return json.Marshal(p.directors)
+}
+
+func (p *FilmsByDirector) Restore(state []byte) error {
+	directors := map[string]map[string]domain.Film{}
+	if err := json.Unmarshal(state, &directors); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.directors = directors
+	return nil
+}
+
+// Films returns the films by the director ordered by name
+func (p *FilmsByDirector) Films(director string) []domain.Film {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	films := make([]domain.Film, 0, len(p.directors[director]))
+	for _, film := range p.directors[director] {
+		films = append(films, film)
+	}
+	sort.Slice(films, func(i, j int) bool {
+		return films[i].Name < films[j].Name
+	})
+	return films
+}
+
+func NewCurrentlyRented() *CurrentlyRented {
+	return &CurrentlyRented{rentals: map[string]domain.RentalStarted{}}
+}
+
+func (p *CurrentlyRented) Name() string {
+	return "currently_rented"
+}
+
+func (p *CurrentlyRented) Handle(record driver.RecordedEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch event := record.Event.(type) {
+	case domain.RentalStarted:
+		p.rentals[event.RentalID] = event
+	case domain.RentalReturned:
+		delete(p.rentals, event.RentalID)
+	}
+	return nil
+}
+
+func (p *CurrentlyRented) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rentals = map[string]domain.RentalStarted{}
+}
+
+func (p *CurrentlyRented) State() ([]byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return json.Marshal(p.rentals)
+}
+
+func (p *CurrentlyRented) Restore(state []byte) error {
+	rentals := map[string]domain.RentalStarted{}
+	if err := json.Unmarshal(state, &rentals); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rentals = rentals
+	return nil
+}
+
+// Rentals returns every rental which has not yet been returned ordered by rental id
+func (p *CurrentlyRented) Rentals() []domain.RentalStarted {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	rentals := make([]domain.RentalStarted, 0, len(p.rentals))
+	for _, rental := range p.rentals {
+		rentals = append(rentals, rental)
+	}
+	sort.Slice(rentals, func(i, j int) bool {
+		return rentals[i].RentalID < rentals[j].RentalID
+	})
+	return rentals
+}
+
+// CurrentRentals returns the rentals of film which have not yet been returned ordered by rental id
+func (p *CurrentlyRented) CurrentRentals(ctx context.Context, film string) ([]domain.RentalStarted, error) {
+	var rentals []domain.RentalStarted
+	for _, rental := range p.Rentals() {
+		if rental.Film.Name == film {
+			rentals = append(rentals, rental)
+		}
+	}
+	return rentals, nil
+}
+
+// Copies returns how many copies of the film are currently rented out
+func (p *CurrentlyRented) Copies(film string) int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	copies := 0
+	for _, rental := range p.rentals {
+		if rental.Film.Name == film {
+			copies++
+		}
+	}
+	return copies
+}
+
+func NewRevenuePerDay() *RevenuePerDay {
+	return &RevenuePerDay{revenue: map[string]domain.SEK{}}
+}
+
+func (p *RevenuePerDay) Name() string {
+	return "revenue_per_day"
+}
+
+func (p *RevenuePerDay) Handle(record driver.RecordedEvent) error {
+	if event, ok := record.Event.(domain.RentalReturned); ok {
+		p.mu.Lock()
+		p.revenue[record.RecordedAt.UTC().Format(DayLayout)] += event.Cost
+		p.mu.Unlock()
+	}
+	return nil
+}
+
+func (p *RevenuePerDay) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.revenue = map[string]domain.SEK{}
+}
+
+func (p *RevenuePerDay) State() ([]byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return json.Marshal(p.revenue)
+}
+
+func (p *RevenuePerDay) Restore(state []byte) error {
+	revenue := map[string]domain.SEK{}
+	if err := json.Unmarshal(state, &revenue); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.revenue = revenue
+	return nil
+}
+
+// Revenue returns the revenue of every day keyed by DayLayout
+func (p *RevenuePerDay) Revenue() map[string]domain.SEK {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	revenue := make(map[string]domain.SEK, len(p.revenue))
+	for day, amount := range p.revenue {
+		revenue[day] = amount
+	}
+	return revenue
+}
@@ -0,0 +1,88 @@
+package projection
+
+import (
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"testing"
+	"time"
+)
+
+var (
+	loki = domain.Film{Name: "Loki", Director: "Marvel", Release: domain.New}
+	dune = domain.Film{Name: "Dune", Director: "Villeneuve", Release: domain.Regular}
+	day  = time.Date(2021, 6, 9, 18, 0, 0, 0, time.UTC)
+)
+
+func replay(t *testing.T, p Projection, events ...domain.Event) {
+	t.Helper()
+	for i, event := range events {
+		record := driver.RecordedEvent{Position: uint64(i + 1), Event: event, RecordedAt: day.Add(time.Duration(i) * 4 * time.Hour)}
+		if err := p.Handle(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFilmsByDirector(t *testing.T) {
+	p := NewFilmsByDirector()
+	replay(t, p,
+		domain.FilmAdded{Name: "Thor", Director: "Marvel", Release: domain.Old},
+		domain.FilmAdded{Name: loki.Name, Director: loki.Director, Release: loki.Release},
+		domain.FilmAdded{Name: dune.Name, Director: dune.Director, Release: dune.Release},
+		domain.FilmReleaseChanged{Name: loki.Name, From: domain.New, To: domain.Regular},
+		domain.FilmAdded{Name: "Blade Runner", Director: "Scott", Release: domain.Old},
+		domain.FilmUpdated{Name: "Blade Runner", Director: "Villeneuve", Release: domain.Old, Version: 2},
+	)
+
+	films := p.Films("Marvel")
+	if len(films) != 2 || films[0].Name != "Loki" || films[1].Name != "Thor" {
+		t.Fatalf("was expecting Marvel films ordered by name but got %#v", films)
+	}
+	if films[0].Release != domain.Regular {
+		t.Errorf("was expecting reclassification to be projected but got %#v", films[0])
+	}
+	if films := p.Films("Villeneuve"); len(films) != 2 || films[0].Name != "Blade Runner" || films[1].Name != dune.Name {
+		t.Errorf("was expecting the update to move the film to its new director but got %#v", films)
+	}
+	if films := p.Films("Scott"); len(films) != 0 {
+		t.Errorf("was expecting the film to leave its former director but got %#v", films)
+	}
+
+	p.Reset()
+	if films := p.Films("Marvel"); len(films) != 0 {
+		t.Errorf("was expecting an empty read model after reset but got %#v", films)
+	}
+}
+
+func TestCurrentlyRented(t *testing.T) {
+	p := NewCurrentlyRented()
+	replay(t, p,
+		domain.RentalStarted{RentalID: "r-1", Film: loki, Days: 1},
+		domain.RentalStarted{RentalID: "r-2", Film: loki, Days: 3},
+		domain.RentalStarted{RentalID: "r-3", Film: dune, Days: 2},
+		domain.RentalReturned{RentalID: "r-1", Film: loki.Name, Days: 1, Cost: domain.PREMIUM},
+	)
+
+	rentals := p.Rentals()
+	if len(rentals) != 2 || rentals[0].RentalID != "r-2" || rentals[1].RentalID != "r-3" {
+		t.Errorf("was expecting r-2 and r-3 to still be rented but got %#v", rentals)
+	}
+
+	if copies := p.Copies(loki.Name); copies != 1 {
+		t.Errorf("was expecting a single copy of Loki to be rented but got %d", copies)
+	}
+}
+
+func TestRevenuePerDay(t *testing.T) {
+	p := NewRevenuePerDay()
+	replay(t, p,
+		domain.RentalReturned{RentalID: "r-1", Film: loki.Name, Days: 1, Cost: domain.PREMIUM},
+		domain.RentalStarted{RentalID: "r-2", Film: dune, Days: 3},
+		domain.RentalReturned{RentalID: "r-2", Film: dune.Name, Days: 3, Cost: domain.BASIC},
+	)
+
+	revenue := p.Revenue()
+	if revenue["2021-06-09"] != domain.PREMIUM || revenue["2021-06-10"] != domain.BASIC {
+		t.Errorf("was expecting revenue to be split by day but got %v", revenue)
+	}
+}
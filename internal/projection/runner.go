@@ -0,0 +1,222 @@
+package projection
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"sync"
+	"time"
+)
+
+type (
+	// Runner feeds every projection the events recorded after its checkpoint. With driver.StatefulCheckpoints
+	// the read model of a Stateful projection is saved with its checkpoint once per catch up and restored the
+	// first time the projection catches up, any other projection is replayed from the start of the log
+	Runner struct {
+		events      driver.EventStore
+		checkpoints driver.Checkpoints
+		mu          sync.Mutex
+		projections map[string]Projection
+		order       []string
+		restored    map[string]bool
+	}
+
+	UnknownProjectionError struct {
+		Name string
+	}
+)
+
+func (e *UnknownProjectionError) Error() string {
+	return fmt.Sprintf("projection: %q is not registered", e.Name)
+}
+
+func NewRunner(events driver.EventStore, checkpoints driver.Checkpoints, projections ...Projection) *Runner {
+	r := &Runner{
+		events:      events,
+		checkpoints: checkpoints,
+		projections: map[string]Projection{},
+		restored:    map[string]bool{},
+	}
+	for _, p := range projections {
+		r.projections[p.Name()] = p
+		r.order = append(r.order, p.Name())
+	}
+	return r
+}
+
+// CatchUp applies every outstanding event to each projection
+func (r *Runner) CatchUp() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, name := range r.order {
+		if err := r.catchUp(r.projections[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run catches up every interval until ctx is cancelled
+func (r *Runner) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.CatchUp(); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Rebuild discards the read model of the projection and replays the whole log into it
+func (r *Runner) Rebuild(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.projections[name]
+	if !ok {
+		return &UnknownProjectionError{Name: name}
+	}
+
+	p.Reset()
+	if err := r.checkpoints.SaveCheckpoint(name, 0); err != nil {
+		return err
+	}
+	r.restored[name] = true
+	return r.catchUp(p)
+}
+
+// Lag reports how many events each projection is behind the head of the log
+func (r *Runner) Lag() (map[string]uint64, error) {
+	head, err := r.events.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	lag := map[string]uint64{}
+	for _, name := range r.order {
+		checkpoint, err := r.checkpoints.Checkpoint(name)
+		if err != nil {
+			return nil, err
+		}
+		if head > checkpoint {
+			lag[name] = head - checkpoint
+		} else {
+			lag[name] = 0
+		}
+	}
+	return lag, nil
+}
+
+// PublishLag exposes the lag of every projection through expvar under the given name
+func (r *Runner) PublishLag(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		lag, err := r.Lag()
+		if err != nil {
+			return err.Error()
+		}
+		return lag
+	}))
+}
+
+func (r *Runner) catchUp(p Projection) error {
+	if err := r.restore(p); err != nil {
+		return err
+	}
+
+	checkpoint, err := r.checkpoints.Checkpoint(p.Name())
+	if err != nil {
+		return err
+	}
+
+	records, err := r.events.ReadAll(checkpoint + 1)
+	if err != nil {
+		return err
+	}
+
+	checkpoints, stateful := r.stateful(p)
+	var applied uint64
+	for _, record := range records {
+		if err := p.Handle(record); err != nil {
+			err = fmt.Errorf("projection %q failed at position %d: %w", p.Name(), record.Position, err)
+			// the records applied before the failure are kept so they are not applied twice on the next catch up
+			if stateful != nil && applied > 0 {
+				if saveErr := r.saveState(checkpoints, stateful, applied); saveErr != nil {
+					return errors.Join(err, saveErr)
+				}
+			}
+			return err
+		}
+		applied = record.Position
+		if stateful == nil {
+			if err := r.checkpoints.SaveCheckpoint(p.Name(), record.Position); err != nil {
+				return err
+			}
+		}
+	}
+
+	if stateful != nil && applied > 0 {
+		return r.saveState(checkpoints, stateful, applied)
+	}
+	return nil
+}
+
+// restore loads the read model saved with the checkpoint of p the first time it catches up, a projection
+// without a saved read model is replayed from the start of the log
+func (r *Runner) restore(p Projection) error {
+	if r.restored[p.Name()] {
+		return nil
+	}
+	checkpoints, ok := r.checkpoints.(driver.StatefulCheckpoints)
+	if !ok {
+		r.restored[p.Name()] = true
+		return nil
+	}
+
+	state, err := checkpoints.State(p.Name())
+	if err != nil {
+		return err
+	}
+	if stateful, ok := p.(Stateful); ok && state != nil {
+		if err := stateful.Restore(state); err != nil {
+			return fmt.Errorf("unable to restore projection %q: %w", p.Name(), err)
+		}
+	} else {
+		p.Reset()
+		if err := checkpoints.SaveCheckpoint(p.Name(), 0); err != nil {
+			return err
+		}
+	}
+	r.restored[p.Name()] = true
+	return nil
+}
+
+// stateful returns the checkpoints and projection when the read model of p is to be saved with its checkpoint
+func (r *Runner) stateful(p Projection) (driver.StatefulCheckpoints, Stateful) {
+	checkpoints, ok := r.checkpoints.(driver.StatefulCheckpoints)
+	if !ok {
+		return nil, nil
+	}
+	stateful, ok := p.(Stateful)
+	if !ok {
+		return nil, nil
+	}
+	return checkpoints, stateful
+}
+
+func (r *Runner) saveState(checkpoints driver.StatefulCheckpoints, p Stateful, position uint64) error {
+	state, err := p.State()
+	if err != nil {
+		return fmt.Errorf("unable to save projection %q: %w", p.Name(), err)
+	}
+	return checkpoints.SaveState(p.Name(), position, state)
+}
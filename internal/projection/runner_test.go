@@ -0,0 +1,151 @@
+package projection
+
+import (
+	"errors"
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/eventstore"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"path/filepath"
+	"testing"
+)
+
+type countingProjection struct {
+	handled []uint64
+	resets  int
+}
+
+func (p *countingProjection) Name() string { return "counting" }
+
+func (p *countingProjection) Handle(record driver.RecordedEvent) error {
+	p.handled = append(p.handled, record.Position)
+	return nil
+}
+
+func (p *countingProjection) Reset() {
+	p.handled = nil
+	p.resets++
+}
+
+func appendFilms(t *testing.T, store driver.EventStore, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		event := domain.FilmAdded{Name: name, Director: "Dwight", Release: domain.New}
+		if err := store.Append(domain.FilmStream(name), 0, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRunner_CatchUpResumesFromCheckpoint(t *testing.T) {
+	store := eventstore.NewMemoryStore()
+	checkpoints := NewMemoryCheckpoints()
+	counting := &countingProjection{}
+	runner := NewRunner(store, checkpoints, counting)
+
+	appendFilms(t, store, "Loki", "Dune")
+	if err := runner.CatchUp(); err != nil {
+		t.Fatal(err)
+	}
+
+	appendFilms(t, store, "Alien")
+	if err := runner.CatchUp(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(counting.handled) != 3 || counting.handled[2] != 3 {
+		t.Errorf("was expecting every event to be handled exactly once but got %v", counting.handled)
+	}
+
+	if checkpoint, _ := checkpoints.Checkpoint(counting.Name()); checkpoint != 3 {
+		t.Errorf("was expecting checkpoint at position 3 but got %d", checkpoint)
+	}
+}
+
+func TestRunner_Rebuild(t *testing.T) {
+	store := eventstore.NewMemoryStore()
+	counting := &countingProjection{}
+	runner := NewRunner(store, NewMemoryCheckpoints(), counting)
+
+	appendFilms(t, store, "Loki", "Dune")
+	if err := runner.CatchUp(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runner.Rebuild(counting.Name()); err != nil {
+		t.Fatal(err)
+	}
+
+	if counting.resets != 1 || len(counting.handled) != 2 || counting.handled[0] != 1 {
+		t.Errorf("was expecting the projection to be reset and replayed from the start but got %v", counting.handled)
+	}
+
+	var unknown *UnknownProjectionError
+	if err := runner.Rebuild("missing"); !errors.As(err, &unknown) {
+		t.Errorf("was expecting UnknownProjectionError but got %#v", err)
+	}
+}
+
+func TestRunner_Lag(t *testing.T) {
+	store := eventstore.NewMemoryStore()
+	counting := &countingProjection{}
+	runner := NewRunner(store, NewMemoryCheckpoints(), counting)
+
+	appendFilms(t, store, "Loki", "Dune", "Alien")
+
+	if lag, err := runner.Lag(); err != nil || lag[counting.Name()] != 3 {
+		t.Errorf("was expecting a lag of 3 before catching up but got %v, %v", lag, err)
+	}
+
+	if err := runner.CatchUp(); err != nil {
+		t.Fatal(err)
+	}
+
+	if lag, err := runner.Lag(); err != nil || lag[counting.Name()] != 0 {
+		t.Errorf("was expecting no lag after catching up but got %v, %v", lag, err)
+	}
+}
+
+func TestRunner_ResumesFromSavedState(t *testing.T) {
+	store := eventstore.NewMemoryStore()
+	path := filepath.Join(t.TempDir(), "events.checkpoints")
+	loki := domain.Film{Name: "Loki", Director: "Marvel", Release: domain.New}
+	for _, id := range []string{"r-1", "r-2"} {
+		if err := store.Append(domain.RentalStream(id), 0, domain.RentalStarted{RentalID: id, Film: loki, Days: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkpoints, err := eventstore.OpenFileCheckpoints(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewRunner(store, checkpoints, NewCurrentlyRented(), &countingProjection{}).CatchUp(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Append(domain.RentalStream("r-1"), 1, domain.RentalReturned{RentalID: "r-1", Film: "Loki", Days: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a restart starts from an empty read model and the checkpoints kept on disk
+	if checkpoints, err = eventstore.OpenFileCheckpoints(path); err != nil {
+		t.Fatal(err)
+	}
+	rented, counting := NewCurrentlyRented(), &countingProjection{}
+	runner := NewRunner(store, checkpoints, rented, counting)
+	if err := runner.CatchUp(); err != nil {
+		t.Fatal(err)
+	}
+
+	if rentals := rented.Rentals(); len(rentals) != 1 || rentals[0].RentalID != "r-2" {
+		t.Errorf("was expecting the read model to resume from its saved state but got %#v", rentals)
+	}
+	if checkpoint, _ := checkpoints.Checkpoint(rented.Name()); checkpoint != 3 {
+		t.Errorf("was expecting checkpoint at position 3 but got %d", checkpoint)
+	}
+	if len(counting.handled) != 3 || counting.handled[0] != 1 {
+		t.Errorf("was expecting a projection without saved state to be replayed from the start but got %v", counting.handled)
+	}
+	if lag, err := runner.Lag(); err != nil || lag[rented.Name()] != 0 || lag[counting.Name()] != 0 {
+		t.Errorf("was expecting no lag after catching up but got %v, %v", lag, err)
+	}
+}
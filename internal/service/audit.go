@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"go.opentelemetry.io/otel/attribute"
+	"strconv"
+	"strings"
+)
+
+// WithAuditLog records the actor, inputs and outcome of every mutating call in log
+func WithAuditLog(log driver.AuditLog) Option {
+	return func(svc *StoreService) {
+		svc.auditLog = log
+	}
+}
+
+func (svc *StoreService) AuditTrail(ctx context.Context, query domain.AuditQuery) (_ []domain.AuditEntry, err error) {
+	_, span := svc.start(ctx, "StoreService.AuditTrail", attribute.String("audit.entity", query.Entity))
+	defer func() { end(span, err) }()
+
+	if svc.auditLog == nil {
+		return nil, nil
+	}
+	return svc.auditLog.Query(query)
+}
+
+// audit records the outcome of an operation and passes its error through. The operation has already been
+// committed by then, so a failure to record the entry is logged rather than reported as a failure of a write which
+// took place and which a retry would repeat
+func (svc *StoreService) audit(ctx context.Context, operation string, entity string, inputs map[string]string, err error) error {
+	if err != nil {
+		svc.logger.DebugContext(ctx, "operation failed", "operation", operation, "entity", entity, "error", err)
+	}
+	if svc.auditLog == nil {
+		return err
+	}
+
+	entry := domain.AuditEntry{
+		Timestamp: svc.now().UTC(),
+		Actor:     driven.ActorFrom(ctx),
+		Operation: operation,
+		Entity:    entity,
+		Inputs:    inputs,
+		Outcome:   domain.OutcomeSuccess,
+	}
+	if err != nil {
+		entry.Outcome = domain.OutcomeFailure
+		entry.Error = err.Error()
+	}
+
+	if auditErr := svc.auditLog.Record(entry); auditErr != nil {
+		svc.logger.ErrorContext(ctx, "unable to audit operation", "operation", operation, "entity", entity, "error", auditErr)
+	}
+	return err
+}
+
+func filmInputs(film domain.Film) map[string]string {
+	return map[string]string{
+		"name":     film.Name,
+		"director": film.Director,
+		"release":  string(film.Release),
+	}
+}
+
+func invoiceInputs(store domain.StoreID, request []driven.FilmReturn, invoice *domain.RentalInvoice) map[string]string {
+	returns := make([]string, 0, len(request))
+	for _, r := range request {
+		returns = append(returns, fmt.Sprintf("%s=%d", r.FilmName, r.Days))
+	}
+
+	inputs := map[string]string{
+		"store":   string(store),
+		"returns": strings.Join(returns, ","),
+	}
+	if invoice != nil {
+		inputs["cost"] = strconv.FormatUint(uint64(invoice.Cost), 10)
+	}
+	return inputs
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/shawnritchie/go-video-store/internal/adapter/logging"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"strings"
+	"testing"
+	"time"
+)
+
+type spyAuditLog struct {
+	entries []domain.AuditEntry
+	err     error
+}
+
+func (s *spyAuditLog) Record(entry domain.AuditEntry) error {
+	s.entries = append(s.entries, entry)
+	return s.err
+}
+
+func (s *spyAuditLog) Query(query domain.AuditQuery) ([]domain.AuditEntry, error) {
+	return s.entries, nil
+}
+
+func TestAudit_AddFilm(t *testing.T) {
+	auditLog := &spyAuditLog{}
+	catalogue := setupCatalogue()
+	service := New(catalogue, catalogue, WithAuditLog(auditLog))
+	service.now = func() time.Time { return time.Date(2021, 6, 9, 12, 0, 0, 0, time.UTC) }
+
+	ctx := driven.WithActor(context.Background(), "manager")
+	if err := service.AddNew(ctx, "Loki", "Marvel"); err != nil {
+		t.Fatal(err)
+	}
+	service.AddOld(ctx, films[0].Name, films[0].Director)
+
+	if len(auditLog.entries) != 2 {
+		t.Fatalf("was expecting both calls to be audited but got %#v", auditLog.entries)
+	}
+
+	added, rejected := auditLog.entries[0], auditLog.entries[1]
+	switch {
+	case added.Actor != "manager" || added.Operation != "AddFilm" || added.Entity != "film:Loki":
+		t.Errorf("unexpected audit entry %#v", added)
+	case added.Outcome != domain.OutcomeSuccess || added.Inputs["release"] != string(domain.New) || added.Timestamp.Hour() != 12:
+		t.Errorf("unexpected audit entry %#v", added)
+	case rejected.Outcome != domain.OutcomeFailure || rejected.Error == "":
+		t.Errorf("was expecting the duplicate to be audited as a failure but got %#v", rejected)
+	}
+}
+
+func TestAudit_Invoice(t *testing.T) {
+	auditLog := &spyAuditLog{}
+	catalogue := setupCatalogue()
+	service := New(catalogue, catalogue, WithAuditLog(auditLog))
+
+	if _, err := service.Invoice(context.Background(), mapFilmReturn(films[:1], 2)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(auditLog.entries) != 1 {
+		t.Fatalf("was expecting the invoice to be audited but got %#v", auditLog.entries)
+	}
+	entry := auditLog.entries[0]
+	if entry.Actor != driven.Anonymous || entry.Inputs["returns"] != "Matrix 11=2" || entry.Inputs["cost"] != "80" {
+		t.Errorf("unexpected audit entry %#v", entry)
+	}
+}
+
+func TestAudit_FailureToRecordDoesNotFailTheOperation(t *testing.T) {
+	auditLog := &spyAuditLog{err: errors.New("disk full")}
+	catalogue := setupCatalogue()
+	service := New(catalogue, catalogue, WithAuditLog(auditLog))
+
+	if err := service.AddNew(context.Background(), "Loki", "Marvel"); err != nil {
+		t.Errorf("was expecting the committed operation to succeed but got %v", err)
+	}
+	if film, err := service.Find(context.Background(), "Loki"); err != nil || film.Name != "Loki" {
+		t.Errorf("was expecting the film to be added but got %#v %v", film, err)
+	}
+}
+
+func TestAudit_FailureToRecordIsLogged(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := logging.New(&out, "info", logging.Text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditLog := &spyAuditLog{err: errors.New("disk full")}
+	catalogue := setupCatalogue()
+	service := New(catalogue, catalogue, WithAuditLog(auditLog), WithLogger(logger))
+
+	ctx := driven.WithRequestID(context.Background(), "req-1")
+	service.AddNew(ctx, "Loki", "Marvel")
+
+	line := out.String()
+	if !strings.Contains(line, "level=ERROR") || !strings.Contains(line, "disk full") || !strings.Contains(line, "request_id=req-1") {
+		t.Errorf("was expecting the audit failure to be logged under its request id but got %q", line)
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/inmem"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"testing"
+)
+
+func TestEvents_AddFilmEnqueuesFilmAdded(t *testing.T) {
+	catalogue, outbox := inmem.NewStoreCatalogue(), inmem.NewOutbox()
+	service := New(catalogue, catalogue, WithUnitOfWork(inmem.NewUnitOfWork(catalogue, outbox, inmem.NewStores())))
+
+	if err := service.AddOld(context.Background(), "Loki", "Marvel"); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.AddOld(context.Background(), "Loki", "Marvel"); err == nil {
+		t.Fatal("was expecting the duplicate film to be rejected")
+	}
+
+	pending, _ := outbox.Pending(10)
+	expected := domain.FilmAdded{Name: "Loki", Director: "Marvel", Release: domain.Old}
+	if len(pending) != 1 || pending[0].Event != expected {
+		t.Errorf("was expecting only %#v to be enqueued but got %#v", expected, pending)
+	}
+}
+
+func TestEvents_UpdateFilmEnqueuesFilmUpdated(t *testing.T) {
+	catalogue, outbox := inmem.NewStoreCatalogue(films...), inmem.NewOutbox()
+	service := New(catalogue, catalogue, WithUnitOfWork(inmem.NewUnitOfWork(catalogue, outbox, inmem.NewStores())))
+
+	update := domain.Film{Name: films[0].Name, Director: "Wachowski", Release: domain.Regular, Version: 1}
+	if _, err := service.UpdateFilm(context.Background(), update); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.UpdateFilm(context.Background(), update); err == nil {
+		t.Fatal("was expecting the stale update to be rejected")
+	}
+
+	pending, _ := outbox.Pending(10)
+	expected := domain.FilmUpdated{Name: update.Name, Director: update.Director, Release: update.Release, Version: 2}
+	if len(pending) != 1 || pending[0].Event != expected {
+		t.Errorf("was expecting only %#v to be enqueued but got %#v", expected, pending)
+	}
+}
+
+func TestEvents_InvoiceEnqueuesReturnsAndInvoice(t *testing.T) {
+	catalogue, outbox := inmem.NewStoreCatalogue(films...), inmem.NewOutbox()
+	service := New(catalogue, catalogue, WithUnitOfWork(inmem.NewUnitOfWork(catalogue, outbox, inmem.NewStores())))
+	service.newID = func() string { return "inv" }
+
+	request := []driven.FilmReturn{{FilmName: films[0].Name, Days: 2}, {FilmName: films[3].Name, Days: 7}}
+	invoice, err := service.Invoice(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pending, _ := outbox.Pending(10)
+	if len(pending) != 3 {
+		t.Fatalf("was expecting 2 returns and the invoice to be enqueued but got %#v", pending)
+	}
+
+	expected := []domain.RentalReturned{
+		{RentalID: "inv-1", Store: domain.DefaultStore, Film: films[0].Name, Days: 2, Cost: 80},
+		{RentalID: "inv-2", Store: domain.DefaultStore, Film: films[3].Name, Days: 7, Cost: 90},
+	}
+	for i, returned := range expected {
+		if pending[i].Event != returned {
+			t.Errorf("was expecting %#v but got %#v", returned, pending[i].Event)
+		}
+	}
+
+	issued, ok := pending[2].Event.(domain.InvoiceIssued)
+	if !ok || issued.InvoiceID != "inv" || issued.Cost != invoice.Cost || len(issued.Rentals) != 2 {
+		t.Errorf("was expecting the invoice to be issued for %d but got %#v", invoice.Cost, pending[2].Event)
+	}
+}
+
+func TestEvents_RejectedInvoiceEnqueuesNothing(t *testing.T) {
+	catalogue, outbox := inmem.NewStoreCatalogue(films...), inmem.NewOutbox()
+	service := New(catalogue, catalogue, WithUnitOfWork(inmem.NewUnitOfWork(catalogue, outbox, inmem.NewStores())))
+
+	if _, err := service.Invoice(context.Background(), []driven.FilmReturn{{FilmName: "Unknown", Days: 1}}); err == nil {
+		t.Fatal("was expecting the invoice to be rejected")
+	}
+
+	if pending, _ := outbox.Pending(10); len(pending) != 0 {
+		t.Errorf("was expecting nothing to be enqueued but got %#v", pending)
+	}
+}
+
+func TestEvents_OutboxWithoutUnitOfWork(t *testing.T) {
+	catalogue, outbox := inmem.NewStoreCatalogue(), inmem.NewOutbox()
+	service := New(catalogue, catalogue, WithOutbox(outbox))
+
+	if err := service.AddNew(context.Background(), "Loki", "Marvel"); err != nil {
+		t.Fatal(err)
+	}
+
+	if pending, _ := outbox.Pending(10); len(pending) != 1 {
+		t.Errorf("was expecting the FilmAdded to be enqueued but got %#v", pending)
+	}
+}
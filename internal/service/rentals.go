@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"go.opentelemetry.io/otel/attribute"
+	"strconv"
+)
+
+// RentalsNotConfiguredError is returned by Rent, and by Invoice for a return naming a rental, when no rentals
+// have been configured
+var RentalsNotConfiguredError = fmt.Errorf("rentals have not been configured")
+
+// WithRentals follows every rental through its aggregate, from Rent until the return which names its rental id
+func WithRentals(rentals driver.Rentals) Option {
+	return func(svc *StoreService) {
+		svc.rentals = rentals
+	}
+}
+
+func (svc *StoreService) Rent(ctx context.Context, film string, days uint16) (rental *domain.RentalStarted, err error) {
+	id := svc.newID()
+	ctx, span := svc.start(ctx, "StoreService.Rent", rentalAttribute(id), storeAttribute(ctx), attribute.String("film.name", film),
+		attribute.Int("rental.days", int(days)))
+	defer func() { end(span, err) }()
+	defer func() {
+		err = svc.audit(ctx, "Rent", domain.RentalEntity(id), map[string]string{"film": film, "days": strconv.Itoa(int(days))}, err)
+	}()
+
+	if svc.rentals == nil {
+		return nil, RentalsNotConfiguredError
+	}
+
+	err = svc.atomically(func(cat catalogue, outbox driver.Outbox, _ driver.Stores) error {
+		found, err := svc.findBy(ctx, cat, film)
+		if err != nil {
+			return err
+		}
+		started, err := domain.StartRental(id, *found, domain.Days(days))
+		if err != nil {
+			return err
+		}
+
+		event := started.(domain.RentalStarted)
+		event.Store = driven.StoreFrom(ctx)
+		if err := svc.rentals.SaveRental(&domain.RentalAggregate{}, event); err != nil {
+			return err
+		}
+		rental = &event
+		return outbox.Enqueue(event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rental, nil
+}
+
+// openRentals rehydrates the rental named by every return which names one, a rental which has not been started,
+// has been returned already or is of another film is reported as invalid
+func (svc *StoreService) openRentals(request []driven.FilmReturn) (map[string]*domain.RentalAggregate, driven.InvalidRentalRequestError, error) {
+	rentals := map[string]*domain.RentalAggregate{}
+	invalidReq := driven.InvalidRentalRequestError{}
+	for _, r := range request {
+		if r.RentalID == "" {
+			continue
+		}
+		if svc.rentals == nil {
+			return nil, nil, RentalsNotConfiguredError
+		}
+		if _, seen := rentals[r.RentalID]; seen {
+			invalidReq.Append(fmt.Errorf("rental %q: %w", r.RentalID, domain.RentalAlreadyReturnedError))
+			continue
+		}
+
+		rental, err := svc.rentals.Rental(r.RentalID)
+		switch {
+		case err != nil:
+			return nil, nil, err
+		case rental.Version == 0:
+			invalidReq.Append(fmt.Errorf("rental %q: %w", r.RentalID, domain.RentalNotStartedError))
+		case rental.Returned:
+			invalidReq.Append(fmt.Errorf("rental %q: %w", r.RentalID, domain.RentalAlreadyReturnedError))
+		case rental.Film.Name != r.FilmName:
+			invalidReq.Append(fmt.Errorf("rental %q is of film %q", r.RentalID, rental.Film.Name))
+		}
+		rentals[r.RentalID] = rental
+	}
+	return rentals, invalidReq, nil
+}
+
+// closeRentals returns every open rental named by request through its aggregate
+func (svc *StoreService) closeRentals(rentals map[string]*domain.RentalAggregate, store domain.StoreID, prices domain.PriceList, request []driven.FilmReturn) error {
+	for _, r := range request {
+		rental, ok := rentals[r.RentalID]
+		if !ok {
+			continue
+		}
+		event, err := rental.ReturnWith(domain.Days(r.Days), prices)
+		if err != nil {
+			return err
+		}
+		returned := event.(domain.RentalReturned)
+		returned.Store = store
+		if err := svc.rentals.SaveRental(rental, returned); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func rentalAttribute(id string) attribute.KeyValue {
+	return attribute.String("rental.id", id)
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/eventstore"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"testing"
+)
+
+func TestRentals_RentAndReturn(t *testing.T) {
+	service, outbox := newStoresService()
+	rentals := eventstore.NewAggregates(eventstore.NewMemoryStore(), eventstore.NewMemorySnapshots(), 0)
+	WithRentals(rentals)(service)
+	film := films[0]
+
+	started, err := service.Rent(north, film.Name, 3)
+	if err != nil || started.RentalID == "" || started.Film.Name != film.Name || started.Days != 3 || started.Store != "north" {
+		t.Fatalf("was expecting the rental to be started in north but got %#v: %v", started, err)
+	}
+
+	invoice, err := service.Invoice(north, []driven.FilmReturn{{FilmName: film.Name, Days: 3, RentalID: started.RentalID}})
+	if err != nil || len(invoice.Rentals) != 1 {
+		t.Fatalf("was expecting the rental to be invoiced but got %#v: %v", invoice, err)
+	}
+
+	rental, err := rentals.Rental(started.RentalID)
+	if err != nil || !rental.Returned {
+		t.Errorf("was expecting the rental to be returned but got %#v: %v", rental, err)
+	}
+
+	pending, _ := outbox.Pending(10)
+	if len(pending) != 3 || pending[0].Event != *started {
+		t.Fatalf("was expecting the rental to be started, returned and invoiced but got %#v", pending)
+	}
+	if returned, ok := pending[1].Event.(domain.RentalReturned); !ok || returned.RentalID != started.RentalID || returned.Store != "north" {
+		t.Errorf("was expecting rental %q to be returned to north but got %#v", started.RentalID, pending[1].Event)
+	}
+}
+
+func TestRentals_InvalidReturns(t *testing.T) {
+	service, outbox := newStoresService()
+	WithRentals(eventstore.NewAggregates(eventstore.NewMemoryStore(), eventstore.NewMemorySnapshots(), 0))(service)
+
+	started, err := service.Rent(north, films[0].Name, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Invoice(north, []driven.FilmReturn{{FilmName: films[0].Name, Days: 1, RentalID: started.RentalID}}); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := outbox.Pending(10)
+
+	tests := []struct {
+		name    string
+		request []driven.FilmReturn
+	}{
+		{"already returned", []driven.FilmReturn{{FilmName: films[0].Name, Days: 1, RentalID: started.RentalID}}},
+		{"not started", []driven.FilmReturn{{FilmName: films[0].Name, Days: 1, RentalID: "unknown"}}},
+		{"other film", []driven.FilmReturn{{FilmName: films[1].Name, Days: 1, RentalID: started.RentalID}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := service.Invoice(north, test.request); !errors.As(err, &driven.TypeInvalidRentalRequest) {
+				t.Errorf("was expecting the return to be invalid but got %v", err)
+			}
+		})
+	}
+
+	if after, _ := outbox.Pending(10); len(after) != len(before) {
+		t.Errorf("was expecting invalid returns to enqueue nothing but got %#v", after[len(before):])
+	}
+}
+
+func TestRentals_NotConfigured(t *testing.T) {
+	service, _ := newStoresService()
+
+	if _, err := service.Rent(north, films[0].Name, 1); !errors.Is(err, RentalsNotConfiguredError) {
+		t.Errorf("was expecting rentals to need configuring but got %v", err)
+	}
+	if _, err := service.Invoice(north, []driven.FilmReturn{{FilmName: films[0].Name, Days: 1, RentalID: "r-1"}}); !errors.Is(err, RentalsNotConfiguredError) {
+		t.Errorf("was expecting a return naming its rental to need rentals but got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"go.opentelemetry.io/otel/attribute"
+	"strconv"
+)
+
+// StoresNotConfiguredError is returned by the calls which change a store when no stores have been configured
+var StoresNotConfiguredError = fmt.Errorf("stores have not been configured")
+
+// WithStores keeps stock, prices and invoices per store, every call is scoped to the store of its context
+func WithStores(stores driver.Stores) Option {
+	return func(svc *StoreService) {
+		svc.stores = stores
+	}
+}
+
+func (svc *StoreService) Inventory(ctx context.Context) (_ []domain.Stock, err error) {
+	_, span := svc.start(ctx, "StoreService.Inventory", storeAttribute(ctx))
+	defer func() { end(span, err) }()
+
+	if svc.stores == nil {
+		return nil, nil
+	}
+	return svc.stores.Inventory(driven.StoreFrom(ctx))
+}
+
+// AddStock adds copies of a catalogued film to the store the context is scoped to
+func (svc *StoreService) AddStock(ctx context.Context, film string, copies int) (stock *domain.Stock, err error) {
+	store := driven.StoreFrom(ctx)
+	ctx, span := svc.start(ctx, "StoreService.AddStock", storeAttribute(ctx), attribute.String("film.name", film), attribute.Int("stock.copies", copies))
+	defer func() { end(span, err) }()
+	defer func() {
+		err = svc.audit(ctx, "AddStock", domain.StoreEntity(store), map[string]string{"film": film, "copies": strconv.Itoa(copies)}, err)
+	}()
+
+	if svc.stores == nil {
+		return nil, StoresNotConfiguredError
+	}
+	if copies <= 0 {
+		return nil, domain.InvalidCopiesError
+	}
+
+	err = svc.atomically(func(cat catalogue, outbox driver.Outbox, stores driver.Stores) error {
+		if _, err := svc.findBy(ctx, cat, film); err != nil {
+			return err
+		}
+
+		held, err := stores.AdjustStock(store, film, copies)
+		if err != nil {
+			return err
+		}
+		stock = &domain.Stock{Film: film, Copies: held}
+		return outbox.Enqueue(domain.StockAdjusted{Store: store, Film: film, Delta: copies, Copies: held})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stock, nil
+}
+
+func (svc *StoreService) PriceList(ctx context.Context) (_ domain.PriceList, err error) {
+	_, span := svc.start(ctx, "StoreService.PriceList", storeAttribute(ctx))
+	defer func() { end(span, err) }()
+
+	if svc.stores == nil {
+		return domain.DefaultPriceList, nil
+	}
+	return svc.stores.PriceList(driven.StoreFrom(ctx))
+}
+
+// ChangePrices replaces the price list of the store the context is scoped to, invoices already issued keep
+// the prices they were issued with
+func (svc *StoreService) ChangePrices(ctx context.Context, prices domain.PriceList) (err error) {
+	store := driven.StoreFrom(ctx)
+	ctx, span := svc.start(ctx, "StoreService.ChangePrices", storeAttribute(ctx))
+	defer func() { end(span, err) }()
+	defer func() {
+		err = svc.audit(ctx, "ChangePrices", domain.StoreEntity(store), priceInputs(prices), err)
+	}()
+
+	if svc.stores == nil {
+		return StoresNotConfiguredError
+	}
+	if err := prices.IsValid(); err != nil {
+		return err
+	}
+
+	return svc.atomically(func(_ catalogue, outbox driver.Outbox, stores driver.Stores) error {
+		if err := stores.SavePriceList(store, prices); err != nil {
+			return err
+		}
+		return outbox.Enqueue(domain.PriceListChanged{Store: store, Prices: prices})
+	})
+}
+
+func (svc *StoreService) Invoices(ctx context.Context) (_ []domain.IssuedInvoice, err error) {
+	_, span := svc.start(ctx, "StoreService.Invoices", storeAttribute(ctx))
+	defer func() { end(span, err) }()
+
+	if svc.stores == nil {
+		return nil, nil
+	}
+	return svc.stores.Invoices(driven.StoreFrom(ctx))
+}
+
+func priceInputs(prices domain.PriceList) map[string]string {
+	return map[string]string{
+		"premium": strconv.FormatUint(uint64(prices.Premium), 10),
+		"basic":   strconv.FormatUint(uint64(prices.Basic), 10),
+	}
+}
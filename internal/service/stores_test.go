@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/inmem"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"testing"
+)
+
+var (
+	north = driven.WithStore(context.Background(), "north")
+	south = driven.WithStore(context.Background(), "south")
+)
+
+func newStoresService() (*StoreService, *inmem.Outbox) {
+	catalogue, outbox, stores := inmem.NewStoreCatalogue(films...), inmem.NewOutbox(), inmem.NewStores()
+	return New(catalogue, catalogue,
+		WithUnitOfWork(inmem.NewUnitOfWork(catalogue, outbox, stores)),
+		WithStores(stores),
+	), outbox
+}
+
+func TestStores_StockIsInvisibleToOtherStores(t *testing.T) {
+	service, _ := newStoresService()
+	if _, err := service.AddStock(north, films[0].Name, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if inventory, err := service.Inventory(south); err != nil || len(inventory) != 0 {
+		t.Errorf("was expecting south to hold nothing but got %#v: %v", inventory, err)
+	}
+	inventory, err := service.Inventory(north)
+	if err != nil || len(inventory) != 1 || inventory[0] != (domain.Stock{Film: films[0].Name, Copies: 2}) {
+		t.Errorf("was expecting north to hold 2 copies of %q but got %#v: %v", films[0].Name, inventory, err)
+	}
+
+	// a return does not touch the stock, so a store takes back films it holds no copy of
+	if _, err := service.Invoice(south, mapFilmReturn(films[:1], 1)); err != nil {
+		t.Errorf("was expecting south to take back a film it does not stock but got %v", err)
+	}
+}
+
+func TestStores_InvoicesAreInvisibleToOtherStores(t *testing.T) {
+	service, _ := newStoresService()
+	for _, ctx := range []context.Context{north, south} {
+		if _, err := service.AddStock(ctx, films[0].Name, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := service.Invoice(north, mapFilmReturn(films[:1], 2)); err != nil {
+		t.Fatal(err)
+	}
+
+	invoices, err := service.Invoices(north)
+	if err != nil || len(invoices) != 1 || invoices[0].Store != "north" || invoices[0].Cost != 80 {
+		t.Errorf("was expecting north to keep its invoice but got %#v: %v", invoices, err)
+	}
+	if invoices, err := service.Invoices(south); err != nil || len(invoices) != 0 {
+		t.Errorf("was expecting the invoice of north to be invisible to south but got %#v: %v", invoices, err)
+	}
+}
+
+func TestStores_PricesArePerStore(t *testing.T) {
+	service, outbox := newStoresService()
+	for _, ctx := range []context.Context{north, south} {
+		if _, err := service.AddStock(ctx, films[0].Name, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	prices := domain.PriceList{Premium: 50, Basic: 20}
+	if err := service.ChangePrices(south, prices); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.ChangePrices(south, domain.PriceList{}); !errors.Is(err, domain.InvalidPriceListError) {
+		t.Errorf("was expecting free rentals to be rejected but got %v", err)
+	}
+
+	for _, test := range []struct {
+		ctx  context.Context
+		cost domain.SEK
+	}{{north, 80}, {south, 100}} {
+		invoice, err := service.Invoice(test.ctx, mapFilmReturn(films[:1], 2))
+		if err != nil || invoice.Cost != test.cost {
+			t.Errorf("was expecting %q to charge %d but got %#v: %v", driven.StoreFrom(test.ctx), test.cost, invoice, err)
+		}
+	}
+
+	pending, _ := outbox.Pending(10)
+	expected := domain.PriceListChanged{Store: "south", Prices: prices}
+	if len(pending) < 3 || pending[2].Event != expected {
+		t.Errorf("was expecting %#v to be enqueued after the stock but got %#v", expected, pending)
+	}
+}
+
+func TestStores_AddStock(t *testing.T) {
+	service, outbox := newStoresService()
+
+	if _, err := service.AddStock(north, "Unknown", 1); !errors.As(err, &driven.TypeFilmNotFound) {
+		t.Errorf("was expecting a film which is not catalogued to be refused but got %v", err)
+	}
+	if _, err := service.AddStock(north, films[0].Name, 0); !errors.Is(err, domain.InvalidCopiesError) {
+		t.Errorf("was expecting no copies to be refused but got %v", err)
+	}
+
+	service.AddStock(north, films[0].Name, 1)
+	stock, err := service.AddStock(north, films[0].Name, 2)
+	if err != nil || *stock != (domain.Stock{Film: films[0].Name, Copies: 3}) {
+		t.Errorf("was expecting 3 copies but got %#v: %v", stock, err)
+	}
+
+	pending, _ := outbox.Pending(10)
+	expected := domain.StockAdjusted{Store: "north", Film: films[0].Name, Delta: 2, Copies: 3}
+	if len(pending) != 2 || pending[1].Event != expected {
+		t.Errorf("was expecting %#v to be enqueued but got %#v", expected, pending)
+	}
+}
+
+func TestStores_NotConfigured(t *testing.T) {
+	catalogue := setupCatalogue()
+	service := New(catalogue, catalogue)
+
+	if _, err := service.AddStock(north, films[0].Name, 1); !errors.Is(err, StoresNotConfiguredError) {
+		t.Errorf("was expecting stock to need stores but got %v", err)
+	}
+	if prices, err := service.PriceList(north); err != nil || prices != domain.DefaultPriceList {
+		t.Errorf("was expecting the default prices but got %#v: %v", prices, err)
+	}
+	if _, err := service.Invoice(north, mapFilmReturn(films[:1], 1)); err != nil {
+		t.Errorf("was expecting returns to be taken without stores but got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/shawnritchie/go-video-store/internal/service"
+
+// WithTracerProvider traces every call made to the service along with the catalogue lookups it makes, through the
+// global provider without it
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(svc *StoreService) {
+		svc.tracer = provider.Tracer(tracerName)
+	}
+}
+
+// start opens the span of operation as a child of the span ctx carries
+func (svc *StoreService) start(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return svc.tracer.Start(ctx, operation, trace.WithAttributes(attrs...))
+}
+
+// end marks the span as failed when err is not nil before ending it
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// findBy looks name up in the catalogue under a span of its own, so the time a call spends on lookups shows apart
+// from the rest of it
+func (svc *StoreService) findBy(ctx context.Context, finder driver.Queryable, name string) (film *domain.Film, err error) {
+	_, span := svc.start(ctx, "Queryable.FindBy", attribute.String("film.name", name))
+	defer func() { end(span, err) }()
+
+	if film, err = finder.FindBy(name); err == nil {
+		span.SetAttributes(attribute.String("film.release", string(film.Release)))
+	}
+	return film, err
+}
+
+func storeAttribute(ctx context.Context) attribute.KeyValue {
+	return attribute.String("store.id", string(driven.StoreFrom(ctx)))
+}
+
+func transferAttribute(id string) attribute.KeyValue {
+	return attribute.String("transfer.id", id)
+}
+
+func filmAttributes(film domain.Film) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("film.name", film.Name),
+		attribute.String("film.director", film.Director),
+		attribute.String("film.release", string(film.Release)),
+	}
+}
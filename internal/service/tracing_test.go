@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func newTracedService(options ...Option) (*StoreService, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	catalogue := setupCatalogue()
+	return New(catalogue, catalogue, append(options, WithTracerProvider(provider))...), exporter
+}
+
+// spanNamed returns the first span ended under name
+func spanNamed(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("was expecting a %q span but got %d others", name, len(spans))
+	return tracetest.SpanStub{}
+}
+
+func attributeOf(span tracetest.SpanStub, key string) attribute.Value {
+	for _, attr := range span.Attributes {
+		if string(attr.Key) == key {
+			return attr.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracing_Invoice(t *testing.T) {
+	svc, exporter := newTracedService()
+
+	if _, err := svc.Invoice(context.Background(), mapFilmReturn(films[:2], 4)); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	invoice := spanNamed(t, spans, "StoreService.Invoice")
+	validate := spanNamed(t, spans, "StoreService.validateFilmReturn")
+	pricing := spanNamed(t, spans, "RentalReturn.InvoiceWith")
+
+	var lookups []tracetest.SpanStub
+	for _, span := range spans {
+		if span.Name == "Queryable.FindBy" {
+			lookups = append(lookups, span)
+		}
+	}
+
+	switch {
+	case attributeOf(invoice, "rental.count").AsInt64() != 2 || attributeOf(invoice, "invoice.cost").AsInt64() != 220:
+		t.Errorf("unexpected invoice span attributes %v", invoice.Attributes)
+	case validate.Parent.SpanID() != invoice.SpanContext.SpanID() || pricing.Parent.SpanID() != invoice.SpanContext.SpanID():
+		t.Errorf("was expecting the validation and pricing to be traced within the invoice")
+	case len(lookups) != 2:
+		t.Fatalf("was expecting a span for every film looked up but got %d", len(lookups))
+	}
+	for i, lookup := range lookups {
+		if lookup.Parent.SpanID() != validate.SpanContext.SpanID() || lookup.SpanContext.TraceID() != invoice.SpanContext.TraceID() {
+			t.Errorf("was expecting the lookup of %s to be traced within the validation", attributeOf(lookup, "film.name").AsString())
+		}
+		if attributeOf(lookup, "film.name").AsString() != films[i].Name || attributeOf(lookup, "film.release").AsString() != string(films[i].Release) {
+			t.Errorf("unexpected lookup span attributes %v", lookup.Attributes)
+		}
+	}
+}
+
+func TestTracing_FailuresAreRecorded(t *testing.T) {
+	svc, exporter := newTracedService()
+
+	svc.Invoice(context.Background(), []driven.FilmReturn{{FilmName: "Black Widow", Days: 1}})
+	svc.AddNew(context.Background(), films[0].Name, films[0].Director)
+
+	spans := exporter.GetSpans()
+	lookup := spanNamed(t, spans, "Queryable.FindBy")
+	invoice := spanNamed(t, spans, "StoreService.Invoice")
+	add := spanNamed(t, spans, "StoreService.AddFilm")
+	switch {
+	case lookup.Status.Code != codes.Error || invoice.Status.Code != codes.Error:
+		t.Errorf("was expecting the failed lookup and invoice to be marked as errors but got %v and %v", lookup.Status, invoice.Status)
+	case attributeOf(invoice, "rental.count").AsInt64() != 1 || len(invoice.Events) == 0:
+		t.Errorf("was expecting the invoice span to record its error but got %#v", invoice)
+	case add.Status.Code != codes.Error || attributeOf(add, "film.release").AsString() != string(domain.New):
+		t.Errorf("was expecting the duplicate film to be traced as an error but got %v %v", add.Status, add.Attributes)
+	}
+}
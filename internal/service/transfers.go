@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"go.opentelemetry.io/otel/attribute"
+	"strconv"
+)
+
+// RequestTransfer asks the store from to send copies of a catalogued film to the store to
+func (svc *StoreService) RequestTransfer(ctx context.Context, film string, from domain.StoreID, to domain.StoreID, copies int) (transfer *domain.Transfer, err error) {
+	id := svc.newID()
+	ctx, span := svc.start(ctx, "StoreService.RequestTransfer", transferAttribute(id), attribute.String("film.name", film),
+		attribute.String("transfer.from", string(from)), attribute.String("transfer.to", string(to)), attribute.Int("transfer.copies", copies))
+	defer func() { end(span, err) }()
+	defer func() {
+		err = svc.audit(ctx, "RequestTransfer", domain.TransferEntity(id), transferInputs(film, from, to, copies), err)
+	}()
+
+	if svc.stores == nil {
+		return nil, StoresNotConfiguredError
+	}
+	requested, err := domain.RequestTransfer(id, film, from, to, copies)
+	if err != nil {
+		return nil, err
+	}
+
+	err = svc.atomically(func(cat catalogue, outbox driver.Outbox, stores driver.Stores) error {
+		if _, err := svc.findBy(ctx, cat, film); err != nil {
+			return err
+		}
+
+		transfer = &domain.Transfer{RequestedAt: svc.now().UTC()}
+		transfer.Apply(requested)
+		if err := stores.SaveTransfer(*transfer); err != nil {
+			return err
+		}
+		return outbox.Enqueue(requested)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// ApproveTransfer is refused while the sending store holds fewer copies than requested, shipping checks again
+// since the copies are not set aside until then
+func (svc *StoreService) ApproveTransfer(ctx context.Context, id string) (*domain.Transfer, error) {
+	return svc.advanceTransfer(ctx, "ApproveTransfer", id, func(transfer *domain.Transfer, stores driver.Stores) ([]domain.Event, error) {
+		approved, err := transfer.Approve()
+		if err != nil {
+			return nil, err
+		}
+		available, err := stores.Stock(transfer.From, transfer.Film)
+		if err != nil {
+			return nil, err
+		}
+		if available < transfer.Copies {
+			return nil, &driven.InsufficientStockError{Store: transfer.From, Film: transfer.Film, Available: available, Requested: transfer.Copies}
+		}
+		return []domain.Event{approved}, nil
+	})
+}
+
+// ShipTransfer takes the copies out of the stock of the sending store, they are available nowhere until received
+func (svc *StoreService) ShipTransfer(ctx context.Context, id string) (*domain.Transfer, error) {
+	return svc.advanceTransfer(ctx, "ShipTransfer", id, func(transfer *domain.Transfer, stores driver.Stores) ([]domain.Event, error) {
+		shipped, err := transfer.Ship()
+		if err != nil {
+			return nil, err
+		}
+		copies, err := stores.AdjustStock(transfer.From, transfer.Film, -transfer.Copies)
+		if err != nil {
+			return nil, err
+		}
+		return []domain.Event{shipped, domain.StockAdjusted{Store: transfer.From, Film: transfer.Film, Delta: -transfer.Copies, Copies: copies}}, nil
+	})
+}
+
+// ReceiveTransfer adds the copies to the stock of the receiving store
+func (svc *StoreService) ReceiveTransfer(ctx context.Context, id string) (*domain.Transfer, error) {
+	return svc.advanceTransfer(ctx, "ReceiveTransfer", id, func(transfer *domain.Transfer, stores driver.Stores) ([]domain.Event, error) {
+		received, err := transfer.Receive()
+		if err != nil {
+			return nil, err
+		}
+		copies, err := stores.AdjustStock(transfer.To, transfer.Film, transfer.Copies)
+		if err != nil {
+			return nil, err
+		}
+		return []domain.Event{received, domain.StockAdjusted{Store: transfer.To, Film: transfer.Film, Delta: transfer.Copies, Copies: copies}}, nil
+	})
+}
+
+func (svc *StoreService) Transfer(ctx context.Context, id string) (_ *domain.Transfer, err error) {
+	_, span := svc.start(ctx, "StoreService.Transfer", transferAttribute(id))
+	defer func() { end(span, err) }()
+
+	if svc.stores == nil {
+		return nil, &driven.TransferNotFoundError{ID: id}
+	}
+	return svc.stores.Transfer(id)
+}
+
+func (svc *StoreService) Transfers(ctx context.Context) (_ []domain.Transfer, err error) {
+	_, span := svc.start(ctx, "StoreService.Transfers", storeAttribute(ctx))
+	defer func() { end(span, err) }()
+
+	if svc.stores == nil {
+		return nil, nil
+	}
+	return svc.stores.Transfers(driven.StoreFrom(ctx))
+}
+
+// advanceTransfer moves the transfer on by a single step, the first event step returns is applied to it and
+// every event is enqueued alongside the saved transfer
+func (svc *StoreService) advanceTransfer(ctx context.Context, operation string, id string, step func(transfer *domain.Transfer, stores driver.Stores) ([]domain.Event, error)) (transfer *domain.Transfer, err error) {
+	ctx, span := svc.start(ctx, "StoreService."+operation, transferAttribute(id))
+	defer func() { end(span, err) }()
+	defer func() {
+		err = svc.audit(ctx, operation, domain.TransferEntity(id), map[string]string{"id": id}, err)
+	}()
+
+	if svc.stores == nil {
+		return nil, StoresNotConfiguredError
+	}
+
+	err = svc.atomically(func(_ catalogue, outbox driver.Outbox, stores driver.Stores) error {
+		if transfer, err = stores.Transfer(id); err != nil {
+			return err
+		}
+
+		events, err := step(transfer, stores)
+		if err != nil {
+			return err
+		}
+		transfer.Apply(events[0])
+		if err := stores.SaveTransfer(*transfer); err != nil {
+			return err
+		}
+		return outbox.Enqueue(events...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+func transferInputs(film string, from domain.StoreID, to domain.StoreID, copies int) map[string]string {
+	return map[string]string{
+		"film":   film,
+		"from":   string(from),
+		"to":     string(to),
+		"copies": strconv.Itoa(copies),
+	}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/shawnritchie/go-video-store/internal/domain"
+	"github.com/shawnritchie/go-video-store/internal/port/driven"
+	"testing"
+)
+
+func TestTransfers_Workflow(t *testing.T) {
+	service, outbox := newStoresService()
+	film := films[0].Name
+	if _, err := service.AddStock(north, film, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	transfer, err := service.RequestTransfer(north, film, "north", "south", 2)
+	if err != nil || transfer.Status != domain.TransferRequestedStatus || transfer.RequestedAt.IsZero() {
+		t.Fatalf("was expecting the transfer to be requested but got %#v: %v", transfer, err)
+	}
+	assertCopies(t, service, north, film, 3)
+
+	if transfer, err = service.ApproveTransfer(north, transfer.ID); err != nil || transfer.Status != domain.TransferApprovedStatus {
+		t.Fatalf("was expecting the transfer to be approved but got %#v: %v", transfer, err)
+	}
+	assertCopies(t, service, north, film, 3)
+
+	if transfer, err = service.ShipTransfer(north, transfer.ID); err != nil || transfer.Status != domain.TransferInTransitStatus {
+		t.Fatalf("was expecting the transfer to be in transit but got %#v: %v", transfer, err)
+	}
+	assertCopies(t, service, north, film, 1)
+	assertCopies(t, service, south, film, 0)
+
+	if transfer, err = service.ReceiveTransfer(south, transfer.ID); err != nil || transfer.Status != domain.TransferReceivedStatus {
+		t.Fatalf("was expecting the transfer to be received but got %#v: %v", transfer, err)
+	}
+	assertCopies(t, service, north, film, 1)
+	assertCopies(t, service, south, film, 2)
+
+	for _, ctx := range []context.Context{north, south} {
+		if transfers, err := service.Transfers(ctx); err != nil || len(transfers) != 1 || transfers[0] != *transfer {
+			t.Errorf("was expecting %q to see the transfer but got %#v: %v", driven.StoreFrom(ctx), transfers, err)
+		}
+	}
+	if found, err := service.Transfer(north, transfer.ID); err != nil || *found != *transfer {
+		t.Errorf("was expecting to find %#v but got %#v: %v", transfer, found, err)
+	}
+
+	pending, _ := outbox.Pending(10)
+	expected := []domain.Event{
+		domain.StockAdjusted{Store: "north", Film: film, Delta: 3, Copies: 3},
+		domain.TransferRequested{TransferID: transfer.ID, Film: film, From: "north", To: "south", Copies: 2},
+		domain.TransferApproved{TransferID: transfer.ID, From: "north", To: "south"},
+		domain.TransferShipped{TransferID: transfer.ID, Film: film, From: "north", To: "south", Copies: 2},
+		domain.StockAdjusted{Store: "north", Film: film, Delta: -2, Copies: 1},
+		domain.TransferReceived{TransferID: transfer.ID, Film: film, From: "north", To: "south", Copies: 2},
+		domain.StockAdjusted{Store: "south", Film: film, Delta: 2, Copies: 2},
+	}
+	if len(pending) != len(expected) {
+		t.Fatalf("was expecting %d events but got %#v", len(expected), pending)
+	}
+	for i, event := range expected {
+		if pending[i].Event != event {
+			t.Errorf("was expecting event %d to be %#v but got %#v", i, event, pending[i].Event)
+		}
+	}
+}
+
+func TestTransfers_InvalidSteps(t *testing.T) {
+	service, outbox := newStoresService()
+	film := films[0].Name
+	service.AddStock(north, film, 1)
+
+	if _, err := service.RequestTransfer(north, "Unknown", "north", "south", 1); !errors.As(err, &driven.TypeFilmNotFound) {
+		t.Errorf("was expecting a film which is not catalogued to be refused but got %v", err)
+	}
+	if _, err := service.RequestTransfer(north, film, "north", "north", 1); !errors.Is(err, domain.SameStoreTransferError) {
+		t.Errorf("was expecting a transfer within a store to be refused but got %v", err)
+	}
+	if _, err := service.ApproveTransfer(north, "missing"); !errors.As(err, &driven.TypeTransferNotFound) {
+		t.Errorf("was expecting an unknown transfer to be refused but got %v", err)
+	}
+
+	transfer, err := service.RequestTransfer(north, film, "north", "south", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.ShipTransfer(north, transfer.ID); !errors.As(err, &domain.TypeTransferStatus) {
+		t.Errorf("was expecting shipping before approval to be refused but got %v", err)
+	}
+	var insufficient *driven.InsufficientStockError
+	if _, err := service.ApproveTransfer(north, transfer.ID); !errors.As(err, &insufficient) || insufficient.Available != 1 {
+		t.Errorf("was expecting approval to need 2 copies in stock but got %v", err)
+	}
+
+	service.AddStock(north, film, 1)
+	if _, err := service.ApproveTransfer(north, transfer.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.ReceiveTransfer(south, transfer.ID); !errors.As(err, &domain.TypeTransferStatus) {
+		t.Errorf("was expecting receiving before shipping to be refused but got %v", err)
+	}
+	if found, _ := service.Transfer(north, transfer.ID); found.Status != domain.TransferApprovedStatus {
+		t.Errorf("was expecting a refused step to leave the transfer approved but got %#v", found)
+	}
+
+	pending, _ := outbox.Pending(10)
+	if len(pending) != 4 {
+		t.Errorf("was expecting refused steps to enqueue nothing but got %#v", pending)
+	}
+}
+
+func TestTransfers_NotConfigured(t *testing.T) {
+	catalogue := setupCatalogue()
+	service := New(catalogue, catalogue)
+
+	if _, err := service.RequestTransfer(north, films[0].Name, "north", "south", 1); !errors.Is(err, StoresNotConfiguredError) {
+		t.Errorf("was expecting transfers to need stores but got %v", err)
+	}
+	if _, err := service.Transfer(north, "missing"); !errors.As(err, &driven.TypeTransferNotFound) {
+		t.Errorf("was expecting no transfer to be found but got %v", err)
+	}
+}
+
+func assertCopies(t *testing.T, service *StoreService, ctx context.Context, film string, copies int) {
+	t.Helper()
+	inventory, err := service.Inventory(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stock := range inventory {
+		if stock.Film == film {
+			if stock.Copies != copies {
+				t.Errorf("was expecting %q to hold %d copies of %q but got %d", driven.StoreFrom(ctx), copies, film, stock.Copies)
+			}
+			return
+		}
+	}
+	if copies != 0 {
+		t.Errorf("was expecting %q to hold %d copies of %q but got none", driven.StoreFrom(ctx), copies, film)
+	}
+}
@@ -1,75 +1,340 @@
 package service
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"github.com/shawnritchie/go-video-store/internal/domain"
 	"github.com/shawnritchie/go-video-store/internal/port/driven"
 	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"time"
 )
 
+// UpdatesNotConfiguredError is returned by UpdateFilm when neither an updater nor a unit of work has been configured
+var UpdatesNotConfiguredError = fmt.Errorf("film updates have not been configured")
+
 type (
 	StoreService struct {
 		finder   driver.Queryable
+		batch    driver.BatchQueryable
 		appender driver.Insertable
+		lister   driver.Listable
+		updater  driver.Updatable
+		stores   driver.Stores
+		rentals  driver.Rentals
+		uow      driver.UnitOfWork
+		outbox   driver.Outbox
+		auditLog driver.AuditLog
+		logger   *slog.Logger
+		tracer   trace.Tracer
+		now      func() time.Time
+		newID    func() string
+	}
+
+	Option func(svc *StoreService)
+
+	// catalogue is the part of driver.Catalogue the service writes through, finder and appender make one up
+	// when no unit of work has been configured
+	catalogue interface {
+		driver.Queryable
+		driver.Insertable
+		driver.Updatable
+	}
+
+	splitCatalogue struct {
+		driver.Queryable
+		driver.Insertable
+		driver.Updatable
 	}
+
+	discardOutbox struct{}
 )
 
-func New(finder driver.Queryable, appender driver.Insertable) *StoreService {
-	return &StoreService{
-		finder,
-		appender,
+func New(finder driver.Queryable, appender driver.Insertable, options ...Option) *StoreService {
+	svc := &StoreService{
+		finder:   finder,
+		appender: appender,
+		outbox:   discardOutbox{},
+		logger:   slog.Default(),
+		tracer:   otel.Tracer(tracerName),
+		now:      time.Now,
+		newID:    randomID,
+	}
+	for _, option := range options {
+		option(svc)
+	}
+	return svc
+}
+
+// WithUnitOfWork makes every write performed by the service atomic across the repositories it touches
+func WithUnitOfWork(uow driver.UnitOfWork) Option {
+	return func(svc *StoreService) {
+		svc.uow = uow
+	}
+}
+
+// WithOutbox records the domain events raised by the service when no unit of work has been configured,
+// the events are then enqueued after the write rather than atomically with it
+func WithOutbox(outbox driver.Outbox) Option {
+	return func(svc *StoreService) {
+		svc.outbox = outbox
+	}
+}
+
+// WithLister lets the service list the whole catalogue, List returns nothing without it
+func WithLister(lister driver.Listable) Option {
+	return func(svc *StoreService) {
+		svc.lister = lister
+	}
+}
+
+// WithBatchFinder lets FindAll look every name up in a single lookup
+func WithBatchFinder(finder driver.BatchQueryable) Option {
+	return func(svc *StoreService) {
+		svc.batch = finder
+	}
+}
+
+// WithUpdater lets the service update catalogued films when no unit of work has been configured
+func WithUpdater(updater driver.Updatable) Option {
+	return func(svc *StoreService) {
+		svc.updater = updater
+	}
+}
+
+// WithLogger logs the operations which fail and the audit entries which cannot be recorded, through slog.Default
+// without it
+func WithLogger(logger *slog.Logger) Option {
+	return func(svc *StoreService) {
+		svc.logger = logger
+	}
+}
+
+func (svc *StoreService) Find(ctx context.Context, name string) (film *domain.Film, err error) {
+	ctx, span := svc.start(ctx, "StoreService.Find", attribute.String("film.name", name))
+	defer func() { end(span, err) }()
+
+	return svc.findBy(ctx, svc.finder, name)
+}
+
+// FindAll looks every name up in a single lookup when a batch finder has been configured, one at a time otherwise
+func (svc *StoreService) FindAll(ctx context.Context, names []string) (_ []domain.Film, err error) {
+	ctx, span := svc.start(ctx, "StoreService.FindAll", attribute.Int("film.count", len(names)))
+	defer func() { end(span, err) }()
+
+	wanted := map[string]bool{}
+	distinct := make([]string, 0, len(names))
+	for _, name := range names {
+		if !wanted[name] {
+			wanted[name] = true
+			distinct = append(distinct, name)
+		}
+	}
+
+	if svc.batch != nil {
+		return svc.batch.FindAll(distinct)
+	}
+
+	var films []domain.Film
+	for _, name := range distinct {
+		film, err := svc.findBy(ctx, svc.finder, name)
+		var notFound *driven.FilmNotFoundError
+		if errors.As(err, &notFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		films = append(films, *film)
 	}
+	return films, nil
 }
 
-func (svc *StoreService) Find(name string) (*domain.Film, error) {
-	return svc.finder.FindBy(name)
+func (svc *StoreService) List(ctx context.Context) (_ []domain.Film, err error) {
+	_, span := svc.start(ctx, "StoreService.List")
+	defer func() { end(span, err) }()
+
+	if svc.lister == nil {
+		return nil, nil
+	}
+	return svc.lister.List()
 }
 
-func (svc *StoreService) AddNew(name string, director string) error {
-	return svc.addFilm(domain.Film{Name: name, Director: director, Release: domain.New})
+func (svc *StoreService) AddNew(ctx context.Context, name string, director string) error {
+	return svc.addFilm(ctx, domain.Film{Name: name, Director: director, Release: domain.New})
 }
 
-func (svc *StoreService) AddRegular(name string, director string) error {
-	return svc.addFilm(domain.Film{Name: name, Director: director, Release: domain.Regular})
+func (svc *StoreService) AddRegular(ctx context.Context, name string, director string) error {
+	return svc.addFilm(ctx, domain.Film{Name: name, Director: director, Release: domain.Regular})
 }
 
-func (svc *StoreService) AddOld(name string, director string) error {
-	return svc.addFilm(domain.Film{Name: name, Director: director, Release: domain.Old})
+func (svc *StoreService) AddOld(ctx context.Context, name string, director string) error {
+	return svc.addFilm(ctx, domain.Film{Name: name, Director: director, Release: domain.Old})
 }
 
-func (svc *StoreService) Invoice(request []driven.FilmReturn) (*domain.RentalInvoice, error) {
-	rentalRequest, invalidReq := svc.validateFilmReturn(request)
+// Invoice prices the returns with the price list of the store the context is scoped to. Once stores have been
+// configured the invoice is kept in its history, a return does not change the stock of the store. A return which
+// names its rental closes that rental too
+func (svc *StoreService) Invoice(ctx context.Context, request []driven.FilmReturn) (invoice *domain.RentalInvoice, err error) {
+	store := driven.StoreFrom(ctx)
+	ctx, span := svc.start(ctx, "StoreService.Invoice", storeAttribute(ctx), attribute.Int("rental.count", len(request)))
+	defer func() { end(span, err) }()
+	defer func() {
+		err = svc.audit(ctx, "Invoice", "invoice", invoiceInputs(store, request, invoice), err)
+	}()
+
+	err = svc.atomically(func(cat catalogue, outbox driver.Outbox, stores driver.Stores) error {
+		prices := domain.DefaultPriceList
+		if stores != nil {
+			if prices, err = stores.PriceList(store); err != nil {
+				return err
+			}
+		}
+
+		rentals, invalidRentals, err := svc.openRentals(request)
+		if err != nil {
+			return err
+		}
+		if invoice, err = svc.invoice(ctx, cat, prices, request, invalidRentals); err != nil {
+			return err
+		}
+		if err := svc.closeRentals(rentals, store, prices, request); err != nil {
+			return err
+		}
+
+		invoiceID := svc.newID()
+		if stores != nil {
+			issued := domain.IssuedInvoice{ID: invoiceID, Store: store, Rentals: invoice.Rentals, Cost: invoice.Cost, IssuedAt: svc.now().UTC()}
+			if err := stores.SaveInvoice(issued); err != nil {
+				return err
+			}
+		}
+		return outbox.Enqueue(invoiceEvents(invoiceID, store, prices, request, *invoice)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int64("invoice.cost", int64(invoice.Cost)))
+	return invoice, nil
+}
+
+func (svc *StoreService) invoice(ctx context.Context, finder driver.Queryable, prices domain.PriceList, request []driven.FilmReturn, invalidRentals driven.InvalidRentalRequestError) (*domain.RentalInvoice, error) {
+	rentalRequest, invalidReq := svc.validateFilmReturn(ctx, finder, request)
+	invalidReq = append(invalidReq, invalidRentals...)
 	if len(invalidReq) > 0 {
 		return nil, &invalidReq
 	}
 
-	if invoice, errors := rentalRequest.Invoice(); errors != nil {
+	_, span := svc.start(ctx, "RentalReturn.InvoiceWith", attribute.Int("rental.count", len(rentalRequest.Rentals)))
+	if invoice, errors := rentalRequest.InvoiceWith(prices); errors != nil {
 		error := driven.InvalidRentalRequestError(errors)
+		end(span, &error)
 		return nil, &error
 	} else {
+		end(span, nil)
 		return &invoice, nil
 	}
 }
 
-func (svc *StoreService) addFilm(film domain.Film) error {
+// invoiceEvents raises a RentalReturned for every rental on the invoice followed by the InvoiceIssued itself, a
+// return which does not name its rental is numbered after the invoice
+func invoiceEvents(invoiceID string, store domain.StoreID, prices domain.PriceList, request []driven.FilmReturn, invoice domain.RentalInvoice) []domain.Event {
+	events := make([]domain.Event, 0, len(invoice.Rentals)+1)
+	for i, rental := range invoice.Rentals {
+		cost, _ := rental.PriceWith(prices)
+		rentalID := request[i].RentalID
+		if rentalID == "" {
+			rentalID = fmt.Sprintf("%s-%d", invoiceID, i+1)
+		}
+		events = append(events, domain.RentalReturned{
+			RentalID: rentalID,
+			Film:     rental.Film.Name,
+			Days:     rental.Days,
+			Cost:     cost,
+			Store:    store,
+		})
+	}
+	return append(events, domain.InvoiceIssued{InvoiceID: invoiceID, Rentals: invoice.Rentals, Cost: invoice.Cost, Store: store})
+}
+
+func (svc *StoreService) addFilm(ctx context.Context, film domain.Film) (err error) {
+	ctx, span := svc.start(ctx, "StoreService.AddFilm", filmAttributes(film)...)
+	defer func() { end(span, err) }()
+	defer func() {
+		err = svc.audit(ctx, "AddFilm", domain.FilmEntity(film.Name), filmInputs(film), err)
+	}()
+
 	if err := film.IsValid(); err != nil {
 		return err
 	}
 
-	if _, err := svc.finder.FindBy(film.Name); err != nil {
-		if errors.As(err, &driven.TypeFilmNotFound) {
-			return svc.appender.Insert(film)
+	return svc.atomically(func(cat catalogue, outbox driver.Outbox, _ driver.Stores) error {
+		if err := cat.InsertIfAbsent(film); err != nil {
+			return err
 		}
-		return err
+		return outbox.Enqueue(domain.FilmAdded{Name: film.Name, Director: film.Director, Release: film.Release})
+	})
+}
+
+func (svc *StoreService) UpdateFilm(ctx context.Context, film domain.Film) (updated *domain.Film, err error) {
+	ctx, span := svc.start(ctx, "StoreService.UpdateFilm", append(filmAttributes(film), attribute.Int64("film.version", int64(film.Version)))...)
+	defer func() { end(span, err) }()
+	defer func() {
+		err = svc.audit(ctx, "UpdateFilm", domain.FilmEntity(film.Name), filmInputs(film), err)
+	}()
+
+	if svc.uow == nil && svc.updater == nil {
+		return nil, UpdatesNotConfiguredError
+	}
+	event, err := domain.UpdateFilm(film)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	err = svc.atomically(func(cat catalogue, outbox driver.Outbox, _ driver.Stores) error {
+		if err := cat.Update(film); err != nil {
+			return err
+		}
+		return outbox.Enqueue(event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	film.Version++
+	return &film, nil
 }
 
-func (svc *StoreService) validateFilmReturn(request []driven.FilmReturn) (req domain.RentalReturn, invalidReq driven.InvalidRentalRequestError) {
+// atomically runs fx within the unit of work when one has been configured, otherwise the writes are made
+// one after the other against the service repositories. stores is nil unless stores have been configured
+func (svc *StoreService) atomically(fx func(cat catalogue, outbox driver.Outbox, stores driver.Stores) error) error {
+	if svc.uow == nil {
+		return fx(splitCatalogue{svc.finder, svc.appender, svc.updater}, svc.outbox, svc.stores)
+	}
+
+	return svc.uow.Atomically(func(tx driver.Tx) error {
+		var stores driver.Stores
+		if svc.stores != nil {
+			stores = tx.Stores()
+		}
+		return fx(tx.Catalogue(), tx.Outbox(), stores)
+	})
+}
+
+func (svc *StoreService) validateFilmReturn(ctx context.Context, finder driver.Queryable, request []driven.FilmReturn) (req domain.RentalReturn, invalidReq driven.InvalidRentalRequestError) {
+	ctx, span := svc.start(ctx, "StoreService.validateFilmReturn", attribute.Int("rental.count", len(request)))
+	defer func() {
+		span.SetAttributes(attribute.Int("rental.invalid", len(invalidReq)))
+		span.End()
+	}()
+
 	invalidReq = driven.InvalidRentalRequestError{}
 	for _, rental := range request {
-		if film, err := svc.finder.FindBy(rental.FilmName); err != nil {
+		if film, err := svc.findBy(ctx, finder, rental.FilmName); err != nil {
 			invalidReq.Append(err)
 		} else {
 			req.AddRental(*film, domain.Days(rental.Days))
@@ -77,3 +342,13 @@ func (svc *StoreService) validateFilmReturn(request []driven.FilmReturn) (req do
 	}
 	return req, invalidReq
 }
+
+func (discardOutbox) Enqueue(...domain.Event) error {
+	return nil
+}
+
+func randomID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"github.com/shawnritchie/go-video-store/internal/adapter/repository/inmem"
 	"github.com/shawnritchie/go-video-store/internal/domain"
 	"github.com/shawnritchie/go-video-store/internal/port/driven"
@@ -16,30 +18,29 @@ var films = []domain.Film{
 }
 
 type spyCatalogue struct {
-	findBy func(name string) (*domain.Film, error)
-	insert func(film domain.Film) error
+	findBy         func(name string) (*domain.Film, error)
+	insertIfAbsent func(film domain.Film) error
 }
 
 func (s *spyCatalogue) FindBy(name string) (*domain.Film, error) {
 	return s.findBy(name)
 }
 
-func (s *spyCatalogue) Insert(film domain.Film) error {
-	return s.insert(film)
+func (s *spyCatalogue) InsertIfAbsent(film domain.Film) error {
+	return s.insertIfAbsent(film)
 }
 
 func newSpyCatalogue(
 	findBy func(name string) (*domain.Film, error),
-	insert func(film domain.Film) error) *spyCatalogue {
+	insertIfAbsent func(film domain.Film) error) *spyCatalogue {
 	return &spyCatalogue{
-		findBy: findBy,
-		insert: insert,
+		findBy:         findBy,
+		insertIfAbsent: insertIfAbsent,
 	}
 }
 
 func setupCatalogue() driver.Catalogue {
-	cat := inmem.StoreCatalogue(films)
-	return &cat
+	return inmem.NewStoreCatalogue(films...)
 }
 
 func mockFindByError(err error) func(name string) (*domain.Film, error) {
@@ -63,7 +64,7 @@ func TestStoreService_AddNewFilm(t *testing.T) {
 		})
 
 	service := New(catalogue, catalogue)
-	service.AddNew(newFilm.Name, newFilm.Director)
+	service.AddNew(context.Background(), newFilm.Name, newFilm.Director)
 
 	if !hasBeenInvoked {
 		t.Errorf("film %+v hasn't been added to catalogue", newFilm)
@@ -79,17 +80,17 @@ func TestAddFilm(t *testing.T) {
 		{
 			testName:     "addNewFilmTest",
 			insertedFilm: domain.Film{Name: "Loki", Director: "Marvel", Release: domain.New},
-			addFx:        func(s *StoreService, f domain.Film) { s.AddNew(f.Name, f.Director) },
+			addFx:        func(s *StoreService, f domain.Film) { s.AddNew(context.Background(), f.Name, f.Director) },
 		},
 		{
 			testName:     "addRegularFilmTest",
 			insertedFilm: domain.Film{Name: "Loki", Director: "Marvel", Release: domain.Regular},
-			addFx:        func(s *StoreService, f domain.Film) { s.AddRegular(f.Name, f.Director) },
+			addFx:        func(s *StoreService, f domain.Film) { s.AddRegular(context.Background(), f.Name, f.Director) },
 		},
 		{
 			testName:     "addOldFilmTest",
 			insertedFilm: domain.Film{Name: "Loki", Director: "Marvel", Release: domain.Old},
-			addFx:        func(s *StoreService, f domain.Film) { s.AddOld(f.Name, f.Director) },
+			addFx:        func(s *StoreService, f domain.Film) { s.AddOld(context.Background(), f.Name, f.Director) },
 		},
 	}
 	for _, test := range tests {
@@ -116,6 +117,117 @@ func TestAddFilm(t *testing.T) {
 	}
 }
 
+func TestAddFilm_AlreadyExists(t *testing.T) {
+	service := New(setupCatalogue(), setupCatalogue())
+
+	err := service.AddNew(context.Background(), films[0].Name, "Someone Else")
+	if !errors.As(err, &driven.TypeFilmAlreadyExist) {
+		t.Errorf("was expecting TypeFilmAlreadyExist error but got %#v", err)
+	}
+}
+
+func TestAddFilm_RepositoryConflictIsPropagated(t *testing.T) {
+	conflict := &driven.FilmAlreadyExistError{Name: "Loki"}
+	catalogue := newSpyCatalogue(
+		func(name string) (*domain.Film, error) {
+			t.Errorf("uniqueness must be enforced by the repository, FindBy(%q) should not be invoked", name)
+			return nil, nil
+		},
+		func(film domain.Film) error {
+			return conflict
+		})
+
+	service := New(catalogue, catalogue)
+	if err := service.AddNew(context.Background(), "Loki", "Marvel"); err != conflict {
+		t.Errorf("was expecting %#v but got %#v", conflict, err)
+	}
+}
+
+type spyUnitOfWork struct {
+	uow         driver.UnitOfWork
+	invocations int
+}
+
+func (s *spyUnitOfWork) Atomically(fx func(tx driver.Tx) error) error {
+	s.invocations++
+	return s.uow.Atomically(fx)
+}
+
+func TestAddFilm_WithinUnitOfWork(t *testing.T) {
+	catalogue := inmem.NewStoreCatalogue()
+	uow := &spyUnitOfWork{uow: inmem.NewUnitOfWork(catalogue, inmem.NewOutbox(), inmem.NewStores())}
+
+	service := New(
+		newSpyCatalogue(nil, func(film domain.Film) error {
+			t.Errorf("film %#v should have been inserted through the unit of work", film)
+			return nil
+		}),
+		nil,
+		WithUnitOfWork(uow))
+
+	if err := service.AddRegular(context.Background(), "Loki", "Marvel"); err != nil {
+		t.Fatal(err)
+	}
+
+	if uow.invocations != 1 {
+		t.Errorf("was expecting a single unit of work but got %d", uow.invocations)
+	}
+
+	if _, err := catalogue.FindBy("Loki"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUpdateFilm(t *testing.T) {
+	catalogue := setupCatalogue()
+	service := New(catalogue, catalogue, WithUpdater(catalogue))
+
+	update := domain.Film{Name: films[1].Name, Director: "Raimi", Release: domain.Old, Version: 1}
+	updated, err := service.UpdateFilm(context.Background(), update)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := update
+	want.Version = 2
+	if *updated != want {
+		t.Errorf("was expecting %#v to be returned but got %#v", want, *updated)
+	}
+	if found, err := catalogue.FindBy(update.Name); err != nil {
+		t.Error(err)
+	} else if *found != want {
+		t.Errorf("was expecting %#v to be catalogued but found %#v", want, *found)
+	}
+
+	var conflict *driven.FilmVersionConflictError
+	if _, err := service.UpdateFilm(context.Background(), update); !errors.As(err, &conflict) || conflict.Actual != 2 {
+		t.Errorf("was expecting a stale update to conflict with version 2 but got %#v", err)
+	}
+}
+
+func TestUpdateFilm_Rejected(t *testing.T) {
+	catalogue := setupCatalogue()
+
+	update := domain.Film{Name: films[1].Name, Director: "Raimi", Release: domain.Old, Version: 1}
+	if _, err := New(catalogue, catalogue).UpdateFilm(context.Background(), update); err != UpdatesNotConfiguredError {
+		t.Errorf("was expecting UpdatesNotConfiguredError but got %v", err)
+	}
+
+	service := New(catalogue, catalogue, WithUpdater(catalogue))
+	invalid := update
+	invalid.Director = ""
+	if _, err := service.UpdateFilm(context.Background(), invalid); !errors.As(err, &domain.TypeInvalidFilm) {
+		t.Errorf("was expecting the invalid film to be rejected but got %v", err)
+	}
+
+	var notFound *driven.FilmNotFoundError
+	missing := update
+	missing.Name = "Black Widow"
+	if _, err := service.UpdateFilm(context.Background(), missing); !errors.As(err, &notFound) {
+		t.Errorf("was expecting FilmNotFoundError but got %v", err)
+	}
+}
+
 func TestStoreService_FindByName(t *testing.T) {
 	searchFor := domain.Film{Name: "Loki", Director: "Marvel", Release: domain.New}
 	hasBeenInvoked := false
@@ -124,14 +236,14 @@ func TestStoreService_FindByName(t *testing.T) {
 		func(name string) (*domain.Film, error) {
 			hasBeenInvoked = true
 			if name != searchFor.Name {
-				t.Errorf("looking for wrong film expected search was %q, but search for %q", searchFor, name)
+				t.Errorf("looking for wrong film expected search was %q, but search for %q", searchFor.Name, name)
 			}
 			return &searchFor, nil
 		},
 		nil)
 
 	service := New(catalogue, catalogue)
-	service.Find(searchFor.Name)
+	service.Find(context.Background(), searchFor.Name)
 
 	if !hasBeenInvoked {
 		t.Errorf("findBy hasn't been invoked")
@@ -143,11 +255,11 @@ func TestStoreService_StoreReturn(t *testing.T) {
 	service := New(catalogue, catalogue)
 
 	duration := uint16(5)
-	if invoice, err := service.Invoice(mapFilmReturn(films, duration)); err != nil {
+	if invoice, err := service.Invoice(context.Background(), mapFilmReturn(films, duration)); err != nil {
 		t.Error(err)
 	} else {
 		for i, rental := range invoice.Rentals {
-			if rental.Film != films[i] {
+			if rental.Film.Name != films[i].Name || rental.Film.Release != films[i].Release {
 				t.Errorf("film %#v is missing from invoice", films[i])
 			} else if rental.Days != domain.Days(duration) {
 				t.Errorf("film %#v has been incorrectly invoiced billed duration %d actual duration", rental.Days, duration)
@@ -166,3 +278,44 @@ func mapFilmReturn(films []domain.Film, duration uint16) (ret []driven.FilmRetur
 	}
 	return ret
 }
+
+func TestStoreService_List(t *testing.T) {
+	catalogue := inmem.NewStoreCatalogue(films...)
+
+	listed, err := New(catalogue, catalogue, WithLister(catalogue)).List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != len(films) || listed[0].Name != "Matrix 11" {
+		t.Errorf("was expecting the catalogue ordered by name but got %#v", listed)
+	}
+
+	if listed, _ := New(catalogue, catalogue).List(context.Background()); listed != nil {
+		t.Errorf("was expecting nothing to be listed without a lister but got %#v", listed)
+	}
+}
+
+func TestStoreService_FindAll(t *testing.T) {
+	catalogue := inmem.NewStoreCatalogue(films...)
+	names := []string{"Out of Africa", "Unknown", "Matrix 11"}
+
+	for name, svc := range map[string]*StoreService{
+		"BatchFinder": New(catalogue, catalogue, WithBatchFinder(catalogue), WithLister(catalogue)),
+		"FindEach":    New(catalogue, catalogue),
+	} {
+		t.Run(name, func(t *testing.T) {
+			found, err := svc.FindAll(context.Background(), names)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			catalogued := map[string]bool{}
+			for _, film := range found {
+				catalogued[film.Name] = true
+			}
+			if len(found) != 2 || !catalogued["Out of Africa"] || !catalogued["Matrix 11"] {
+				t.Errorf("was expecting only the catalogued films but got %#v", found)
+			}
+		})
+	}
+}
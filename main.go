@@ -1,20 +1,511 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"flag"
+	"fmt"
+	"github.com/shawnritchie/go-video-store/internal/adapter/audit"
+	"github.com/shawnritchie/go-video-store/internal/adapter/eventbus"
+	"github.com/shawnritchie/go-video-store/internal/adapter/logging"
+	"github.com/shawnritchie/go-video-store/internal/adapter/metrics"
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/bolt"
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/eventstore"
 	"github.com/shawnritchie/go-video-store/internal/adapter/repository/inmem"
+	"github.com/shawnritchie/go-video-store/internal/adapter/repository/sqlite"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/auth"
+	"github.com/shawnritchie/go-video-store/internal/adapter/web/graphql"
+	rpc "github.com/shawnritchie/go-video-store/internal/adapter/web/grpc"
 	web "github.com/shawnritchie/go-video-store/internal/adapter/web/http"
+	"github.com/shawnritchie/go-video-store/internal/adapter/webhook"
+	"github.com/shawnritchie/go-video-store/internal/port/driver"
+	"github.com/shawnritchie/go-video-store/internal/projection"
 	"github.com/shawnritchie/go-video-store/internal/service"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc"
+	"io"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"time"
+)
+
+type (
+	config struct {
+		addr       string
+		grpcAddr   string
+		adminAddr  string
+		repository string
+		sqliteDSN  string
+		boltPath   string
+		eventLog   string
+		auditLog   string
+		webhooks   string
+		spool      string
+		apiKeys    string
+		jwtSecret  string
+		jwtKey     string
+		rateLimits string
+		logLevel   string
+		logFormat  string
+		otlpURL    string
+	}
+
+	repositories struct {
+		catalogue driver.Catalogue
+		uow       driver.UnitOfWork
+		outbox    driver.OutboxStore
+		stores    driver.Stores
+		events    driver.EventStore
+		rentals   driver.Rentals
+		closer    io.Closer
+	}
 )
 
 func main() {
-	catalogue := &inmem.StoreCatalogue{}
-	service := service.New(catalogue, catalogue)
-	s := web.New(
-		service,
-		service,
-		service,
+	cfg := parseConfig()
+
+	// once the default, every line the log package writes goes through logger too
+	logger, err := logging.New(os.Stderr, cfg.logLevel, cfg.logFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(logger)
+
+	shutdownTracing, err := setupTracing(cfg.otlpURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer shutdownTracing(context.Background())
+
+	repos, err := newRepositories(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer repos.closer.Close()
+	stats := metrics.New()
+	instrument(repos, stats)
+
+	auditLog, err := audit.OpenFileLog(cfg.auditLog)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer auditLog.Close()
+
+	service := service.New(repos.catalogue, repos.catalogue,
+		service.WithUnitOfWork(repos.uow),
+		service.WithOutbox(repos.outbox),
+		service.WithLister(repos.catalogue),
+		service.WithBatchFinder(repos.catalogue),
+		service.WithUpdater(repos.catalogue),
+		service.WithStores(repos.stores),
+		service.WithRentals(repos.rentals),
+		service.WithAuditLog(auditLog),
+		service.WithLogger(logger),
+		service.WithTracerProvider(otel.GetTracerProvider()),
+	)
+
+	bus := eventbus.New()
+	broadcaster := eventbus.NewBroadcaster(1000, 64)
+	bus.Subscribe(broadcaster.Handle)
+	if err := subscribeWebhooks(bus, cfg.webhooks, cfg.spool); err != nil {
+		log.Fatal(err)
+	}
+	appender := metrics.NewFilmAppender(service, stats)
+	invoicer := metrics.NewFilmInvoicer(service, service, stats)
+
+	go func() {
+		log.Println(eventbus.NewRelay(repos.outbox, bus).Run(context.Background(), time.Second))
+	}()
+
+	authenticator, err := newAuthenticator(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	webOptions := []web.Option{
+		web.WithLister(service),
+		web.WithUpdater(metrics.NewFilmUpdater(service, stats)),
+		web.WithAuditTrail(service),
+		web.WithEventStream(broadcaster),
+		web.WithStores(service, service, service),
+		web.WithTransfers(service),
+		web.WithLogger(logger),
+		web.WithRequestMetrics(stats),
+		web.WithTracerProvider(otel.GetTracerProvider()),
+		// a day covers any retry a kiosk makes after losing its connection
+		web.WithIdempotency(inmem.NewIdempotencyStore(), 24*time.Hour),
+	}
+	limiter, err := newRateLimiter(cfg.rateLimits)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if limiter != nil {
+		webOptions = append(webOptions, web.WithRateLimiter(limiter))
+	}
+	if authenticator != nil {
+		webOptions = append(webOptions, web.WithAuthenticator(authenticator))
+	} else {
+		logger.Warn("no api keys or jwt keys configured, the http api is open to anyone")
+	}
+	graphqlOptions := []graphql.Option{graphql.WithLister(service), graphql.WithPriceLists(service), graphql.WithInventory(service)}
+	if repos.rentals != nil {
+		webOptions = append(webOptions, web.WithRenter(service))
+		graphqlOptions = append(graphqlOptions, graphql.WithRenter(service))
+	}
+	s := web.New(service, appender, invoicer, webOptions...)
+
+	// the metrics and expvars tell how the store is doing, they are kept off the public listener along with the
+	// projection rebuilds when an admin address is configured and left to admins otherwise
+	admin := http.NewServeMux()
+	admin.Handle("/debug/vars", expvar.Handler())
+	admin.Handle("/metrics", stats.Handler())
+
+	if repos.events != nil {
+		rented := projection.NewCurrentlyRented()
+		graphqlOptions = append(graphqlOptions, graphql.WithRentalTracker(rented))
+		// the checkpoints are kept next to the log, the projections resume from them rather than replaying it
+		checkpoints, err := eventstore.OpenFileCheckpoints(cfg.eventLog + ".checkpoints")
+		if err != nil {
+			log.Fatal(err)
+		}
+		runner := newProjections(repos.events, checkpoints, rented, stats)
+		admin.Handle("/projections/rebuild", rebuildProjection(runner))
+		go func() {
+			log.Println(runner.Run(context.Background(), time.Second))
+		}()
+	}
+
+	mux := http.NewServeMux()
+	if cfg.adminAddr != "" {
+		go func() {
+			log.Fatal(http.ListenAndServe(cfg.adminAddr, admin))
+		}()
+	} else {
+		var adminHandler http.Handler = admin
+		if authenticator != nil {
+			adminHandler = web.Authorized(authenticator, auth.Admin, adminHandler)
+		}
+		mux.Handle("/debug/vars", adminHandler)
+		mux.Handle("/metrics", adminHandler)
+		mux.Handle("/projections/rebuild", adminHandler)
+	}
+	var graphqlHandler http.Handler = graphql.New(service, invoicer, graphqlOptions...)
+	if limiter != nil {
+		graphqlHandler = limiter.Limit(graphqlHandler)
+	}
+	if authenticator != nil {
+		graphqlHandler = web.Authorized(authenticator, auth.Clerk, graphqlHandler)
+	}
+	mux.Handle("/graphql", s.Logged(graphqlHandler))
+	mux.Handle("/", s.Router())
+
+	if cfg.grpcAddr != "" {
+		grpcOptions := []rpc.Option{rpc.WithLister(service)}
+		if authenticator != nil {
+			grpcOptions = append(grpcOptions, rpc.WithAuthenticator(authenticator))
+		}
+		go func() {
+			log.Fatal(serveGRPC(cfg.grpcAddr, rpc.New(service, appender, invoicer, grpcOptions...).Server()))
+		}()
+	}
+	log.Fatal(http.ListenAndServe(cfg.addr, mux))
+}
+
+// setupTracing exports the spans of every request to the OTLP/HTTP collector at endpoint, tracing is left off
+// when no endpoint has been configured. Traces are continued and propagated through W3C traceparent headers
+func setupTracing(endpoint string) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	if endpoint == "" {
+		return func(ctx context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("unable to export traces to %q: %w", endpoint, err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "videostore"))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// instrument times every operation made on the repositories, the event store is left to the projection lag
+func instrument(repos *repositories, stats *metrics.Metrics) {
+	repos.catalogue = metrics.NewCatalogue(repos.catalogue, stats)
+	repos.outbox = metrics.NewOutbox(repos.outbox, stats)
+	repos.stores = metrics.NewStores(repos.stores, stats)
+	if repos.uow != nil {
+		repos.uow = metrics.NewUnitOfWork(repos.uow, stats)
+	}
+}
+
+// runProjections keeps the read models up to date with the event log, their lag is published on /debug/vars and
+// /metrics
+func newProjections(events driver.EventStore, checkpoints driver.Checkpoints, rented *projection.CurrentlyRented, stats *metrics.Metrics) *projection.Runner {
+	runner := projection.NewRunner(events, checkpoints,
+		projection.NewFilmsByDirector(),
+		rented,
+		projection.NewRevenuePerDay(),
 	)
-	log.Fatal(http.ListenAndServe(":8080", s.Router()))
+	runner.PublishLag("projection_lag")
+	stats.ObserveProjectionLag(runner.Lag)
+	return runner
+}
+
+// rebuildProjection replays the whole log into the projection named by the name parameter of a POST, the
+// projection is served from its rebuilt read model and checkpoint once it answers 204 No Content
+func rebuildProjection(runner *projection.Runner) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "projections are rebuilt with POST", http.StatusMethodNotAllowed)
+			return
+		}
+
+		err := runner.Rebuild(r.URL.Query().Get("name"))
+		var unknown *projection.UnknownProjectionError
+		switch {
+		case errors.As(err, &unknown):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case err != nil:
+			log.Printf("unable to rebuild projection: %v", err)
+			http.Error(w, "unable to rebuild projection", http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+}
+
+// serveGRPC serves the grpc adapter next to the http one, both sit on the same service
+func serveGRPC(addr string, server *grpc.Server) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("unable to listen for grpc on %q: %w", addr, err)
+	}
+	return server.Serve(listener)
+}
+
+// subscribeWebhooks delivers every event to the endpoints listed in the JSON file at path, deliveries are spooled
+// in the spool directory until they are made and dead letters are published on /debug/vars
+func subscribeWebhooks(bus *eventbus.Bus, path string, spool string) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read webhooks: %w", err)
+	}
+	var endpoints []webhook.Endpoint
+	if err := json.Unmarshal(data, &endpoints); err != nil {
+		return fmt.Errorf("unable to parse webhooks %q: %w", path, err)
+	}
+
+	dispatcher := webhook.NewDispatcher(endpoints, webhook.WithSpool(spool))
+	if err := dispatcher.Open(); err != nil {
+		return err
+	}
+	bus.Subscribe(dispatcher.Handle)
+	expvar.Publish("webhook_dead_letters", expvar.Func(func() interface{} {
+		return dispatcher.DeadLetters()
+	}))
+	go dispatcher.Run(context.Background())
+	return nil
+}
+
+// newRateLimiter limits the routes listed in the JSON file at path, nil when no path is configured. Buckets idle
+// for ten minutes are swept every minute
+func newRateLimiter(path string) (*web.RateLimiter, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read rate limits: %w", err)
+	}
+	limiter, err := web.ParseRateLimits(data, 10*time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("rate limits %q: %w", path, err)
+	}
+	go limiter.Run(context.Background(), time.Minute)
+	return limiter, nil
+}
+
+// newAuthenticator accepts the kiosk API keys and staff JWTs which have been configured, nil when neither has
+func newAuthenticator(cfg config) (auth.Authenticator, error) {
+	var chain auth.Chain
+	if cfg.apiKeys != "" {
+		data, err := os.ReadFile(cfg.apiKeys)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read api keys: %w", err)
+		}
+		keys, err := auth.ParseAPIKeys(data)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, keys)
+	}
+
+	var jwtOptions []auth.JWTOption
+	if cfg.jwtSecret != "" {
+		jwtOptions = append(jwtOptions, auth.WithHS256([]byte(cfg.jwtSecret)))
+	}
+	if cfg.jwtKey != "" {
+		data, err := os.ReadFile(cfg.jwtKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read jwt public key: %w", err)
+		}
+		key, err := auth.ParseRSAPublicKey(data)
+		if err != nil {
+			return nil, err
+		}
+		jwtOptions = append(jwtOptions, auth.WithRS256(key))
+	}
+	if len(jwtOptions) > 0 {
+		chain = append(chain, auth.NewJWT(jwtOptions...))
+	}
+
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	return chain, nil
+}
+
+// parseConfig reads the configuration from the command line falling back onto VIDEOSTORE_* environment variables
+func parseConfig() config {
+	var cfg config
+	flag.StringVar(&cfg.addr, "addr", env("VIDEOSTORE_ADDR", ":8080"), "address the http server listens on")
+	flag.StringVar(&cfg.grpcAddr, "grpc-addr", env("VIDEOSTORE_GRPC_ADDR", ":9090"), "address the grpc server listens on, empty to disable it")
+	flag.StringVar(&cfg.adminAddr, "admin-addr", env("VIDEOSTORE_ADMIN_ADDR", ""), "address /metrics, /debug/vars and /projections/rebuild are served on without authentication, empty to serve them next to the api to admins only")
+	flag.StringVar(&cfg.repository, "repository", env("VIDEOSTORE_REPOSITORY", "inmem"), "repository adapter [inmem,sqlite,bolt,eventsourced]")
+	flag.StringVar(&cfg.sqliteDSN, "sqlite-dsn", env("VIDEOSTORE_SQLITE_DSN", "videostore.db"), "sqlite database file")
+	flag.StringVar(&cfg.boltPath, "bolt-path", env("VIDEOSTORE_BOLT_PATH", "videostore.bolt"), "bolt database file, it keeps the outbox and stores of the eventsourced repository too")
+	flag.StringVar(&cfg.eventLog, "event-log", env("VIDEOSTORE_EVENT_LOG", "videostore.events"), "append only event log used by the eventsourced repository")
+	flag.StringVar(&cfg.auditLog, "audit-log", env("VIDEOSTORE_AUDIT_LOG", "videostore.audit"), "hash chained audit log of every mutating operation")
+	flag.StringVar(&cfg.webhooks, "webhooks", env("VIDEOSTORE_WEBHOOKS", ""), "JSON file listing the webhook endpoints [{\"url\",\"secret\",\"events\"}]")
+	flag.StringVar(&cfg.spool, "webhook-spool", env("VIDEOSTORE_WEBHOOK_SPOOL", "videostore.webhooks"), "directory webhook deliveries and dead letters are kept in until they are made or dropped")
+	flag.StringVar(&cfg.apiKeys, "api-keys", env("VIDEOSTORE_API_KEYS", ""), "JSON file listing the hashed kiosk api keys [{\"hash\",\"subject\",\"role\",\"store\"}]")
+	flag.StringVar(&cfg.rateLimits, "rate-limits", env("VIDEOSTORE_RATE_LIMITS", ""), "JSON file listing the token bucket of every route [{\"route\":\"POST /store/return\",\"rate\",\"burst\"}], \"*\" for any other route")
+	flag.StringVar(&cfg.jwtKey, "jwt-public-key", env("VIDEOSTORE_JWT_PUBLIC_KEY", ""), "PEM file of the rsa key RS256 staff tokens are verified with")
+	// the secret is only read from the environment so it does not show up in the process list
+	flag.StringVar(&cfg.logLevel, "log-level", env("VIDEOSTORE_LOG_LEVEL", "info"), "lowest level logged [debug,info,warn,error]")
+	flag.StringVar(&cfg.logFormat, "log-format", env("VIDEOSTORE_LOG_FORMAT", "text"), "format of the log lines [text,json]")
+	flag.StringVar(&cfg.otlpURL, "otlp-endpoint", env("VIDEOSTORE_OTLP_ENDPOINT", ""), "OTLP/HTTP url traces are exported to such as http://localhost:4318/v1/traces, empty to disable tracing")
+	cfg.jwtSecret = env("VIDEOSTORE_JWT_SECRET", "")
+	flag.Parse()
+	return cfg
+}
+
+func newRepositories(cfg config) (*repositories, error) {
+	switch cfg.repository {
+	case "inmem":
+		catalogue, outbox, stores := inmem.NewStoreCatalogue(), inmem.NewOutbox(), inmem.NewStores()
+		return &repositories{
+			catalogue: catalogue,
+			uow:       inmem.NewUnitOfWork(catalogue, outbox, stores),
+			outbox:    outbox,
+			stores:    stores,
+			closer:    closerFunc(func() error { return nil }),
+		}, nil
+	case "sqlite":
+		db, err := sqlite.Open(cfg.sqliteDSN)
+		if err != nil {
+			return nil, err
+		}
+		catalogue, err := sqlite.NewCatalogue(db)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		outbox, err := sqlite.NewOutbox(db)
+		if err != nil {
+			catalogue.Close()
+			db.Close()
+			return nil, err
+		}
+		stores, err := sqlite.NewStores(db)
+		if err != nil {
+			outbox.Close()
+			catalogue.Close()
+			db.Close()
+			return nil, err
+		}
+		return &repositories{
+			catalogue: catalogue,
+			uow:       sqlite.NewUnitOfWork(db, catalogue, outbox, stores),
+			outbox:    outbox,
+			stores:    stores,
+			closer: closerFunc(func() error {
+				stores.Close()
+				outbox.Close()
+				catalogue.Close()
+				return db.Close()
+			}),
+		}, nil
+	case "bolt":
+		catalogue, err := bolt.Open(cfg.boltPath)
+		if err != nil {
+			return nil, err
+		}
+		outbox, stores := bolt.NewOutbox(catalogue), bolt.NewStores(catalogue)
+		return &repositories{
+			catalogue: catalogue,
+			uow:       bolt.NewUnitOfWork(catalogue, outbox, stores),
+			outbox:    outbox,
+			stores:    stores,
+			closer:    catalogue,
+		}, nil
+	case "eventsourced":
+		store, err := eventstore.OpenFileStore(cfg.eventLog)
+		if err != nil {
+			return nil, err
+		}
+		// the outbox and the stores which are not event sourced are kept in the bolt database, its catalogue is
+		// left unused. Uniqueness is guaranteed by the event store, every film is added through a single append.
+		// Without a unit of work the outbox is only written once the append has succeeded
+		state, err := bolt.Open(cfg.boltPath)
+		if err != nil {
+			store.Close()
+			return nil, err
+		}
+		return &repositories{
+			catalogue: eventstore.NewCatalogue(store),
+			outbox:    bolt.NewOutbox(state),
+			stores:    bolt.NewStores(state),
+			events:    store,
+			// a rental is started and returned, too few events to be worth a snapshot
+			rentals: eventstore.NewAggregates(store, nil, 0),
+			closer: closerFunc(func() error {
+				state.Close()
+				return store.Close()
+			}),
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown repository %q must be one of [inmem,sqlite,bolt,eventsourced]", cfg.repository)
+}
+
+type closerFunc func() error
+
+func (fn closerFunc) Close() error {
+	return fn()
+}
+
+func env(key string, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
 }